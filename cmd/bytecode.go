@@ -0,0 +1,172 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/smarthome-go/homescript/v3/homescript"
+	"github.com/smarthome-go/homescript/v3/homescript/compiler"
+	"github.com/smarthome-go/homescript/v3/homescript/diagnostic"
+	"github.com/smarthome-go/homescript/v3/homescript/optimizer"
+)
+
+const bytecodeExtension = ".hmsc"
+
+// Returns the default path of the bytecode artifact of a Homescript file.
+func artifactPath(filename string) string {
+	return strings.TrimSuffix(filename, ".hms") + bytecodeExtension
+}
+
+// Artifacts are always optimized, like the programs which are run by `hms vm`.
+// As the optimizer changes the generated bytecode, its mode is part of the hash of an artifact.
+const artifactOptimizationMode = "optimized"
+
+// Analyzes, optimizes and compiles the given file and its imports.
+// `hms vm`, `hms compile` and the artifact cache share this so that the same source code always results in the same bytecode.
+// If `printAnalyzed` is set, the analyzed and the optimized modules are printed as well.
+func compileFile(program string, filename string, printAnalyzed bool) (compiler.CompileOutput, error) {
+	// The modules are only optimized by `analyzeFile` if they are printed.
+	analyzed, entryModule, err := analyzeFile(program, filename, true, printAnalyzed, true, DefaultReadFileProvider)
+	if err != nil {
+		return compiler.CompileOutput{}, err
+	}
+
+	if !printAnalyzed {
+		opt := optimizer.NewOptimizer()
+		optimized, diagnostics := opt.Optimize(analyzed)
+		for _, item := range diagnostics {
+			if item.Level == diagnostic.DiagnosticLevelError {
+				return compiler.CompileOutput{}, fmt.Errorf("Optimizer failed: %s", item.Message)
+			}
+		}
+		analyzed = optimized
+	}
+
+	return CompileVm(analyzed, entryModule), nil
+}
+
+// Compiles the given file and its imports into a bytecode artifact.
+func compileArtifact(filename string, outputPath string) error {
+	file, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+
+	code, err := compileFile(string(file), filename, false)
+	if err != nil {
+		return err
+	}
+
+	sources, err := artifactSources(string(file), filename)
+	if err != nil {
+		return err
+	}
+
+	bytecode, err := compiler.EncodeBytecode(code, artifactHash(sources))
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(outputPath, bytecode, 0644); err != nil {
+		return err
+	}
+
+	log.Printf("Compiled %d module(s) into `%s` (%d bytes)\n", len(sources), outputPath, len(bytecode))
+
+	return nil
+}
+
+// Returns the source code of the file and of all modules it imports, their hash is stored in the artifact.
+func artifactSources(program string, filename string) (map[string]string, error) {
+	return homescript.ModuleSources(
+		homescript.InputProgram{
+			ProgramText: program,
+			Filename:    filename,
+		},
+		homescript.TestingAnalyzerHost{
+			IsInvokedInTests: false,
+		},
+	)
+}
+
+// Returns the hash of everything an artifact was compiled from: its sources and the optimization mode.
+func artifactHash(sources map[string]string) string {
+	return compiler.HashSources(sources) + "/" + artifactOptimizationMode
+}
+
+// Loads the program from the artifact of the file if the artifact was compiled from the current source code.
+// Otherwise, the file is compiled like by `compileArtifact`, and the artifact is replaced.
+func cachedCompile(program string, filename string) (compiler.CompileOutput, error) {
+	sources, err := artifactSources(program, filename)
+	if err != nil {
+		return compiler.CompileOutput{}, err
+	}
+	hash := artifactHash(sources)
+
+	path := artifactPath(filename)
+	if bytecode, err := os.ReadFile(path); err == nil {
+		code, err := compiler.DecodeCachedBytecode(bytecode, hash)
+		if err == nil {
+			return code, nil
+		}
+
+		log.Printf("Recompiling `%s`: %s\n", filename, err.Error())
+	}
+
+	code, err := compileFile(program, filename, false)
+	if err != nil {
+		return compiler.CompileOutput{}, err
+	}
+
+	bytecode, err := compiler.EncodeBytecode(code, hash)
+	if err != nil {
+		return compiler.CompileOutput{}, err
+	}
+
+	if err := os.WriteFile(path, bytecode, 0644); err != nil {
+		return compiler.CompileOutput{}, err
+	}
+
+	return code, nil
+}
+
+// Runs a bytecode artifact without invoking the lexer, parser or analyzer.
+func execArtifact(path string) error {
+	bytecode, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	_, code, err := compiler.DecodeBytecode(bytecode)
+	if err != nil {
+		if errors.Is(err, compiler.ErrBytecodeVersionMismatch) {
+			return fmt.Errorf("%w: the artifact must be recompiled", err)
+		}
+		return err
+	}
+
+	if _, d := TestingRunVm(code, true, ArtifactReadFileProvider); d != nil {
+		return errors.New("Program terminated with an exception")
+	}
+
+	return nil
+}
+
+// Unlike the `DefaultReadFileProvider`, this does not panic if a file does not exist:
+// bytecode artifacts are usually executed without their source code.
+func ArtifactReadFileProvider(path string) (string, error) {
+	newPath := path
+	if !strings.HasSuffix(path, ".hms") {
+		newPath = fmt.Sprintf("%s.hms", path)
+	}
+
+	file, err := os.ReadFile(newPath)
+	if err != nil {
+		return "", err
+	}
+
+	return string(file), nil
+}
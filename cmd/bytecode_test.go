@@ -0,0 +1,201 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/smarthome-go/homescript/v3/homescript/compiler"
+	"github.com/stretchr/testify/assert"
+)
+
+const artifactLibrary = `pub let GREETING = "Hello";
+
+pub fn greet(name: str) -> str {
+    GREETING + ", " + name
+}
+
+fn main() {}
+`
+
+const artifactProgram = `import { greet } from lib;
+
+fn fib(n: int) -> int {
+    if n < 2 { return n; }
+    fib(n - 1) + fib(n - 2)
+}
+
+fn main() {
+    println(greet("artifact"));
+    for i in 0..5 {
+        println(fib(i * (1 + 2)));
+    }
+}
+`
+
+// Writes the test program into a temporary directory and compiles it into an artifact.
+func compileTestArtifact(t *testing.T) (dir string, artifact string) {
+	dir = t.TempDir()
+	chdir(t, dir)
+
+	assert.NoError(t, os.WriteFile("lib.hms", []byte(artifactLibrary), 0o644))
+	assert.NoError(t, os.WriteFile("main.hms", []byte(artifactProgram), 0o644))
+
+	artifact = filepath.Join(dir, artifactPath("main.hms"))
+	captureStdout(t, func() {
+		assert.NoError(t, compileArtifact("main.hms", artifact))
+	})
+
+	return dir, artifact
+}
+
+// Removes the timing information which is printed after each run.
+func withoutElapsed(output string) string {
+	lines := strings.Split(output, "\n")
+	kept := make([]string, 0, len(lines))
+	for _, line := range lines {
+		if !strings.HasPrefix(line, "VM elapsed:") {
+			kept = append(kept, line)
+		}
+	}
+	return strings.Join(kept, "\n")
+}
+
+func TestExecArtifact(t *testing.T) {
+	_, artifact := compileTestArtifact(t)
+
+	// The artifact must behave like `hms vm` on the same file.
+	var code compiler.CompileOutput
+	captureStdout(t, func() {
+		var err error
+		code, err = compileFile(artifactProgram, "main.hms", true)
+		assert.NoError(t, err)
+	})
+
+	expected := captureStdout(t, func() {
+		_, d := TestingRunVm(code, true, ArtifactReadFileProvider)
+		assert.Nil(t, d)
+	})
+
+	output := captureStdout(t, func() {
+		assert.NoError(t, execArtifact(artifact))
+	})
+
+	assert.Equal(t, "Hello, artifact\n0\n2\n8\n34\n144\n", withoutElapsed(expected))
+	assert.Equal(t, withoutElapsed(expected), withoutElapsed(output))
+}
+
+func TestExecCorruptArtifact(t *testing.T) {
+	_, artifact := compileTestArtifact(t)
+
+	valid, err := os.ReadFile(artifact)
+	assert.NoError(t, err)
+
+	// An artifact which decodes, but calls a function which does not exist.
+	var decoded map[string]any
+	assert.NoError(t, json.Unmarshal(valid, &decoded))
+	delete(decoded["functions"].(map[string]any), "@lib_greet")
+	delete(decoded["sourceMap"].(map[string]any), "@lib_greet")
+	unverified, err := json.Marshal(decoded)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		Name    string
+		Data    []byte
+		Message string
+	}{
+		{Name: "garbage", Data: []byte("this is not bytecode"), Message: "invalid bytecode header"},
+		{Name: "truncated", Data: valid[:len(valid)/2], Message: "invalid bytecode"},
+		{Name: "fails verification", Data: unverified, Message: "call to non-existent function `@lib_greet`"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			assert.NoError(t, os.WriteFile(artifact, test.Data, 0o644))
+
+			var err error
+			output := captureStdout(t, func() {
+				err = execArtifact(artifact)
+			})
+
+			assert.Error(t, err)
+			assert.Contains(t, output+err.Error(), test.Message)
+		})
+	}
+}
+
+func TestExecArtifactVersionMismatch(t *testing.T) {
+	_, artifact := compileTestArtifact(t)
+
+	valid, err := os.ReadFile(artifact)
+	assert.NoError(t, err)
+
+	var decoded map[string]any
+	assert.NoError(t, json.Unmarshal(valid, &decoded))
+	decoded["header"].(map[string]any)["version"] = compiler.BytecodeVersion + 1
+	outdated, err := json.Marshal(decoded)
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(artifact, outdated, 0o644))
+
+	err = execArtifact(artifact)
+	assert.ErrorIs(t, err, compiler.ErrBytecodeVersionMismatch)
+	assert.Contains(t, err.Error(), "the artifact must be recompiled")
+}
+
+func TestCachedCompile(t *testing.T) {
+	dir := t.TempDir()
+	chdir(t, dir)
+
+	assert.NoError(t, os.WriteFile("lib.hms", []byte(artifactLibrary), 0o644))
+	assert.NoError(t, os.WriteFile("main.hms", []byte(artifactProgram), 0o644))
+
+	compile := func() compiler.CompileOutput {
+		var code compiler.CompileOutput
+		captureStdout(t, func() {
+			var err error
+			code, err = cachedCompile(artifactProgram, "main.hms")
+			assert.NoError(t, err)
+		})
+		return code
+	}
+
+	artifact := artifactPath("main.hms")
+	past := time.Now().Add(-time.Hour)
+	modified := func() bool {
+		stat, err := os.Stat(artifact)
+		assert.NoError(t, err)
+		return !stat.ModTime().Equal(past)
+	}
+
+	// The artifact is created if it does not exist.
+	code := compile()
+	assert.FileExists(t, artifact)
+	assert.NoError(t, os.Chtimes(artifact, past, past))
+
+	// An up-to-date artifact is loaded instead of compiling again.
+	cached := compile()
+	assert.False(t, modified())
+	assert.Equal(t, code.AsmString(false), cached.AsmString(false))
+
+	// A change in an imported module invalidates the artifact.
+	assert.NoError(t, os.WriteFile("lib.hms", []byte(strings.Replace(artifactLibrary, "Hello", "Hi", 1)), 0o644))
+	code = compile()
+	assert.True(t, modified())
+
+	// `hms vm` compiles the program like the cache and `hms compile`, whose artifact is reused.
+	var uncached compiler.CompileOutput
+	captureStdout(t, func() {
+		var err error
+		uncached, err = compileFile(artifactProgram, "main.hms", true)
+		assert.NoError(t, err)
+		assert.NoError(t, compileArtifact("main.hms", artifact))
+	})
+	assert.Equal(t, uncached.AsmString(false), code.AsmString(false))
+
+	assert.NoError(t, os.Chtimes(artifact, past, past))
+	compile()
+	assert.False(t, modified())
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/smarthome-go/homescript/v3/homescript"
+	"github.com/smarthome-go/homescript/v3/homescript/diagnostic"
+	"github.com/smarthome-go/homescript/v3/homescript/optimizer"
+)
+
+type checkFormat string
+
+const (
+	checkFormatText  checkFormat = "text"
+	checkFormatJSON  checkFormat = "json"
+	checkFormatSarif checkFormat = "sarif"
+)
+
+func parseCheckFormat(from string) (checkFormat, error) {
+	switch format := checkFormat(from); format {
+	case checkFormatText, checkFormatJSON, checkFormatSarif:
+		return format, nil
+	default:
+		return "", fmt.Errorf("Unknown output format `%s`: expected one of text, json, sarif", from)
+	}
+}
+
+// Runs the lexer, parser, analyzer, and optimizer on each file and reports every diagnostic.
+// In JSON mode, each diagnostic is printed as a single line.
+// In SARIF mode, a single log containing the diagnostics of all files is printed.
+// If `fix` is set, all suggested fixes are applied first and only the remaining diagnostics are reported.
+// An error is returned if any diagnostic is an error.
+func checkFiles(filenames []string, format checkFormat, requireMain bool, fix bool) error {
+	diagnostics, err := collectDiagnostics(filenames, requireMain)
+	if err != nil {
+		return err
+	}
+
+	if fix {
+		if err := applyFixes(diagnostics); err != nil {
+			return err
+		}
+
+		if diagnostics, err = collectDiagnostics(filenames, requireMain); err != nil {
+			return err
+		}
+	}
+
+	return reportDiagnostics(diagnostics, format)
+}
+
+func collectDiagnostics(filenames []string, requireMain bool) ([]diagnostic.Diagnostic, error) {
+	diagnostics := make([]diagnostic.Diagnostic, 0)
+	seen := make(map[string]bool)
+
+	for _, filename := range filenames {
+		source, err := os.ReadFile(filename)
+		if err != nil {
+			return nil, err
+		}
+
+		// Imported modules are analyzed again for every file which imports them.
+		// Therefore, diagnostics are deduplicated.
+		for _, item := range checkProgram(string(source), filename, requireMain) {
+			key := fmt.Sprintf("%s:%v:%v:%s", item.Span.Filename, item.Span.Start, item.Span.End, item.Message)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			diagnostics = append(diagnostics, item)
+		}
+	}
+
+	return diagnostics, nil
+}
+
+// Applies the fixes of the given diagnostics to the files they refer to.
+// A summary is printed to stderr so that it does not interfere with the output format.
+func applyFixes(diagnostics []diagnostic.Diagnostic) error {
+	fixes := make(map[string][]*diagnostic.Fix)
+	filenames := make([]string, 0)
+
+	for _, item := range diagnostics {
+		if item.Fix == nil {
+			continue
+		}
+
+		filename := modulePath(item.Span.Filename)
+		if _, exists := fixes[filename]; !exists {
+			filenames = append(filenames, filename)
+		}
+		fixes[filename] = append(fixes[filename], item.Fix)
+	}
+
+	for _, filename := range filenames {
+		source, err := os.ReadFile(filename)
+		if err != nil {
+			return err
+		}
+
+		output, applied := diagnostic.ApplyFixes(string(source), fixes[filename])
+		if err := os.WriteFile(filename, []byte(output), 0644); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(os.Stderr, "Applied %d fix(es) to %s\n", applied, filename)
+	}
+
+	return nil
+}
+
+func reportDiagnostics(diagnostics []diagnostic.Diagnostic, format checkFormat) error {
+	switch format {
+	case checkFormatText:
+		for _, item := range diagnostics {
+			// Unlike `DefaultReadFileProvider`, this does not print anything which is not a diagnostic.
+			source, err := os.ReadFile(modulePath(item.Span.Filename))
+			if err != nil {
+				return err
+			}
+			fmt.Println(item.DisplayWithSources(string(source), readSource))
+		}
+	case checkFormatJSON:
+		for _, item := range diagnostics {
+			output, err := json.Marshal(item)
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(output))
+		}
+	case checkFormatSarif:
+		for idx := range diagnostics {
+			diagnostics[idx].Span.Filename = modulePath(diagnostics[idx].Span.Filename)
+
+			if fix := diagnostics[idx].Fix; fix != nil {
+				edits := make([]diagnostic.Edit, len(fix.Edits))
+				for editIdx, edit := range fix.Edits {
+					edit.Span.Filename = modulePath(edit.Span.Filename)
+					edits[editIdx] = edit
+				}
+				diagnostics[idx].Fix = diagnostic.NewFix(fix.Description, edits...)
+			}
+
+			labels := make([]diagnostic.Label, len(diagnostics[idx].Labels))
+			for labelIdx, label := range diagnostics[idx].Labels {
+				label.Span.Filename = modulePath(label.Span.Filename)
+				labels[labelIdx] = label
+			}
+			diagnostics[idx].Labels = labels
+		}
+
+		output, err := json.MarshalIndent(diagnostic.NewSarifLog(programName, version, diagnostics), "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(output))
+	default:
+		panic("A new check format was added without updating this code")
+	}
+
+	for _, item := range diagnostics {
+		if item.Level == diagnostic.DiagnosticLevelError {
+			return errors.New("Encountered error(s)")
+		}
+	}
+
+	return nil
+}
+
+// Returns all diagnostics of the given program.
+// Syntax errors are converted into diagnostics with the error level.
+// The optimizer only runs if the analyzer did not report any errors.
+func checkProgram(source string, filename string, requireMain bool) []diagnostic.Diagnostic {
+	analyzed, diagnostics, syntaxErrors := homescript.Analyze(
+		homescript.InputProgram{
+			ProgramText: source,
+			Filename:    filename,
+		},
+		homescript.TestingAnalyzerScopeAdditions(),
+		homescript.TestingAnalyzerHost{
+			IsInvokedInTests: false,
+		},
+		requireMain,
+	)
+
+	if len(syntaxErrors) != 0 {
+		output := make([]diagnostic.Diagnostic, 0)
+		for _, syntaxErr := range syntaxErrors {
+			output = append(output, diagnostic.Diagnostic{
+				Level:   diagnostic.DiagnosticLevelError,
+				Code:    syntaxErr.Code,
+				Message: fmt.Sprintf("%s: %s", syntaxErr.Kind, syntaxErr.Message),
+				Notes:   []string{},
+				Span:    syntaxErr.Span,
+			})
+		}
+		return output
+	}
+
+	for _, item := range diagnostics {
+		if item.Level == diagnostic.DiagnosticLevelError {
+			return diagnostics
+		}
+	}
+
+	optimizer := optimizer.NewOptimizer()
+	_, optimizerDiagnostics := optimizer.Optimize(analyzed)
+	return append(diagnostics, optimizerDiagnostics...)
+}
+
+// Provides the source code of labels which are located in imported modules.
+func readSource(filename string) (string, bool) {
+	source, err := os.ReadFile(modulePath(filename))
+	if err != nil {
+		return "", false
+	}
+	return string(source), true
+}
+
+// Imported modules are referred to by their name, not by their path.
+func modulePath(filename string) string {
+	if strings.HasSuffix(filename, ".hms") {
+		return filename
+	}
+	return filename + ".hms"
+}
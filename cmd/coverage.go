@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/smarthome-go/homescript/v3/homescript"
+	"github.com/smarthome-go/homescript/v3/homescript/compiler"
+	"github.com/smarthome-go/homescript/v3/homescript/coverage"
+	"github.com/smarthome-go/homescript/v3/homescript/runtime"
+)
+
+// Runs the main function of each file and reports the combined coverage of all runs.
+// Programs are not optimized so that unused functions are reported as uncovered.
+func runCoverage(filenames []string, lcovPath string, htmlPath string) error {
+	report := coverage.NewReport()
+	failed := false
+
+	for _, filename := range filenames {
+		file, err := os.ReadFile(filename)
+		if err != nil {
+			return err
+		}
+
+		analyzed, entryModule, err := analyzeFile(string(file), filename, true, false, true, ArtifactReadFileProvider)
+		if err != nil {
+			return err
+		}
+
+		compilerStruct := compiler.NewCompiler(analyzed, entryModule)
+		code, err := compilerStruct.Compile()
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+
+		executor := homescript.TestingVmExecutor{
+			PrintToStdout: false,
+			PrintBuf:      new(string),
+			PintBufMutex:  &sync.Mutex{},
+		}
+
+		vm := runtime.NewVM(code, executor, &ctx, &cancel, homescript.TestingVmScopeAdditions(), vmLimits)
+
+		vm.StartCoverage()
+		vm.SpawnAsync(runtime.MainFn(), nil, nil, nil)
+		coreNum, i := vm.Wait()
+		report.Add(code, vm.StopCoverage())
+		cancel()
+
+		// The coverage of a failed run is still reported.
+		if err := reportException(coreNum, i, ArtifactReadFileProvider); err != nil {
+			fmt.Printf("%s: %s\n", filename, err.Error())
+			failed = true
+		}
+	}
+
+	for _, file := range report.SortedFiles() {
+		covered, total := file.LineCoverage()
+		fmt.Printf("%s: %d/%d lines covered\n", file.Filename, covered, total)
+	}
+
+	if lcovPath != "" {
+		if err := writeCoverage(lcovPath, func(output *os.File) error { return report.WriteLcov(output) }); err != nil {
+			return err
+		}
+	}
+
+	if htmlPath != "" {
+		if err := writeCoverage(htmlPath, func(output *os.File) error {
+			return report.WriteHTML(output, ArtifactReadFileProvider)
+		}); err != nil {
+			return err
+		}
+	}
+
+	if failed {
+		return fmt.Errorf("At least one program terminated with an exception")
+	}
+
+	return nil
+}
+
+func writeCoverage(path string, write func(output *os.File) error) error {
+	output, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer output.Close()
+
+	return write(output)
+}
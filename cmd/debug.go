@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/smarthome-go/homescript/v3/homescript"
+	"github.com/smarthome-go/homescript/v3/homescript/compiler"
+	"github.com/smarthome-go/homescript/v3/homescript/dap"
+	"github.com/smarthome-go/homescript/v3/homescript/diagnostic"
+	"github.com/smarthome-go/homescript/v3/homescript/runtime"
+	vmValue "github.com/smarthome-go/homescript/v3/homescript/runtime/value"
+)
+
+// Stdout is used by the protocol, therefore, the output of the program is forwarded to the client instead.
+type dapExecutor struct {
+	homescript.TestingVmExecutor
+	output io.Writer
+}
+
+func (self dapExecutor) WriteStringTo(input string) error {
+	_, err := io.WriteString(self.output, input)
+	return err
+}
+
+// Analyzes and compiles a program for the debugger.
+// Diagnostics are printed to stderr as stdout is used by the protocol.
+func compileForDebugger(filename string) (compiler.CompileOutput, error) {
+	source, err := os.ReadFile(filename)
+	if err != nil {
+		return compiler.CompileOutput{}, err
+	}
+
+	analyzed, diagnostics, syntaxErrors := homescript.Analyze(
+		homescript.InputProgram{
+			ProgramText: string(source),
+			Filename:    filename,
+		},
+		homescript.TestingAnalyzerScopeAdditions(),
+		homescript.TestingAnalyzerHost{},
+		true,
+	)
+
+	for _, syntaxErr := range syntaxErrors {
+		file, _ := readSource(syntaxErr.Span.Filename)
+		fmt.Fprintln(os.Stderr, syntaxErr.Display(file))
+	}
+
+	failed := len(syntaxErrors) != 0
+	for _, item := range diagnostics {
+		if item.Level == diagnostic.DiagnosticLevelError {
+			failed = true
+		}
+
+		file, _ := readSource(item.Span.Filename)
+		fmt.Fprintln(os.Stderr, item.DisplayWithSources(file, readSource))
+	}
+
+	if failed {
+		return compiler.CompileOutput{}, errors.New("Program contains errors")
+	}
+
+	compilerStruct := compiler.NewCompiler(analyzed, filename)
+	return compilerStruct.Compile()
+}
+
+func serveDap(in io.Reader, out io.Writer) error {
+	server := dap.NewServer(
+		compileForDebugger,
+		func(output io.Writer) vmValue.Executor {
+			return dapExecutor{
+				TestingVmExecutor: homescript.TestingVmExecutor{
+					PrintToStdout: false,
+					PrintBuf:      new(string),
+					PintBufMutex:  &sync.Mutex{},
+				},
+				output: output,
+			}
+		},
+		homescript.TestingVmScopeAdditions,
+		vmLimits,
+	)
+
+	return server.Serve(in, out)
+}
+
+// Runs a file under the interactive terminal debugger.
+// Execution stops before the first instruction, use `run` to start it.
+func runTerminalDebugger(filename string) error {
+	compiled, err := compileForDebugger(filename)
+	if err != nil {
+		return err
+	}
+
+	source, _ := readSource(filename)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	executor := homescript.TestingVmExecutor{
+		PrintToStdout: true,
+		PrintBuf:      new(string),
+		PintBufMutex:  &sync.Mutex{},
+	}
+
+	vm := runtime.NewVM(compiled, executor, &ctx, &cancel, homescript.TestingVmScopeAdditions(), vmLimits)
+
+	debugger := homescript.NewDebugger(nil, nil, nil, source, compiled)
+	vm.DebugHook = debugger.Hook()
+
+	vm.SpawnAsync(runtime.MainFn(), nil, nil, nil)
+
+	if coreNum, i := vm.Wait(); i != nil {
+		d := diagnostic.Diagnostic{
+			Level:   diagnostic.DiagnosticLevelError,
+			Message: (*i).Message(),
+			Notes:   []string{fmt.Sprintf("Exception occurred on core %d", coreNum)},
+			Span:    (*i).GetSpan(),
+		}
+
+		file, _ := readSource(d.Span.Filename)
+		fmt.Println(d.Display(file))
+		return errors.New("Program terminated with an exception")
+	}
+
+	return nil
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/smarthome-go/homescript/v3/homescript"
+	"github.com/smarthome-go/homescript/v3/homescript/diagnostic"
+	"github.com/smarthome-go/homescript/v3/homescript/doc"
+)
+
+// Renders the documentation of the given module.
+// If the output path is empty, the documentation is printed.
+func generateDoc(filename string, format doc.Format, includePrivate bool, outputPath string) error {
+	file, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+
+	analyzed, diagnostics, syntaxErrors := homescript.Analyze(
+		homescript.InputProgram{
+			ProgramText: string(file),
+			Filename:    filename,
+		},
+		homescript.TestingAnalyzerScopeAdditions(),
+		homescript.TestingAnalyzerHost{
+			IsInvokedInTests: false,
+		},
+		// Modules which are only imported by other scripts do not have a `main` function.
+		false,
+	)
+
+	if len(syntaxErrors) != 0 {
+		for _, syntaxErr := range syntaxErrors {
+			source, err := DefaultReadFileProvider(syntaxErr.Span.Filename)
+			if err != nil {
+				return err
+			}
+			fmt.Println(syntaxErr.Display(source))
+		}
+		return errors.New("Encountered syntax error(s)")
+	}
+
+	for _, item := range diagnostics {
+		if item.Level != diagnostic.DiagnosticLevelError {
+			continue
+		}
+
+		source, err := DefaultReadFileProvider(item.Span.Filename)
+		if err != nil {
+			return err
+		}
+		fmt.Println(item.Display(source))
+
+		return errors.New("Encountered semantic error(s)")
+	}
+
+	moduleName := strings.TrimSuffix(filepath.Base(filename), ".hms")
+	output := doc.Generate(analyzed[filename], moduleName, format, includePrivate)
+
+	if outputPath == "" {
+		fmt.Print(output)
+		return nil
+	}
+
+	return os.WriteFile(outputPath, []byte(output), 0644)
+}
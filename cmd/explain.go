@@ -0,0 +1,26 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/smarthome-go/homescript/v3/homescript/errors"
+)
+
+// Prints the long-form explanation of the given diagnostic code.
+// If the code is empty, all known codes are listed instead.
+func explainCode(from string) error {
+	if from == "" {
+		for _, code := range errors.Codes() {
+			fmt.Printf("%s: %s\n", code, code.Title())
+		}
+		return nil
+	}
+
+	code, found := errors.ParseCode(from)
+	if !found {
+		return fmt.Errorf("Unknown code `%s`: use `%s explain` to list all codes", from, programName)
+	}
+
+	fmt.Printf("%s: %s\n\n%s\n", code, code.Title(), code.Explanation())
+	return nil
+}
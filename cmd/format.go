@@ -0,0 +1,51 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/smarthome-go/homescript/v3/homescript/formatter"
+)
+
+// Formats the given files in place.
+// In check mode, files are not modified, instead, an error is returned if any file is not formatted.
+func formatFiles(filenames []string, check bool) error {
+	unformatted := 0
+
+	for _, filename := range filenames {
+		file, err := os.ReadFile(filename)
+		if err != nil {
+			return err
+		}
+
+		formatted, syntaxErrors := formatter.Format(string(file), filename)
+		if len(syntaxErrors) > 0 {
+			for _, syntaxErr := range syntaxErrors {
+				fmt.Println(syntaxErr.Display(string(file)))
+			}
+			return fmt.Errorf("Could not format `%s`: encountered syntax error(s)", filename)
+		}
+
+		if formatted == string(file) {
+			continue
+		}
+
+		unformatted++
+
+		if check {
+			fmt.Println(filename)
+			continue
+		}
+
+		if err := os.WriteFile(filename, []byte(formatted), 0644); err != nil {
+			return err
+		}
+	}
+
+	if check && unformatted > 0 {
+		return errors.New("Some files are not formatted")
+	}
+
+	return nil
+}
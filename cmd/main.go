@@ -14,9 +14,13 @@ import (
 	"github.com/davecgh/go-spew/spew"
 	"github.com/smarthome-go/homescript/v3/homescript"
 	"github.com/smarthome-go/homescript/v3/homescript/analyzer/ast"
+	"github.com/smarthome-go/homescript/v3/homescript/compiler"
 	"github.com/smarthome-go/homescript/v3/homescript/diagnostic"
+	"github.com/smarthome-go/homescript/v3/homescript/doc"
 	"github.com/smarthome-go/homescript/v3/homescript/fuzzer"
+	"github.com/smarthome-go/homescript/v3/homescript/lsp"
 	"github.com/smarthome-go/homescript/v3/homescript/optimizer"
+	"github.com/smarthome-go/homescript/v3/homescript/test"
 	"github.com/urfave/cli/v2"
 )
 
@@ -36,6 +40,7 @@ func fileValidator(ctx *cli.Context) error {
 func analyzeFile(
 	program string,
 	pathS string,
+	requireMain bool,
 	printAnalyzed bool,
 	printDiagnostics bool,
 	fileReader func(path string) (string, error),
@@ -49,7 +54,7 @@ func analyzeFile(
 		homescript.TestingAnalyzerHost{
 			IsInvokedInTests: false,
 		},
-		true,
+		requireMain,
 	)
 
 	if len(syntaxErrors) != 0 {
@@ -85,7 +90,7 @@ func analyzeFile(
 			return nil, "", fmt.Errorf("Could not read file `%s`: %s\n%s | %v", item.Span.Filename, err.Error(), item.Message, item.Span)
 		}
 
-		fmt.Println(item.Display(string(file)))
+		fmt.Println(item.DisplayWithSources(string(file), readSource))
 	}
 
 	if abort {
@@ -178,7 +183,7 @@ func main() {
 						return err
 					}
 
-					analyzed, entryModule, err := analyzeFile(string(file), filename, true, true, DefaultReadFileProvider)
+					analyzed, entryModule, err := analyzeFile(string(file), filename, true, true, true, DefaultReadFileProvider)
 					if err != nil {
 						return err
 					}
@@ -199,6 +204,18 @@ func main() {
 						Usage:   "If set, the VM asm is printed.",
 						Aliases: []string{"s"},
 					},
+					&cli.StringFlag{
+						Name:  "record",
+						Usage: "If set, a trace of the execution is recorded to this path, see `replay`.",
+					},
+					&cli.StringFlag{
+						Name:  "profile",
+						Usage: "If set, a pprof profile of the execution is written to this path.",
+					},
+					&cli.BoolFlag{
+						Name:  "cache",
+						Usage: "If set, the program is loaded from its `.hmsc` artifact if it is up to date, otherwise the artifact is updated.",
+					},
 				},
 				Before: fileValidator,
 				Action: func(c *cli.Context) error {
@@ -210,13 +227,16 @@ func main() {
 						return err
 					}
 
-					analyzedAndOpt, entryModule, err := analyzeFile(string(file), filename, true, true, DefaultReadFileProvider)
+					var code compiler.CompileOutput
+					if c.Bool("cache") {
+						code, err = cachedCompile(string(file), filename)
+					} else {
+						code, err = compileFile(string(file), filename, true)
+					}
 					if err != nil {
 						return err
 					}
 
-					code := CompileVm(analyzedAndOpt, entryModule)
-
 					if emitAsm {
 						fmt.Println("========= COMPILED (ASM) ============")
 						fmt.Println(code.AsmString(true))
@@ -225,7 +245,7 @@ func main() {
 						for key, annotations := range code.Annotations {
 							module := fmt.Sprintf("mod %s", key.Module)
 
-							if key.Module == entryModule {
+							if key.Module == filename {
 								module = fmt.Sprintf("mod (MAIN) %s", key.Module)
 							}
 
@@ -233,11 +253,259 @@ func main() {
 						}
 					}
 
+					if tracePath := c.String("record"); tracePath != "" {
+						return recordVm(code, tracePath)
+					}
+
+					if profilePath := c.String("profile"); profilePath != "" {
+						return profileVm(code, profilePath)
+					}
+
 					TestingRunVm(code, true, DefaultReadFileProvider)
 
 					return nil
 				},
 			},
+			{
+				Name:      "coverage",
+				Usage:     "Run Homescript files using the VM and report which of their code was executed",
+				ArgsUsage: "[files...]",
+				Args:      true,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "lcov",
+						Usage: "If set, the coverage is written to this path in the LCOV format.",
+					},
+					&cli.StringFlag{
+						Name:  "html",
+						Usage: "If set, an HTML report of the annotated source code is written to this path.",
+					},
+				},
+				Action: func(c *cli.Context) error {
+					if c.Args().Len() == 0 {
+						return fmt.Errorf("Expected at least one argument <file>")
+					}
+
+					return runCoverage(c.Args().Slice(), c.String("lcov"), c.String("html"))
+				},
+			},
+			{
+				Name:      "test",
+				Usage:     "Run the `#[test]` functions of Homescript files, each in a new VM",
+				ArgsUsage: "[paths...]",
+				Args:      true,
+				Flags: []cli.Flag{
+					&cli.DurationFlag{
+						Name:  "timeout",
+						Usage: "Each test fails if it does not terminate within this duration.",
+						Value: test.DefaultTimeout,
+					},
+				},
+				Action: func(c *cli.Context) error {
+					return runTests(c.Args().Slice(), c.Duration("timeout"))
+				},
+			},
+			{
+				Name:      "replay",
+				Usage:     "Deterministically re-execute a trace recorded by `vm --record`",
+				ArgsUsage: "[trace]",
+				Args:      true,
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "debug",
+						Usage: "If set, the replay runs under the debugger, which also supports the reverse commands `rsi` and `rc`.",
+					},
+				},
+				Before: fileValidator,
+				Action: func(c *cli.Context) error {
+					return replayTrace(c.Args().Get(0), c.Bool("debug"))
+				},
+			},
+			{
+				Name:      "compile",
+				Usage:     "Compile a Homescript file and its imports into a bytecode artifact",
+				ArgsUsage: "[file]",
+				Args:      true,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:    "output",
+						Usage:   "Path of the artifact. (Default is the input path with the `.hmsc` extension)",
+						Aliases: []string{"o"},
+					},
+				},
+				Before: fileValidator,
+				Action: func(c *cli.Context) error {
+					filename := c.Args().Get(0)
+
+					output := c.String("output")
+					if output == "" {
+						output = artifactPath(filename)
+					}
+
+					return compileArtifact(filename, output)
+				},
+			},
+			{
+				Name:      "exec",
+				Usage:     "Run a bytecode artifact produced by `compile` using the VM interpreter",
+				ArgsUsage: "[artifact]",
+				Args:      true,
+				Before:    fileValidator,
+				Action: func(c *cli.Context) error {
+					return execArtifact(c.Args().Get(0))
+				},
+			},
+			{
+				Name:      "fmt",
+				Usage:     "Format Homescript files in place",
+				ArgsUsage: "[files...]",
+				Args:      true,
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:    "check",
+						Usage:   "If set, files are not modified. Instead, unformatted files are listed and the command fails.",
+						Aliases: []string{"c"},
+					},
+				},
+				Before: func(ctx *cli.Context) error {
+					if ctx.Args().Len() == 0 {
+						return fmt.Errorf("Expected at least one argument <file>")
+					}
+					return nil
+				},
+				Action: func(c *cli.Context) error {
+					return formatFiles(c.Args().Slice(), c.Bool("check"))
+				},
+			},
+			{
+				Name:      "check",
+				Usage:     "Report the diagnostics of Homescript files",
+				ArgsUsage: "[files...]",
+				Args:      true,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:    "format",
+						Usage:   "Output format: text, json (one diagnostic per line), or sarif",
+						Value:   "text",
+						Aliases: []string{"f"},
+					},
+					&cli.BoolFlag{
+						Name:    "main",
+						Usage:   "If set, each file is required to declare a main function.",
+						Aliases: []string{"m"},
+					},
+					&cli.BoolFlag{
+						Name:    "fix",
+						Usage:   "If set, suggested fixes are applied to the files before the remaining diagnostics are reported.",
+						Aliases: []string{"x"},
+					},
+				},
+				Before: func(ctx *cli.Context) error {
+					if ctx.Args().Len() == 0 {
+						return fmt.Errorf("Expected at least one argument <file>")
+					}
+					return nil
+				},
+				Action: func(c *cli.Context) error {
+					format, err := parseCheckFormat(c.String("format"))
+					if err != nil {
+						return err
+					}
+
+					return checkFiles(c.Args().Slice(), format, c.Bool("main"), c.Bool("fix"))
+				},
+			},
+			{
+				Name:  "repl",
+				Usage: "Start an interactive session which evaluates Homescript using the VM",
+				Action: func(c *cli.Context) error {
+					return runRepl(os.Stdin)
+				},
+			},
+			{
+				Name:      "explain",
+				Usage:     "Explain a diagnostic code, or list all codes if none is given",
+				ArgsUsage: "[code]",
+				Args:      true,
+				Before: func(ctx *cli.Context) error {
+					if ctx.Args().Len() > 1 {
+						return fmt.Errorf("Expected at most one argument <code>")
+					}
+					return nil
+				},
+				Action: func(c *cli.Context) error {
+					return explainCode(c.Args().Get(0))
+				},
+			},
+			{
+				Name:      "doc",
+				Usage:     "Render the documentation of a Homescript module",
+				ArgsUsage: "[file]",
+				Args:      true,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:    "format",
+						Usage:   "Output format: markdown or html",
+						Value:   "markdown",
+						Aliases: []string{"f"},
+					},
+					&cli.StringFlag{
+						Name:    "output",
+						Usage:   "Path of the output file. (Default is stdout)",
+						Aliases: []string{"o"},
+					},
+					&cli.BoolFlag{
+						Name:    "private",
+						Usage:   "If set, items which are not public are documented as well.",
+						Aliases: []string{"p"},
+					},
+				},
+				Before: fileValidator,
+				Action: func(c *cli.Context) error {
+					format, err := doc.ParseFormat(c.String("format"))
+					if err != nil {
+						return err
+					}
+
+					return generateDoc(c.Args().Get(0), format, c.Bool("private"), c.String("output"))
+				},
+			},
+			{
+				Name:  "lsp",
+				Usage: "Start a language server which communicates over stdin and stdout",
+				Action: func(c *cli.Context) error {
+					server := lsp.NewServer(
+						homescript.TestingAnalyzerHost{
+							IsInvokedInTests: false,
+						},
+						homescript.TestingAnalyzerScopeAdditions,
+					)
+
+					return server.Serve(os.Stdin, os.Stdout)
+				},
+			},
+			{
+				Name:      "debug",
+				Usage:     "Debug a Homescript file using the VM",
+				ArgsUsage: "[file]",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "dap",
+						Usage: "If set, the debugger speaks the Debug Adapter Protocol over stdin and stdout instead of debugging a file.",
+					},
+				},
+				Action: func(c *cli.Context) error {
+					if c.Bool("dap") {
+						return serveDap(os.Stdin, os.Stdout)
+					}
+
+					if c.Args().Len() != 1 {
+						return fmt.Errorf("Expected exactly one argument <file>, or use --dap")
+					}
+
+					return runTerminalDebugger(c.Args().Get(0))
+				},
+			},
 			{
 				Name:    "fuzz",
 				Aliases: []string{"f"},
@@ -317,7 +585,7 @@ func main() {
 								return err
 							}
 
-							analyzed, entryModule, err := analyzeFile(string(file), filename, true, true, DefaultReadFileProvider)
+							analyzed, entryModule, err := analyzeFile(string(file), filename, true, true, true, DefaultReadFileProvider)
 							if err != nil {
 								return err
 							}
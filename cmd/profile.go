@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"sync"
+
+	"github.com/smarthome-go/homescript/v3/homescript"
+	"github.com/smarthome-go/homescript/v3/homescript/compiler"
+	"github.com/smarthome-go/homescript/v3/homescript/runtime"
+)
+
+// Runs the main function of a program and writes a pprof profile of the execution to the given path.
+// The profile can be inspected using `go tool pprof`.
+func profileVm(code compiler.CompileOutput, profilePath string) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	executor := homescript.TestingVmExecutor{
+		PrintToStdout: true,
+		PrintBuf:      new(string),
+		PintBufMutex:  &sync.Mutex{},
+	}
+
+	vm := runtime.NewVM(code, executor, &ctx, &cancel, homescript.TestingVmScopeAdditions(), vmLimits)
+
+	vm.StartProfiling(0)
+	vm.SpawnAsync(runtime.MainFn(), nil, nil, nil)
+	coreNum, i := vm.Wait()
+	profile := vm.StopProfiling()
+
+	file, err := os.Create(profilePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if err := profile.WritePprof(file); err != nil {
+		return err
+	}
+
+	log.Printf("Wrote profile of %d sample(s) to `%s`\n", len(profile.Samples), profilePath)
+
+	return reportException(coreNum, i, ArtifactReadFileProvider)
+}
@@ -0,0 +1,595 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+
+	"github.com/smarthome-go/homescript/v3/homescript"
+	"github.com/smarthome-go/homescript/v3/homescript/analyzer"
+	"github.com/smarthome-go/homescript/v3/homescript/analyzer/ast"
+	"github.com/smarthome-go/homescript/v3/homescript/compiler"
+	"github.com/smarthome-go/homescript/v3/homescript/diagnostic"
+	herrors "github.com/smarthome-go/homescript/v3/homescript/errors"
+	"github.com/smarthome-go/homescript/v3/homescript/runtime"
+	vmValue "github.com/smarthome-go/homescript/v3/homescript/runtime/value"
+)
+
+const replFilename = "repl"
+
+// Statements are compiled into this function, which is replaced on every input.
+const replEvalFunction = "__repl_eval"
+
+// The evaluation function is opened on the same line as the input.
+// The function returns `any` so that it accepts any trailing expression.
+const replEvalPrefix = "fn " + replEvalFunction + "() -> any { "
+
+const replHelp = `Enter statements, expressions, or declarations (fn, type, import, ...).
+Top-level let bindings persist across inputs.
+
+Commands:
+  :type <expr>   Print the type of an expression without evaluating it
+  :asm <fn>      Print the bytecode of a function
+  :load <file>   Load the declarations of a file into the session
+  :help          Print this message
+  :quit          Exit the session`
+
+// A REPL session keeps one VM alive across all inputs.
+// Declarations are kept as source code and are analyzed again together with every input.
+// Modules are only initialized once, so that their globals keep their values.
+// Top-level let bindings are stored as globals of the VM.
+// Therefore, the analyzer treats them like builtin values.
+type replSession struct {
+	// Source code of all declarations entered so far.
+	items []string
+	// Types of the top-level let bindings of the session.
+	bindings map[string]ast.Type
+	vm       runtime.VM
+	// The most recently compiled program.
+	compiled compiler.CompileOutput
+	// Cancels the current evaluation, is set while code is running.
+	cancel      context.CancelFunc
+	cancelMutex sync.Mutex
+}
+
+func newReplSession() (*replSession, error) {
+	self := &replSession{
+		items:    make([]string, 0),
+		bindings: make(map[string]ast.Type),
+	}
+
+	modules, ok := self.analyze(newReplSource(self.source("")), false)
+	if !ok {
+		return nil, errors.New("Could not analyze the empty session")
+	}
+
+	compiled, err := compileRepl(modules)
+	if err != nil {
+		return nil, err
+	}
+
+	rawExecutor := homescript.TestingVmExecutor{
+		PrintToStdout: true,
+		PrintBuf:      new(string),
+		PintBufMutex:  &sync.Mutex{},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	self.vm = runtime.NewVM(compiled, vmValue.Executor(rawExecutor), &ctx, &cancel, homescript.TestingVmScopeAdditions(), vmLimits)
+
+	self.compiled = compiled
+	return self, nil
+}
+
+func runRepl(input io.Reader) error {
+	session, err := newReplSession()
+	if err != nil {
+		return err
+	}
+
+	// An interrupt aborts the running evaluation instead of the whole session.
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt)
+	defer signal.Stop(interrupts)
+	go func() {
+		for range interrupts {
+			session.cancelMutex.Lock()
+			if session.cancel != nil {
+				session.cancel()
+			}
+			session.cancelMutex.Unlock()
+		}
+	}()
+
+	reader := bufio.NewReader(input)
+	for {
+		line, done := readReplInput(reader)
+		line = strings.TrimSpace(line)
+
+		if line == ":quit" || line == ":q" {
+			return nil
+		}
+
+		if line != "" {
+			session.handle(line)
+		}
+
+		if done {
+			fmt.Println()
+			return nil
+		}
+	}
+}
+
+// Reads lines until all brackets of the input are closed.
+// Returns `done = true` if the input has ended.
+func readReplInput(reader *bufio.Reader) (input string, done bool) {
+	prompt := ">> "
+	for {
+		fmt.Print(prompt)
+		line, err := reader.ReadString('\n')
+		input += line
+
+		if err != nil {
+			return input, true
+		}
+
+		if replInputComplete(input) {
+			return input, false
+		}
+
+		prompt = ".. "
+	}
+}
+
+// Reports whether all brackets of the input are closed.
+// Brackets inside of strings and comments are ignored.
+func replInputComplete(input string) bool {
+	depth := 0
+	var quote rune
+	escaped := false
+	comment := false
+	prev := rune(0)
+
+	for _, char := range input {
+		switch {
+		case comment:
+			if char == '\n' {
+				comment = false
+			}
+		case quote != 0:
+			if escaped {
+				escaped = false
+			} else if char == '\\' {
+				escaped = true
+			} else if char == quote {
+				quote = 0
+			}
+		case char == '/' && prev == '/':
+			comment = true
+		case char == '"' || char == '\'':
+			quote = char
+		case char == '(' || char == '[' || char == '{':
+			depth++
+		case char == ')' || char == ']' || char == '}':
+			depth--
+		}
+		prev = char
+	}
+
+	return depth <= 0 && quote == 0
+}
+
+func (self *replSession) handle(input string) {
+	command, arg, _ := strings.Cut(input, " ")
+	arg = strings.TrimSpace(arg)
+
+	switch command {
+	case ":help", ":h":
+		fmt.Println(replHelp)
+	case ":type", ":t":
+		self.printType(arg)
+	case ":asm":
+		self.printAsm(arg)
+	case ":load", ":l":
+		source, err := os.ReadFile(arg)
+		if err != nil {
+			fmt.Printf("Could not read file `%s`: %s\n", arg, err.Error())
+			return
+		}
+		self.declare(string(source))
+	default:
+		if strings.HasPrefix(command, ":") {
+			fmt.Printf("Unknown command `%s`, see `:help`\n", command)
+			return
+		}
+
+		if isReplDeclaration(input) {
+			self.declare(input)
+		} else {
+			self.eval(input)
+		}
+	}
+}
+
+// Reports whether the input only consists of declarations, like functions or imports.
+// Top-level let statements are evaluated as statements so that they become bindings of the session.
+func isReplDeclaration(input string) bool {
+	program, errs, criticalErr := homescript.Parse(input, replFilename)
+	if len(errs) != 0 || criticalErr != nil || len(program.Globals) != 0 {
+		return false
+	}
+
+	return len(program.Imports)+len(program.Types)+len(program.Singletons)+len(program.ImplBlocks)+len(program.Functions) != 0
+}
+
+// Adds declarations to the session if they are valid and their initialization succeeds.
+func (self *replSession) declare(input string) {
+	source := newReplSource(self.source(input))
+
+	modules, ok := self.analyze(source, false)
+	if !ok {
+		return
+	}
+
+	if self.run(source, modules, nil, nil) {
+		self.items = append(self.items, input)
+	}
+}
+
+// Evaluates statements and an optional trailing expression.
+func (self *replSession) eval(input string) {
+	source := self.evalSource(input)
+
+	modules, ok := self.analyze(source, true)
+	if !ok {
+		return
+	}
+
+	body := replEvalBody(modules)
+
+	// Top-level let statements are rewritten into assignments to globals of the VM.
+	edits := make([]diagnostic.Edit, 0)
+	newBindings := make(map[string]ast.Type)
+	for _, statement := range body.Statements {
+		if statement.Kind() != ast.LetStatementKind {
+			continue
+		}
+
+		let := statement.(ast.AnalyzedLetStatement)
+
+		// Function values cannot be assigned, therefore they cannot become bindings.
+		if let.VarType.Kind() == ast.FnTypeKind {
+			fmt.Printf("Function values cannot be bound to `%s`: use `fn %s(...) { ... }` instead\n", let.Ident.Ident(), let.Ident.Ident())
+			return
+		}
+
+		newBindings[let.Ident.Ident()] = let.VarType
+		edits = append(edits, replBindingEdit(source.code, let))
+	}
+
+	resultType := ast.Type(ast.NewNullType(herrors.Span{}))
+	if body.Expression != nil {
+		resultType = body.Expression.Type()
+	}
+
+	// If the evaluation fails, all bindings of this input are restored.
+	previousTypes := make(map[string]ast.Type)
+	previousValues := make(map[string]vmValue.Value)
+	globals := self.vm.GetGlobals()
+	for name, typ := range newBindings {
+		if previous, found := self.bindings[name]; found {
+			previousTypes[name] = previous
+			previousValues[name] = globals[name]
+		}
+
+		self.bindings[name] = typ
+		if _, found := globals[name]; !found {
+			globals[name] = *vmValue.NewValueNull()
+		}
+	}
+
+	restore := func() {
+		for name := range newBindings {
+			if previous, found := previousTypes[name]; found {
+				self.bindings[name] = previous
+				globals[name] = previousValues[name]
+			} else {
+				delete(self.bindings, name)
+				delete(globals, name)
+			}
+		}
+	}
+
+	// The rewritten code has to be analyzed again, as the new bindings are now builtin values.
+	if len(edits) != 0 {
+		source.code, _ = diagnostic.ApplyFixes(source.code, []*diagnostic.Fix{diagnostic.NewFix("", edits...)})
+
+		if modules, ok = self.analyze(source, false); !ok {
+			restore()
+			return
+		}
+	}
+
+	if !self.run(source, modules, &runtime.FunctionInvocation{
+		Function:    replEvalFunction,
+		LiteralName: false,
+		Args:        make([]vmValue.Value, 0),
+		FunctionSignature: runtime.FunctionInvocationSignature{
+			Params:     []runtime.FunctionInvocationSignatureParam{},
+			ReturnType: resultType,
+		},
+	}, func(result vmValue.Value) {
+		switch resultType.Kind() {
+		case ast.NullTypeKind, ast.NeverTypeKind:
+			return
+		}
+
+		disp, i := result.Display()
+		if i != nil {
+			fmt.Println((*i).Message())
+			return
+		}
+
+		fmt.Printf("%s: %s\n", disp, resultType)
+	}) {
+		restore()
+	}
+}
+
+// Rewrites a let statement into an assignment to the global of its binding.
+// The layout of the code is preserved so that spans still refer to the input:
+// everything in front of the `=` except for the identifier and newlines is replaced by spaces.
+func replBindingEdit(code string, let ast.AnalyzedLetStatement) diagnostic.Edit {
+	span := let.Range
+	span.End = let.Expression.Span().Start
+	span.End.Index--
+
+	replacement := []rune(code)[span.Start.Index : span.End.Index+1]
+
+	assignment := len(replacement) - 1
+	for replacement[assignment] != '=' {
+		assignment--
+	}
+
+	ident := let.Ident.Span()
+	for idx, char := range replacement[:assignment] {
+		index := span.Start.Index + uint(idx)
+		if char != '\n' && (index < ident.Start.Index || index > ident.End.Index) {
+			replacement[idx] = ' '
+		}
+	}
+
+	return diagnostic.Edit{Span: span, Replacement: string(replacement)}
+}
+
+// Prints the type of an expression without evaluating it.
+func (self *replSession) printType(input string) {
+	modules, ok := self.analyze(self.evalSource(input), true)
+	if !ok {
+		return
+	}
+
+	body := replEvalBody(modules)
+	if body.Expression == nil {
+		fmt.Println(ast.NewNullType(herrors.Span{}))
+		return
+	}
+
+	fmt.Println(body.Expression.Type())
+}
+
+func (self *replSession) printAsm(function string) {
+	mangled, found := self.compiled.Mappings.Functions[function]
+	if !found {
+		fmt.Printf("Function `%s` does not exist\n", function)
+		return
+	}
+
+	fmt.Println(self.compiled.AsmStringHighlight(stdoutIsTerminal(), &mangled, nil))
+}
+
+// Reports whether stdout is a terminal, output is only colored in this case.
+func stdoutIsTerminal() bool {
+	stat, err := os.Stdout.Stat()
+	if err != nil {
+		return false
+	}
+	return stat.Mode()&os.ModeCharDevice != 0
+}
+
+// Returns the source code of all declarations, preceded by the input.
+// The input comes first so that its line numbers are preserved in diagnostics.
+func (self *replSession) source(input string) string {
+	return strings.Join(append([]string{input}, self.items...), "\n")
+}
+
+// Wraps statements into the evaluation function.
+// The opening brace is placed on the same line as the input so that its line numbers are preserved.
+// The function is hidden in diagnostics.
+func (self *replSession) evalSource(input string) replSource {
+	return replSource{
+		code:    self.source(fmt.Sprintf("%s%s\n}", replEvalPrefix, input)),
+		display: self.source(fmt.Sprintf("%s\n", input)),
+		offset:  uint(len(replEvalPrefix)),
+	}
+}
+
+// Code of the entry module of the session, it consists of one input and all declarations.
+type replSource struct {
+	// The code which is analyzed and compiled.
+	code string
+	// The code which is shown in diagnostics.
+	// It is the code without the first `offset` characters, the rest of its layout is the same.
+	display string
+	offset  uint
+}
+
+func newReplSource(code string) replSource {
+	return replSource{code: code, display: code, offset: 0}
+}
+
+func (self replSource) read(filename string) (string, bool) {
+	if filename == replFilename {
+		return self.display, true
+	}
+	return readSource(filename)
+}
+
+// Maps a span of the code to the displayed code.
+// Spans inside of the hidden prefix are moved to the start of the input.
+func (self replSource) span(span herrors.Span) herrors.Span {
+	if span.Filename != replFilename || self.offset == 0 {
+		return span
+	}
+
+	shift := func(location herrors.Location) herrors.Location {
+		if location.Index < self.offset {
+			return herrors.Location{Line: 1, Column: 1, Index: 0}
+		}
+
+		location.Index -= self.offset
+		if location.Line == 1 {
+			location.Column -= self.offset
+		}
+		return location
+	}
+
+	span.Start = shift(span.Start)
+	span.End = shift(span.End)
+	return span
+}
+
+func (self replSource) printDiagnostic(d diagnostic.Diagnostic) {
+	d.Span = self.span(d.Span)
+
+	labels := make([]diagnostic.Label, len(d.Labels))
+	for idx, label := range d.Labels {
+		label.Span = self.span(label.Span)
+		labels[idx] = label
+	}
+	d.Labels = labels
+
+	file, _ := self.read(d.Span.Filename)
+	fmt.Println(d.DisplayWithSources(file, self.read))
+}
+
+// Unlike `CompileVm`, unreachable functions are kept as they may be called by later inputs.
+func compileRepl(modules map[string]ast.AnalyzedProgram) (compiler.CompileOutput, error) {
+	compilerStruct := compiler.NewCompiler(modules, replFilename)
+	return compilerStruct.Compile()
+}
+
+func replEvalBody(modules map[string]ast.AnalyzedProgram) ast.AnalyzedBlock {
+	for _, fn := range modules[replFilename].Functions {
+		if fn.Ident.Ident() == replEvalFunction {
+			return fn.Body
+		}
+	}
+	panic(fmt.Sprintf("Function `%s` was not analyzed", replEvalFunction))
+}
+
+// Analyzes the source code and prints its diagnostics.
+// Warnings about unused items are omitted as they may still be used by later inputs.
+// Returns `ok = false` if there are errors.
+func (self *replSession) analyze(source replSource, printWarnings bool) (modules map[string]ast.AnalyzedProgram, ok bool) {
+	scopeAdditions := homescript.TestingAnalyzerScopeAdditions()
+	for name, typ := range self.bindings {
+		scopeAdditions[name] = analyzer.NewBuiltinVar(typ)
+	}
+
+	modules, diagnostics, syntaxErrors := homescript.Analyze(
+		homescript.InputProgram{
+			ProgramText: source.code,
+			Filename:    replFilename,
+		},
+		scopeAdditions,
+		homescript.TestingAnalyzerHost{},
+		false,
+	)
+
+	for _, syntaxErr := range syntaxErrors {
+		syntaxErr.Span = source.span(syntaxErr.Span)
+		file, _ := source.read(syntaxErr.Span.Filename)
+		fmt.Println(syntaxErr.Display(file))
+	}
+
+	ok = len(syntaxErrors) == 0
+	for _, item := range diagnostics {
+		switch item.Code {
+		case herrors.UnusedVariable, herrors.UnusedType, herrors.UnusedImport, herrors.UnusedFunction, herrors.UnusedSingleton:
+			continue
+		}
+
+		if item.Level == diagnostic.DiagnosticLevelError {
+			ok = false
+		} else if !printWarnings {
+			continue
+		}
+
+		source.printDiagnostic(item)
+	}
+
+	return modules, ok
+}
+
+// Compiles the analyzed modules, loads them into the VM, and calls the invocation, if any.
+// The VM keeps its globals, so that the state of modules is preserved across inputs.
+// The result of the invocation is passed to `onResult`.
+// Returns `false` if the code could not be executed successfully.
+func (self *replSession) run(source replSource, modules map[string]ast.AnalyzedProgram, invocation *runtime.FunctionInvocation, onResult func(vmValue.Value)) bool {
+	compiled, err := compileRepl(modules)
+	if err != nil {
+		fmt.Println(err.Error())
+		return false
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	self.cancelMutex.Lock()
+	self.vm.CancelCtx = &ctx
+	self.vm.CancelFunc = &cancel
+	self.cancel = cancel
+	self.cancelMutex.Unlock()
+
+	defer func() {
+		self.cancelMutex.Lock()
+		self.cancel = nil
+		self.cancelMutex.Unlock()
+	}()
+
+	if err := self.vm.Reload(compiled); err != nil {
+		fmt.Println(err.Error())
+		return false
+	}
+
+	self.compiled = compiled
+
+	if invocation == nil {
+		return true
+	}
+
+	result := self.vm.SpawnSync(*invocation, nil, nil)
+	if result.Exception != nil {
+		i := result.Exception.Interrupt
+		source.printDiagnostic(diagnostic.Diagnostic{
+			Level:   diagnostic.DiagnosticLevelError,
+			Message: i.Message(),
+			Notes:   []string{},
+			Span:    i.GetSpan(),
+		})
+		return false
+	}
+
+	onResult(result.ReturnValue)
+	return true
+}
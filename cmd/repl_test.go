@@ -0,0 +1,122 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Returns everything which is printed to stdout while `fn` runs.
+func captureStdout(t *testing.T, fn func()) string {
+	reader, writer, err := os.Pipe()
+	assert.NoError(t, err)
+
+	stdout := os.Stdout
+	os.Stdout = writer
+	defer func() { os.Stdout = stdout }()
+
+	output := make(chan string)
+	go func() {
+		out, _ := io.ReadAll(reader)
+		output <- string(out)
+	}()
+
+	fn()
+	writer.Close()
+
+	return <-output
+}
+
+// Changes the working directory until the test has finished.
+func chdir(t *testing.T, dir string) {
+	wd, err := os.Getwd()
+	assert.NoError(t, err)
+	assert.NoError(t, os.Chdir(dir))
+	t.Cleanup(func() { os.Chdir(wd) })
+}
+
+// Runs a REPL session on the input and returns everything it printed.
+func runReplCapture(t *testing.T, input string) string {
+	return captureStdout(t, func() {
+		assert.NoError(t, runRepl(strings.NewReader(input)))
+	})
+}
+
+func TestReplBindings(t *testing.T) {
+	output := runReplCapture(t, "let x = 40;\nx + 2\nlet x = x * 2;\nx\n")
+
+	assert.Contains(t, output, "42: int")
+	assert.Contains(t, output, "80: int")
+}
+
+func TestReplKeepsModuleState(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "counter.hms"), []byte(`let count = 0;
+pub fn inc() -> int { count += 1; count }
+`), 0o644))
+
+	chdir(t, dir)
+
+	// Every input compiles a new program, the module must not be initialized again.
+	output := runReplCapture(t, "import { inc } from counter;\ninc()\nfn twice() { inc(); inc(); }\ntwice();\ninc()\n")
+
+	assert.Contains(t, output, "1: int")
+	assert.Contains(t, output, "4: int")
+}
+
+func TestReplErrorsReferToInput(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Input    string
+		Location string
+		Line     string
+	}{
+		{
+			Name:     "analyzer error",
+			Input:    "let a = 1; a + missing",
+			Location: "repl:1:16",
+			Line:     "let a = 1; a + missing",
+		},
+		{
+			Name:     "runtime error",
+			Input:    "let l = [1, 2]; l[5]",
+			Location: "repl:1:17",
+			Line:     "let l = [1, 2]; l[5]",
+		},
+		{
+			Name:     "runtime error of a binding",
+			Input:    "let b: int = (\n[1][3]);",
+			Location: "repl:2:1",
+			Line:     "[1][3]);",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			output := runReplCapture(t, test.Input+"\n")
+
+			assert.Contains(t, output, test.Location)
+			assert.Contains(t, output, test.Line)
+			assert.NotContains(t, output, replEvalFunction)
+		})
+	}
+}
+
+func TestReplAsmWithoutTerminal(t *testing.T) {
+	output := runReplCapture(t, "fn one() -> int { 1 }\n:asm one\n")
+
+	assert.Contains(t, output, "Return")
+	assert.NotContains(t, output, "\x1b[")
+}
+
+func TestReplInputComplete(t *testing.T) {
+	assert.True(t, replInputComplete("1 + 2\n"))
+	assert.False(t, replInputComplete("fn foo() {\n"))
+	assert.True(t, replInputComplete("\"{\"\n"))
+	assert.True(t, replInputComplete("// {\n"))
+	assert.False(t, replInputComplete("[1, (2\n"))
+}
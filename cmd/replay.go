@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+
+	"github.com/smarthome-go/homescript/v3/homescript"
+	"github.com/smarthome-go/homescript/v3/homescript/compiler"
+	"github.com/smarthome-go/homescript/v3/homescript/diagnostic"
+	"github.com/smarthome-go/homescript/v3/homescript/runtime"
+	vmValue "github.com/smarthome-go/homescript/v3/homescript/runtime/value"
+)
+
+// Output which is replayed while the debugger rewinds has already been printed before.
+type replayExecutor struct {
+	homescript.TestingVmExecutor
+	silent func() bool
+}
+
+func (self replayExecutor) WriteStringTo(input string) error {
+	if self.silent() {
+		return nil
+	}
+	return self.TestingVmExecutor.WriteStringTo(input)
+}
+
+// Runs the main function of a program and records a trace of the execution to the given path.
+func recordVm(code compiler.CompileOutput, tracePath string) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	executor := homescript.TestingVmExecutor{
+		PrintToStdout: true,
+		PrintBuf:      new(string),
+		PintBufMutex:  &sync.Mutex{},
+	}
+
+	vm := runtime.NewRecordingVM(code, executor, &ctx, &cancel, homescript.TestingVmScopeAdditions(), vmLimits)
+
+	vm.SpawnAsync(runtime.MainFn(), nil, nil, nil)
+	coreNum, i := vm.Wait()
+	exceptionErr := reportException(coreNum, i, ArtifactReadFileProvider)
+
+	// A trace which ends in an exception is the most useful one.
+	trace, err := runtime.EncodeTrace(*vm.Trace(), code)
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(tracePath, trace, 0644); err != nil {
+		return err
+	}
+
+	log.Printf("Recorded %d event(s) into `%s`\n", len(vm.Trace().Events), tracePath)
+
+	return exceptionErr
+}
+
+// Replays a trace which was recorded by `recordVm`.
+// If `debug` is set, the replay runs under the terminal debugger, which is then able to execute in reverse.
+func replayTrace(tracePath string, debug bool) error {
+	file, err := os.ReadFile(tracePath)
+	if err != nil {
+		return err
+	}
+
+	trace, code, err := runtime.DecodeTrace(file)
+	if err != nil {
+		if errors.Is(err, runtime.ErrTraceVersionMismatch) || errors.Is(err, compiler.ErrBytecodeVersionMismatch) {
+			return fmt.Errorf("%s: the trace must be recorded again", err.Error())
+		}
+		return err
+	}
+
+	var debugger *homescript.Debugger
+	if debug {
+		// The source code is optional, like for bytecode artifacts.
+		mainFn := code.SourceMap[code.Mappings.Functions[compiler.MainFunctionIdent]]
+		source := ""
+		if len(mainFn) != 0 {
+			source, _ = ArtifactReadFileProvider(mainFn[0].Filename)
+		}
+
+		d := homescript.NewDebugger(nil, nil, nil, source, code)
+		debugger = &d
+	}
+
+	for {
+		ctx, cancel := context.WithCancel(context.Background())
+
+		executor := replayExecutor{
+			TestingVmExecutor: homescript.TestingVmExecutor{
+				PrintToStdout: true,
+				PrintBuf:      new(string),
+				PintBufMutex:  &sync.Mutex{},
+			},
+			silent: func() bool { return debugger != nil && debugger.Rewinding() },
+		}
+
+		vm, err := runtime.NewReplayVM(code, trace, vmValue.Executor(executor), &ctx, &cancel, homescript.TestingVmScopeAdditions(), vmLimits)
+		if err != nil {
+			cancel()
+			return err
+		}
+
+		if debugger != nil {
+			debugger.Restart()
+			debugger.AttachReplay(cancel)
+			vm.DebugHook = debugger.Hook()
+		}
+
+		vm.SpawnAsync(runtime.MainFn(), nil, nil, nil)
+
+		coreNum, i := vm.Wait()
+		cancel()
+
+		// Each rewind cancels the replay and starts it from the beginning.
+		if debugger != nil && debugger.Rewinding() {
+			continue
+		}
+
+		return reportException(coreNum, i, ArtifactReadFileProvider)
+	}
+}
+
+// Prints the exception which terminated a VM, if there is one.
+func reportException(coreNum uint, i *vmValue.VmInterrupt, readFile func(path string) (string, error)) error {
+	if i == nil {
+		return nil
+	}
+
+	d := diagnostic.Diagnostic{
+		Level:   diagnostic.DiagnosticLevelError,
+		Message: (*i).Message(),
+		Notes:   []string{fmt.Sprintf("Exception occurred on core %d", coreNum)},
+		Span:    (*i).GetSpan(),
+	}
+
+	file, _ := readFile(d.Span.Filename)
+	fmt.Println(d.Display(file))
+
+	return errors.New("Program terminated with an exception")
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/smarthome-go/homescript/v3/homescript"
+	"github.com/smarthome-go/homescript/v3/homescript/analyzer"
+	"github.com/smarthome-go/homescript/v3/homescript/compiler"
+	"github.com/smarthome-go/homescript/v3/homescript/diagnostic"
+	"github.com/smarthome-go/homescript/v3/homescript/optimizer"
+	pAst "github.com/smarthome-go/homescript/v3/homescript/parser/ast"
+	"github.com/smarthome-go/homescript/v3/homescript/runtime/value"
+	"github.com/smarthome-go/homescript/v3/homescript/test"
+)
+
+// Discovers and runs the `#[test]` functions of the given files and directories.
+// Directories are searched recursively for Homescript files.
+// A test which is imported by several files is only run once.
+func runTests(paths []string, timeout time.Duration) error {
+	filenames, err := testFiles(paths)
+	if err != nil {
+		return err
+	}
+
+	passed, failed := 0, 0
+	seen := make(map[string]struct{})
+
+	for _, filename := range filenames {
+		file, err := os.ReadFile(filename)
+		if err != nil {
+			return err
+		}
+
+		// Library modules and fixtures are not required to contain any tests.
+		if !containsTests(string(file), filename) {
+			continue
+		}
+
+		analyzed, entryModule, err := analyzeFile(string(file), filename, false, false, true, ArtifactReadFileProvider)
+		if err != nil {
+			fmt.Printf("%s: %s\n", filename, err.Error())
+			failed++
+			continue
+		}
+
+		// Test functions are roots of the tree shaker, therefore, they are kept even if they are part of an imported module.
+		compilerStruct := compiler.NewCompiler(optimizer.TreeShake(analyzed, entryModule), entryModule)
+		code, err := compilerStruct.Compile()
+		if err != nil {
+			return err
+		}
+
+		for _, current := range test.Discover(code) {
+			// Imported modules are named differently than the same file given as an entry module.
+			key := fmt.Sprintf("%s::%s", filepath.Clean(modulePath(current.Module)), current.Function)
+			if _, found := seen[key]; found {
+				continue
+			}
+			seen[key] = struct{}{}
+
+			output := new(string)
+			result := test.Run(code, current, test.Options{
+				Timeout: timeout,
+				Limits:  vmLimits,
+				NewExecutor: func() value.Executor {
+					*output = ""
+					return homescript.TestingVmExecutor{
+						PrintToStdout: false,
+						PrintBuf:      output,
+						PintBufMutex:  &sync.Mutex{},
+					}
+				},
+				NewScopeAdditions: homescript.TestingVmScopeAdditions,
+			})
+
+			fmt.Printf("test %s ... %s (%v)\n", current, result.Outcome, result.Duration.Round(time.Microsecond))
+
+			if result.Outcome == test.Passed {
+				passed++
+				continue
+			}
+
+			failed++
+			reportTestFailure(result, *output)
+		}
+	}
+
+	status := "ok"
+	if failed != 0 {
+		status = "FAILED"
+	}
+
+	fmt.Printf("\ntest result: %s. %d passed; %d failed\n", status, passed, failed)
+
+	if failed != 0 {
+		return fmt.Errorf("%d test(s) failed", failed)
+	}
+
+	return nil
+}
+
+func reportTestFailure(result test.Result, output string) {
+	if result.Span.Filename == "" {
+		fmt.Println(result.Message)
+	} else {
+		d := diagnostic.Diagnostic{
+			Level:   diagnostic.DiagnosticLevelError,
+			Message: result.Message,
+			Notes:   []string{fmt.Sprintf("In test `%s`", result.Test)},
+			Span:    result.Span,
+		}
+
+		file, _ := ArtifactReadFileProvider(d.Span.Filename)
+		fmt.Println(d.Display(file))
+	}
+
+	if output != "" {
+		fmt.Printf("--- output of `%s` ---\n%s\n", result.Test, strings.TrimSuffix(output, "\n"))
+	}
+}
+
+// Returns whether the file declares a `#[test]` function.
+// Files which cannot be parsed are assumed to contain tests so that their errors are reported.
+func containsTests(source string, filename string) bool {
+	program, _, critical := homescript.Parse(source, filename)
+	if critical != nil {
+		return true
+	}
+
+	for _, fn := range program.Functions {
+		if fn.Annotation == nil {
+			continue
+		}
+
+		for _, item := range fn.Annotation.Items {
+			if ident, isIdent := item.(pAst.AnnotationItemIdent); isIdent && ident.Ident.Ident() == analyzer.TestAnnotation {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// Expands directories into the Homescript files they contain.
+func testFiles(paths []string) ([]string, error) {
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	filenames := make([]string, 0)
+
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+
+		if !info.IsDir() {
+			filenames = append(filenames, path)
+			continue
+		}
+
+		err = filepath.WalkDir(path, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+
+			if !entry.IsDir() && strings.HasSuffix(path, ".hms") {
+				filenames = append(filenames, path)
+			}
+
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return filenames, nil
+}
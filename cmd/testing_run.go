@@ -14,6 +14,7 @@ import (
 	"github.com/smarthome-go/homescript/v3/homescript/diagnostic"
 	"github.com/smarthome-go/homescript/v3/homescript/errors"
 	"github.com/smarthome-go/homescript/v3/homescript/interpreter/value"
+	"github.com/smarthome-go/homescript/v3/homescript/optimizer"
 	"github.com/smarthome-go/homescript/v3/homescript/runtime"
 	vmValue "github.com/smarthome-go/homescript/v3/homescript/runtime/value"
 )
@@ -25,7 +26,8 @@ var vmLimits = runtime.CoreLimits{
 }
 
 func CompileVm(analyzed map[string]ast.AnalyzedProgram, filename string) compiler.CompileOutput {
-	compilerStruct := compiler.NewCompiler(analyzed, filename)
+	// Unreachable code must not bloat the bytecode.
+	compilerStruct := compiler.NewCompiler(optimizer.TreeShake(analyzed, filename), filename)
 	compiled, err := compilerStruct.Compile()
 
 	if err != nil {
@@ -62,7 +64,17 @@ func TestingRunVm(compiled compiler.CompileOutput, printToStdout bool, readFile
 	executor := vmValue.Executor(rawExecutor)
 
 	start := time.Now()
-	vm := runtime.NewVM(compiled, executor, &ctx, &cancel, homescript.TestingVmScopeAdditions(), vmLimits)
+	vm, verifyErr := runtime.NewVerifiedVM(compiled, executor, &ctx, &cancel, homescript.TestingVmScopeAdditions(), vmLimits)
+	if verifyErr != nil {
+		d := diagnostic.Diagnostic{
+			Level:   diagnostic.DiagnosticLevelError,
+			Message: verifyErr.Error(),
+			Notes:   []string{},
+			Span:    errors.Span{},
+		}
+		fmt.Println(d.Display(""))
+		return "", &d
+	}
 
 	//
 	// Run all annotations which have a separate function.
@@ -122,9 +134,10 @@ func TestingRunVm(compiled compiler.CompileOutput, printToStdout bool, readFile
 		nil,
 	)
 
+	// The source code is optional: precompiled bytecode may be executed without it.
 	sourceCode, e := readFile(compiled.SourceMap[compiled.Mappings.Functions[compiler.MainFunctionIdent]][0].Filename)
 	if e != nil {
-		panic(e.Error())
+		sourceCode = ""
 	}
 
 	d := homescript.NewDebugger(
@@ -149,7 +162,9 @@ func TestingRunVm(compiled compiler.CompileOutput, printToStdout bool, readFile
 
 		file, err := readFile(i.GetSpan().Filename)
 		if err != nil {
-			panic(err.Error())
+			// Without the source code, only the filename can be displayed.
+			d.Span.Start = errors.Location{}
+			d.Span.End = errors.Location{}
 		}
 
 		fmt.Printf("%s\n", d.Display(string(file)))
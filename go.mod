@@ -5,6 +5,7 @@ go 1.21
 require (
 	github.com/agnivade/levenshtein v1.1.1
 	github.com/davecgh/go-spew v1.1.1
+	github.com/pmezard/go-difflib v1.0.0
 	github.com/stretchr/testify v1.8.0
 	github.com/urfave/cli/v2 v2.27.1
 	golang.org/x/text v0.9.0
@@ -12,7 +13,6 @@ require (
 
 require (
 	github.com/cpuguy83/go-md2man/v2 v2.0.2 // indirect
-	github.com/pmezard/go-difflib v1.0.0 // indirect
 	github.com/russross/blackfriday/v2 v2.1.0 // indirect
 	github.com/xrash/smetrics v0.0.0-20201216005158-039620a65673 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
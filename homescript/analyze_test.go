@@ -0,0 +1,95 @@
+package homescript
+
+import (
+	"testing"
+
+	"github.com/smarthome-go/homescript/v3/homescript/diagnostic"
+	"github.com/smarthome-go/homescript/v3/homescript/errors"
+	pAst "github.com/smarthome-go/homescript/v3/homescript/parser/ast"
+	"github.com/stretchr/testify/assert"
+)
+
+// Incompatible types are reported as one diagnostic whose labels point at the expected type.
+func TestTypeMismatchLabels(t *testing.T) {
+	tests := []struct {
+		Name    string
+		Program string
+		Code    errors.Code
+		Labels  []string
+	}{
+		{
+			Name:    "mismatched types",
+			Program: "fn main() {\n    let a: int = \"text\";\n}\n",
+			Code:    errors.MismatchedTypes,
+			Labels:  []string{"Type 'int' expected due to this"},
+		},
+		{
+			Name:    "missing field",
+			Program: "fn main() {\n    let o: { a: int, b: int } = new { a: 1 };\n}\n",
+			Code:    errors.MissingField,
+			Labels:  []string{"Field expected due to this"},
+		},
+		{
+			Name:    "unexpected field",
+			Program: "fn main() {\n    let o: { a: int } = new { a: 1, b: 2 };\n}\n",
+			Code:    errors.UnexpectedField,
+			Labels:  []string{"Field 'b' does not exist on this type"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			_, diagnostics, syntaxErrors := Analyze(
+				InputProgram{ProgramText: test.Program, Filename: "labels.hms"},
+				TestingAnalyzerScopeAdditions(),
+				TestingAnalyzerHost{IsInvokedInTests: true},
+				true,
+			)
+			assert.Empty(t, syntaxErrors)
+
+			matching := make([]diagnostic.Diagnostic, 0)
+			for _, d := range diagnostics {
+				if d.Code == test.Code {
+					matching = append(matching, d)
+				}
+			}
+
+			if !assert.Len(t, matching, 1) {
+				return
+			}
+
+			assert.Equal(t, diagnostic.DiagnosticLevelError, matching[0].Level)
+
+			labels := make([]string, len(matching[0].Labels))
+			for idx, label := range matching[0].Labels {
+				labels[idx] = label.Message
+				assert.Equal(t, "labels.hms", label.Span.Filename)
+			}
+			assert.Equal(t, test.Labels, labels)
+		})
+	}
+}
+
+// Every import suggested by the testing host must also resolve, but only when imported with its kind.
+func TestTestingHostBuiltinImports(t *testing.T) {
+	host := TestingAnalyzerHost{}
+	kinds := []pAst.IMPORT_KIND{pAst.IMPORT_KIND_NORMAL, pAst.IMPORT_KIND_TYPE, pAst.IMPORT_KIND_TEMPLATE, pAst.IMPORT_KIND_TRIGGER}
+
+	assert.Equal(t, []string{"net", "templates", "testing", "triggers"}, host.BuiltinModules())
+	assert.Equal(t, []string{"http", "ping"}, host.BuiltinImports("net", pAst.IMPORT_KIND_NORMAL))
+
+	for _, module := range host.BuiltinModules() {
+		for _, kind := range kinds {
+			for _, name := range host.BuiltinImports(module, kind) {
+				for _, other := range kinds {
+					_, moduleFound, valueFound := host.GetBuiltinImport(module, name, errors.Span{}, other)
+					assert.True(t, moduleFound)
+					assert.Equal(t, kind == other, valueFound, "import `%s` of kind %d from `%s`", name, other, module)
+				}
+			}
+		}
+	}
+
+	_, moduleFound, _ := host.GetBuiltinImport("foo", "bar", errors.Span{}, pAst.IMPORT_KIND_NORMAL)
+	assert.False(t, moduleFound)
+}
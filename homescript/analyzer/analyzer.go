@@ -25,6 +25,8 @@ type Analyzer struct {
 	currentModule                   *Module
 	host                            HostProvider
 	knownObjectTypeFieldAnnotations []string
+	// Whether every module must contain a `main` function, imported modules inherit this from the entry module.
+	mainShallExist bool
 }
 
 func NewAnalyzer(host HostProvider, scopeAdditions map[string]Variable) Analyzer {
@@ -49,6 +51,7 @@ func NewAnalyzer(host HostProvider, scopeAdditions map[string]Variable) Analyzer
 		currentModule:                   nil,
 		host:                            host,
 		knownObjectTypeFieldAnnotations: make([]string, 0),
+		mainShallExist:                  true,
 	}
 
 	// Precompute this list as this could be expensive (depens on the host).
@@ -66,33 +69,110 @@ func NewAnalyzer(host HostProvider, scopeAdditions map[string]Variable) Analyzer
 // Analyzer helper functions.
 //
 
-func (self *Analyzer) error(message string, notes []string, span errors.Span) {
+func (self *Analyzer) error(code errors.Code, message string, notes []string, span errors.Span) {
 	self.diagnostics = append(self.diagnostics, diagnostic.Diagnostic{
 		Level:   diagnostic.DiagnosticLevelError,
+		Code:    code,
 		Message: message,
 		Notes:   notes,
 		Span:    span,
+		Labels:  nil,
+		Fix:     nil,
 	})
 }
 
-func (self *Analyzer) warn(message string, notes []string, span errors.Span) {
+func (self *Analyzer) warn(code errors.Code, message string, notes []string, span errors.Span) {
 	self.diagnostics = append(self.diagnostics, diagnostic.Diagnostic{
 		Level:   diagnostic.DiagnosticLevelWarning,
+		Code:    code,
 		Message: message,
 		Notes:   notes,
 		Span:    span,
+		Labels:  nil,
+		Fix:     nil,
 	})
 }
 
-func (self *Analyzer) hint(message string, notes []string, span errors.Span) {
+func (self *Analyzer) hint(code errors.Code, message string, notes []string, span errors.Span) {
 	self.diagnostics = append(self.diagnostics, diagnostic.Diagnostic{
 		Level:   diagnostic.DiagnosticLevelHint,
+		Code:    code,
 		Message: message,
 		Notes:   notes,
 		Span:    span,
+		Labels:  nil,
+		Fix:     nil,
 	})
 }
 
+// Like `error`, but the diagnostic also points at other spans which explain it.
+func (self *Analyzer) errorWithLabels(code errors.Code, message string, notes []string, span errors.Span, labels ...diagnostic.Label) {
+	self.diagnostics = append(self.diagnostics, diagnostic.Diagnostic{
+		Level:   diagnostic.DiagnosticLevelError,
+		Code:    code,
+		Message: message,
+		Notes:   notes,
+		Span:    span,
+		Labels:  labels,
+		Fix:     nil,
+	})
+}
+
+// Like `warn`, but the diagnostic also points at other spans which explain it.
+func (self *Analyzer) warnWithLabels(code errors.Code, message string, notes []string, span errors.Span, labels ...diagnostic.Label) {
+	self.diagnostics = append(self.diagnostics, diagnostic.Diagnostic{
+		Level:   diagnostic.DiagnosticLevelWarning,
+		Code:    code,
+		Message: message,
+		Notes:   notes,
+		Span:    span,
+		Labels:  labels,
+		Fix:     nil,
+	})
+}
+
+// Like `error`, but the diagnostic also contains a fix which can be applied automatically.
+func (self *Analyzer) errorWithFix(code errors.Code, message string, notes []string, span errors.Span, fix *diagnostic.Fix) {
+	self.diagnostics = append(self.diagnostics, diagnostic.Diagnostic{
+		Level:   diagnostic.DiagnosticLevelError,
+		Code:    code,
+		Message: message,
+		Notes:   notes,
+		Span:    span,
+		Labels:  nil,
+		Fix:     fix,
+	})
+}
+
+// Like `warn`, but the diagnostic also contains a fix which can be applied automatically.
+// Optionally, the diagnostic can point at other spans which explain it.
+func (self *Analyzer) warnWithFix(
+	code errors.Code,
+	message string,
+	notes []string,
+	span errors.Span,
+	fix *diagnostic.Fix,
+	labels ...diagnostic.Label,
+) {
+	self.diagnostics = append(self.diagnostics, diagnostic.Diagnostic{
+		Level:   diagnostic.DiagnosticLevelWarning,
+		Code:    code,
+		Message: message,
+		Notes:   notes,
+		Span:    span,
+		Labels:  labels,
+		Fix:     fix,
+	})
+}
+
+// Returns a fix which prefixes an unused name with `_` so that it is no longer reported.
+func unusedNameFix(name string, span errors.Span) *diagnostic.Fix {
+	return diagnostic.NewFix(
+		fmt.Sprintf("Rename to '_%s'", name),
+		diagnostic.Edit{Span: span, Replacement: "_" + name},
+	)
+}
+
 func (self *Analyzer) dropScope(remove bool) {
 	var scope scope
 	if remove {
@@ -105,6 +185,7 @@ func (self *Analyzer) dropScope(remove bool) {
 	for key, typ := range scope.Types {
 		if !typ.Used {
 			self.warn(
+				errors.UnusedType,
 				fmt.Sprintf("Type '%s' is unused", key),
 				[]string{fmt.Sprintf("If this is intentional, change the name to '_%s' to hide this message", key)},
 				typ.NameSpan,
@@ -124,13 +205,16 @@ func (self *Analyzer) dropScope(remove bool) {
 				label = "Parameter"
 			}
 
-			self.warn(
+			self.warnWithFix(
+				errors.UnusedVariable,
 				fmt.Sprintf("%s '%s' is unused", label, key),
 				[]string{fmt.Sprintf("If this is intentional, change the name to '_%s' to hide this message", key)},
 				variable.Span,
+				unusedNameFix(key, variable.Span),
 			)
 		case ImportedVariableOriginKind:
 			self.warn(
+				errors.UnusedImport,
 				fmt.Sprintf("Import `%s` is unused", key),
 				nil,
 				variable.Span,
@@ -261,6 +345,7 @@ func (self *Analyzer) analyzeModule(moduleName string, module pAst.Program, main
 
 	if !mainExists && mainShallExist {
 		self.error(
+			errors.MissingMain,
 			"Missing 'main' function",
 			[]string{"the 'main' function can be implemented like this: `fn main() { ... }`"},
 			errors.Span{Filename: module.Filename},
@@ -278,6 +363,7 @@ func (self *Analyzer) analyzeModule(moduleName string, module pAst.Program, main
 			continue
 		}
 		self.warn(
+			errors.UnusedFunction,
 			fmt.Sprintf("Function '%s' is never used", fnType.Ident.Ident()),
 			[]string{fmt.Sprintf(
 				"If this is intentional, change the name to '_%s' to hide this message",
@@ -291,6 +377,7 @@ func (self *Analyzer) analyzeModule(moduleName string, module pAst.Program, main
 	for singletonName, singleton := range self.currentModule.Singletons {
 		if !singleton.Used {
 			self.warn(
+				errors.UnusedSingleton,
 				fmt.Sprintf("Singleton '%s' is never used", singletonName),
 				[]string{fmt.Sprintf(
 					"If this is intentional, change the name to '_%s' to hide this message",
@@ -321,6 +408,7 @@ func (self *Analyzer) Analyze(
 	diagnostics []diagnostic.Diagnostic,
 	syntaxErrors []errors.Error,
 ) {
+	self.mainShallExist = mainShallExist
 	self.analyzeModule(parsedEntryModule.Filename, parsedEntryModule, mainShallExist)
 
 	// If there are no serious errors found, call the post-validation hook.
@@ -4,6 +4,8 @@ import (
 	"fmt"
 
 	"github.com/smarthome-go/homescript/v3/homescript/analyzer/ast"
+	"github.com/smarthome-go/homescript/v3/homescript/diagnostic"
+	"github.com/smarthome-go/homescript/v3/homescript/errors"
 	pAst "github.com/smarthome-go/homescript/v3/homescript/parser/ast"
 )
 
@@ -33,8 +35,18 @@ func (self *Analyzer) analyzeFnAnnotation(annotation pAst.AnnotationItem, fnIden
 			}
 
 			fn.Used = true
+		case TestAnnotation:
+			fn, found := self.currentModule.getFunc(fnIdent)
+			if !found {
+				panic("impossible")
+			}
+
+			// Tests are only invoked by the test runner.
+			fn.Used = true
+			self.analyzeTestFunction(fn, fnIdent)
 		default:
 			self.error(
+				errors.IllegalAnnotation,
 				fmt.Sprintf("Illegal annotation: `%s`", ann.Ident),
 				[]string{},
 				ann.Span(),
@@ -49,12 +61,15 @@ func (self *Analyzer) analyzeFnAnnotation(annotation pAst.AnnotationItem, fnIden
 		trigger, triggerFound := self.currentModule.getTrigger(ann.TriggerSource.Ident())
 		// triggerType, found, connectiveCorrect := self.host.GetTriggerEvent(node.EventIdent.Ident(), node.DispatchKeyword)
 		if !triggerFound {
-			self.error(
+			self.errorWithSuggestion(
+				errors.UndefinedTriggerFunction,
 				fmt.Sprintf("Use of undefined trigger function '%s'", ann.TriggerSource.Ident()),
 				[]string{
 					fmt.Sprintf("Trigger functions can be imported like this: `import { trigger %s } from ... ;`", ann.TriggerSource.Ident()),
 				},
+				ann.TriggerSource.Ident(),
 				ann.TriggerSource.Span(),
+				mapKeys(self.currentModule.TriggerFunctions),
 			)
 		}
 
@@ -70,13 +85,15 @@ func (self *Analyzer) analyzeFnAnnotation(annotation pAst.AnnotationItem, fnIden
 				message = "Target function misses the `event` modifier"
 			}
 
-			self.error(
+			self.errorWithFix(
+				errors.CallbackModifier,
 				message,
 				[]string{
 					"Functions invoked through a `trigger` will run once an *event* takes place",
 					fmt.Sprintf("The correct modifier `event` can be implemented like this: `event fn %s(...)`", fnIdent),
 				},
 				callbackFn.IdentSpan,
+				eventModifierFix(callbackFn),
 			)
 		}
 
@@ -98,27 +115,13 @@ func (self *Analyzer) analyzeFnAnnotation(annotation pAst.AnnotationItem, fnIden
 					IgnoreFnParamNameMismatches: true,
 				},
 			); err != nil {
-				self.diagnostics = append(
-					self.diagnostics,
-					err.GotDiagnostic.WithContext("Regarding callback function"),
-				)
-				if err.ExpectedDiagnostic != nil {
-					self.diagnostics = append(
-						self.diagnostics,
-						err.ExpectedDiagnostic.WithContext(
-							fmt.Sprintf("Regarding callback function for trigger `%s`", ann.TriggerSource),
-						),
-					)
-					err.ExpectedDiagnostic.Notes = append(
-						err.ExpectedDiagnostic.Notes,
-						fmt.Sprintf("Function `%s` used as callback for trigger `%s`", fnIdent, ann.TriggerSource),
-					)
-				}
-				self.hint(
-					fmt.Sprintf("This function is used as a callback for trigger `%s`", ann.TriggerSource),
-					make([]string, 0),
+				d := err.Diagnostic.WithContext("Regarding callback function")
+				d.Notes = append(d.Notes, fmt.Sprintf("Function `%s` used as callback for trigger `%s`", fnIdent, ann.TriggerSource))
+				d.Labels = append(d.Labels, diagnostic.NewLabel(
 					callbackFn.IdentSpan,
-				)
+					fmt.Sprintf("This function is used as a callback for trigger `%s`", ann.TriggerSource),
+				))
+				self.diagnostics = append(self.diagnostics, d)
 			}
 			args = self.callArgs(
 				trigger.TriggerFnType,
@@ -139,3 +142,53 @@ func (self *Analyzer) analyzeFnAnnotation(annotation pAst.AnnotationItem, fnIden
 		panic("TODO: a new kind of annotation item was added without updating this code")
 	}
 }
+
+// Functions annotated using `#[test]` are discovered and invoked by the test runner.
+const TestAnnotation = "test"
+
+// Test functions are invoked without arguments and their result is ignored.
+func (self *Analyzer) analyzeTestFunction(fn *function, fnIdent string) {
+	notes := []string{"Test functions are invoked by the test runner without any arguments"}
+
+	if fn.Modifier != pAst.FN_MODIFIER_NONE {
+		self.error(
+			errors.InvalidTestFunction,
+			fmt.Sprintf("Test function `%s` must not have the modifier `%s`", fnIdent, fn.Modifier),
+			notes,
+			fn.ModifierSpan,
+		)
+	}
+
+	if len(fn.Parameters) != 0 {
+		self.error(
+			errors.InvalidTestFunction,
+			fmt.Sprintf("Test function `%s` must not have any parameters", fnIdent),
+			notes,
+			fn.ParamsSpan,
+		)
+	}
+
+	if fn.ReturnType.Kind() != ast.NullTypeKind {
+		self.error(
+			errors.InvalidTestFunction,
+			fmt.Sprintf("Test function `%s` must not return a value, found return type `%s`", fnIdent, fn.ReturnType),
+			[]string{"A test fails if it throws an exception, for instance through a failed assertion"},
+			fn.ReturnTypeSpan,
+		)
+	}
+}
+
+// Returns a fix which adds the `event` modifier to the function or replaces its wrong modifier.
+func eventModifierFix(fn *function) *diagnostic.Fix {
+	if fn.Modifier == pAst.FN_MODIFIER_NONE {
+		return diagnostic.NewFix(
+			"Add the `event` modifier",
+			diagnostic.Edit{Span: fn.ModifierSpan, Replacement: "event fn"},
+		)
+	}
+
+	return diagnostic.NewFix(
+		fmt.Sprintf("Replace the modifier `%s` with `event`", fn.Modifier),
+		diagnostic.Edit{Span: fn.ModifierSpan, Replacement: "event"},
+	)
+}
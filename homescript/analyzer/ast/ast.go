@@ -133,6 +133,7 @@ type AnalyzedFunctionDefinition struct {
 	Modifier   ast.FunctionModifier
 	Annotation *AnalyzedFunctionAnnotation
 	Range      errors.Span
+	DocComment string
 }
 
 func (self AnalyzedFunctionDefinition) Span() errors.Span { return self.Range }
@@ -98,6 +98,7 @@ func DetermineCapabilityConflicts(
 
 			return true, conflict.ConflictingCapability, diagnostic.Diagnostic{
 				Level:   diagnostic.DiagnosticLevelError,
+				Code:    errors.ConflictingCapabilities,
 				Message: fmt.Sprintf("Template capability `%s` conflicts with other capability `%s`", capabilityName, conflict.ConflictingCapability),
 				Notes:   notes,
 				Span:    span,
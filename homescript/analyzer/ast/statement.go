@@ -82,9 +82,11 @@ func (self AnalyzedTriggerStatement) Type() Type { return NewNullType(self.Range
 //
 
 type AnalyzedTypeDefinition struct {
-	LhsIdent string
-	RhsType  Type
-	Range    errors.Span
+	LhsIdent   string
+	RhsType    Type
+	IsPub      bool
+	Range      errors.Span
+	DocComment string
 }
 
 func (self AnalyzedTypeDefinition) Kind() AnalyzedStatementKind { return TypeDefinitionStatementKind }
@@ -102,6 +104,9 @@ type AnalyzedLetStatement struct {
 	NeedsRuntimeTypeValidation bool // is set to `true` if the rhs is of type `any`
 	OptType                    Type
 	Range                      errors.Span
+	// These are only set for globals.
+	IsPub      bool
+	DocComment string
 }
 
 func (self AnalyzedLetStatement) Kind() AnalyzedStatementKind { return LetStatementKind }
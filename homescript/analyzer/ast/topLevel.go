@@ -18,6 +18,7 @@ type AnalyzedSingletonTypeDefinition struct {
 	// This is mainly used for the post-validation hook so that it can analyze these easily.
 	ImplementsTemplates []ast.ImplBlockTemplate
 	Used                bool
+	DocComment          string
 }
 
 func (self AnalyzedSingletonTypeDefinition) Kind() AnalyzedStatementKind {
@@ -43,4 +44,5 @@ type AnalyzedImplBlock struct {
 	Span           errors.Span
 	// Capabilities which exist on the singleton after consideration of default capabilities.
 	FinalCapabilities map[string]TemplateCapabilityWithSpan
+	DocComment        string
 }
@@ -109,6 +109,7 @@ func (self *Analyzer) expression(node pAst.Expression) ast.AnalyzedExpression {
 		case ast.FnTypeKind, ast.OptionTypeKind:
 		default:
 			self.error(
+				errors.ImplicitAny,
 				"Implicit use of 'any' type: explicit type annotations required",
 				[]string{"Consider casting this expression like this: `.. as type`"},
 				res.Span(),
@@ -189,11 +190,17 @@ func (self *Analyzer) identExpression(node pAst.IdentExpression) ast.AnalyzedIde
 
 		singleton, found := self.currentModule.Singletons[node.Ident.Ident()]
 		if !found {
+			// The span of a singleton reference does not only cover its name, therefore, no fix is suggested.
 			self.error(
+				errors.UndefinedSingleton,
 				fmt.Sprintf("Reference of undeclared singleton type '%s'", node.Ident.Ident()),
-				[]string{
-					fmt.Sprintf("Singleton types can be declared like this: `@%s\n type %sFoo = ...;`", node.Ident.Ident(), node.Ident.Ident()),
-				},
+				withSuggestion(
+					[]string{
+						fmt.Sprintf("Singleton types can be declared like this: `@%s\n type %sFoo = ...;`", node.Ident.Ident(), node.Ident.Ident()),
+					},
+					node.Ident.Ident(),
+					mapKeys(self.currentModule.Singletons),
+				),
 				node.Ident.Span(),
 			)
 		} else {
@@ -216,14 +223,25 @@ func (self *Analyzer) identExpression(node pAst.IdentExpression) ast.AnalyzedIde
 		// check if there is a function with the required name
 		fn, found := self.currentModule.getFunc(node.Ident.Ident())
 		if !found {
-			self.error(
+			match, suggested := self.errorWithSuggestion(
+				errors.UndefinedName,
 				fmt.Sprintf("Use of undefined variable or function '%s'", node.Ident.Ident()),
 				[]string{
 					fmt.Sprintf("Variables can be defined like this: `let %s = ...;`", node.Ident.Ident()),
 				},
+				node.Ident.Ident(),
 				node.Ident.Span(),
+				self.currentModule.valueNames(),
 			)
 
+			// The suggested variable was most likely meant to be used here.
+			// Otherwise, it would also be reported as unused and the fixes would contradict each other.
+			if suggested {
+				if variable, _, found := self.currentModule.getVar(match); found {
+					variable.Used = true
+				}
+			}
+
 			return ast.AnalyzedIdentExpression{
 				Ident:      node.Ident,
 				ResultType: ast.NewUnknownType(),
@@ -297,6 +315,7 @@ func (self *Analyzer) rangeLiteralExpression(node pAst.RangeLiteralExpression) a
 	// ensure that both values of the range are `int`
 	if err := self.TypeCheck(start.Type(), ast.NewIntType(errors.Span{}), TypeCheckOptions{}); err != nil {
 		self.error(
+			errors.MismatchedTypes,
 			fmt.Sprintf("Type mismatch: expected '%s', found '%s'", ast.NewIntType(errors.Span{}).Kind(), start.Type().Kind()),
 			nil,
 			start.Span(),
@@ -305,6 +324,7 @@ func (self *Analyzer) rangeLiteralExpression(node pAst.RangeLiteralExpression) a
 
 	if err := self.TypeCheck(end.Type(), ast.NewIntType(errors.Span{}), TypeCheckOptions{}); err != nil {
 		self.error(
+			errors.MismatchedTypes,
 			fmt.Sprintf("Type mismatch: expected '%s', found '%s'", ast.NewIntType(errors.Span{}).Kind(), end.Type().Kind()),
 			nil,
 			end.Span(),
@@ -333,10 +353,7 @@ func (self *Analyzer) listLiteralExpression(node pAst.ListLiteralExpression) ast
 		newValues = append(newValues, valExpression)
 
 		if err := self.TypeCheck(valExpression.Type(), listType, TypeCheckOptions{}); err != nil && listType.Kind() != ast.AnyTypeKind {
-			self.diagnostics = append(self.diagnostics, err.GotDiagnostic)
-			if err.ExpectedDiagnostic != nil {
-				self.diagnostics = append(self.diagnostics, *err.ExpectedDiagnostic)
-			}
+			self.diagnostics = append(self.diagnostics, err.Diagnostic)
 			listType = ast.NewUnknownType()
 		} else if listType.Kind() == ast.AnyTypeKind {
 			listType = valExpression.Type()
@@ -370,6 +387,7 @@ func (self *Analyzer) objectLiteralExpression(node pAst.ObjectLiteralExpression)
 		// test if the current field conflicts with a builtin field
 		if _, isBuiltin := ast.NewObjectType(make([]ast.ObjectTypeField, 0), node.Range).Fields(node.Range)[field.Key.Ident()]; isBuiltin {
 			self.error(
+				errors.ReservedFieldName,
 				fmt.Sprintf("Cannot use '%s' as a field name: already used for builtin purposes", field.Key.Ident()),
 				nil,
 				field.Key.Span(),
@@ -380,6 +398,7 @@ func (self *Analyzer) objectLiteralExpression(node pAst.ObjectLiteralExpression)
 		// test if the current field occurs multiple times
 		if _, alreadyExists := fieldSet[field.Key.Ident()]; alreadyExists {
 			self.error(
+				errors.DuplicateField,
 				fmt.Sprintf("Duplicate definition of field '%s'", field.Key.Ident()),
 				nil,
 				field.Key.Span(),
@@ -416,6 +435,7 @@ func (self *Analyzer) functionLiteral(node pAst.FunctionLiteralExpression) ast.A
 	for _, param := range node.Parameters {
 		if _, alreadyExists := existentParams[param.Ident.Ident()]; alreadyExists {
 			self.error(
+				errors.DuplicateParameter,
 				fmt.Sprintf("Duplicate declaration of parameter '%s'", param.Ident),
 				nil,
 				param.Span,
@@ -451,6 +471,7 @@ func (self *Analyzer) functionLiteral(node pAst.FunctionLiteralExpression) ast.A
 		fnReturntype,
 		node.Span(),
 		pAst.FN_MODIFIER_NONE,
+		node.Span(),
 	)
 	self.currentModule.CurrentFunction = &moduleFn
 
@@ -462,10 +483,7 @@ func (self *Analyzer) functionLiteral(node pAst.FunctionLiteralExpression) ast.A
 		AllowFunctionTypes:          true,
 		IgnoreFnParamNameMismatches: false,
 	}); err != nil {
-		self.diagnostics = append(self.diagnostics, err.GotDiagnostic)
-		if err.ExpectedDiagnostic != nil {
-			self.diagnostics = append(self.diagnostics, *err.ExpectedDiagnostic)
-		}
+		self.diagnostics = append(self.diagnostics, err.Diagnostic)
 	}
 
 	self.dropScope(true)
@@ -498,6 +516,7 @@ func (self *Analyzer) prefixExpression(node pAst.PrefixExpression) ast.AnalyzedP
 			resultType = ast.NewUnknownType()
 		default:
 			self.error(
+				errors.IllegalOperator,
 				fmt.Sprintf("Prefix operator '%s' cannot be used on values of type '%s'", operator, base.Type().Kind()),
 				nil,
 				node.Range,
@@ -512,6 +531,7 @@ func (self *Analyzer) prefixExpression(node pAst.PrefixExpression) ast.AnalyzedP
 			resultType = ast.NewUnknownType()
 		default:
 			self.error(
+				errors.IllegalOperator,
 				fmt.Sprintf("Prefix operator '%s' cannot be used on values of type '%s'", operator, base.Type().Kind()),
 				nil,
 				node.Range,
@@ -551,10 +571,7 @@ func (self *Analyzer) infixExpression(node pAst.InfixExpression) ast.AnalyzedInf
 			AllowFunctionTypes:          true,
 			IgnoreFnParamNameMismatches: false,
 		}); err != nil {
-		self.diagnostics = append(self.diagnostics, err.GotDiagnostic)
-		if err.ExpectedDiagnostic != nil {
-			self.diagnostics = append(self.diagnostics, *err.ExpectedDiagnostic)
-		}
+		self.diagnostics = append(self.diagnostics, err.Diagnostic)
 		lhsTypeKind = ast.UnknownTypeKind
 	}
 
@@ -578,6 +595,7 @@ func (self *Analyzer) infixExpression(node pAst.InfixExpression) ast.AnalyzedInf
 			resultType = ast.NewBoolType(node.Range)
 		default:
 			self.error(
+				errors.IllegalOperator,
 				fmt.Sprintf("Infix operator '%s' cannot be used on values of type '%s'", node.Operator, lhs.Type().Kind()),
 				nil,
 				node.Span(),
@@ -599,6 +617,7 @@ func (self *Analyzer) infixExpression(node pAst.InfixExpression) ast.AnalyzedInf
 			resultType = ast.NewBoolType(node.Range)
 		default:
 			self.error(
+				errors.IllegalOperator,
 				fmt.Sprintf("Infix operator '%s' cannot be used on values of type '%s'", node.Operator, lhs.Type().Kind()),
 				nil,
 				node.Span(),
@@ -615,6 +634,7 @@ func (self *Analyzer) infixExpression(node pAst.InfixExpression) ast.AnalyzedInf
 			resultType = ast.NewBoolType(node.Range)
 		default:
 			self.error(
+				errors.IllegalOperator,
 				fmt.Sprintf("Infix operator '%s' cannot be used on values of type '%s'", node.Operator, lhs.Type().Kind()),
 				nil,
 				node.Span(),
@@ -630,6 +650,7 @@ func (self *Analyzer) infixExpression(node pAst.InfixExpression) ast.AnalyzedInf
 			resultType = ast.NewBoolType(node.Range)
 		default:
 			self.error(
+				errors.IllegalOperator,
 				fmt.Sprintf("Infix operator '%s' cannot be used on values of type '%s'", node.Operator, lhs.Type().Kind()),
 				nil,
 				node.Span(),
@@ -645,6 +666,7 @@ func (self *Analyzer) infixExpression(node pAst.InfixExpression) ast.AnalyzedInf
 			resultType = ast.NewBoolType(node.Range)
 		default:
 			self.error(
+				errors.IllegalOperator,
 				fmt.Sprintf("Infix operator '%s' cannot be used on values of type '%s'", node.Operator, lhsTypeKind),
 				nil,
 				node.Span(),
@@ -678,10 +700,7 @@ func (self *Analyzer) assignExpression(node pAst.AssignExpression) ast.AnalyzedA
 	}
 
 	if err := self.TypeCheck(rhs.Type(), lhs.Type(), TypeCheckOptions{}); err != nil {
-		self.diagnostics = append(self.diagnostics, err.GotDiagnostic)
-		if err.ExpectedDiagnostic != nil {
-			self.diagnostics = append(self.diagnostics, *err.ExpectedDiagnostic)
-		}
+		self.diagnostics = append(self.diagnostics, err.Diagnostic)
 		prevErr = true
 	}
 
@@ -755,6 +774,7 @@ func (self *Analyzer) assignExpression(node pAst.AssignExpression) ast.AnalyzedA
 
 func (self *Analyzer) assignErr(operator pAst.AssignOperator, typ ast.Type, span errors.Span) {
 	self.error(
+		errors.IllegalOperator,
 		fmt.Sprintf("Assign operator '%s' cannot be used on values of type '%s'", operator, typ.Kind()),
 		nil,
 		span,
@@ -809,6 +829,7 @@ func (self *Analyzer) callArgs(fnType ast.FunctionType, args pAst.CallArgs, base
 			}
 
 			self.error(
+				errors.ArgumentCountMismatch,
 				fmt.Sprintf(
 					"Function requires %d argument%s%s, however %d %s supplied",
 					len(newParams),
@@ -826,6 +847,7 @@ func (self *Analyzer) callArgs(fnType ast.FunctionType, args pAst.CallArgs, base
 
 				if argExpr.Type().Kind() == ast.NullTypeKind {
 					self.error(
+						errors.ResultArgument,
 						fmt.Sprintf("Cannot use a value of result type `%s` in function call", argExpr.Type()),
 						[]string{"This expression generates no value, therefore it can be omitted"},
 						argExpr.Span(),
@@ -838,6 +860,7 @@ func (self *Analyzer) callArgs(fnType ast.FunctionType, args pAst.CallArgs, base
 				// Therefore, sending these closures across threads will cause weird memory bugs which must not occur in a Smarthome system.
 				if baseIsSpawn && argExpr.Type().Kind() == ast.FnTypeKind {
 					self.error(
+						errors.ClosureAcrossThreads,
 						"Sending closures across threads is undefined behaviour.",
 						[]string{fmt.Sprintf("It is not possible to use a value of type `%s` as an argument to a `spawn` invocation.", argExpr.Type())},
 						argExpr.Span(),
@@ -849,7 +872,7 @@ func (self *Analyzer) callArgs(fnType ast.FunctionType, args pAst.CallArgs, base
 					AllowFunctionTypes:          true,
 					IgnoreFnParamNameMismatches: false,
 				}); err != nil {
-					self.diagnostics = append(self.diagnostics, err.GotDiagnostic)
+					self.diagnostics = append(self.diagnostics, err.Diagnostic)
 				} else {
 					arguments = append(arguments, ast.AnalyzedCallArgument{
 						Name:       newParams[idx].Name.Ident(),
@@ -874,6 +897,7 @@ func (self *Analyzer) callArgs(fnType ast.FunctionType, args pAst.CallArgs, base
 			}
 
 			self.error(
+				errors.ArgumentCountMismatch,
 				fmt.Sprintf(
 					"Function requires at least %d argument%s, however %d %s supplied",
 					len(varArgType.ParamTypes),
@@ -890,6 +914,7 @@ func (self *Analyzer) callArgs(fnType ast.FunctionType, args pAst.CallArgs, base
 
 				if argExpr.Type().Kind() == ast.NullTypeKind {
 					self.error(
+						errors.ResultArgument,
 						fmt.Sprintf("Cannot use a value of result type `%s` in function call", argExpr.Type()),
 						[]string{"This expression generates no value, therefore it can be omitted"},
 						argExpr.Span(),
@@ -902,6 +927,7 @@ func (self *Analyzer) callArgs(fnType ast.FunctionType, args pAst.CallArgs, base
 				// Therefore, sending these closures across threads will cause weird memory bugs which must not occur in a Smarthome system.
 				if baseIsSpawn && argExpr.Type().Kind() == ast.FnTypeKind {
 					self.error(
+						errors.ClosureAcrossThreads,
 						"Sending closures across threads is undefined behaviour.",
 						[]string{fmt.Sprintf("It is not possible to use a value of type `%s` as an argument to a `spawn` invocation.", argExpr.Type())},
 						argExpr.Span(),
@@ -918,7 +944,7 @@ func (self *Analyzer) callArgs(fnType ast.FunctionType, args pAst.CallArgs, base
 					AllowFunctionTypes:          true,
 					IgnoreFnParamNameMismatches: false,
 				}); err != nil {
-					self.diagnostics = append(self.diagnostics, err.GotDiagnostic)
+					self.diagnostics = append(self.diagnostics, err.Diagnostic)
 				} else {
 					arguments = append(arguments, ast.AnalyzedCallArgument{
 						Name:       "", // no name: is vararg
@@ -967,6 +993,7 @@ func (self *Analyzer) callExpression(node pAst.CallExpression) ast.AnalyzedCallE
 		}
 
 		self.error(
+			errors.NotCallable,
 			fmt.Sprintf("Type '%s' cannot be called", base.Type().Kind()),
 			notes,
 			base.Span(),
@@ -1018,6 +1045,7 @@ func (self *Analyzer) indexExpression(node pAst.IndexExpression) ast.AnalyzedInd
 		// ensure that the index expression is a `str`
 		if index.Type().Kind() != ast.StringTypeKind {
 			self.error(
+				errors.IllegalIndex,
 				fmt.Sprintf("A value of type '%s' cannot be indexed by '%s'", base.Type().Kind(), index.Type().Kind()),
 				nil,
 				node.Index.Span(),
@@ -1033,6 +1061,7 @@ func (self *Analyzer) indexExpression(node pAst.IndexExpression) ast.AnalyzedInd
 		// ensure that the index expression is a `str`
 		if index.Type().Kind() != ast.StringTypeKind {
 			self.error(
+				errors.IllegalIndex,
 				fmt.Sprintf("A value of type '%s' cannot be indexed by '%s'", base.Type().Kind(), index.Type().Kind()),
 				nil,
 				node.Index.Span(),
@@ -1049,17 +1078,20 @@ func (self *Analyzer) indexExpression(node pAst.IndexExpression) ast.AnalyzedInd
 				// check if the object contains this key
 
 				var fieldRes ast.Type = nil
+				fieldNames := make([]string, 0)
 				for _, field := range objType.ObjFields {
 					if field.FieldName.Ident() == indexStr {
 						fieldRes = field.Type
 						break
 					}
+					fieldNames = append(fieldNames, field.FieldName.Ident())
 				}
 
 				if fieldRes == nil {
 					self.error(
+						errors.UnknownMember,
 						fmt.Sprintf("Object does not contain a field with name '%s'", indexStr),
-						nil,
+						withSuggestion(nil, indexStr, fieldNames),
 						node.Range,
 					)
 					resultType = ast.NewUnknownType()
@@ -1075,6 +1107,7 @@ func (self *Analyzer) indexExpression(node pAst.IndexExpression) ast.AnalyzedInd
 		// ensure that the index expression is an `int`
 		if index.Type().Kind() != ast.IntTypeKind {
 			self.error(
+				errors.IllegalIndex,
 				fmt.Sprintf("A value of type '%s' cannot be indexed by '%s'", base.Type().Kind(), index.Type().Kind()),
 				nil,
 				node.Index.Span(),
@@ -1089,6 +1122,7 @@ func (self *Analyzer) indexExpression(node pAst.IndexExpression) ast.AnalyzedInd
 		// ensure that the index expression is an `int`
 		if index.Type().Kind() != ast.IntTypeKind {
 			self.error(
+				errors.IllegalIndex,
 				fmt.Sprintf("A value of type '%s' cannot be indexed by '%s'", base.Type().Kind(), index.Type().Kind()),
 				nil,
 				node.Index.Span(),
@@ -1104,6 +1138,7 @@ func (self *Analyzer) indexExpression(node pAst.IndexExpression) ast.AnalyzedInd
 	default:
 		// show an error
 		self.error(
+			errors.IllegalIndex,
 			fmt.Sprintf("Cannot index a value of type '%s'", base.Type().Kind()),
 			nil,
 			node.Span(),
@@ -1139,6 +1174,7 @@ func (self *Analyzer) memberExpression(node pAst.MemberExpression) ast.AnalyzedE
 		resultType = base.Type()
 	case ast.AnyTypeKind:
 		self.error(
+			errors.ImplicitAny,
 			"Implicit use of 'any' type: explicit type annotations required",
 			[]string{"Consider casting this expression like this: `.. as type`"},
 			base.Span(),
@@ -1164,6 +1200,7 @@ func (self *Analyzer) memberExpression(node pAst.MemberExpression) ast.AnalyzedE
 				}
 
 				self.error(
+					errors.IllegalOperator,
 					fmt.Sprintf(
 						"The '%s' operator cannot be used on values of type '%s'",
 						node.Operator,
@@ -1209,10 +1246,13 @@ func (self *Analyzer) memberExpression(node pAst.MemberExpression) ast.AnalyzedE
 					}
 				}
 
-				self.error(
+				self.errorWithSuggestion(
+					errors.UnknownMember,
 					fmt.Sprintf("Type '%s' has no member named '%s'", base.Type(), node.Member.Ident()),
 					notes,
+					node.Member.Ident(),
 					node.Member.Span(),
+					mapKeys(fields),
 				)
 
 				// use `unknown` as the result type
@@ -1290,13 +1330,15 @@ func (self *Analyzer) castExpression(node pAst.CastExpression) ast.AnalyzedCastE
 	if err := self.TypeCheck(base.Type(), asType, TypeCheckOptions{}); err != nil {
 		// check if the cast is legal
 		self.error(
+			errors.ImpossibleCast,
 			fmt.Sprintf("Impossible cast: cannot cast value of type '%s' to '%s'", base.Type(), asType),
-			[]string{err.GotDiagnostic.Message},
+			[]string{err.Diagnostic.Message},
 			node.Span(),
 		)
 	} else if asType.Kind() == ast.FnTypeKind {
 		// check if the rhs contains a fn
 		self.error(
+			errors.FunctionValueCast,
 			"Impossible cast: cannot cast a function value at runtime",
 			[]string{"If the function is called later, consider casting its result value: `func() as type`"},
 			node.Span(),
@@ -1322,11 +1364,11 @@ func (self *Analyzer) ifExpression(node pAst.IfExpression) ast.AnalyzedIfExpress
 		AllowFunctionTypes:          true,
 		IgnoreFnParamNameMismatches: false,
 	}); err != nil {
-		err.GotDiagnostic.Notes = append(
-			err.GotDiagnostic.Notes,
+		err.Diagnostic.Notes = append(
+			err.Diagnostic.Notes,
 			fmt.Sprintf("A condition must be of type '%s'", ast.TypeKind(ast.BoolTypeKind)),
 		)
-		self.diagnostics = append(self.diagnostics, err.GotDiagnostic)
+		self.diagnostics = append(self.diagnostics, err.Diagnostic)
 	}
 
 	var resultType ast.Type
@@ -1345,11 +1387,8 @@ func (self *Analyzer) ifExpression(node pAst.IfExpression) ast.AnalyzedIfExpress
 			AllowFunctionTypes:          true,
 			IgnoreFnParamNameMismatches: false,
 		}); err != nil {
-			err.GotDiagnostic.Notes = append(err.GotDiagnostic.Notes, "The `if` and `else` branches must result in the identical type")
-			self.diagnostics = append(self.diagnostics, err.GotDiagnostic)
-			if err.ExpectedDiagnostic != nil {
-				self.diagnostics = append(self.diagnostics, *err.ExpectedDiagnostic)
-			}
+			err.Diagnostic.Notes = append(err.Diagnostic.Notes, "The `if` and `else` branches must result in the identical type")
+			self.diagnostics = append(self.diagnostics, err.Diagnostic)
 			resultType = ast.NewUnknownType()
 		} else {
 			resultType = elseBlock.ResultType
@@ -1372,9 +1411,10 @@ func (self *Analyzer) ifExpression(node pAst.IfExpression) ast.AnalyzedIfExpress
 		}); err != nil {
 			self.diagnostics = append(self.diagnostics, diagnostic.Diagnostic{
 				Level:   diagnostic.DiagnosticLevelError,
-				Message: fmt.Sprintf("%s: missing `else` branch with result type '%s'", err.GotDiagnostic.Message, thenBlock.ResultType.Kind()),
+				Code:    errors.MissingElseBranch,
+				Message: fmt.Sprintf("%s: missing `else` branch with result type '%s'", err.Diagnostic.Message, thenBlock.ResultType.Kind()),
 				Notes:   []string{fmt.Sprintf("The `then` branch results in a value of type '%s', therefore an else branch is expected", thenBlock.ResultType.Kind())},
-				Span:    err.GotDiagnostic.Span,
+				Span:    err.Diagnostic.Span,
 			})
 			resultType = ast.NewUnknownType()
 		} else {
@@ -1417,10 +1457,7 @@ func (self *Analyzer) matchExpression(node pAst.MatchExpression) ast.AnalyzedMat
 			IgnoreFnParamNameMismatches: false,
 		}); err != nil {
 			hadTypeErr = true
-			self.diagnostics = append(self.diagnostics, err.GotDiagnostic)
-			if err.ExpectedDiagnostic != nil {
-				self.diagnostics = append(self.diagnostics, *err.ExpectedDiagnostic)
-			}
+			self.diagnostics = append(self.diagnostics, err.Diagnostic)
 		}
 
 		containsDefault := false
@@ -1436,6 +1473,7 @@ func (self *Analyzer) matchExpression(node pAst.MatchExpression) ast.AnalyzedMat
 		if containsDefault {
 			if len(arm.Literals) > 1 {
 				self.error(
+					errors.MixedDefaultArm,
 					"Default case `_` used in the same arm as literal values",
 					[]string{"To declare a default arm, use the `_` as the only matching value"},
 					arm.Range,
@@ -1446,16 +1484,12 @@ func (self *Analyzer) matchExpression(node pAst.MatchExpression) ast.AnalyzedMat
 		}
 
 		if defaultArmSpan != nil && !warnUnreachable {
-			self.warn(
+			self.warnWithLabels(
+				errors.UnreachableMatchArm,
 				"This match-arm is unreachable",
 				nil,
 				arm.Range,
-			)
-
-			self.hint(
-				"Any branches following this arm are unreachable",
-				nil,
-				*defaultArmSpan,
+				diagnostic.NewLabel(*defaultArmSpan, "Any branches following this arm are unreachable"),
 			)
 
 			warnUnreachable = true
@@ -1471,10 +1505,7 @@ func (self *Analyzer) matchExpression(node pAst.MatchExpression) ast.AnalyzedMat
 				AllowFunctionTypes:          true,
 				IgnoreFnParamNameMismatches: false,
 			}); err != nil {
-				self.diagnostics = append(self.diagnostics, err.GotDiagnostic)
-				if err.ExpectedDiagnostic != nil {
-					self.diagnostics = append(self.diagnostics, *err.ExpectedDiagnostic)
-				}
+				self.diagnostics = append(self.diagnostics, err.Diagnostic)
 			}
 		}
 
@@ -1493,17 +1524,16 @@ func (self *Analyzer) matchExpression(node pAst.MatchExpression) ast.AnalyzedMat
 		AllowFunctionTypes:          true,
 		IgnoreFnParamNameMismatches: false,
 	}); defaultArm == nil && err != nil {
-		self.error(
+		self.errorWithLabels(
+			errors.MissingDefaultBranch,
 			"Missing default branch",
 			[]string{
 				fmt.Sprintf("A value of type '%s' is expected, therefore cannot result in 'null'", resultType),
 				"A default branch can be created like this: `_ => { ... },`",
 			},
 			lastSpan,
+			err.Diagnostic.Labels...,
 		)
-		if err.ExpectedDiagnostic != nil {
-			self.diagnostics = append(self.diagnostics, *err.ExpectedDiagnostic)
-		}
 	}
 
 	return ast.AnalyzedMatchExpression{
@@ -1554,11 +1584,8 @@ func (self *Analyzer) tryExpression(node pAst.TryExpression) ast.AnalyzedTryExpr
 		AllowFunctionTypes:          true,
 		IgnoreFnParamNameMismatches: false,
 	}); err != nil {
-		err.GotDiagnostic.Notes = append(err.GotDiagnostic.Notes, "The `try` and `catch` branches must result in the identical type")
-		self.diagnostics = append(self.diagnostics, err.GotDiagnostic)
-		if err.ExpectedDiagnostic != nil {
-			self.diagnostics = append(self.diagnostics, *err.ExpectedDiagnostic)
-		}
+		err.Diagnostic.Notes = append(err.Diagnostic.Notes, "The `try` and `catch` branches must result in the identical type")
+		self.diagnostics = append(self.diagnostics, err.Diagnostic)
 		resultType = ast.NewUnknownType()
 	}
 
@@ -52,3 +52,12 @@ type HostProvider interface {
 	// NOTE: these must not include the prefix token for annotations
 	GetKnownObjectTypeFieldAnnotations() []string
 }
+
+// Hosts may implement this interface in addition to the `HostProvider`.
+// It allows tools, such as the language server, to suggest builtin imports.
+type BuiltinImportLister interface {
+	// Returns the names of all builtin modules.
+	BuiltinModules() []string
+	// Returns the names of all values of a builtin module which can be imported using the given kind.
+	BuiltinImports(moduleName string, kind pAst.IMPORT_KIND) []string
+}
@@ -34,6 +34,7 @@ type function struct {
 	ReturnTypeSpan errors.Span
 	Used           bool
 	Modifier       pAst.FunctionModifier
+	ModifierSpan   errors.Span
 }
 
 func (self function) Type(span errors.Span) ast.Type {
@@ -67,6 +68,7 @@ func newFunction(
 	returnType ast.Type,
 	returnSpan errors.Span,
 	modifier pAst.FunctionModifier,
+	modifierSpan errors.Span,
 ) function {
 	return function{
 		IdentSpan:      identSpan,
@@ -77,6 +79,7 @@ func newFunction(
 		ReturnTypeSpan: returnSpan,
 		Used:           false,
 		Modifier:       modifier,
+		ModifierSpan:   modifierSpan,
 	}
 }
 
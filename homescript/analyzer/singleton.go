@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"github.com/smarthome-go/homescript/v3/homescript/analyzer/ast"
+	"github.com/smarthome-go/homescript/v3/homescript/diagnostic"
 	"github.com/smarthome-go/homescript/v3/homescript/errors"
 	pAst "github.com/smarthome-go/homescript/v3/homescript/parser/ast"
 )
@@ -29,14 +30,31 @@ func (self *Analyzer) templateCapabilitiesWithDefault(
 	}
 
 	// Furthermore, use the implemented capabilities
-	for _, implementedCap := range userImplementsCapabilities.List {
+	for idx, implementedCap := range userImplementsCapabilities.List {
 		capability, exists := templateSpec.Capabilities[implementedCap.Ident()]
 		if !exists {
-			self.error(
-				fmt.Sprintf("Capability `%s` not found on template `%s`", implementedCap.Ident(), templateSpecName),
-				[]string{"Remove this capability from the `impl` block"},
-				implementedCap.Span(),
-			)
+			message := fmt.Sprintf("Capability `%s` not found on template `%s`", implementedCap.Ident(), templateSpecName)
+			candidates := mapKeys(templateSpec.Capabilities)
+
+			// If the capability is likely misspelled, fixing its name is more helpful than removing it.
+			if _, misspelled := closestMatch(implementedCap.Ident(), candidates); misspelled {
+				self.errorWithSuggestion(
+					errors.UndefinedCapability,
+					message,
+					nil,
+					implementedCap.Ident(),
+					implementedCap.Span(),
+					candidates,
+				)
+			} else {
+				self.errorWithFix(
+					errors.UndefinedCapability,
+					message,
+					[]string{"Remove this capability from the `impl` block"},
+					implementedCap.Span(),
+					removeCapabilityFix(userImplementsCapabilities.List, idx),
+				)
+			}
 
 			// Ignore this erroneous capability
 			continue
@@ -103,3 +121,29 @@ func (self *Analyzer) WithCapabilities(
 	// BUG: this returns nothing currently
 	return methods, err
 }
+
+// Returns a fix which removes the capability at the given index.
+// As the list of capabilities cannot be empty, there is no fix for removing the only capability.
+func removeCapabilityFix(capabilities []pAst.SpannedIdent, idx int) *diagnostic.Fix {
+	if len(capabilities) < 2 {
+		return nil
+	}
+
+	description := fmt.Sprintf("Remove the capability `%s`", capabilities[idx].Ident())
+
+	// The capability is removed together with the separator by replacing both the capability
+	// and one of its neighbors with just the neighbor.
+	first, last, remaining := capabilities[idx], capabilities[idx], ""
+	if idx+1 < len(capabilities) {
+		last = capabilities[idx+1]
+		remaining = last.Ident()
+	} else {
+		first = capabilities[idx-1]
+		remaining = first.Ident()
+	}
+
+	return diagnostic.NewFix(description, diagnostic.Edit{
+		Span:        first.Span().Start.Until(last.Span().End, first.Span().Filename),
+		Replacement: remaining,
+	})
+}
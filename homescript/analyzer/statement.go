@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/smarthome-go/homescript/v3/homescript/analyzer/ast"
+	"github.com/smarthome-go/homescript/v3/homescript/diagnostic"
 	"github.com/smarthome-go/homescript/v3/homescript/errors"
 	pAst "github.com/smarthome-go/homescript/v3/homescript/parser/ast"
 	"golang.org/x/text/cases"
@@ -73,24 +74,30 @@ func (self *Analyzer) triggerStatement(node pAst.TriggerStatement) ast.AnalyzedT
 	trigger, triggerFound := self.currentModule.getTrigger(node.TriggerIdent.Ident())
 	// triggerType, found, connectiveCorrect := self.host.GetTriggerEvent(node.EventIdent.Ident(), node.DispatchKeyword)
 	if !triggerFound {
-		self.error(
+		self.errorWithSuggestion(
+			errors.UndefinedTriggerFunction,
 			fmt.Sprintf("Use of undefined trigger function '%s'", node.TriggerIdent.Ident()),
 			[]string{
 				fmt.Sprintf("Trigger functions can be imported like this: `import { trigger %s } from ... ;`", node.TriggerIdent.Ident()),
 			},
+			node.TriggerIdent.Ident(),
 			node.TriggerIdent.Span(),
+			mapKeys(self.currentModule.TriggerFunctions),
 		)
 	}
 
 	// Analyze callback function
 	callbackFn, callbackFound := self.currentModule.getFunc(node.CallbackFnIdent.Ident())
 	if !callbackFound {
-		self.error(
+		self.errorWithSuggestion(
+			errors.UndefinedCallbackFunction,
 			fmt.Sprintf("Use of undefined callback function '%s'", node.CallbackFnIdent.Ident()),
 			[]string{
 				fmt.Sprintf("Functions can be defined like this: `fn %s(...) { ... }`", node.CallbackFnIdent.Ident()),
 			},
+			node.CallbackFnIdent.Ident(),
 			node.CallbackFnIdent.Span(),
+			self.currentModule.functionNames(),
 		)
 
 		// Still analyze arguments
@@ -117,6 +124,7 @@ func (self *Analyzer) triggerStatement(node pAst.TriggerStatement) ast.AnalyzedT
 				callbackFn.Used = true
 			} else {
 				self.error(
+					errors.RecursiveTrigger,
 					"Cannot trigger function from itself",
 					[]string{
 						"This could lead to unwanted recursive behavior",
@@ -134,13 +142,15 @@ func (self *Analyzer) triggerStatement(node pAst.TriggerStatement) ast.AnalyzedT
 			message = "Target function misses the `event` modifier"
 		}
 
-		self.error(
+		self.errorWithFix(
+			errors.CallbackModifier,
 			message,
 			[]string{
 				"Functions invoked through a `trigger` will run once an *event* takes place",
 				fmt.Sprintf("The correct modifier `event` can be implemented like this: `event fn %s(...)`", node.CallbackFnIdent),
 			},
 			callbackFn.IdentSpan,
+			eventModifierFix(callbackFn),
 		)
 	}
 
@@ -174,24 +184,13 @@ func (self *Analyzer) triggerStatement(node pAst.TriggerStatement) ast.AnalyzedT
 				IgnoreFnParamNameMismatches: true,
 			},
 		); err != nil {
-			self.diagnostics = append(self.diagnostics, err.GotDiagnostic.WithContext("Regarding callback function"))
-			if err.ExpectedDiagnostic != nil {
-				self.diagnostics = append(
-					self.diagnostics,
-					err.ExpectedDiagnostic.WithContext(
-						fmt.Sprintf("Regarding callback function for trigger `%s`", node.TriggerIdent),
-					),
-				)
-				err.ExpectedDiagnostic.Notes = append(
-					err.ExpectedDiagnostic.Notes,
-					fmt.Sprintf("Function `%s` used as callback for trigger `%s`", node.CallbackFnIdent, node.TriggerIdent),
-				)
-			}
-			self.hint(
-				fmt.Sprintf("This function is used as a callback for trigger `%s`", node.TriggerIdent),
-				make([]string, 0),
+			d := err.Diagnostic.WithContext("Regarding callback function")
+			d.Notes = append(d.Notes, fmt.Sprintf("Function `%s` used as callback for trigger `%s`", node.CallbackFnIdent, node.TriggerIdent))
+			d.Labels = append(d.Labels, diagnostic.NewLabel(
 				callbackFn.IdentSpan,
-			)
+				fmt.Sprintf("This function is used as a callback for trigger `%s`", node.TriggerIdent),
+			))
+			self.diagnostics = append(self.diagnostics, d)
 		}
 		args = self.callArgs(
 			trigger.TriggerFnType,
@@ -223,6 +222,7 @@ func (self *Analyzer) typeDefStatement(node pAst.TypeDefinition) ast.AnalyzedTyp
 	// also add the declaration to the current type scope
 	if prev := (*self.currentModule).addType(node.LhsIdent.Ident(), newTypeWrapper(converted, node.IsPub, node.LhsIdent.Span(), node.IsPub)); prev != nil {
 		self.error(
+			errors.DuplicateDefinition,
 			fmt.Sprintf("Type '%s' is already declared as '%s' in this scope", node.LhsIdent.Ident(), prev.Type),
 			[]string{"Consider altering this type's name"},
 			node.LhsIdent.Span(),
@@ -230,9 +230,11 @@ func (self *Analyzer) typeDefStatement(node pAst.TypeDefinition) ast.AnalyzedTyp
 	}
 
 	return ast.AnalyzedTypeDefinition{
-		LhsIdent: node.LhsIdent.Ident(),
-		RhsType:  converted,
-		Range:    node.Range,
+		LhsIdent:   node.LhsIdent.Ident(),
+		RhsType:    converted,
+		IsPub:      node.IsPub,
+		Range:      node.Range,
+		DocComment: node.DocComment,
 	}
 }
 
@@ -246,6 +248,7 @@ func (self *Analyzer) singletonDeclStatement(node pAst.SingletonTypeDefinition)
 	singleton, found := self.currentModule.Singletons[node.Ident.Ident()]
 	if found {
 		self.error(
+			errors.DuplicateDefinition,
 			fmt.Sprintf("Singleton type '%s' is already declared as '%s' in this module", node.Ident.Ident(), singleton.Type),
 			[]string{"Consider altering this type's name"},
 			node.Ident.Span(),
@@ -267,6 +270,7 @@ func (self *Analyzer) singletonDeclStatement(node pAst.SingletonTypeDefinition)
 		Range:               node.Range,
 		ImplementsTemplates: make([]pAst.ImplBlockTemplate, 0),
 		Used:                false,
+		DocComment:          node.DocComment,
 	}
 }
 
@@ -289,6 +293,7 @@ func (self *Analyzer) letStatement(node pAst.LetStatement, isGlobal bool) ast.An
 		// ensure that the initializer is constant
 		if !initExpr.Constant() {
 			self.error(
+				errors.NonConstantGlobal,
 				"Global initializer must be constant",
 				[]string{fmt.Sprintf("Values of type `%s` are not allowed in global variables.", initExpr.Type()), "Consider using a value with a supported type."},
 				node.Expression.Span(),
@@ -313,21 +318,20 @@ func (self *Analyzer) letStatement(node pAst.LetStatement, isGlobal bool) ast.An
 			AllowFunctionTypes:          !rhsHasAny,
 			IgnoreFnParamNameMismatches: false,
 		}); err != nil {
-			self.diagnostics = append(self.diagnostics, err.GotDiagnostic)
-			if err.ExpectedDiagnostic != nil {
-				self.diagnostics = append(self.diagnostics, *err.ExpectedDiagnostic)
-			}
+			self.diagnostics = append(self.diagnostics, err.Diagnostic)
 		} else {
 			// if the optional type annotation fixed the issue, use the optional type
 			varType = optType
 		}
 	} else if rhsHasAny {
 		self.error(
+			errors.ImplicitAny,
 			"Implicit use of 'any' type: explicit type annotations required",
 			[]string{"An explicit type can be declared like this: `let foo: type = ...`"},
 			node.Ident.Span(),
 		)
 		self.hint(
+			errors.ImplicitAny,
 			fmt.Sprintf("This expression is of type '%s'", rhsType),
 			[]string{"Or consider casting this expression: `... as type`"},
 			initExpr.Span(),
@@ -344,16 +348,12 @@ func (self *Analyzer) letStatement(node pAst.LetStatement, isGlobal bool) ast.An
 	if prev := self.currentModule.addVar(node.Ident.Ident(), NewVar(varType, node.Ident.Span(), NormalVariableOriginKind, node.IsPub), true); prev != nil {
 		if isGlobal {
 			// prevent duplicate globals
-			self.error(
+			self.errorWithLabels(
+				errors.DuplicateDefinition,
 				fmt.Sprintf("Duplicate definition of global '%s'", node.Ident.Ident()),
 				make([]string, 0),
 				node.Ident.Span(),
-			)
-
-			self.hint(
-				fmt.Sprintf("Previous definition of global '%s'", node.Ident.Ident()),
-				nil,
-				prev.Span,
+				diagnostic.NewLabel(prev.Span, fmt.Sprintf("Previous definition of global '%s'", node.Ident.Ident())),
 			)
 		} else {
 			if !strings.HasPrefix(node.Ident.Ident(), "_") && !prev.Used {
@@ -368,16 +368,14 @@ func (self *Analyzer) letStatement(node pAst.LetStatement, isGlobal bool) ast.An
 				}
 
 				// variable is being shadowed, warn if the old variable was unused
-				self.warn(
+				caser := cases.Title(language.AmericanEnglish)
+				self.warnWithFix(
+					errors.UnusedVariable,
 					fmt.Sprintf("Unused %s '%s'", label, node.Ident.Ident()),
 					nil,
 					prev.Span,
-				)
-				caser := cases.Title(language.AmericanEnglish)
-				self.hint(
-					fmt.Sprintf("%s '%s' shadowed here", caser.String(label), node.Ident.Ident()),
-					nil,
-					node.Ident.Span(),
+					unusedNameFix(node.Ident.Ident(), prev.Span),
+					diagnostic.NewLabel(node.Ident.Span(), fmt.Sprintf("%s '%s' shadowed here", caser.String(label), node.Ident.Ident())),
 				)
 			}
 		}
@@ -390,6 +388,8 @@ func (self *Analyzer) letStatement(node pAst.LetStatement, isGlobal bool) ast.An
 		NeedsRuntimeTypeValidation: rhsHasAny,
 		OptType:                    optType,
 		Range:                      node.Range,
+		IsPub:                      node.IsPub,
+		DocComment:                 node.DocComment,
 	}
 }
 
@@ -410,6 +410,7 @@ func (self *Analyzer) returnStatement(node pAst.ReturnStatement) ast.AnalyzedRet
 	// check if the statement is inside a function or lambda literal
 	if self.currentModule.CurrentFunction == nil {
 		self.error(
+			errors.ReturnOutsideFunction,
 			"Illegal use of return statement outside of function body",
 			nil,
 			node.Span(),
@@ -427,10 +428,7 @@ func (self *Analyzer) returnStatement(node pAst.ReturnStatement) ast.AnalyzedRet
 		AllowFunctionTypes:          true,
 		IgnoreFnParamNameMismatches: false,
 	}); err != nil {
-		self.diagnostics = append(self.diagnostics, err.GotDiagnostic)
-		if err.ExpectedDiagnostic != nil {
-			self.diagnostics = append(self.diagnostics, *err.ExpectedDiagnostic)
-		}
+		self.diagnostics = append(self.diagnostics, err.Diagnostic)
 	}
 
 	return ast.AnalyzedReturnStatement{
@@ -447,6 +445,7 @@ func (self *Analyzer) breakStatement(node pAst.BreakStatement) ast.AnalyzedBreak
 	// check that this statement is only called inside of a loop
 	if self.currentModule.LoopDepth == 0 {
 		self.error(
+			errors.BreakOutsideLoop,
 			"Illegal use of 'break' outside of a loop",
 			[]string{"This statement can only be used in loop bodies"},
 			node.Range,
@@ -468,6 +467,7 @@ func (self *Analyzer) breakStatement(node pAst.BreakStatement) ast.AnalyzedBreak
 func (self *Analyzer) continueStatement(node pAst.ContinueStatement) ast.AnalyzedContinueStatement {
 	if self.currentModule.LoopDepth == 0 {
 		self.error(
+			errors.ContinueOutsideLoop,
 			"Illegal use of 'continue' statement outside of a loop",
 			[]string{"This statement can only be used in loop bodies"},
 			node.Range,
@@ -518,7 +518,7 @@ func (self *Analyzer) whileStatement(node pAst.WhileStatement) ast.AnalyzedWhile
 		AllowFunctionTypes:          true,
 		IgnoreFnParamNameMismatches: false,
 	}); err != nil {
-		self.diagnostics = append(self.diagnostics, err.GotDiagnostic)
+		self.diagnostics = append(self.diagnostics, err.Diagnostic)
 	}
 
 	// validate that the block returns `null`
@@ -570,6 +570,7 @@ func (self *Analyzer) forStatement(node pAst.ForStatement) ast.AnalyzedForStatem
 		// ignore these, caused by earlier errors / warnings
 	default:
 		self.error(
+			errors.NotIterable,
 			fmt.Sprintf("A value of type '%s' cannot be used as an iterator", iterExpr.Type()),
 			nil,
 			iterExpr.Span(),
@@ -618,6 +619,7 @@ func (self *Analyzer) expectLoopToReturnNull(typ ast.Type, errSpan errors.Span)
 		// ignore this, this is the desired state
 	default:
 		self.error(
+			errors.IllegalLoopResultType,
 			fmt.Sprintf(
 				"Loop requires a block of type '%s' or '%s', found '%s'",
 				ast.TypeKind(ast.NullTypeKind),
@@ -0,0 +1,176 @@
+package analyzer
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/agnivade/levenshtein"
+	"github.com/smarthome-go/homescript/v3/homescript/diagnostic"
+	"github.com/smarthome-go/homescript/v3/homescript/errors"
+	pAst "github.com/smarthome-go/homescript/v3/homescript/parser/ast"
+)
+
+// If a name is not known, a known name which is `this` or closer to the unknown one
+// will be suggested to the user "undefined variable: (did you mean ... ?)"
+const SUGGESTION_MAX_LEVENSHTEIN_DISTANCE = 3
+
+// Returns the candidate which is closest to the given name.
+// Candidates which would replace most of the name are never suggested, for instance `x` instead of `y`.
+// If several candidates are equally close, the alphabetically first one is used so that the output is stable.
+func closestMatch(name string, candidates []string) (match string, found bool) {
+	sorted := make([]string, len(candidates))
+	copy(sorted, candidates)
+	sort.Strings(sorted)
+
+	closestDist := -1
+	for _, candidate := range sorted {
+		if candidate == name {
+			continue
+		}
+
+		distance := levenshtein.ComputeDistance(name, candidate)
+		if distance > SUGGESTION_MAX_LEVENSHTEIN_DISTANCE || distance*2 > len([]rune(name)) {
+			continue
+		}
+
+		if closestDist < 0 || distance < closestDist {
+			closestDist = distance
+			match = candidate
+		}
+	}
+
+	return match, closestDist >= 0
+}
+
+// Appends a note which suggests the closest candidate to the notes, if there is one.
+func withSuggestion(notes []string, name string, candidates []string) []string {
+	match, found := closestMatch(name, candidates)
+	if !found {
+		return notes
+	}
+	return append(notes, fmt.Sprintf("Did you mean `%s`?", match))
+}
+
+// Like `error`, but suggests the closest candidate to the unknown name.
+// The suggestion is added as a note and as a fix which replaces the span with the candidate.
+// Therefore, the span must only cover the name.
+// Returns the suggested candidate, if any.
+func (self *Analyzer) errorWithSuggestion(
+	code errors.Code,
+	message string,
+	notes []string,
+	name string,
+	span errors.Span,
+	candidates []string,
+) (match string, found bool) {
+	match, found = closestMatch(name, candidates)
+	if !found {
+		self.error(code, message, notes, span)
+		return match, found
+	}
+
+	self.errorWithFix(
+		code,
+		message,
+		append(notes, fmt.Sprintf("Did you mean `%s`?", match)),
+		span,
+		diagnostic.NewFix(
+			fmt.Sprintf("Replace with `%s`", match),
+			diagnostic.Edit{Span: span, Replacement: match},
+		),
+	)
+
+	return match, found
+}
+
+//
+// Candidates.
+//
+
+func mapKeys[T any](input map[string]T) []string {
+	output := make([]string, 0, len(input))
+	for key := range input {
+		output = append(output, key)
+	}
+	return output
+}
+
+// Returns the names of all variables and functions which are visible in the current scope.
+// This includes builtin values.
+func (self Module) valueNames() []string {
+	output := make([]string, 0)
+	for _, scope := range self.Scopes {
+		output = append(output, mapKeys(scope.Values)...)
+	}
+	return append(output, self.functionNames()...)
+}
+
+// Returns the names of all functions of the module.
+func (self Module) functionNames() []string {
+	output := make([]string, 0)
+	for _, fn := range self.Functions {
+		if fn.FnType.Kind() == normalFunctionKind {
+			output = append(output, fn.FnType.(normalFunction).Ident.Ident())
+		}
+	}
+	return output
+}
+
+// Returns the names of all types which are visible in the current scope.
+func (self Module) typeNames() []string {
+	output := []string{"null", "int", "float", "range", "bool", "str", "any"}
+	for _, scope := range self.Scopes {
+		output = append(output, mapKeys(scope.Types)...)
+	}
+	return output
+}
+
+// Returns the names of all items of the given kind which another module could import from this module.
+func (self Module) importableNames(kind pAst.IMPORT_KIND) []string {
+	output := make([]string, 0)
+
+	switch kind {
+	case pAst.IMPORT_KIND_TYPE:
+		for name, typ := range self.Scopes[0].Types {
+			if typ.IsPub {
+				output = append(output, name)
+			}
+		}
+	case pAst.IMPORT_KIND_TEMPLATE:
+		output = append(output, mapKeys(self.Templates)...)
+	case pAst.IMPORT_KIND_TRIGGER:
+		output = append(output, mapKeys(self.TriggerFunctions)...)
+	default:
+		for _, fn := range self.Functions {
+			if fn.FnType.Kind() == normalFunctionKind && fn.Modifier == pAst.FN_MODIFIER_PUB {
+				output = append(output, fn.FnType.(normalFunction).Ident.Ident())
+			}
+		}
+		for name, value := range self.Scopes[0].Values {
+			if value.IsPub {
+				output = append(output, name)
+			}
+		}
+	}
+
+	return output
+}
+
+// Returns the names of all values of a builtin module which can be imported using the given kind.
+// Builtin imports can only be suggested if the host implements `BuiltinImportLister`.
+func (self *Analyzer) builtinImportNames(moduleName string, kind pAst.IMPORT_KIND) []string {
+	lister, canList := self.host.(BuiltinImportLister)
+	if !canList {
+		return nil
+	}
+	return lister.BuiltinImports(moduleName, kind)
+}
+
+// Returns the names of all builtin modules.
+func (self *Analyzer) builtinModuleNames() []string {
+	lister, canList := self.host.(BuiltinImportLister)
+	if !canList {
+		return nil
+	}
+	return lister.BuiltinModules()
+}
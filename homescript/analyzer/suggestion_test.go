@@ -0,0 +1,31 @@
+package analyzer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClosestMatch(t *testing.T) {
+	candidates := []string{"counter", "print", "println", "to_upper", "x"}
+
+	match, found := closestMatch("countr", candidates)
+	assert.True(t, found)
+	assert.Equal(t, "counter", match)
+
+	match, found = closestMatch("to_uper", candidates)
+	assert.True(t, found)
+	assert.Equal(t, "to_upper", match)
+
+	// Ties are resolved alphabetically.
+	match, found = closestMatch("printn", candidates)
+	assert.True(t, found)
+	assert.Equal(t, "print", match)
+
+	// Short names are not replaced entirely.
+	_, found = closestMatch("y", candidates)
+	assert.False(t, found)
+
+	_, found = closestMatch("something_else", candidates)
+	assert.False(t, found)
+}
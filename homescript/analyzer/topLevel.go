@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/smarthome-go/homescript/v3/homescript/analyzer/ast"
+	"github.com/smarthome-go/homescript/v3/homescript/diagnostic"
 	"github.com/smarthome-go/homescript/v3/homescript/errors"
 	"github.com/smarthome-go/homescript/v3/homescript/lexer"
 	"github.com/smarthome-go/homescript/v3/homescript/parser"
@@ -31,6 +32,7 @@ func (self *Analyzer) functionSignature(node pAst.FunctionDefinition) {
 			_, alreadyExtracted := extractedSet[singletonIdent]
 			if alreadyExtracted {
 				self.error(
+					errors.DuplicateExtraction,
 					fmt.Sprintf("Singleton `%s` is already being extracted", singletonIdent),
 					[]string{
 						"Singletons can only be extracted once at the start of the parameter list",
@@ -56,15 +58,15 @@ func (self *Analyzer) functionSignature(node pAst.FunctionDefinition) {
 	// add function to current module
 	if prev, exists := self.currentModule.getFunc(node.Ident.Ident()); exists {
 		// check if the identifier conflicts with another function
-		self.error(
+		self.errorWithLabels(
+			errors.DuplicateDefinition,
 			fmt.Sprintf("Duplicate function definition of '%s'", node.Ident.Ident()),
 			[]string{"Consider changing the name of this function"},
 			node.Ident.Span(),
-		)
-		self.hint(
-			fmt.Sprintf("Function '%s' previously defined here", node.Ident.Ident()),
-			nil,
-			(*prev).FnType.(normalFunction).Ident.Span(),
+			diagnostic.NewLabel(
+				(*prev).FnType.(normalFunction).Ident.Span(),
+				fmt.Sprintf("Function '%s' previously defined here", node.Ident.Ident()),
+			),
 		)
 	}
 
@@ -76,6 +78,7 @@ func (self *Analyzer) functionSignature(node pAst.FunctionDefinition) {
 		self.ConvertType(node.ReturnType, false), // errors are only reported in the `self.functionDefinition` method
 		node.ReturnType.Span(),                   // is the return span really required?
 		node.Modifier,
+		node.ModifierSpan,
 	))
 }
 
@@ -123,6 +126,7 @@ func (self *Analyzer) functionDefinition(node pAst.FunctionDefinition) ast.Analy
 			}
 
 			self.error(
+				errors.InvalidEntrySignature,
 				fmt.Sprintf("The '%s%s' function must have 0 parameters, however %d %s defined", modifierErrMsg, node.Ident.Ident(), len(filteredWithoutExtractions), errMsgVerb),
 				nil,
 				node.ParamSpan,
@@ -132,6 +136,7 @@ func (self *Analyzer) functionDefinition(node pAst.FunctionDefinition) ast.Analy
 		// the return type of the `main` function is always `null`
 		if fnReturnType.Kind() != ast.UnknownTypeKind && fnReturnType.Kind() != ast.NullTypeKind {
 			self.error(
+				errors.InvalidEntrySignature,
 				fmt.Sprintf("The return type of the '%s%s' function must be '%s', but is declared as '%s'", modifierErrMsg, node.Ident.Ident(), ast.NewNullType(errors.Span{}).Kind(), fnReturnType.Kind()),
 				[]string{fmt.Sprintf("Remove the return type: `fn %s%s() { ... }`", modifierErrMsg, node.Ident.Ident())},
 				fnReturnType.Span(),
@@ -153,10 +158,7 @@ func (self *Analyzer) functionDefinition(node pAst.FunctionDefinition) ast.Analy
 		AllowFunctionTypes:          true,
 		IgnoreFnParamNameMismatches: false,
 	}); err != nil {
-		self.diagnostics = append(self.diagnostics, err.GotDiagnostic)
-		if err.ExpectedDiagnostic != nil {
-			self.diagnostics = append(self.diagnostics, *err.ExpectedDiagnostic)
-		}
+		self.diagnostics = append(self.diagnostics, err.Diagnostic)
 	}
 
 	// drop scope when finished
@@ -183,6 +185,7 @@ func (self *Analyzer) functionDefinition(node pAst.FunctionDefinition) ast.Analy
 		Modifier:   node.Modifier,
 		Annotation: annotations,
 		Range:      node.Range,
+		DocComment: node.DocComment,
 	}
 }
 
@@ -224,6 +227,7 @@ func (self *Analyzer) analyzeParams(params []pAst.FnParam) []ast.AnalyzedFnParam
 				), false)
 
 				self.error(
+					errors.MisplacedExtraction,
 					fmt.Sprintf("Extraction of singleton '%s' follows normal parameter", param.Ident.Ident()),
 					[]string{"Singletons are to be extracted as the first parameters of a function."},
 					param.Span,
@@ -236,6 +240,7 @@ func (self *Analyzer) analyzeParams(params []pAst.FnParam) []ast.AnalyzedFnParam
 
 			if _, duplicate := existentSingletons[singleton]; duplicate {
 				self.error(
+					errors.DuplicateExtraction,
 					fmt.Sprintf("Duplicate extraction of singleton '%s'", param.Ident.Ident()),
 					[]string{"Every unique singleton can only be extracted once per function"},
 					param.Span,
@@ -249,6 +254,7 @@ func (self *Analyzer) analyzeParams(params []pAst.FnParam) []ast.AnalyzedFnParam
 
 			if _, duplicate := existentParams[param.Ident.Ident()]; duplicate {
 				self.error(
+					errors.DuplicateParameter,
 					fmt.Sprintf("Duplicate declaration of parameter '%s'", param.Ident.Ident()),
 					nil,
 					param.Span,
@@ -311,7 +317,7 @@ func (self *Analyzer) importDummyFields(node pAst.ImportStatement) ast.AnalyzedI
 		switch toImport.Kind {
 		case pAst.IMPORT_KIND_TYPE:
 			if prev := self.currentModule.addType(toImport.Ident, newTypeWrapper(ast.NewUnknownType(), false, toImport.Span, true)); prev != nil {
-				self.error(fmt.Sprintf("Type '%s' already exists in current scope", toImport.Ident), nil, toImport.Span)
+				self.error(errors.DuplicateDefinition, fmt.Sprintf("Type '%s' already exists in current scope", toImport.Ident), nil, toImport.Span)
 			}
 		case pAst.IMPORT_KIND_TEMPLATE:
 			templ := ast.TemplateSpec{
@@ -321,8 +327,13 @@ func (self *Analyzer) importDummyFields(node pAst.ImportStatement) ast.AnalyzedI
 				Span:                toImport.Span,
 			}
 			if prev, prevFound := self.currentModule.addTemplate(toImport.Ident, templ); prevFound {
-				self.error(fmt.Sprintf("Template '%s' already exists in current module", toImport.Ident), nil, toImport.Span)
-				self.hint(fmt.Sprintf("Template `%s` previously imported here", toImport.Ident), make([]string, 0), prev.Span)
+				self.errorWithLabels(
+					errors.DuplicateDefinition,
+					fmt.Sprintf("Template '%s' already exists in current module", toImport.Ident),
+					nil,
+					toImport.Span,
+					diagnostic.NewLabel(prev.Span, fmt.Sprintf("Template `%s` previously imported here", toImport.Ident)),
+				)
 			}
 		case pAst.IMPORT_KIND_TRIGGER:
 			// TODO: what to do here?
@@ -345,7 +356,7 @@ func (self *Analyzer) importDummyFields(node pAst.ImportStatement) ast.AnalyzedI
 			})
 
 			if prev := self.currentModule.addVar(toImport.Ident, NewVar(ast.NewUnknownType(), toImport.Span, ImportedVariableOriginKind, false), false); prev != nil {
-				self.error(fmt.Sprintf("Name '%s' already exists in current scope", toImport.Ident), nil, toImport.Span)
+				self.error(errors.DuplicateDefinition, fmt.Sprintf("Name '%s' already exists in current scope", toImport.Ident), nil, toImport.Span)
 			}
 		}
 	}
@@ -364,6 +375,7 @@ func (self *Analyzer) importItem(node pAst.ImportStatement) ast.AnalyzedImport {
 	codeModule, found, err := self.host.ResolveCodeModule(node.FromModule.Ident())
 	if err != nil {
 		self.error(
+			errors.ModuleResolutionFailed,
 			fmt.Sprintf("Host error: could not resolve module '%s': %s", node.FromModule.Ident(), err.Error()),
 			nil,
 			node.Span(),
@@ -387,13 +399,14 @@ func (self *Analyzer) importItem(node pAst.ImportStatement) ast.AnalyzedImport {
 		module, alreadyAnalyzed := self.modules[node.FromModule.Ident()]
 
 		if !alreadyAnalyzed {
-			self.analyzeModule(node.FromModule.Ident(), parsed, true)
+			self.analyzeModule(node.FromModule.Ident(), parsed, self.mainShallExist)
 
 			// analyze if this import causes a cyclic dependency
 			if path, isCyclic := self.importGraphIsCyclic(self.currentModuleName); isCyclic {
 				importInner := strings.Join(path, " -> ")
 
 				self.error(
+					errors.CyclicImport,
 					fmt.Sprintf("Illegal cyclic import: module %s", importInner),
 					nil,
 					node.Span(),
@@ -411,34 +424,34 @@ func (self *Analyzer) importItem(node pAst.ImportStatement) ast.AnalyzedImport {
 			if item.Kind == pAst.IMPORT_KIND_TYPE {
 				typ, found := module.getType(item.Ident)
 				if !found {
-					self.error(
+					self.errorWithSuggestion(
+						errors.ImportNotFound,
 						fmt.Sprintf("No type named '%s' found in module '%s'", item.Ident, node.FromModule),
 						nil,
-						item.Span,
+						item.Ident,
+						item.NameSpan,
+						module.importableNames(pAst.IMPORT_KIND_TYPE),
 					)
 
 					if prev := self.currentModule.addType(item.Ident, newTypeWrapper(ast.NewUnknownType(), false, item.Span, true)); prev != nil {
-						self.error(fmt.Sprintf("Type '%s' already exists in current scope", item.Ident), nil, item.Span)
+						self.error(errors.DuplicateDefinition, fmt.Sprintf("Type '%s' already exists in current scope", item.Ident), nil, item.Span)
 					}
 					continue
 				}
 
 				// cannot import this type
 				if !typ.IsPub {
-					self.error(
+					self.errorWithLabels(
+						errors.PrivateImport,
 						fmt.Sprintf("Cannot import private type: '%s' is not declared as 'pub'", item.Ident),
-						nil,
-						item.Span,
-					)
-					self.hint(
-						"This type is not declared as 'pub'",
 						[]string{"A type can be declared as 'pub' like this: `pub type = ...`"},
-						typ.NameSpan,
+						item.Span,
+						diagnostic.NewLabel(typ.NameSpan, "This type is not declared as 'pub'"),
 					)
 				}
 
 				if prev := self.currentModule.addType(item.Ident, newTypeWrapper(typ.Type.SetSpan(item.Span), false, item.Span, false)); prev != nil {
-					self.error(fmt.Sprintf("Type '%s' already exists in current scope", item.Ident), nil, item.Span)
+					self.error(errors.DuplicateDefinition, fmt.Sprintf("Type '%s' already exists in current scope", item.Ident), nil, item.Span)
 				}
 				continue
 			}
@@ -446,20 +459,23 @@ func (self *Analyzer) importItem(node pAst.ImportStatement) ast.AnalyzedImport {
 			if item.Kind == pAst.IMPORT_KIND_TEMPLATE {
 				templ, found := module.getTemplate(item.Ident)
 				if !found {
-					self.error(
+					self.errorWithSuggestion(
+						errors.ImportNotFound,
 						fmt.Sprintf("No template named '%s' found in module '%s'", item.Ident, node.FromModule),
 						nil,
-						item.Span,
+						item.Ident,
+						item.NameSpan,
+						module.importableNames(pAst.IMPORT_KIND_TEMPLATE),
 					)
 
 					if _, prevFound := self.currentModule.addTemplate(item.Ident, templ); prevFound {
-						self.error(fmt.Sprintf("Template '%s' already exists in current scope", item.Ident), nil, item.Span)
+						self.error(errors.DuplicateDefinition, fmt.Sprintf("Template '%s' already exists in current scope", item.Ident), nil, item.Span)
 					}
 					continue
 				}
 
 				if _, prevFound := self.currentModule.addTemplate(item.Ident, templ); prevFound {
-					self.error(fmt.Sprintf("Template '%s' already exists in current scope", item.Ident), nil, item.Span)
+					self.error(errors.DuplicateDefinition, fmt.Sprintf("Template '%s' already exists in current scope", item.Ident), nil, item.Span)
 				}
 
 				continue
@@ -468,20 +484,23 @@ func (self *Analyzer) importItem(node pAst.ImportStatement) ast.AnalyzedImport {
 			if item.Kind == pAst.IMPORT_KIND_TRIGGER {
 				trigg, found := module.getTrigger(item.Ident)
 				if !found {
-					self.error(
+					self.errorWithSuggestion(
+						errors.ImportNotFound,
 						fmt.Sprintf("No trigger named '%s' found in module '%s'", item.Ident, node.FromModule),
 						nil,
-						item.Span,
+						item.Ident,
+						item.NameSpan,
+						module.importableNames(pAst.IMPORT_KIND_TRIGGER),
 					)
 
 					if _, prevFound := self.currentModule.addTrigger(item.Ident, trigg); prevFound {
-						self.error(fmt.Sprintf("Trigger '%s' already exists in current scope", item.Ident), nil, item.Span)
+						self.error(errors.DuplicateDefinition, fmt.Sprintf("Trigger '%s' already exists in current scope", item.Ident), nil, item.Span)
 					}
 					continue
 				}
 
 				if _, prevFound := self.currentModule.addTrigger(item.Ident, trigg); prevFound {
-					self.error(fmt.Sprintf("Trigger '%s' already exists in current scope", item.Ident), nil, item.Span)
+					self.error(errors.DuplicateDefinition, fmt.Sprintf("Trigger '%s' already exists in current scope", item.Ident), nil, item.Span)
 				}
 
 				toImport = append(toImport, ast.AnalyzedImportValue{
@@ -502,15 +521,12 @@ func (self *Analyzer) importItem(node pAst.ImportStatement) ast.AnalyzedImport {
 
 				// cannot import this function
 				if fn.Modifier != pAst.FN_MODIFIER_PUB {
-					self.error(
+					self.errorWithLabels(
+						errors.PrivateImport,
 						fmt.Sprintf("Cannot import private function: '%s' is not declared as 'pub'", item.Ident),
-						nil,
-						item.Span,
-					)
-					self.hint(
-						"This function is not declared as 'pub'",
 						[]string{"A function can be declared as 'pub' like this: `pub fn name(...) { ... }`"},
-						fn.FnType.(normalFunction).Ident.Span(),
+						item.Span,
+						diagnostic.NewLabel(fn.FnType.(normalFunction).Ident.Span(), "This function is not declared as 'pub'"),
 					)
 				}
 
@@ -524,7 +540,7 @@ func (self *Analyzer) importItem(node pAst.ImportStatement) ast.AnalyzedImport {
 					),
 					false,
 				); prev != nil {
-					self.error(fmt.Sprintf("Name '%s' already exists in current scope", item.Ident), nil, item.Span)
+					self.error(errors.DuplicateDefinition, fmt.Sprintf("Name '%s' already exists in current scope", item.Ident), nil, item.Span)
 				}
 
 				continue
@@ -532,10 +548,13 @@ func (self *Analyzer) importItem(node pAst.ImportStatement) ast.AnalyzedImport {
 
 			val, found := module.Scopes[0].Values[item.Ident]
 			if !found {
-				self.error(
+				self.errorWithSuggestion(
+					errors.ImportNotFound,
 					fmt.Sprintf("No %s named '%s' found in module '%s'", entityErrNameFromImportKind(item.Kind), item.Ident, node.FromModule),
 					nil,
-					item.Span,
+					item.Ident,
+					item.NameSpan,
+					module.importableNames(item.Kind),
 				)
 				toImport = append(toImport, ast.AnalyzedImportValue{
 					Ident: pAst.NewSpannedIdent(item.Ident, item.Span),
@@ -543,20 +562,17 @@ func (self *Analyzer) importItem(node pAst.ImportStatement) ast.AnalyzedImport {
 				})
 
 				if prev := self.currentModule.addVar(item.Ident, NewVar(ast.NewUnknownType(), item.Span, ImportedVariableOriginKind, false), false); prev != nil {
-					self.error(fmt.Sprintf("Name '%s' already exists in current scope", item.Ident), nil, item.Span)
+					self.error(errors.DuplicateDefinition, fmt.Sprintf("Name '%s' already exists in current scope", item.Ident), nil, item.Span)
 				}
 			} else {
 				if !val.IsPub {
 					// cannot import this variable
-					self.error(
+					self.errorWithLabels(
+						errors.PrivateImport,
 						fmt.Sprintf("Cannot import private variable: '%s' is not declared as 'pub'", item.Ident),
-						nil,
-						item.Span,
-					)
-					self.hint(
-						"This variable is not declared as 'pub'",
 						[]string{"A variable can be declared as 'pub' like this: `pub let name = ...`"},
-						val.Span,
+						item.Span,
+						diagnostic.NewLabel(val.Span, "This variable is not declared as 'pub'"),
 					)
 				}
 
@@ -565,7 +581,7 @@ func (self *Analyzer) importItem(node pAst.ImportStatement) ast.AnalyzedImport {
 					Type:  val.Type.SetSpan(item.Span),
 				})
 				if prev := self.currentModule.addVar(item.Ident, NewVar(val.Type.SetSpan(item.Span), item.Span, ImportedVariableOriginKind, false), false); prev != nil {
-					self.error(fmt.Sprintf("Name '%s' already exists in current scope", item.Ident), nil, item.Span)
+					self.error(errors.DuplicateDefinition, fmt.Sprintf("Name '%s' already exists in current scope", item.Ident), nil, item.Span)
 				}
 			}
 		}
@@ -581,31 +597,37 @@ func (self *Analyzer) importItem(node pAst.ImportStatement) ast.AnalyzedImport {
 	for _, item := range node.ToImport {
 		imported, moduleFound, valueFound := self.host.GetBuiltinImport(node.FromModule.Ident(), item.Ident, item.Span, item.Kind)
 		if !moduleFound {
-			self.error(
+			self.errorWithSuggestion(
+				errors.ModuleNotFound,
 				fmt.Sprintf("Module '%s' not found", node.FromModule),
 				nil,
+				node.FromModule.Ident(),
 				node.FromModule.Span(),
+				self.builtinModuleNames(),
 			)
 			return self.importDummyFields(node)
 		} else if !valueFound {
-			self.error(
+			self.errorWithSuggestion(
+				errors.ImportNotFound,
 				fmt.Sprintf("No %s named '%s' found in module '%s'", entityErrNameFromImportKind(item.Kind), item.Ident, node.FromModule),
 				nil,
-				item.Span,
+				item.Ident,
+				item.NameSpan,
+				self.builtinImportNames(node.FromModule.Ident(), item.Kind),
 			)
 			toImport = append(toImport, ast.AnalyzedImportValue{
 				Ident: pAst.NewSpannedIdent(item.Ident, item.Span),
 				Type:  ast.NewUnknownType(),
 			})
 			if prev := self.currentModule.addVar(item.Ident, NewVar(ast.NewUnknownType(), item.Span, ImportedVariableOriginKind, false), false); prev != nil {
-				self.error(fmt.Sprintf("Name '%s' already exists in current scope", item.Ident), nil, item.Span)
+				self.error(errors.DuplicateDefinition, fmt.Sprintf("Name '%s' already exists in current scope", item.Ident), nil, item.Span)
 			}
 		} else {
 			// Type and templ imports need special action: only add the type and filter out this import
 			switch item.Kind {
 			case pAst.IMPORT_KIND_TYPE:
 				if prev := self.currentModule.addType(item.Ident, newTypeWrapper(imported.Type.SetSpan(item.Span), false, item.Span, false)); prev != nil {
-					self.error(fmt.Sprintf("Type '%s' already exists in current scope", item.Ident), nil, item.Span)
+					self.error(errors.DuplicateDefinition, fmt.Sprintf("Type '%s' already exists in current scope", item.Ident), nil, item.Span)
 				}
 
 				toImport = append(toImport, ast.AnalyzedImportValue{
@@ -618,8 +640,13 @@ func (self *Analyzer) importItem(node pAst.ImportStatement) ast.AnalyzedImport {
 			case pAst.IMPORT_KIND_TEMPLATE:
 				prev, prevFound := self.currentModule.addTemplate(item.Ident, *imported.Template)
 				if prevFound {
-					self.error(fmt.Sprintf("Template '%s' already exists in current module", item.Ident), nil, item.Span)
-					self.hint(fmt.Sprintf("Template `%s` previously imported here", item.Ident), make([]string, 0), prev.Span)
+					self.errorWithLabels(
+						errors.DuplicateDefinition,
+						fmt.Sprintf("Template '%s' already exists in current module", item.Ident),
+						nil,
+						item.Span,
+						diagnostic.NewLabel(prev.Span, fmt.Sprintf("Template `%s` previously imported here", item.Ident)),
+					)
 				}
 
 				toImport = append(toImport, ast.AnalyzedImportValue{
@@ -632,8 +659,13 @@ func (self *Analyzer) importItem(node pAst.ImportStatement) ast.AnalyzedImport {
 			case pAst.IMPORT_KIND_TRIGGER:
 				prev, prevFound := self.currentModule.addTrigger(item.Ident, *imported.Trigger)
 				if prevFound {
-					self.error(fmt.Sprintf("Trigger function '%s' already exists in current module", item.Ident), nil, item.Span)
-					self.hint(fmt.Sprintf("Trigger function `%s` previously imported here", item.Ident), make([]string, 0), prev.ImportedAt)
+					self.errorWithLabels(
+						errors.DuplicateDefinition,
+						fmt.Sprintf("Trigger function '%s' already exists in current module", item.Ident),
+						nil,
+						item.Span,
+						diagnostic.NewLabel(prev.ImportedAt, fmt.Sprintf("Trigger function `%s` previously imported here", item.Ident)),
+					)
 				}
 
 				toImport = append(toImport, ast.AnalyzedImportValue{
@@ -650,7 +682,7 @@ func (self *Analyzer) importItem(node pAst.ImportStatement) ast.AnalyzedImport {
 				Type:  imported.Type.SetSpan(item.Span),
 			})
 			if prev := self.currentModule.addVar(item.Ident, NewVar(imported.Type.SetSpan(item.Span), item.Span, ImportedVariableOriginKind, false), false); prev != nil {
-				self.error(fmt.Sprintf("Name '%s' already exists in current scope", item.Ident), nil, item.Span)
+				self.error(errors.DuplicateDefinition, fmt.Sprintf("Name '%s' already exists in current scope", item.Ident), nil, item.Span)
 			}
 		}
 	}
@@ -675,12 +707,18 @@ func (self *Analyzer) implBlock(node pAst.ImplBlock) ast.AnalyzedImplBlock {
 	// Check that this singleton singletonExists
 	singleton, singletonExists := self.currentModule.Singletons[node.SingletonIdent.Ident()]
 	if !singletonExists {
+		// The span of a singleton reference does not only cover its name, therefore, no fix is suggested.
 		self.error(
+			errors.UndefinedSingleton,
 			fmt.Sprintf("Undeclared singleton `%s`", node.SingletonIdent.Ident()),
-			[]string{
-				"Cannot implement methods for non-existent singleton",
-				fmt.Sprintf("Singleton types can be declared like this: `@%s\n type %sFoo = ...;`", node.SingletonIdent.Ident(), node.SingletonIdent.Ident()),
-			},
+			withSuggestion(
+				[]string{
+					"Cannot implement methods for non-existent singleton",
+					fmt.Sprintf("Singleton types can be declared like this: `@%s\n type %sFoo = ...;`", node.SingletonIdent.Ident(), node.SingletonIdent.Ident()),
+				},
+				node.SingletonIdent.Ident(),
+				mapKeys(self.currentModule.Singletons),
+			),
 			node.SingletonIdent.Span(),
 		)
 	} else {
@@ -697,10 +735,13 @@ func (self *Analyzer) implBlock(node pAst.ImplBlock) ast.AnalyzedImplBlock {
 	// Check if the template exists and retrieve it
 	tmpl, templateFound := self.currentModule.getTemplate(node.UsingTemplate.Template.Ident())
 	if !templateFound {
-		self.error(
+		self.errorWithSuggestion(
+			errors.UndefinedTemplate,
 			fmt.Sprintf("Template `%s` not found", node.UsingTemplate.Template.Ident()),
 			[]string{fmt.Sprintf("Templates can be imported like this: `import templ %s;`", node.UsingTemplate.Template.Ident())},
+			node.UsingTemplate.Template.Ident(),
 			node.UsingTemplate.Template.Span(),
+			mapKeys(self.currentModule.Templates),
 		)
 
 		return ast.AnalyzedImplBlock{
@@ -710,6 +751,7 @@ func (self *Analyzer) implBlock(node pAst.ImplBlock) ast.AnalyzedImplBlock {
 			Methods:           methods,
 			Span:              node.Span,
 			FinalCapabilities: nil,
+			DocComment:        node.DocComment,
 		}
 	}
 
@@ -733,6 +775,7 @@ func (self *Analyzer) implBlock(node pAst.ImplBlock) ast.AnalyzedImplBlock {
 			Methods:           methods,
 			Span:              node.Span,
 			FinalCapabilities: nil,
+			DocComment:        node.DocComment,
 		}
 	}
 
@@ -763,6 +806,7 @@ func (self *Analyzer) implBlock(node pAst.ImplBlock) ast.AnalyzedImplBlock {
 		Methods:           methods,
 		Span:              node.Span,
 		FinalCapabilities: finalCapabilities,
+		DocComment:        node.DocComment,
 	}
 }
 
@@ -780,6 +824,7 @@ func (self *Analyzer) templateTypeFail(method ast.AnalyzedFunctionDefinition, si
 
 	if !extractsSingleton {
 		self.error(
+			errors.MissingExtraction,
 			fmt.Sprintf("Method does not extract singleton `%s`", singletonIdent),
 			[]string{
 				fmt.Sprintf("Since the method is implemented for `%s`, it should also extract it", singletonIdent),
@@ -854,6 +899,7 @@ func (self *Analyzer) validateTemplateConstraints(
 					}
 
 					self.error(
+						errors.TemplateMethodMismatch,
 						fmt.Sprintf(
 							"Expected %d parameter%s, got %d",
 							len(filteredReq),
@@ -874,6 +920,7 @@ func (self *Analyzer) validateTemplateConstraints(
 						// TODO: break now or later?
 						hasParamErrs = true
 						self.error(
+							errors.TemplateMethodMismatch,
 							fmt.Sprintf("Expected parameter with name `%s`, got `%s`",
 								reqParam.Name,
 								gotParam.Ident.Ident(),
@@ -888,10 +935,7 @@ func (self *Analyzer) validateTemplateConstraints(
 						AllowFunctionTypes:          true,
 						IgnoreFnParamNameMismatches: false,
 					}); err != nil {
-						self.diagnostics = append(self.diagnostics, err.GotDiagnostic)
-						if err.ExpectedDiagnostic != nil {
-							self.diagnostics = append(self.diagnostics, *err.ExpectedDiagnostic)
-						}
+						self.diagnostics = append(self.diagnostics, err.Diagnostic)
 
 						// TODO: BREAK as usual
 						hasParamErrs = true
@@ -912,10 +956,7 @@ func (self *Analyzer) validateTemplateConstraints(
 						IgnoreFnParamNameMismatches: false,
 					},
 				); err != nil {
-					self.diagnostics = append(self.diagnostics, err.GotDiagnostic.WithContext("Regarding function's return type"))
-					if err.ExpectedDiagnostic != nil {
-						self.diagnostics = append(self.diagnostics, *err.ExpectedDiagnostic)
-					}
+					self.diagnostics = append(self.diagnostics, err.Diagnostic.WithContext("Regarding function's return type"))
 				} else {
 					self.templateTypeFail(method, singletonIdent.Ident())
 				}
@@ -929,6 +970,7 @@ func (self *Analyzer) validateTemplateConstraints(
 
 					if reqMethod.Modifier == pAst.FN_MODIFIER_NONE {
 						self.error(
+							errors.TemplateMethodMismatch,
 							fmt.Sprintf("Template method has redundant modifier `%s`", method.Modifier.String()),
 							[]string{
 								fmt.Sprintf("Remove the modifier like this: `fn %s(...)%s {...}`", reqName, returnTypeString),
@@ -937,6 +979,7 @@ func (self *Analyzer) validateTemplateConstraints(
 						)
 					} else if method.Modifier == pAst.FN_MODIFIER_NONE {
 						self.error(
+							errors.TemplateMethodMismatch,
 							fmt.Sprintf("Template method lacks required modifier `%s`", reqMethod.Modifier.String()),
 							[]string{
 								fmt.Sprintf("Add the modifier like this: `%s fn %s(...)%s {...}`", reqMethod.Modifier.String(), reqName, returnTypeString),
@@ -945,6 +988,7 @@ func (self *Analyzer) validateTemplateConstraints(
 						)
 					} else {
 						self.error(
+							errors.TemplateMethodMismatch,
 							fmt.Sprintf("Expected modifier `%s`, but found `%s`", reqMethod.Modifier.String(), method.Modifier.String()),
 							[]string{
 								fmt.Sprintf("Change the `%s` modifier to `%s`", method.Modifier.String(), reqMethod.Modifier.String()),
@@ -977,6 +1021,7 @@ func (self *Analyzer) validateTemplateConstraints(
 			}
 
 			self.error(
+				errors.MissingTemplateMethod,
 				fmt.Sprintf("Not all methods implemented: method `%s` is is missing", reqName),
 				[]string{
 					"Template is not satisfied",
@@ -1007,6 +1052,7 @@ func (self *Analyzer) validateTemplateConstraints(
 
 		if !isRequired {
 			self.error(
+				errors.AdditionalTemplateMethod,
 				fmt.Sprintf("Additional method `%s` implemented: this method is not part of the template `%s`", method.Ident, implementedTemplateWithCapabilities.Template.Ident()),
 				[]string{"Remove this function definition"},
 				method.Range,
@@ -7,6 +7,8 @@ import (
 	"github.com/agnivade/levenshtein"
 	"github.com/smarthome-go/homescript/v3/homescript/analyzer/ast"
 	"github.com/smarthome-go/homescript/v3/homescript/diagnostic"
+	"github.com/smarthome-go/homescript/v3/homescript/errors"
+	"github.com/smarthome-go/homescript/v3/homescript/lexer"
 	pAst "github.com/smarthome-go/homescript/v3/homescript/parser/ast"
 )
 
@@ -23,15 +25,27 @@ func (self *Analyzer) analyzeAnnotation(annotation pAst.SpannedIdent) {
 	}
 
 	notes := []string{}
+	var fix *diagnostic.Fix
 
 	if suggestion != nil {
 		notes = append(notes, fmt.Sprintf("Maybe you intended to use `%s`", *suggestion))
+
+		// The span of the annotation does not include the `@`.
+		fix = diagnostic.NewFix(
+			fmt.Sprintf("Replace with `%s`", *suggestion),
+			diagnostic.Edit{
+				Span:        annotation.Span(),
+				Replacement: strings.TrimPrefix(*suggestion, lexer.TYPE_ANNOTATION_TOKEN.String()),
+			},
+		)
 	}
 
-	self.error(
+	self.errorWithFix(
+		errors.UnknownFieldAnnotation,
 		fmt.Sprintf("Unknown type field annotation `%s`", annotation.Ident()),
 		notes,
 		annotation.Span(),
+		fix,
 	)
 }
 
@@ -83,8 +97,13 @@ func (self *Analyzer) ConvertType(oldType pAst.HmsType, createErrors bool) ast.T
 			}
 
 			self.error(
+				errors.UndefinedSingleton,
 				fmt.Sprintf("Illegal use of undeclared singleton type '%s'", singletonType.Ident.Ident()),
-				[]string{fmt.Sprintf("Consider declaring the type like this: `TODO: type %s = ...`", singletonType.Ident.Ident())},
+				withSuggestion(
+					[]string{fmt.Sprintf("Consider declaring the type like this: `TODO: type %s = ...`", singletonType.Ident.Ident())},
+					singletonType.Ident.Ident(),
+					mapKeys(self.currentModule.Singletons),
+				),
 				singletonType.Span(),
 			)
 
@@ -118,10 +137,13 @@ func (self *Analyzer) ConvertType(oldType pAst.HmsType, createErrors bool) ast.T
 					return ast.NewUnknownType()
 				}
 
-				self.error(
+				self.errorWithSuggestion(
+					errors.UndefinedType,
 					fmt.Sprintf("Illegal use of undeclared type '%s'", nameType.Ident.Ident()),
 					[]string{fmt.Sprintf("Consider declaring the type like this: `type %s = ...`", nameType.Ident.Ident())},
-					nameType.Span(),
+					nameType.Ident.Ident(),
+					nameType.Ident.Span(),
+					self.currentModule.typeNames(),
 				)
 
 				return ast.NewUnknownType()
@@ -149,6 +171,7 @@ func (self *Analyzer) ConvertType(oldType pAst.HmsType, createErrors bool) ast.T
 						}
 
 						self.error(
+							errors.DuplicateField,
 							fmt.Sprintf("Object type field '%s' is declared twice", field.FieldName),
 							nil,
 							field.FieldName.Span(),
@@ -191,6 +214,7 @@ func (self *Analyzer) ConvertType(oldType pAst.HmsType, createErrors bool) ast.T
 					}
 
 					self.error(
+						errors.DuplicateParameter,
 						fmt.Sprintf("Duplicate parameter name '%s' in type declaration", param.Name),
 						nil,
 						param.Name.Span(),
@@ -224,32 +248,16 @@ func (self *Analyzer) ConvertType(oldType pAst.HmsType, createErrors bool) ast.T
 // Type compatibility
 //
 
+// The diagnostic points at the value whose type is incompatible.
+// Its labels point at the types which caused the expectation.
 type CompatibilityError struct {
-	GotDiagnostic      diagnostic.Diagnostic
-	ExpectedDiagnostic *diagnostic.Diagnostic
-}
-
-func (c CompatibilityError) WithContext(context string) CompatibilityError {
-	return CompatibilityError{
-		GotDiagnostic: diagnostic.Diagnostic{
-			Level:   c.GotDiagnostic.Level,
-			Message: fmt.Sprintf("%s: %s", context, c.GotDiagnostic.Message),
-			Notes:   c.GotDiagnostic.Notes,
-			Span:    c.GotDiagnostic.Span,
-		},
-		ExpectedDiagnostic: &diagnostic.Diagnostic{
-			Level:   c.ExpectedDiagnostic.Level,
-			Message: fmt.Sprintf("%s: %s", context, c.ExpectedDiagnostic.Message),
-			Notes:   c.ExpectedDiagnostic.Notes,
-			Span:    c.ExpectedDiagnostic.Span,
-		},
-	}
+	Diagnostic diagnostic.Diagnostic
 }
 
-func newCompatibilityErr(lhs diagnostic.Diagnostic, rhs *diagnostic.Diagnostic) *CompatibilityError {
+func newCompatibilityErr(d diagnostic.Diagnostic, labels ...diagnostic.Label) *CompatibilityError {
+	d.Labels = labels
 	return &CompatibilityError{
-		GotDiagnostic:      lhs,
-		ExpectedDiagnostic: rhs,
+		Diagnostic: d,
 	}
 }
 
@@ -382,16 +390,12 @@ func (self *Analyzer) TypeCheck(got ast.Type, expected ast.Type, options TypeChe
 				return newCompatibilityErr(
 					diagnostic.Diagnostic{
 						Level:   diagnostic.DiagnosticLevelError,
+						Code:    errors.MissingField,
 						Message: fmt.Sprintf("Field '%s' is missing", expectedField.FieldName.Ident()),
 						Notes:   nil,
 						Span:    span,
 					},
-					&diagnostic.Diagnostic{
-						Level:   diagnostic.DiagnosticLevelHint,
-						Message: "Field expected due to this",
-						Notes:   nil,
-						Span:    expectedField.FieldName.Span(),
-					},
+					diagnostic.NewLabel(expectedField.FieldName.Span(), "Field expected due to this"),
 				)
 			}
 
@@ -417,16 +421,12 @@ func (self *Analyzer) TypeCheck(got ast.Type, expected ast.Type, options TypeChe
 				return newCompatibilityErr(
 					diagnostic.Diagnostic{
 						Level:   diagnostic.DiagnosticLevelError,
+						Code:    errors.UnexpectedField,
 						Message: fmt.Sprintf("Found unexpected field '%s'", gotField.FieldName.Ident()),
 						Notes:   nil,
 						Span:    gotField.FieldName.Span(),
 					},
-					&diagnostic.Diagnostic{
-						Level:   diagnostic.DiagnosticLevelHint,
-						Message: fmt.Sprintf("Field '%s' does not exist on this type", gotField.FieldName.Ident()),
-						Notes:   nil,
-						Span:    expected.Span(),
-					},
+					diagnostic.NewLabel(expected.Span(), fmt.Sprintf("Field '%s' does not exist on this type", gotField.FieldName.Ident())),
 				)
 			}
 		}
@@ -449,16 +449,12 @@ func (self *Analyzer) TypeCheck(got ast.Type, expected ast.Type, options TypeChe
 			return newCompatibilityErr(
 				diagnostic.Diagnostic{
 					Level:   diagnostic.DiagnosticLevelError,
+					Code:    errors.FunctionValueCast,
 					Message: "Cannot cast a function value at runtime",
-					Notes:   nil,
-					Span:    expected.Span(),
-				},
-				&diagnostic.Diagnostic{
-					Level:   diagnostic.DiagnosticLevelHint,
-					Message: "Possible function value found here",
 					Notes:   []string{"If this function is called later, cast its return value: `func() as type`"},
-					Span:    got.Span(),
+					Span:    expected.Span(),
 				},
+				diagnostic.NewLabel(got.Span(), "Possible function value found here"),
 			)
 		}
 
@@ -472,8 +468,7 @@ func (self *Analyzer) TypeCheck(got ast.Type, expected ast.Type, options TypeChe
 		// check return type
 		if err := self.TypeCheck(gotFn.ReturnType, expectedFn.ReturnType, options); err != nil {
 			// TODO: include better error message
-			err.GotDiagnostic.Message = fmt.Sprintf("Regarding function's return type: %s", err.GotDiagnostic.Message)
-			err.ExpectedDiagnostic.Message = fmt.Sprintf("Regarding function's return type: %s", err.ExpectedDiagnostic.Message)
+			err.Diagnostic = err.Diagnostic.WithContext("Regarding function's return type")
 			return err
 		}
 
@@ -481,11 +476,11 @@ func (self *Analyzer) TypeCheck(got ast.Type, expected ast.Type, options TypeChe
 			return newCompatibilityErr(
 				diagnostic.Diagnostic{
 					Level:   diagnostic.DiagnosticLevelError,
+					Code:    errors.ParameterKindMismatch,
 					Message: fmt.Sprintf("Expected parameter kind '%s', found '%s'", expectedFn.Params.Kind(), gotFn.Params.Kind()),
 					Notes:   []string{"There is a difference between a function which takes a fixed number of arguments and one which can take an arbitrary amount"},
 					Span:    gotFn.ParamsSpan,
 				},
-				nil,
 			)
 		}
 
@@ -509,16 +504,12 @@ func (self *Analyzer) TypeCheck(got ast.Type, expected ast.Type, options TypeChe
 				return newCompatibilityErr(
 					diagnostic.Diagnostic{
 						Level:   diagnostic.DiagnosticLevelError,
+						Code:    errors.ArgumentCountMismatch,
 						Message: fmt.Sprintf("Expected %d parameter%s (%s), got %d", len(expectedFnParams.Params), s, strings.Join(expectedList, ", "), len(gotFnParams.Params)),
 						Notes:   []string{},
 						Span:    gotFn.ParamsSpan,
 					},
-					&diagnostic.Diagnostic{
-						Level:   diagnostic.DiagnosticLevelHint,
-						Message: fmt.Sprintf("Amount of %d parameter%s expected due to this", len(expectedFnParams.Params), s),
-						Notes:   []string{},
-						Span:    expectedFn.ParamsSpan,
-					},
+					diagnostic.NewLabel(expectedFn.ParamsSpan, fmt.Sprintf("Amount of %d parameter%s expected due to this", len(expectedFnParams.Params), s)),
 				)
 			}
 
@@ -542,16 +533,12 @@ func (self *Analyzer) TypeCheck(got ast.Type, expected ast.Type, options TypeChe
 					return newCompatibilityErr(
 						diagnostic.Diagnostic{
 							Level:   diagnostic.DiagnosticLevelError,
+							Code:    errors.MissingParameter,
 							Message: fmt.Sprintf("Parameter '%s: %s' is missing", expectedParam.Name.Ident(), expectedParam.Type),
 							Notes:   nil,
 							Span:    gotFn.ParamsSpan,
 						},
-						&diagnostic.Diagnostic{
-							Level:   diagnostic.DiagnosticLevelHint,
-							Message: "Parameter expected due to this",
-							Notes:   nil,
-							Span:    expectedParam.Name.Span(),
-						},
+						diagnostic.NewLabel(expectedParam.Name.Span(), "Parameter expected due to this"),
 					)
 				}
 
@@ -582,16 +569,12 @@ func (self *Analyzer) checkTypeKindEquality(got ast.Type, expected ast.Type) (er
 		return newCompatibilityErr(
 			diagnostic.Diagnostic{
 				Level:   diagnostic.DiagnosticLevelError,
+				Code:    errors.MismatchedTypes,
 				Message: fmt.Sprintf("Mismatched types: expected '%s', got '%s'", expected.Kind(), got.Kind()),
 				Notes:   nil,
 				Span:    got.Span(),
 			},
-			&diagnostic.Diagnostic{
-				Level:   diagnostic.DiagnosticLevelHint,
-				Message: fmt.Sprintf("Type '%s' expected due to this", expected.Kind()),
-				Notes:   nil,
-				Span:    expected.Span(),
-			},
+			diagnostic.NewLabel(expected.Span(), fmt.Sprintf("Type '%s' expected due to this", expected.Kind())),
 		), false
 	}
 	return nil, true
@@ -0,0 +1,830 @@
+package compiler
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/smarthome-go/homescript/v3/homescript/analyzer/ast"
+	herrors "github.com/smarthome-go/homescript/v3/homescript/errors"
+	pAst "github.com/smarthome-go/homescript/v3/homescript/parser/ast"
+	"github.com/smarthome-go/homescript/v3/homescript/runtime/value"
+)
+
+//
+// Textual assembler.
+// Parses the (uncolored) listing which is produced by `CompileOutput.AsmString` back into a `CompileOutput`.
+// Values and types are printed in a lossless, single-line syntax so that this roundtrip is possible:
+// - values: `null`, `42`, `1.5`, `"str"`, `[1, 2]`, `{a: 1}`, `{ ? a: 1 }` (any-object), `none`, `some(1)`, `1..=5`, `fn "@mod_ident"`,
+// - types:  `int`, `[int]`, `?int`, `{a: int}`, `{ ? }` (any-object), `fn(a: int) -> str`, `fn(int, ...str) -> null`.
+// The listing contains no spans, therefore, each instruction is mapped to an empty span.
+// Only the function mappings of the entry module are preserved.
+//
+
+const (
+	asmFunctionHeader = "FUNCTION "
+	asmFunctionFooter = "END FUNCTION"
+)
+
+type AsmError struct {
+	Line    int
+	Message string
+}
+
+func (self AsmError) Error() string {
+	return fmt.Sprintf("Assembler error on line %d: %s", self.Line, self.Message)
+}
+
+// Parses a listing which was produced by `AsmString(false)`.
+func ParseAsm(listing string) (CompileOutput, error) {
+	output := CompileOutput{
+		Functions: make(map[string][]Instruction),
+		SourceMap: make(map[string][]herrors.Span),
+		Variables: make(map[string][]VariableSlot),
+		Mappings: MangleMappings{
+			Functions:  make(map[string]string),
+			Globals:    make(map[string]string),
+			Singletons: make(map[string]string),
+		},
+		Annotations: make(ModuleAnnotations),
+	}
+
+	currFn := ""
+	inFn := false
+
+	for idx, line := range strings.Split(listing, "\n") {
+		lineNum := idx + 1
+		fail := func(format string, args ...any) error {
+			return AsmError{Line: lineNum, Message: fmt.Sprintf(format, args...)}
+		}
+
+		switch {
+		case strings.TrimSpace(line) == "":
+			continue
+		case line == asmFunctionFooter:
+			if !inFn {
+				return CompileOutput{}, fail("`%s` outside of a function", asmFunctionFooter)
+			}
+			inFn = false
+		case strings.HasPrefix(line, asmFunctionHeader):
+			name, sourceIdent, err := parseAsmHeader(strings.TrimPrefix(line, asmFunctionHeader))
+			if err != nil {
+				return CompileOutput{}, fail("%s", err.Error())
+			}
+
+			if _, exists := output.Functions[name]; exists {
+				return CompileOutput{}, fail("duplicate function `%s`", name)
+			}
+
+			output.Functions[name] = make([]Instruction, 0)
+			output.SourceMap[name] = make([]herrors.Span, 0)
+
+			if sourceIdent != "" {
+				output.Mappings.Functions[sourceIdent] = name
+			}
+
+			currFn = name
+			inFn = true
+		default:
+			if !inFn {
+				return CompileOutput{}, fail("instruction outside of a function")
+			}
+
+			ipStr, instStr, found := strings.Cut(strings.TrimLeft(line, " \t"), " | ")
+			if !found {
+				return CompileOutput{}, fail("expected `<index> | <instruction>`")
+			}
+
+			ip, err := strconv.Atoi(ipStr)
+			if err != nil || ip != len(output.Functions[currFn]) {
+				return CompileOutput{}, fail("expected instruction index %d, found `%s`", len(output.Functions[currFn]), ipStr)
+			}
+
+			inst, err := parseAsmInstruction(instStr)
+			if err != nil {
+				return CompileOutput{}, fail("%s", err.Error())
+			}
+
+			output.Functions[currFn] = append(output.Functions[currFn], inst)
+			output.SourceMap[currFn] = append(output.SourceMap[currFn], herrors.Span{})
+		}
+	}
+
+	return output, nil
+}
+
+// Parses `<name> (<source ident>)`.
+func parseAsmHeader(header string) (name string, sourceIdent string, err error) {
+	open := strings.LastIndex(header, " (")
+	if open == -1 || !strings.HasSuffix(header, ")") {
+		return "", "", fmt.Errorf("expected `%s<name> (<source ident>)`", asmFunctionHeader)
+	}
+
+	return header[:open], header[open+2 : len(header)-1], nil
+}
+
+func parseAsmInstruction(inst string) (Instruction, error) {
+	name, args, hasArgs := strings.Cut(inst, "(")
+	if hasArgs {
+		if !strings.HasSuffix(args, ")") {
+			return nil, fmt.Errorf("expected `)` at the end of `%s`", inst)
+		}
+		args = args[:len(args)-1]
+	}
+
+	opcode, found := opcodesByName[name]
+	if !found {
+		return nil, fmt.Errorf("unknown opcode `%s`", name)
+	}
+
+	form, valid := opcodeForm(opcode)
+	if !valid {
+		return nil, fmt.Errorf("opcode `%s` must not occur in executable bytecode", name)
+	}
+
+	if hasArgs == (form == bytecodeFormPrimitive) {
+		if hasArgs {
+			return nil, fmt.Errorf("opcode `%s` takes no arguments", name)
+		}
+		return nil, fmt.Errorf("opcode `%s` requires arguments", name)
+	}
+
+	switch form {
+	case bytecodeFormPrimitive:
+		return newPrimitiveInstruction(opcode), nil
+	case bytecodeFormBool:
+		val, err := strconv.ParseBool(args)
+		if err != nil {
+			return nil, fmt.Errorf("expected boolean, found `%s`", args)
+		}
+		return newOneBoolInstruction(opcode, val), nil
+	case bytecodeFormInt:
+		val, err := strconv.ParseInt(args, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("expected integer, found `%s`", args)
+		}
+		return newOneIntInstruction(opcode, val), nil
+	case bytecodeFormString:
+		return newOneStringInstruction(opcode, args), nil
+	case bytecodeFormIntString:
+		sep := strings.LastIndex(args, ":")
+		if sep == -1 {
+			return nil, fmt.Errorf("expected `<string>:<integer>`, found `%s`", args)
+		}
+		val, err := strconv.ParseInt(args[sep+1:], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("expected integer, found `%s`", args[sep+1:])
+		}
+		return newOneIntOneStringInstruction(opcode, args[:sep], val), nil
+	case bytecodeFormTwoString:
+		first, second, found := strings.Cut(args, ", ")
+		if !found {
+			return nil, fmt.Errorf("expected `<string>, <string>`, found `%s`", args)
+		}
+		return newTwoStringInstruction(opcode, first, second), nil
+	case bytecodeFormCast:
+		const typePrefix, castSep = "as_type=", "; perform_cast="
+
+		sep := strings.LastIndex(args, castSep)
+		if !strings.HasPrefix(args, typePrefix) || sep == -1 {
+			return nil, fmt.Errorf("expected `%s<type>%s<boolean>`, found `%s`", typePrefix, castSep, args)
+		}
+
+		allowCast, err := strconv.ParseBool(args[sep+len(castSep):])
+		if err != nil {
+			return nil, fmt.Errorf("expected boolean, found `%s`", args[sep+len(castSep):])
+		}
+
+		scanner := asmScanner{input: args[len(typePrefix):sep], pos: 0}
+		typ, err := scanner.typ()
+		if err != nil {
+			return nil, err
+		}
+		if err := scanner.end(); err != nil {
+			return nil, err
+		}
+
+		return newCastInstruction(typ, allowCast), nil
+	case bytecodeFormValue:
+		scanner := asmScanner{input: args, pos: 0}
+		val, err := scanner.value()
+		if err != nil {
+			return nil, err
+		}
+		if err := scanner.end(); err != nil {
+			return nil, err
+		}
+
+		return newValueInstruction(opcode, *val), nil
+	default:
+		panic("A new instruction form was added without updating this code")
+	}
+}
+
+//
+// Printing of values and types.
+//
+
+func asmKey(key string) string {
+	if isAsmIdent(key) {
+		return key
+	}
+	return strconv.Quote(key)
+}
+
+func isAsmIdent(input string) bool {
+	if input == "" {
+		return false
+	}
+
+	for idx, char := range input {
+		isLetter := char == '_' || char >= 'a' && char <= 'z' || char >= 'A' && char <= 'Z'
+		isDigit := char >= '0' && char <= '9'
+		if !isLetter && (idx == 0 || !isDigit) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func asmValue(val value.Value) string {
+	switch val := val.(type) {
+	case value.ValueNull:
+		return "null"
+	case value.ValueInt:
+		return strconv.FormatInt(val.Inner, 10)
+	case value.ValueFloat:
+		str := strconv.FormatFloat(val.Inner, 'g', -1, 64)
+		// Floats must always be distinguishable from integers.
+		if !strings.ContainsAny(str, ".eIN") {
+			str += ".0"
+		}
+		return str
+	case value.ValueBool:
+		return strconv.FormatBool(val.Inner)
+	case value.ValueString:
+		return strconv.Quote(val.Inner)
+	case value.ValueVMFunction:
+		return fmt.Sprintf("fn %s", strconv.Quote(val.Ident))
+	case value.ValueList:
+		items := make([]string, 0)
+		if val.Values != nil {
+			for _, item := range *val.Values {
+				items = append(items, asmValue(*item))
+			}
+		}
+		return fmt.Sprintf("[%s]", strings.Join(items, ", "))
+	case value.ValueObject:
+		return fmt.Sprintf("{%s}", asmValueFields(val.FieldsInternal))
+	case value.ValueAnyObject:
+		if len(val.FieldsInternal) == 0 {
+			return "{ ? }"
+		}
+		return fmt.Sprintf("{ ? %s }", asmValueFields(val.FieldsInternal))
+	case value.ValueOption:
+		if val.Inner == nil {
+			return "none"
+		}
+		return fmt.Sprintf("some(%s)", asmValue(*val.Inner))
+	case value.ValueRange:
+		operator := ".."
+		if val.EndIsInclusive {
+			operator = "..="
+		}
+		return fmt.Sprintf("%s%s%s", asmValue(*val.Start), operator, asmValue(*val.End))
+	default:
+		// These values are never created by the compiler.
+		disp, i := val.Display()
+		if i != nil {
+			panic(*i)
+		}
+		return strings.ReplaceAll(disp, "\n", " ")
+	}
+}
+
+func asmValueFields(fields map[string]*value.Value) string {
+	// Map iteration is random, the output should be stable.
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	items := make([]string, len(keys))
+	for idx, key := range keys {
+		items[idx] = fmt.Sprintf("%s: %s", asmKey(key), asmValue(*fields[key]))
+	}
+
+	return strings.Join(items, ", ")
+}
+
+func asmType(typ ast.Type) string {
+	switch typ := typ.(type) {
+	case ast.ListType:
+		return fmt.Sprintf("[%s]", asmType(typ.Inner))
+	case ast.OptionType:
+		return fmt.Sprintf("?%s", asmType(typ.Inner))
+	case ast.AnyObjectType:
+		return "{ ? }"
+	case ast.ObjectType:
+		fields := make([]string, len(typ.ObjFields))
+		for idx, field := range typ.ObjFields {
+			annotation := ""
+			if field.Annotation != nil {
+				annotation = asmKey(field.Annotation.Ident()) + " "
+			}
+			fields[idx] = fmt.Sprintf("%s%s: %s", annotation, asmKey(field.FieldName.Ident()), asmType(field.Type))
+		}
+		return fmt.Sprintf("{%s}", strings.Join(fields, ", "))
+	case ast.FunctionType:
+		params := make([]string, 0)
+
+		switch kind := typ.Params.(type) {
+		case ast.NormalFunctionTypeParamKindIdentifier:
+			for _, param := range kind.Params {
+				extractor := ""
+				if param.IsSingletonExtractor {
+					extractor = fmt.Sprintf("@%s ", param.SingletonIdent)
+				}
+				params = append(params, fmt.Sprintf("%s%s: %s", extractor, param.Name.Ident(), asmType(param.Type)))
+			}
+		case ast.VarArgsFunctionTypeParamKindIdentifier:
+			for _, param := range kind.ParamTypes {
+				params = append(params, asmType(param))
+			}
+			params = append(params, "..."+asmType(kind.RemainingType))
+		default:
+			panic("A new function parameter type kind was introduced without updating this code")
+		}
+
+		return fmt.Sprintf("fn(%s) -> %s", strings.Join(params, ", "), asmType(typ.ReturnType))
+	default:
+		return typ.String()
+	}
+}
+
+//
+// Parsing of values and types.
+//
+
+type asmScanner struct {
+	input string
+	pos   int
+}
+
+func (self *asmScanner) skipSpaces() {
+	for self.pos < len(self.input) && self.input[self.pos] == ' ' {
+		self.pos++
+	}
+}
+
+// Consumes the given token if it is next.
+func (self *asmScanner) eat(token string) bool {
+	self.skipSpaces()
+	if strings.HasPrefix(self.input[self.pos:], token) {
+		self.pos += len(token)
+		return true
+	}
+	return false
+}
+
+func (self *asmScanner) expect(token string) error {
+	if !self.eat(token) {
+		return self.errorf("expected `%s`", token)
+	}
+	return nil
+}
+
+func (self *asmScanner) end() error {
+	self.skipSpaces()
+	if self.pos != len(self.input) {
+		return self.errorf("unexpected trailing input")
+	}
+	return nil
+}
+
+func (self *asmScanner) errorf(format string, args ...any) error {
+	return fmt.Errorf("%s at column %d of `%s`", fmt.Sprintf(format, args...), self.pos+1, self.input)
+}
+
+func (self *asmScanner) ident() (string, bool) {
+	self.skipSpaces()
+	start := self.pos
+	for self.pos < len(self.input) && isAsmIdent(self.input[start:self.pos+1]) {
+		self.pos++
+	}
+	return self.input[start:self.pos], self.pos > start
+}
+
+// Parses an identifier or a quoted string.
+func (self *asmScanner) key() (string, error) {
+	self.skipSpaces()
+	if self.pos < len(self.input) && self.input[self.pos] == '"' {
+		return self.quoted()
+	}
+
+	if ident, ok := self.ident(); ok {
+		return ident, nil
+	}
+
+	return "", self.errorf("expected identifier")
+}
+
+func (self *asmScanner) quoted() (string, error) {
+	self.skipSpaces()
+	prefix, err := strconv.QuotedPrefix(self.input[self.pos:])
+	if err != nil {
+		return "", self.errorf("expected string")
+	}
+	self.pos += len(prefix)
+
+	str, err := strconv.Unquote(prefix)
+	if err != nil {
+		return "", self.errorf("invalid string")
+	}
+	return str, nil
+}
+
+// Parses an integer or a float.
+func (self *asmScanner) number() (*value.Value, error) {
+	self.skipSpaces()
+
+	for _, special := range []struct {
+		token string
+		value float64
+	}{{"NaN", math.NaN()}, {"+Inf", math.Inf(1)}, {"-Inf", math.Inf(-1)}} {
+		if self.eat(special.token) {
+			return value.NewValueFloat(special.value), nil
+		}
+	}
+
+	start := self.pos
+	isFloat := false
+
+	digits := func() {
+		for self.pos < len(self.input) && self.input[self.pos] >= '0' && self.input[self.pos] <= '9' {
+			self.pos++
+		}
+	}
+
+	if self.pos < len(self.input) && self.input[self.pos] == '-' {
+		self.pos++
+	}
+	digits()
+
+	// A dot which is followed by another dot belongs to a range.
+	if self.pos+1 < len(self.input) && self.input[self.pos] == '.' && self.input[self.pos+1] != '.' {
+		isFloat = true
+		self.pos++
+		digits()
+	}
+
+	if self.pos < len(self.input) && (self.input[self.pos] == 'e' || self.input[self.pos] == 'E') {
+		isFloat = true
+		self.pos++
+		if self.pos < len(self.input) && (self.input[self.pos] == '+' || self.input[self.pos] == '-') {
+			self.pos++
+		}
+		digits()
+	}
+
+	raw := self.input[start:self.pos]
+
+	if isFloat {
+		float, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return nil, self.errorf("invalid float `%s`", raw)
+		}
+		return value.NewValueFloat(float), nil
+	}
+
+	integer, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		return nil, self.errorf("invalid integer `%s`", raw)
+	}
+	return value.NewValueInt(integer), nil
+}
+
+func (self *asmScanner) value() (*value.Value, error) {
+	self.skipSpaces()
+	if self.pos >= len(self.input) {
+		return nil, self.errorf("expected value")
+	}
+
+	switch self.input[self.pos] {
+	case '"':
+		str, err := self.quoted()
+		if err != nil {
+			return nil, err
+		}
+		return value.NewValueString(str), nil
+	case '[':
+		self.pos++
+		items := make([]*value.Value, 0)
+
+		if !self.eat("]") {
+			for {
+				item, err := self.value()
+				if err != nil {
+					return nil, err
+				}
+				items = append(items, item)
+
+				if self.eat("]") {
+					break
+				}
+				if err := self.expect(","); err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		return value.NewValueList(items), nil
+	case '{':
+		self.pos++
+		isAnyObject := self.eat("?")
+		fields := make(map[string]*value.Value)
+
+		if !self.eat("}") {
+			for {
+				key, err := self.key()
+				if err != nil {
+					return nil, err
+				}
+				if err := self.expect(":"); err != nil {
+					return nil, err
+				}
+				field, err := self.value()
+				if err != nil {
+					return nil, err
+				}
+				fields[key] = field
+
+				if self.eat("}") {
+					break
+				}
+				if err := self.expect(","); err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		if isAnyObject {
+			return value.NewValueAnyObject(fields), nil
+		}
+		return value.NewValueObject(fields), nil
+	}
+
+	start := self.pos
+	if ident, ok := self.ident(); ok {
+		switch ident {
+		case "null":
+			return value.NewValueNull(), nil
+		case "true", "false":
+			return value.NewValueBool(ident == "true"), nil
+		case "none":
+			return value.NewNoneOption(), nil
+		case "some":
+			if err := self.expect("("); err != nil {
+				return nil, err
+			}
+			inner, err := self.value()
+			if err != nil {
+				return nil, err
+			}
+			if err := self.expect(")"); err != nil {
+				return nil, err
+			}
+			return value.NewValueOption(inner), nil
+		case "fn":
+			ident, err := self.quoted()
+			if err != nil {
+				return nil, err
+			}
+			return value.NewValueVMFunction(ident), nil
+		}
+
+		// This could still be a special float, such as `NaN`.
+		self.pos = start
+	}
+
+	number, err := self.number()
+	if err != nil {
+		return nil, err
+	}
+
+	if !self.eat("..") {
+		return number, nil
+	}
+
+	isInclusive := self.eat("=")
+	end, err := self.number()
+	if err != nil {
+		return nil, err
+	}
+
+	if (*number).Kind() != value.IntValueKind || (*end).Kind() != value.IntValueKind {
+		return nil, self.errorf("range bounds must be integers")
+	}
+
+	return value.NewValueRange(*number, *end, isInclusive), nil
+}
+
+func (self *asmScanner) typ() (ast.Type, error) {
+	span := herrors.Span{}
+
+	switch {
+	case self.eat("["):
+		inner, err := self.typ()
+		if err != nil {
+			return nil, err
+		}
+		if err := self.expect("]"); err != nil {
+			return nil, err
+		}
+		return ast.NewListType(inner, span), nil
+	case self.eat("?"):
+		inner, err := self.typ()
+		if err != nil {
+			return nil, err
+		}
+		return ast.NewOptionType(inner, span), nil
+	case self.eat("{"):
+		if self.eat("?") {
+			if err := self.expect("}"); err != nil {
+				return nil, err
+			}
+			return ast.NewAnyObjectType(span), nil
+		}
+
+		fields := make([]ast.ObjectTypeField, 0)
+		if self.eat("}") {
+			return ast.NewObjectType(fields, span), nil
+		}
+
+		for {
+			key, err := self.key()
+			if err != nil {
+				return nil, err
+			}
+
+			// If the first identifier is not followed by a colon, it was the annotation.
+			var annotation *pAst.SpannedIdent
+			if !self.eat(":") {
+				ident := pAst.NewSpannedIdent(key, span)
+				annotation = &ident
+
+				if key, err = self.key(); err != nil {
+					return nil, err
+				}
+				if err := self.expect(":"); err != nil {
+					return nil, err
+				}
+			}
+
+			fieldType, err := self.typ()
+			if err != nil {
+				return nil, err
+			}
+
+			fields = append(fields, ast.NewObjectTypeFieldWithAnnotation(
+				annotation,
+				pAst.NewSpannedIdent(key, span),
+				fieldType,
+				span,
+			))
+
+			if self.eat("}") {
+				break
+			}
+			if err := self.expect(","); err != nil {
+				return nil, err
+			}
+		}
+
+		return ast.NewObjectType(fields, span), nil
+	}
+
+	ident, ok := self.ident()
+	if !ok {
+		return nil, self.errorf("expected type")
+	}
+
+	switch ident {
+	case "unknown":
+		return ast.NewUnknownType(), nil
+	case "never":
+		return ast.NewNeverType(), nil
+	case "any":
+		return ast.NewAnyType(span), nil
+	case "null":
+		return ast.NewNullType(span), nil
+	case "int":
+		return ast.NewIntType(span), nil
+	case "float":
+		return ast.NewFloatType(span), nil
+	case "bool":
+		return ast.NewBoolType(span), nil
+	case "str":
+		return ast.NewStringType(span), nil
+	case "range":
+		return ast.NewRangeType(span), nil
+	case "fn":
+		return self.functionType()
+	default:
+		return nil, self.errorf("unknown type `%s`", ident)
+	}
+}
+
+// Parses the remainder of a function type after the `fn` keyword.
+func (self *asmScanner) functionType() (ast.Type, error) {
+	span := herrors.Span{}
+
+	if err := self.expect("("); err != nil {
+		return nil, err
+	}
+
+	normalParams := make([]ast.FunctionTypeParam, 0)
+	varArgsTypes := make([]ast.Type, 0)
+	var remainingType ast.Type
+
+	if !self.eat(")") {
+		for {
+			start := self.pos
+
+			switch {
+			case self.eat("..."):
+				typ, err := self.typ()
+				if err != nil {
+					return nil, err
+				}
+				remainingType = typ
+			case self.eat("@"):
+				singletonIdent, ok := self.ident()
+				if !ok {
+					return nil, self.errorf("expected singleton identifier")
+				}
+				name, ok := self.ident()
+				if !ok {
+					return nil, self.errorf("expected parameter name")
+				}
+				if err := self.expect(":"); err != nil {
+					return nil, err
+				}
+				typ, err := self.typ()
+				if err != nil {
+					return nil, err
+				}
+				normalParams = append(normalParams, ast.NewFunctionTypeParam(pAst.NewSpannedIdent(name, span), typ, &singletonIdent))
+			default:
+				// Either a named parameter or the type of a variadic parameter.
+				if name, ok := self.ident(); ok && self.eat(":") {
+					typ, err := self.typ()
+					if err != nil {
+						return nil, err
+					}
+					normalParams = append(normalParams, ast.NewFunctionTypeParam(pAst.NewSpannedIdent(name, span), typ, nil))
+					break
+				}
+
+				self.pos = start
+				typ, err := self.typ()
+				if err != nil {
+					return nil, err
+				}
+				varArgsTypes = append(varArgsTypes, typ)
+			}
+
+			if self.eat(")") {
+				break
+			}
+			if err := self.expect(","); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	var params ast.FunctionTypeParamKind
+	switch {
+	case remainingType != nil && len(normalParams) == 0:
+		params = ast.NewVarArgsFunctionTypeParamKind(varArgsTypes, remainingType)
+	case remainingType == nil && len(varArgsTypes) == 0:
+		params = ast.NewNormalFunctionTypeParamKind(normalParams)
+	default:
+		return nil, self.errorf("function parameters must either all be named or end with a variadic type")
+	}
+
+	if err := self.expect("->"); err != nil {
+		return nil, err
+	}
+
+	returnType, err := self.typ()
+	if err != nil {
+		return nil, err
+	}
+
+	return ast.NewFunctionType(params, span, returnType, span), nil
+}
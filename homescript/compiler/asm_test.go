@@ -0,0 +1,52 @@
+package compiler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAsmRoundtrip(t *testing.T) {
+	listing := `FUNCTION @main_add (add)
+	0000 | AddMempointer(2)
+	0001 | SetVarImm(0)
+	0002 | SetVarImm(1)
+	0003 | GetVarImm(0)
+	0004 | GetVarImm(1)
+	0005 | Add
+	0006 | AddMempointer(-2)
+	0007 | Return
+END FUNCTION
+FUNCTION main (main)
+	0000 | CopyPush({ ? })
+	0001 | Drop
+	0002 | CopyPush({"a b": [1, 2.0, -0.5, 1e+21, NaN], c: some(none), d: { ? e: "x\n\"y\"" }})
+	0003 | Cast(as_type={a: [int], ann b: ?{ ? }, c: fn(x: str, @Device d: float) -> null}; perform_cast=false)
+	0004 | Drop
+	0005 | CopyPush(-1..=5)
+	0006 | Cast(as_type=fn(int, ...any) -> range; perform_cast=true)
+	0007 | Drop
+	0008 | CopyPush(fn "@main_add")
+	0009 | Drop
+	0010 | CopyPush(1)
+	0011 | CopyPush(2)
+	0012 | Call_Imm(@main_add)
+	0013 | Drop
+	0014 | Return
+`
+
+	program, err := ParseAsm(listing)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, listing, program.AsmString(false))
+	assert.Equal(t, "@main_add", program.Mappings.Functions["add"])
+	assert.NoError(t, Verify(program, nil))
+
+	_, err = ParseAsm("FUNCTION main (main)\n\t0001 | Return\n")
+	assert.ErrorContains(t, err, "Assembler error on line 2: expected instruction index 0, found `0001`")
+
+	_, err = ParseAsm("FUNCTION main (main)\n\t0000 | Foo(1)\n")
+	assert.ErrorContains(t, err, "unknown opcode `Foo`")
+}
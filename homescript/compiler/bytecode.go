@@ -0,0 +1,319 @@
+package compiler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+
+	herrors "github.com/smarthome-go/homescript/v3/homescript/errors"
+	pAst "github.com/smarthome-go/homescript/v3/homescript/parser/ast"
+	"github.com/smarthome-go/homescript/v3/homescript/runtime/value"
+)
+
+//
+// Serializable bytecode format.
+// A `CompileOutput` is encoded as stable JSON so that it can be cached on disk and loaded without recompilation.
+// The version must be incremented each time the encoding or the semantics of an instruction changes,
+// or if the compiler names functions and variables differently, as hosts look them up by name.
+//
+
+const BytecodeVersion uint32 = 3
+
+var (
+	ErrBytecodeVersionMismatch = errors.New("bytecode was produced by an incompatible compiler version")
+	ErrBytecodeSourceMismatch  = errors.New("bytecode was compiled from different source code")
+)
+
+type BytecodeHeader struct {
+	Version uint32 `json:"version"`
+	// Hash of all source modules, see `HashSources`.
+	SourceHash string `json:"sourceHash"`
+}
+
+type bytecodeFile struct {
+	Header      BytecodeHeader                `json:"header"`
+	Functions   map[string][]bytecodeInstr    `json:"functions"`
+	SourceMap   map[string][]herrors.Span     `json:"sourceMap"`
+	Variables   map[string][]VariableSlot     `json:"variables"`
+	Mappings    bytecodeMappings              `json:"mappings"`
+	Annotations []bytecodeFunctionAnnotations `json:"annotations"`
+}
+
+type bytecodeMappings struct {
+	Functions  map[string]string `json:"functions"`
+	Globals    map[string]string `json:"globals"`
+	Singletons map[string]string `json:"singletons"`
+}
+
+type bytecodeFunctionAnnotations struct {
+	Module   string               `json:"module"`
+	Function string               `json:"function"`
+	Items    []bytecodeAnnotation `json:"items"`
+}
+
+type bytecodeAnnotation struct {
+	Kind string `json:"kind"`
+	// Ident annotation.
+	Ident string `json:"ident,omitempty"`
+	// Trigger annotation.
+	CallbackFnIdent       string `json:"callbackFn,omitempty"`
+	TriggerConnective     uint8  `json:"connective,omitempty"`
+	TriggerSource         string `json:"source,omitempty"`
+	ArgumentFunctionIdent string `json:"argumentFn,omitempty"`
+}
+
+const (
+	bytecodeAnnotationIdent   = "ident"
+	bytecodeAnnotationTrigger = "trigger"
+	// The compiler does not yet support every annotation item, these are stored as `nil`.
+	bytecodeAnnotationNone = "none"
+)
+
+// Computes a hash over the source code of all modules (module name => code).
+// The hash does not depend on the iteration order of the map.
+func HashSources(sources map[string]string) string {
+	names := make([]string, 0, len(sources))
+	for name := range sources {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	hash := sha256.New()
+	for _, name := range names {
+		// The lengths are included so that the boundaries between modules are unambiguous.
+		fmt.Fprintf(hash, "%d:%s%d:", len(name), name, len(sources[name]))
+		hash.Write([]byte(sources[name]))
+	}
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// Encodes the compiler output together with a header which contains the format version and the given source hash.
+func EncodeBytecode(output CompileOutput, sourceHash string) ([]byte, error) {
+	file := bytecodeFile{
+		Header: BytecodeHeader{
+			Version:    BytecodeVersion,
+			SourceHash: sourceHash,
+		},
+		Functions: make(map[string][]bytecodeInstr),
+		SourceMap: output.SourceMap,
+		Variables: output.Variables,
+		Mappings: bytecodeMappings{
+			Functions:  output.Mappings.Functions,
+			Globals:    output.Mappings.Globals,
+			Singletons: output.Mappings.Singletons,
+		},
+		Annotations: make([]bytecodeFunctionAnnotations, 0),
+	}
+
+	for name, instructions := range output.Functions {
+		encoded := make([]bytecodeInstr, len(instructions))
+
+		for idx, inst := range instructions {
+			encodedInst, err := encodeInstruction(inst)
+			if err != nil {
+				return nil, fmt.Errorf("function `%s`, instruction %d: %s", name, idx, err.Error())
+			}
+			encoded[idx] = encodedInst
+		}
+
+		file.Functions[name] = encoded
+	}
+
+	for fn, annotations := range output.Annotations {
+		items := make([]bytecodeAnnotation, len(annotations.Items))
+
+		for idx, item := range annotations.Items {
+			items[idx] = encodeAnnotation(item)
+		}
+
+		file.Annotations = append(file.Annotations, bytecodeFunctionAnnotations{
+			Module:   fn.Module,
+			Function: fn.UnmangledFunction,
+			Items:    items,
+		})
+	}
+
+	// Map iteration is random, the output should be stable.
+	slices.SortFunc(file.Annotations, func(a, b bytecodeFunctionAnnotations) int {
+		if a.Module != b.Module {
+			if a.Module < b.Module {
+				return -1
+			}
+			return 1
+		}
+
+		switch {
+		case a.Function < b.Function:
+			return -1
+		case a.Function > b.Function:
+			return 1
+		default:
+			return 0
+		}
+	})
+
+	return json.Marshal(file)
+}
+
+// Decodes bytecode which was produced by `EncodeBytecode`.
+// If the bytecode was produced by another format version, `ErrBytecodeVersionMismatch` is returned.
+func DecodeBytecode(data []byte) (BytecodeHeader, CompileOutput, error) {
+	// Decode the header first: the remaining format may be different in other versions.
+	var headerOnly struct {
+		Header BytecodeHeader `json:"header"`
+	}
+	if err := json.Unmarshal(data, &headerOnly); err != nil {
+		return BytecodeHeader{}, CompileOutput{}, fmt.Errorf("invalid bytecode header: %s", err.Error())
+	}
+
+	if headerOnly.Header.Version != BytecodeVersion {
+		return headerOnly.Header, CompileOutput{}, fmt.Errorf(
+			"%w: expected version %d, found %d",
+			ErrBytecodeVersionMismatch,
+			BytecodeVersion,
+			headerOnly.Header.Version,
+		)
+	}
+
+	var file bytecodeFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return file.Header, CompileOutput{}, fmt.Errorf("invalid bytecode: %s", err.Error())
+	}
+
+	output := CompileOutput{
+		Functions: make(map[string][]Instruction),
+		SourceMap: file.SourceMap,
+		Variables: file.Variables,
+		Mappings: MangleMappings{
+			Functions:  file.Mappings.Functions,
+			Globals:    file.Mappings.Globals,
+			Singletons: file.Mappings.Singletons,
+		},
+		Annotations: make(ModuleAnnotations),
+	}
+
+	if output.SourceMap == nil {
+		output.SourceMap = make(map[string][]herrors.Span)
+	}
+
+	if output.Variables == nil {
+		output.Variables = make(map[string][]VariableSlot)
+	}
+
+	for name, encoded := range file.Functions {
+		instructions := make([]Instruction, len(encoded))
+
+		for idx, encodedInst := range encoded {
+			inst, err := decodeInstruction(encodedInst)
+			if err != nil {
+				return file.Header, CompileOutput{}, fmt.Errorf("function `%s`, instruction %d: %s", name, idx, err.Error())
+			}
+			instructions[idx] = inst
+		}
+
+		output.Functions[name] = instructions
+	}
+
+	for _, fn := range file.Annotations {
+		items := make([]CompiledAnnotation, len(fn.Items))
+
+		for idx, item := range fn.Items {
+			decoded, err := decodeAnnotation(item)
+			if err != nil {
+				return file.Header, CompileOutput{}, fmt.Errorf("annotations of `%s` in module `%s`: %s", fn.Function, fn.Module, err.Error())
+			}
+			items[idx] = decoded
+		}
+
+		output.Annotations[ModuleFunction{
+			Module:            fn.Module,
+			UnmangledFunction: fn.Function,
+		}] = CompiledAnnotations{Items: items}
+	}
+
+	return file.Header, output, nil
+}
+
+// Decodes cached bytecode and validates that it was compiled from the given sources.
+// If the cache is outdated, an error wrapping `ErrBytecodeVersionMismatch` or `ErrBytecodeSourceMismatch` is returned.
+// In this case, the sources should be recompiled.
+func DecodeCachedBytecode(data []byte, sourceHash string) (CompileOutput, error) {
+	header, output, err := DecodeBytecode(data)
+	if err != nil {
+		return CompileOutput{}, err
+	}
+
+	if header.SourceHash != sourceHash {
+		return CompileOutput{}, ErrBytecodeSourceMismatch
+	}
+
+	return output, nil
+}
+
+// Encodes a value in the same format as the immediate values of instructions.
+// This is used to persist values outside of bytecode, for instance in traces of VM runs.
+// Only data values can be encoded, functions and iterators cannot.
+func EncodeValue(val value.Value) (json.RawMessage, error) {
+	encoded, err := encodeValue(val)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(encoded)
+}
+
+// Decodes a value which was produced by `EncodeValue`.
+func DecodeValue(data json.RawMessage) (*value.Value, error) {
+	var encoded bytecodeValue
+	if err := json.Unmarshal(data, &encoded); err != nil {
+		return nil, err
+	}
+
+	return decodeValue(encoded)
+}
+
+func encodeAnnotation(item CompiledAnnotation) bytecodeAnnotation {
+	if item == nil {
+		return bytecodeAnnotation{Kind: bytecodeAnnotationNone}
+	}
+
+	switch item := item.(type) {
+	case IdentCompiledAnnotation:
+		return bytecodeAnnotation{
+			Kind:  bytecodeAnnotationIdent,
+			Ident: item.Ident,
+		}
+	case TriggerCompiledAnnotation:
+		return bytecodeAnnotation{
+			Kind:                  bytecodeAnnotationTrigger,
+			CallbackFnIdent:       item.CallbackFnIdent,
+			TriggerConnective:     uint8(item.TriggerConnective),
+			TriggerSource:         item.TriggerSource,
+			ArgumentFunctionIdent: item.ArgumentFunctionIdent,
+		}
+	default:
+		panic("A new annotation kind was added without updating this code")
+	}
+}
+
+func decodeAnnotation(item bytecodeAnnotation) (CompiledAnnotation, error) {
+	switch item.Kind {
+	case bytecodeAnnotationNone:
+		return nil, nil
+	case bytecodeAnnotationIdent:
+		return IdentCompiledAnnotation{Ident: item.Ident}, nil
+	case bytecodeAnnotationTrigger:
+		return TriggerCompiledAnnotation{
+			CallbackFnIdent:       item.CallbackFnIdent,
+			TriggerConnective:     pAst.TriggerDispatchKeywordKind(item.TriggerConnective),
+			TriggerSource:         item.TriggerSource,
+			ArgumentFunctionIdent: item.ArgumentFunctionIdent,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown annotation kind `%s`", item.Kind)
+	}
+}
@@ -0,0 +1,541 @@
+package compiler
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/smarthome-go/homescript/v3/homescript/analyzer/ast"
+	herrors "github.com/smarthome-go/homescript/v3/homescript/errors"
+	pAst "github.com/smarthome-go/homescript/v3/homescript/parser/ast"
+	"github.com/smarthome-go/homescript/v3/homescript/runtime/value"
+)
+
+//
+// Encoding of instructions, values and types.
+// Opcodes and kinds are stored by name so that reordering the enums does not invalidate existing bytecode.
+// Spans inside of types are not preserved as they are only required during analysis.
+//
+
+type bytecodeInstrForm string
+
+const (
+	bytecodeFormPrimitive bytecodeInstrForm = "primitive"
+	bytecodeFormBool      bytecodeInstrForm = "bool"
+	bytecodeFormIntString bytecodeInstrForm = "int_string"
+	bytecodeFormInt       bytecodeInstrForm = "int"
+	bytecodeFormString    bytecodeInstrForm = "string"
+	bytecodeFormTwoString bytecodeInstrForm = "two_string"
+	bytecodeFormCast      bytecodeInstrForm = "cast"
+	bytecodeFormValue     bytecodeInstrForm = "value"
+)
+
+type bytecodeInstr struct {
+	Form    bytecodeInstrForm `json:"form"`
+	Opcode  string            `json:"op"`
+	Int     int64             `json:"int,omitempty"`
+	Strings []string          `json:"strings,omitempty"`
+	Bool    bool              `json:"bool,omitempty"`
+	Type    *bytecodeType     `json:"type,omitempty"`
+	Value   *bytecodeValue    `json:"value,omitempty"`
+}
+
+type bytecodeValue struct {
+	Kind string `json:"kind"`
+	// Int, bool, string & function values.
+	Int    int64  `json:"int,omitempty"`
+	Bool   bool   `json:"bool,omitempty"`
+	String string `json:"string,omitempty"`
+	// Floats are encoded as strings so that `NaN` and infinity survive the roundtrip.
+	Float string `json:"float,omitempty"`
+	// Objects.
+	Fields map[string]bytecodeValue `json:"fields,omitempty"`
+	// Lists & ranges (start, end).
+	Items []bytecodeValue `json:"items,omitempty"`
+	// Options: `nil` means none.
+	Inner *bytecodeValue `json:"inner,omitempty"`
+}
+
+type bytecodeType struct {
+	Kind  string        `json:"kind"`
+	Inner *bytecodeType `json:"inner,omitempty"`
+	// Objects.
+	Fields []bytecodeObjectTypeField `json:"fields,omitempty"`
+	// Functions.
+	Params        []bytecodeFunctionTypeParam `json:"params,omitempty"`
+	IsVarArgs     bool                        `json:"varArgs,omitempty"`
+	ParamTypes    []bytecodeType              `json:"paramTypes,omitempty"`
+	RemainingType *bytecodeType               `json:"remainingType,omitempty"`
+	ReturnType    *bytecodeType               `json:"returnType,omitempty"`
+}
+
+type bytecodeObjectTypeField struct {
+	Name       string       `json:"name"`
+	Annotation *string      `json:"annotation,omitempty"`
+	Type       bytecodeType `json:"type"`
+}
+
+type bytecodeFunctionTypeParam struct {
+	Name           string       `json:"name"`
+	Type           bytecodeType `json:"type"`
+	SingletonIdent *string      `json:"singletonIdent,omitempty"`
+}
+
+var opcodesByName = func() map[string]Opcode {
+	opcodes := make(map[string]Opcode)
+	for op := Opcode_Nop; op <= Opcode_IntoIter; op++ {
+		opcodes[op.String()] = op
+	}
+	return opcodes
+}()
+
+//
+// Instructions.
+//
+
+func encodeInstruction(inst Instruction) (bytecodeInstr, error) {
+	out := bytecodeInstr{Opcode: inst.Opcode().String()}
+
+	switch inst := inst.(type) {
+	case PrimitiveInstruction:
+		out.Form = bytecodeFormPrimitive
+	case OneBoolInstruction:
+		out.Form = bytecodeFormBool
+		out.Bool = inst.ValueBool
+	case OneIntOneStringInstruction:
+		out.Form = bytecodeFormIntString
+		out.Int = inst.ValueInt
+		out.Strings = []string{inst.ValueString}
+	case OneIntInstruction:
+		out.Form = bytecodeFormInt
+		out.Int = inst.Value
+	case OneStringInstruction:
+		out.Form = bytecodeFormString
+		out.Strings = []string{inst.Value}
+	case TwoStringInstruction:
+		out.Form = bytecodeFormTwoString
+		out.Strings = inst.Values[:]
+	case CastInstruction:
+		out.Form = bytecodeFormCast
+		out.Bool = inst.AllowCast
+		typ := encodeType(inst.Type)
+		out.Type = &typ
+	case ValueInstruction:
+		out.Form = bytecodeFormValue
+		val, err := encodeValue(inst.Value)
+		if err != nil {
+			return bytecodeInstr{}, err
+		}
+		out.Value = &val
+	default:
+		panic("A new instruction type was added without updating this code")
+	}
+
+	return out, nil
+}
+
+func decodeInstruction(inst bytecodeInstr) (Instruction, error) {
+	opcode, found := opcodesByName[inst.Opcode]
+	if !found {
+		return nil, fmt.Errorf("unknown opcode `%s`", inst.Opcode)
+	}
+
+	expectStrings := func(count int) error {
+		if len(inst.Strings) != count {
+			return fmt.Errorf("`%s` expects %d string argument(s), found %d", inst.Opcode, count, len(inst.Strings))
+		}
+		return nil
+	}
+
+	switch inst.Form {
+	case bytecodeFormPrimitive:
+		return newPrimitiveInstruction(opcode), nil
+	case bytecodeFormBool:
+		return newOneBoolInstruction(opcode, inst.Bool), nil
+	case bytecodeFormIntString:
+		if err := expectStrings(1); err != nil {
+			return nil, err
+		}
+		return newOneIntOneStringInstruction(opcode, inst.Strings[0], inst.Int), nil
+	case bytecodeFormInt:
+		return newOneIntInstruction(opcode, inst.Int), nil
+	case bytecodeFormString:
+		if err := expectStrings(1); err != nil {
+			return nil, err
+		}
+		return newOneStringInstruction(opcode, inst.Strings[0]), nil
+	case bytecodeFormTwoString:
+		if err := expectStrings(2); err != nil {
+			return nil, err
+		}
+		return newTwoStringInstruction(opcode, inst.Strings[0], inst.Strings[1]), nil
+	case bytecodeFormCast:
+		if inst.Type == nil {
+			return nil, fmt.Errorf("`%s` is missing its type", inst.Opcode)
+		}
+		typ, err := decodeType(*inst.Type)
+		if err != nil {
+			return nil, err
+		}
+		return newCastInstruction(typ, inst.Bool), nil
+	case bytecodeFormValue:
+		if inst.Value == nil {
+			return nil, fmt.Errorf("`%s` is missing its value", inst.Opcode)
+		}
+		val, err := decodeValue(*inst.Value)
+		if err != nil {
+			return nil, err
+		}
+		return newValueInstruction(opcode, *val), nil
+	default:
+		return nil, fmt.Errorf("unknown instruction form `%s`", inst.Form)
+	}
+}
+
+//
+// Values.
+// Only values which can be created by the compiler are supported.
+//
+
+func encodeValue(val value.Value) (bytecodeValue, error) {
+	out := bytecodeValue{Kind: val.Kind().String()}
+
+	switch val := val.(type) {
+	case value.ValueNull:
+	case value.ValueInt:
+		out.Int = val.Inner
+	case value.ValueFloat:
+		out.Float = strconv.FormatFloat(val.Inner, 'g', -1, 64)
+	case value.ValueBool:
+		out.Bool = val.Inner
+	case value.ValueString:
+		out.String = val.Inner
+	case value.ValueVMFunction:
+		out.String = val.Ident
+	case value.ValueObject:
+		fields, err := encodeValueFields(val.FieldsInternal)
+		if err != nil {
+			return bytecodeValue{}, err
+		}
+		out.Fields = fields
+	case value.ValueAnyObject:
+		fields, err := encodeValueFields(val.FieldsInternal)
+		if err != nil {
+			return bytecodeValue{}, err
+		}
+		out.Fields = fields
+	case value.ValueOption:
+		if val.Inner != nil {
+			inner, err := encodeValue(*val.Inner)
+			if err != nil {
+				return bytecodeValue{}, err
+			}
+			out.Inner = &inner
+		}
+	case value.ValueList:
+		out.Items = make([]bytecodeValue, 0)
+		if val.Values != nil {
+			for _, item := range *val.Values {
+				encoded, err := encodeValue(*item)
+				if err != nil {
+					return bytecodeValue{}, err
+				}
+				out.Items = append(out.Items, encoded)
+			}
+		}
+	case value.ValueRange:
+		start, err := encodeValue(*val.Start)
+		if err != nil {
+			return bytecodeValue{}, err
+		}
+		end, err := encodeValue(*val.End)
+		if err != nil {
+			return bytecodeValue{}, err
+		}
+		out.Items = []bytecodeValue{start, end}
+		out.Bool = val.EndIsInclusive
+	default:
+		return bytecodeValue{}, fmt.Errorf("value of kind `%s` cannot be encoded", val.Kind())
+	}
+
+	return out, nil
+}
+
+func encodeValueFields(fields map[string]*value.Value) (map[string]bytecodeValue, error) {
+	out := make(map[string]bytecodeValue)
+
+	for key, field := range fields {
+		encoded, err := encodeValue(*field)
+		if err != nil {
+			return nil, err
+		}
+		out[key] = encoded
+	}
+
+	return out, nil
+}
+
+func decodeValue(val bytecodeValue) (*value.Value, error) {
+	switch val.Kind {
+	case value.NullValueKind.String():
+		return value.NewValueNull(), nil
+	case value.IntValueKind.String():
+		return value.NewValueInt(val.Int), nil
+	case value.FloatValueKind.String():
+		float, err := strconv.ParseFloat(val.Float, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid float value `%s`", val.Float)
+		}
+		return value.NewValueFloat(float), nil
+	case value.BoolValueKind.String():
+		return value.NewValueBool(val.Bool), nil
+	case value.StringValueKind.String():
+		return value.NewValueString(val.String), nil
+	case value.VmFunctionValueKind.String():
+		return value.NewValueVMFunction(val.String), nil
+	case value.ObjectValueKind.String():
+		fields, err := decodeValueFields(val.Fields)
+		if err != nil {
+			return nil, err
+		}
+		return value.NewValueObject(fields), nil
+	case value.AnyObjectValueKind.String():
+		fields, err := decodeValueFields(val.Fields)
+		if err != nil {
+			return nil, err
+		}
+		return value.NewValueAnyObject(fields), nil
+	case value.OptionValueKind.String():
+		if val.Inner == nil {
+			return value.NewNoneOption(), nil
+		}
+		inner, err := decodeValue(*val.Inner)
+		if err != nil {
+			return nil, err
+		}
+		return value.NewValueOption(inner), nil
+	case value.ListValueKind.String():
+		items := make([]*value.Value, len(val.Items))
+		for idx, item := range val.Items {
+			decoded, err := decodeValue(item)
+			if err != nil {
+				return nil, err
+			}
+			items[idx] = decoded
+		}
+		return value.NewValueList(items), nil
+	case value.RangeValueKind.String():
+		if len(val.Items) != 2 {
+			return nil, fmt.Errorf("range value expects 2 items, found %d", len(val.Items))
+		}
+		start, err := decodeValue(val.Items[0])
+		if err != nil {
+			return nil, err
+		}
+		end, err := decodeValue(val.Items[1])
+		if err != nil {
+			return nil, err
+		}
+		if (*start).Kind() != value.IntValueKind || (*end).Kind() != value.IntValueKind {
+			return nil, fmt.Errorf("range bounds must be of kind `%s`", value.IntValueKind)
+		}
+		return value.NewValueRange(*start, *end, val.Bool), nil
+	default:
+		return nil, fmt.Errorf("value of kind `%s` cannot be decoded", val.Kind)
+	}
+}
+
+func decodeValueFields(fields map[string]bytecodeValue) (map[string]*value.Value, error) {
+	out := make(map[string]*value.Value)
+
+	for key, field := range fields {
+		decoded, err := decodeValue(field)
+		if err != nil {
+			return nil, err
+		}
+		out[key] = decoded
+	}
+
+	return out, nil
+}
+
+//
+// Types.
+//
+
+func encodeType(typ ast.Type) bytecodeType {
+	out := bytecodeType{Kind: typ.Kind().String()}
+
+	switch typ := typ.(type) {
+	case ast.UnknownType, ast.NeverType, ast.AnyType, ast.NullType, ast.IntType,
+		ast.FloatType, ast.BoolType, ast.StringType, ast.RangeType, ast.AnyObjectType:
+	case ast.ListType:
+		inner := encodeType(typ.Inner)
+		out.Inner = &inner
+	case ast.OptionType:
+		inner := encodeType(typ.Inner)
+		out.Inner = &inner
+	case ast.ObjectType:
+		out.Fields = make([]bytecodeObjectTypeField, len(typ.ObjFields))
+		for idx, field := range typ.ObjFields {
+			var annotation *string
+			if field.Annotation != nil {
+				ident := field.Annotation.Ident()
+				annotation = &ident
+			}
+
+			out.Fields[idx] = bytecodeObjectTypeField{
+				Name:       field.FieldName.Ident(),
+				Annotation: annotation,
+				Type:       encodeType(field.Type),
+			}
+		}
+	case ast.FunctionType:
+		switch params := typ.Params.(type) {
+		case ast.NormalFunctionTypeParamKindIdentifier:
+			out.Params = make([]bytecodeFunctionTypeParam, len(params.Params))
+			for idx, param := range params.Params {
+				var singletonIdent *string
+				if param.IsSingletonExtractor {
+					ident := param.SingletonIdent
+					singletonIdent = &ident
+				}
+
+				out.Params[idx] = bytecodeFunctionTypeParam{
+					Name:           param.Name.Ident(),
+					Type:           encodeType(param.Type),
+					SingletonIdent: singletonIdent,
+				}
+			}
+		case ast.VarArgsFunctionTypeParamKindIdentifier:
+			out.IsVarArgs = true
+			out.ParamTypes = make([]bytecodeType, len(params.ParamTypes))
+			for idx, param := range params.ParamTypes {
+				out.ParamTypes[idx] = encodeType(param)
+			}
+			remaining := encodeType(params.RemainingType)
+			out.RemainingType = &remaining
+		default:
+			panic("A new function parameter type kind was introduced without updating this code")
+		}
+
+		returnType := encodeType(typ.ReturnType)
+		out.ReturnType = &returnType
+	default:
+		panic("A new type was introduced without updating this code")
+	}
+
+	return out
+}
+
+func decodeType(typ bytecodeType) (ast.Type, error) {
+	span := herrors.Span{}
+
+	decodeInner := func(inner *bytecodeType) (ast.Type, error) {
+		if inner == nil {
+			return nil, fmt.Errorf("type `%s` is missing its inner type", typ.Kind)
+		}
+		return decodeType(*inner)
+	}
+
+	switch typ.Kind {
+	case ast.TypeKind(ast.UnknownTypeKind).String():
+		return ast.NewUnknownType(), nil
+	case ast.TypeKind(ast.NeverTypeKind).String():
+		return ast.NewNeverType(), nil
+	case ast.TypeKind(ast.AnyTypeKind).String():
+		return ast.NewAnyType(span), nil
+	case ast.TypeKind(ast.NullTypeKind).String():
+		return ast.NewNullType(span), nil
+	case ast.TypeKind(ast.IntTypeKind).String():
+		return ast.NewIntType(span), nil
+	case ast.TypeKind(ast.FloatTypeKind).String():
+		return ast.NewFloatType(span), nil
+	case ast.TypeKind(ast.BoolTypeKind).String():
+		return ast.NewBoolType(span), nil
+	case ast.TypeKind(ast.StringTypeKind).String():
+		return ast.NewStringType(span), nil
+	case ast.TypeKind(ast.RangeTypeKind).String():
+		return ast.NewRangeType(span), nil
+	case ast.TypeKind(ast.AnyObjectTypeKind).String():
+		return ast.NewAnyObjectType(span), nil
+	case ast.TypeKind(ast.ListTypeKind).String():
+		inner, err := decodeInner(typ.Inner)
+		if err != nil {
+			return nil, err
+		}
+		return ast.NewListType(inner, span), nil
+	case ast.TypeKind(ast.OptionTypeKind).String():
+		inner, err := decodeInner(typ.Inner)
+		if err != nil {
+			return nil, err
+		}
+		return ast.NewOptionType(inner, span), nil
+	case ast.TypeKind(ast.ObjectTypeKind).String():
+		fields := make([]ast.ObjectTypeField, len(typ.Fields))
+		for idx, field := range typ.Fields {
+			fieldType, err := decodeType(field.Type)
+			if err != nil {
+				return nil, err
+			}
+
+			var annotation *pAst.SpannedIdent
+			if field.Annotation != nil {
+				ident := pAst.NewSpannedIdent(*field.Annotation, span)
+				annotation = &ident
+			}
+
+			fields[idx] = ast.NewObjectTypeFieldWithAnnotation(
+				annotation,
+				pAst.NewSpannedIdent(field.Name, span),
+				fieldType,
+				span,
+			)
+		}
+		return ast.NewObjectType(fields, span), nil
+	case ast.TypeKind(ast.FnTypeKind).String():
+		var params ast.FunctionTypeParamKind
+
+		if typ.IsVarArgs {
+			paramTypes := make([]ast.Type, len(typ.ParamTypes))
+			for idx, param := range typ.ParamTypes {
+				paramType, err := decodeType(param)
+				if err != nil {
+					return nil, err
+				}
+				paramTypes[idx] = paramType
+			}
+
+			remaining, err := decodeInner(typ.RemainingType)
+			if err != nil {
+				return nil, err
+			}
+
+			params = ast.NewVarArgsFunctionTypeParamKind(paramTypes, remaining)
+		} else {
+			normalParams := make([]ast.FunctionTypeParam, len(typ.Params))
+			for idx, param := range typ.Params {
+				paramType, err := decodeType(param.Type)
+				if err != nil {
+					return nil, err
+				}
+
+				normalParams[idx] = ast.NewFunctionTypeParam(
+					pAst.NewSpannedIdent(param.Name, span),
+					paramType,
+					param.SingletonIdent,
+				)
+			}
+
+			params = ast.NewNormalFunctionTypeParamKind(normalParams)
+		}
+
+		returnType, err := decodeInner(typ.ReturnType)
+		if err != nil {
+			return nil, err
+		}
+
+		return ast.NewFunctionType(params, span, returnType, span), nil
+	default:
+		return nil, fmt.Errorf("type of kind `%s` cannot be decoded", typ.Kind)
+	}
+}
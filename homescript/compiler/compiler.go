@@ -38,8 +38,12 @@ type Compiler struct {
 	labelNameMangle map[string]uint64
 	varScopes       []map[string]string
 	currScope       *map[string]string
-	currModule      string
-	lambdaCount     uint
+	// The source code range of each scope in `varScopes`.
+	scopeRanges []errors.Span
+	// Maps a mangled variable to its source identifier and scope, required for the debug information.
+	varSources  map[string]variableSource
+	currModule  string
+	lambdaCount uint
 	// Program source: required for invocations of the evaluator.
 	analyzedSource   map[string]ast.AnalyzedProgram
 	entryPointModule string
@@ -60,6 +64,8 @@ func NewCompiler(program map[string]ast.AnalyzedProgram, entryPointModule string
 		labelNameMangle: make(map[string]uint64),
 		varScopes:       scopes,
 		currScope:       currScope,
+		scopeRanges:     make([]errors.Span, 1),
+		varSources:      make(map[string]variableSource),
 		currModule:      "",
 		currFn:          "",
 		// Program source.
@@ -82,7 +88,8 @@ func (self *Compiler) Compile() (CompileOutput, error) {
 	}
 
 	self.relocateLabels()
-	self.renameVariables()
+	self.peephole()
+	variables := self.renameVariables()
 
 	functions := make(map[string][]Instruction)
 	sourceMap := make(map[string][]errors.Span)
@@ -97,6 +104,7 @@ func (self *Compiler) Compile() (CompileOutput, error) {
 	return CompileOutput{
 		Functions:   functions,
 		SourceMap:   sourceMap,
+		Variables:   variables,
 		Mappings:    mappings,
 		Annotations: annotations,
 	}, nil
@@ -243,12 +251,17 @@ func (self *Compiler) compileProgram(
 		// 	mappings.Functions[oldIdent] = mangled
 		// }
 
-		if moduleName == entryPointModule {
-			// If the current module is the entry module,
-			// Go back to the entrypoint function and insert the main function call.
-			self.currFn = InitFunctionIdent
-			self.currModule = entryPointModule
+		// Go back to the init function of the module.
+		self.currFn = InitFunctionIdent
+		self.currModule = moduleName
+
+		// Modules without a main function attribute their return to the module itself.
+		if mainFnSpan.Filename == "" {
+			mainFnSpan = errors.Span{Filename: moduleName}
+		}
 
+		if moduleName == entryPointModule {
+			// If the current module is the entry module, insert the calls of the other init functions.
 			for moduleName, otherInit := range initFns {
 				if moduleName == entryPointModule {
 					continue
@@ -257,8 +270,6 @@ func (self *Compiler) compileProgram(
 				self.insert(newOneStringInstruction(Opcode_Call_Imm, otherInit), mainFnSpan)
 			}
 
-			self.insert(newPrimitiveInstruction(Opcode_Return), mainFnSpan)
-
 			// mangledMain, found := self.getMangledFn(MainFunctionIdent)
 			// if !found {
 			// 	panic(fmt.Sprintf("`%s` function not found in current module", MainFunctionIdent))
@@ -269,6 +280,10 @@ func (self *Compiler) compileProgram(
 			// self.insert(newOneStringInstruction(Opcode_Call_Imm, mangledMain), mainFnSpan)
 			// self.insert(newPrimitiveInstruction(Opcode_Return), mainFnSpan)
 		}
+
+		// Every init function is terminated explicitly,
+		// as the optimizer may remove all globals of a module, which would leave its init function empty.
+		self.insert(newPrimitiveInstruction(Opcode_Return), mainFnSpan)
 	}
 
 	return mappings, moduleAnnotations, nil
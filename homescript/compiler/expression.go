@@ -432,6 +432,8 @@ func (self *Compiler) compileExpr(node ast.AnalyzedExpression) {
 		if node.DefaultArmAction != nil {
 			self.insert(newOneStringInstruction(Opcode_Jump, default_branch), node.Range)
 		} else {
+			// No arm matched: the control value is still on the stack
+			self.insert(newPrimitiveInstruction(Opcode_Drop), node.Range)
 			self.insert(newOneStringInstruction(Opcode_Jump, after_branch), node.Range)
 		}
 
@@ -446,6 +448,8 @@ func (self *Compiler) compileExpr(node ast.AnalyzedExpression) {
 
 		if node.DefaultArmAction != nil {
 			self.insert(newOneStringInstruction(Opcode_Label, default_branch), node.Range)
+			// The control value is also left on the stack if the default branch is taken
+			self.insert(newPrimitiveInstruction(Opcode_Drop), node.Range)
 			self.compileExpr(*node.DefaultArmAction)
 			self.insert(newOneStringInstruction(Opcode_Jump, after_branch), node.Range)
 		}
@@ -466,10 +470,10 @@ func (self *Compiler) compileExpr(node ast.AnalyzedExpression) {
 		self.insert(newOneStringInstruction(Opcode_Jump, afterCatchLabel), node.Range)
 
 		// exception case
-		mangledExceptionName := self.mangleVar(node.CatchIdent.Ident())
 		self.insert(newOneStringInstruction(Opcode_Label, exceptionLabel), node.Range)
-		self.pushScope()
+		self.pushScope(node.CatchBlock.Range)
 		defer self.popScope()
+		mangledExceptionName := self.mangleVar(node.CatchIdent.Ident())
 		self.insert(newOneStringInstruction(Opcode_SetVarImm, mangledExceptionName), node.Range)
 		self.insert(newPrimitiveInstruction(Opcode_PopTryLabel), node.Range)
 		self.compileBlock(node.CatchBlock, false)
@@ -49,7 +49,7 @@ func (self *Compiler) compileFn(node ast.AnalyzedFunctionDefinition) (annotation
 	mangledFn := self.mangleFn(node.Ident.Ident())
 	self.addFn(node.Ident.Ident(), mangledFn)
 	self.currFn = node.Ident.Ident()
-	self.pushScope()
+	self.pushScope(node.Range)
 	defer self.popScope()
 
 	// Compile annotations.
@@ -111,6 +111,10 @@ func (self *Compiler) compileFn(node ast.AnalyzedFunctionDefinition) (annotation
 					TriggerSource:         ann.TriggerSource.Ident(),
 					ArgumentFunctionIdent: annotationCallbackIdent,
 				}
+			case ast.AnalyzedAnnotationItemIdent:
+				compiledItems[idx] = IdentCompiledAnnotation{
+					Ident: ann.Ident.Ident(),
+				}
 			case ast.AnalyzedAnnotationItem:
 				fmt.Println("======= WARN: TODO: this is not yet implemented")
 			default:
@@ -21,6 +21,9 @@ type CompileOutput struct {
 	Functions map[string][]Instruction
 	// Associates a mangled function with its instruction-spans.
 	SourceMap map[string][]errors.Span
+	// Associates a mangled function with the memory slots of its named local variables.
+	// This is only required by debuggers and may be empty, for instance if the program was assembled.
+	Variables map[string][]VariableSlot
 	// NOTE: this type is returned by the compiler so that the execution environment
 	// is still able to interact with the runtime through function calls and global variable access.
 	Mappings    MangleMappings
@@ -34,6 +37,12 @@ func (self CompileOutput) AsmStringHighlight(color bool, activeFunc *string, lin
 	}
 	slices.Sort(functionNames)
 
+	// The mappings are keyed by the source identifier, not by the mangled name.
+	sourceIdents := make(map[string]string)
+	for sourceIdent, mangled := range self.Mappings.Functions {
+		sourceIdents[mangled] = sourceIdent
+	}
+
 	functionsStr := make([]string, 0)
 
 	for _, fnName := range functionNames {
@@ -43,7 +52,7 @@ func (self CompileOutput) AsmStringHighlight(color bool, activeFunc *string, lin
 			continue
 		}
 
-		fnHeaderStr := fmt.Sprintf("FUNCTION %s (%s)", fnName, self.Mappings.Functions[fnName])
+		fnHeaderStr := fmt.Sprintf("FUNCTION %s (%s)", fnName, sourceIdents[fnName])
 		if color && activeFunc != nil && *activeFunc == fnName {
 			fnHeaderStr = fmt.Sprintf("\x1b[1;32m%s\x1b[0m | ACTIVE", fnHeaderStr)
 		}
@@ -78,7 +87,7 @@ func (self CompileOutput) AsmString(color bool) string {
 	// functionsStr := make([]string, 0)
 	//
 	// for fnName, instructions := range self.Functions {
-	// 	fnHeaderStr := fmt.Sprintf("FUNCTION %s (%s)", fnName, self.Mappings.Functions[fnName])
+	// 	fnHeaderStr := fmt.Sprintf("FUNCTION %s (%s)", fnName, sourceIdents[fnName])
 	// 	fnInstructions := make([]string, 0)
 	//
 	// 	for idx, instruction := range instructions {
@@ -364,6 +373,9 @@ func (self OneIntInstruction) String() string {
 	return fmt.Sprintf("%s(%d)", self.opCode, self.Value)
 }
 func (self OneIntInstruction) Display(color bool) string {
+	if !color {
+		return self.String()
+	}
 	return fmt.Sprintf("%s%s%s(%s%d%s)", opcodeColor, self.opCode, colorReset, argumentColor, self.Value, colorReset)
 }
 
@@ -445,21 +457,19 @@ type CastInstruction struct {
 
 func (self CastInstruction) Opcode() Opcode { return self.opCode }
 func (self CastInstruction) String() string {
-	typeStr := strings.ReplaceAll(self.Type.String(), "\n", "\n        ")
-	return fmt.Sprintf("%v(as_type=%s; perform_cast=%t)", self.Opcode(), typeStr, self.AllowCast)
+	return fmt.Sprintf("%v(as_type=%s; perform_cast=%t)", self.Opcode(), asmType(self.Type), self.AllowCast)
 }
 func (self CastInstruction) Display(color bool) string {
 	if !color {
 		return self.String()
 	}
-	typeStr := strings.ReplaceAll(self.Type.String(), "\n", "\n        ")
 	return fmt.Sprintf(
 		"%s%v%s(as_type=%s%s%s; perform_cast=%s%t%s)",
 		opcodeColor,
 		self.Opcode(),
 		colorReset,
 		argumentColor,
-		typeStr,
+		asmType(self.Type),
 		colorReset,
 		argumentColor,
 		self.AllowCast,
@@ -484,17 +494,7 @@ type ValueInstruction struct {
 
 func (self ValueInstruction) Opcode() Opcode { return self.opCode }
 func (self ValueInstruction) String() string {
-	str, i := self.Value.Display()
-	if i != nil {
-		panic(*i)
-	}
-
-	if self.Value.Kind() == value.StringValueKind {
-		str = "\"" + str + "\""
-	}
-
-	str = strings.ReplaceAll(strings.ReplaceAll(str, "\n    ", ""), "\n", "")
-	return fmt.Sprintf("%v(%s)", self.Opcode(), str)
+	return fmt.Sprintf("%v(%s)", self.Opcode(), asmValue(self.Value))
 }
 
 func (self ValueInstruction) Display(color bool) string {
@@ -502,17 +502,7 @@ func (self ValueInstruction) Display(color bool) string {
 		return self.String()
 	}
 
-	str, i := self.Value.Display()
-	if i != nil {
-		panic(*i)
-	}
-
-	if self.Value.Kind() == value.StringValueKind {
-		str = "\"" + str + "\""
-	}
-
-	str = strings.ReplaceAll(strings.ReplaceAll(str, "\n    ", ""), "\n", "")
-	return fmt.Sprintf("%s%v%s(%s%s%s)", opcodeColor, self.Opcode(), colorReset, argumentColor, str, colorReset)
+	return fmt.Sprintf("%s%v%s(%s%s%s)", opcodeColor, self.Opcode(), colorReset, argumentColor, asmValue(self.Value), colorReset)
 }
 
 func newValueInstruction(opCode Opcode, value value.Value) ValueInstruction {
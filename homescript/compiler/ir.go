@@ -2,6 +2,7 @@ package compiler
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/smarthome-go/homescript/v3/homescript/errors"
 )
@@ -63,32 +64,44 @@ func (self *Compiler) relocateLabels() {
 	}
 }
 
-func (self *Compiler) renameVariables() {
+// Replaces the mangled names of local variables with their memory slots.
+// Returns the slots of all named variables of each function, see `CompileOutput.Variables`.
+func (self *Compiler) renameVariables() map[string][]VariableSlot {
 	slot := make(map[string]int64, 0)
+	variables := make(map[string][]VariableSlot)
 
 	for _, module := range self.modules {
 		for name, fn := range module {
 			cnt := 0
+			fnVariables := make([]VariableSlot, 0)
+
 			for idx, inst := range fn.Instructions {
 				switch inst.Opcode() {
-				case Opcode_GetVarImm:
-					i := inst.(OneStringInstruction)
-					if _, found := slot[i.Value]; !found {
-						slot[i.Value] = int64(cnt)
-						cnt++
-					}
-					module[name].Instructions[idx] = newOneIntInstruction(Opcode_GetVarImm, slot[i.Value])
-				case Opcode_SetVarImm:
+				case Opcode_GetVarImm, Opcode_SetVarImm:
 					i := inst.(OneStringInstruction)
 					if _, found := slot[i.Value]; !found {
 						slot[i.Value] = int64(cnt)
 						cnt++
+
+						// Variables generated by the compiler, such as iterators, cannot be referenced by the user.
+						if source, found := self.varSources[i.Value]; found && !strings.HasPrefix(source.name, "$") {
+							fnVariables = append(fnVariables, VariableSlot{
+								Name:     source.name,
+								Slot:     slot[i.Value],
+								Declared: idx,
+								Scope:    source.scope,
+							})
+						}
 					}
-					module[name].Instructions[idx] = newOneIntInstruction(Opcode_SetVarImm, slot[i.Value])
+					module[name].Instructions[idx] = newOneIntInstruction(inst.Opcode(), slot[i.Value])
 				default:
 					continue
 				}
 			}
+
+			variables[fn.MangledName] = fnVariables
 		}
 	}
+
+	return variables
 }
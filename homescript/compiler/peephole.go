@@ -0,0 +1,129 @@
+package compiler
+
+import (
+	"github.com/smarthome-go/homescript/v3/homescript/errors"
+)
+
+//
+// Peephole optimizer.
+// Runs after the labels have been relocated and removes instruction sequences which have no effect.
+// Since jump targets are absolute, every jump and try label is updated accordingly.
+//
+
+func (self *Compiler) peephole() {
+	for moduleName, module := range self.modules {
+		for name, fn := range module {
+			instructions, sourceMap := fn.Instructions, fn.SourceMap
+
+			// Removing instructions can expose new patterns, therefore, repeat until nothing changes.
+			for {
+				var changed bool
+				instructions, sourceMap, changed = peepholeFn(instructions, sourceMap)
+				if !changed {
+					break
+				}
+			}
+
+			self.modules[moduleName][name].Instructions = instructions
+			self.modules[moduleName][name].SourceMap = sourceMap
+		}
+	}
+}
+
+func peepholeFn(instructions []Instruction, sourceMap []errors.Span) ([]Instruction, []errors.Span, bool) {
+	isJumpTarget := make([]bool, len(instructions)+1)
+	for _, inst := range instructions {
+		if target, ok := jumpTarget(inst); ok && target >= 0 && target <= int64(len(instructions)) {
+			isJumpTarget[target] = true
+		}
+	}
+
+	remove := make([]bool, len(instructions))
+	changed := false
+
+	for idx := 0; idx < len(instructions); idx++ {
+		inst := instructions[idx]
+
+		switch inst.Opcode() {
+		case Opcode_Nop:
+			remove[idx] = true
+			changed = true
+			continue
+		case Opcode_Jump:
+			if inst.(OneIntInstruction).Value == int64(idx+1) {
+				remove[idx] = true
+				changed = true
+				continue
+			}
+		case Opcode_JumpIfFalse:
+			// The condition still has to be popped off the stack.
+			if inst.(OneIntInstruction).Value == int64(idx+1) {
+				instructions[idx] = newPrimitiveInstruction(Opcode_Drop)
+				changed = true
+				continue
+			}
+		}
+
+		// Pairs of instructions which cancel each other out.
+		// If the second instruction is a jump target, the stack layout at this target depends on the first instruction.
+		if idx+1 >= len(instructions) || isJumpTarget[idx+1] || instructions[idx+1].Opcode() != Opcode_Drop {
+			continue
+		}
+
+		switch inst.Opcode() {
+		case Opcode_Copy_Push, Opcode_Cloning_Push, Opcode_Duplicate:
+			remove[idx] = true
+			remove[idx+1] = true
+			changed = true
+			idx++
+		}
+	}
+
+	if !changed {
+		return instructions, sourceMap, false
+	}
+
+	// Maps each old instruction index to its new index.
+	// Removed instructions are mapped to the next instruction which is kept.
+	newIndex := make([]int64, len(instructions)+1)
+
+	instructionsOut := make([]Instruction, 0, len(instructions))
+	sourceMapOut := make([]errors.Span, 0, len(sourceMap))
+
+	for idx, inst := range instructions {
+		newIndex[idx] = int64(len(instructionsOut))
+
+		if remove[idx] {
+			continue
+		}
+
+		instructionsOut = append(instructionsOut, inst)
+		sourceMapOut = append(sourceMapOut, sourceMap[idx])
+	}
+	newIndex[len(instructions)] = int64(len(instructionsOut))
+
+	for idx, inst := range instructionsOut {
+		switch inst.Opcode() {
+		case Opcode_Jump, Opcode_JumpIfFalse:
+			i := inst.(OneIntInstruction)
+			instructionsOut[idx] = newOneIntInstruction(i.opCode, newIndex[i.Value])
+		case Opcode_SetTryLabel:
+			i := inst.(OneIntOneStringInstruction)
+			instructionsOut[idx] = newOneIntOneStringInstruction(i.opCode, i.ValueString, newIndex[i.ValueInt])
+		}
+	}
+
+	return instructionsOut, sourceMapOut, true
+}
+
+// Returns the instruction index which the given instruction may jump to.
+func jumpTarget(inst Instruction) (int64, bool) {
+	switch inst.Opcode() {
+	case Opcode_Jump, Opcode_JumpIfFalse:
+		return inst.(OneIntInstruction).Value, true
+	case Opcode_SetTryLabel:
+		return inst.(OneIntOneStringInstruction).ValueInt, true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,147 @@
+package compiler
+
+import (
+	"testing"
+
+	"github.com/smarthome-go/homescript/v3/homescript/errors"
+	"github.com/smarthome-go/homescript/v3/homescript/runtime/value"
+	"github.com/stretchr/testify/assert"
+)
+
+// Runs the peephole pass until nothing changes, like `Compiler.peephole`.
+// Each span refers to the original index of its instruction so that the source map can be checked.
+func runPeephole(t *testing.T, instructions []Instruction) ([]Instruction, []uint) {
+	sourceMap := make([]errors.Span, len(instructions))
+	for idx := range sourceMap {
+		sourceMap[idx] = errors.Span{Start: errors.Location{Index: uint(idx)}}
+	}
+
+	for {
+		var changed bool
+		instructions, sourceMap, changed = peepholeFn(instructions, sourceMap)
+		assert.Len(t, sourceMap, len(instructions))
+		if !changed {
+			break
+		}
+	}
+
+	origins := make([]uint, len(sourceMap))
+	for idx, span := range sourceMap {
+		origins[idx] = span.Start.Index
+	}
+
+	return instructions, origins
+}
+
+func TestPeephole(t *testing.T) {
+	push := newValueInstruction(Opcode_Copy_Push, *value.NewValueInt(42))
+	cloningPush := newValueInstruction(Opcode_Cloning_Push, *value.NewValueInt(42))
+	drop := newPrimitiveInstruction(Opcode_Drop)
+	ret := newPrimitiveInstruction(Opcode_Return)
+
+	tests := []struct {
+		name         string
+		instructions []Instruction
+		expected     []Instruction
+		// The original index of each remaining instruction.
+		origins []uint
+	}{
+		{
+			name:         "push followed by drop",
+			instructions: []Instruction{push, drop, cloningPush, drop, ret},
+			expected:     []Instruction{ret},
+			origins:      []uint{4},
+		},
+		{
+			name: "duplicate followed by drop",
+			instructions: []Instruction{
+				push,
+				newPrimitiveInstruction(Opcode_Duplicate),
+				drop,
+				ret,
+			},
+			expected: []Instruction{push, ret},
+			origins:  []uint{0, 3},
+		},
+		{
+			name: "jump to the next instruction",
+			instructions: []Instruction{
+				newOneIntInstruction(Opcode_Jump, 1),
+				newOneIntInstruction(Opcode_GetVarImm, 0),
+				// The condition must still be popped off the stack.
+				newOneIntInstruction(Opcode_JumpIfFalse, 3),
+				ret,
+			},
+			expected: []Instruction{newOneIntInstruction(Opcode_GetVarImm, 0), drop, ret},
+			origins:  []uint{1, 2, 3},
+		},
+		{
+			name: "removals expose new patterns",
+			instructions: []Instruction{
+				push,
+				newOneIntInstruction(Opcode_JumpIfFalse, 2),
+				ret,
+			},
+			expected: []Instruction{ret},
+			origins:  []uint{2},
+		},
+		{
+			name: "nop",
+			instructions: []Instruction{
+				// Memory pointer adjustments are kept, even if they are 0.
+				newOneIntInstruction(Opcode_AddMempointer, 0),
+				newPrimitiveInstruction(Opcode_Nop),
+				newPrimitiveInstruction(Opcode_Nop),
+				ret,
+			},
+			expected: []Instruction{newOneIntInstruction(Opcode_AddMempointer, 0), ret},
+			origins:  []uint{0, 3},
+		},
+		{
+			name: "drop which is a jump target",
+			instructions: []Instruction{
+				newOneIntInstruction(Opcode_JumpIfFalse, 3),
+				push,
+				push,
+				drop,
+				ret,
+			},
+			expected: []Instruction{
+				newOneIntInstruction(Opcode_JumpIfFalse, 3),
+				push,
+				push,
+				drop,
+				ret,
+			},
+			origins: []uint{0, 1, 2, 3, 4},
+		},
+		{
+			name: "jump and try label targets are remapped",
+			instructions: []Instruction{
+				newOneIntOneStringInstruction(Opcode_SetTryLabel, "e", 6),
+				newPrimitiveInstruction(Opcode_Nop),
+				push,
+				drop,
+				newOneIntInstruction(Opcode_Jump, 7),
+				newPrimitiveInstruction(Opcode_Nop),
+				newPrimitiveInstruction(Opcode_PopTryLabel),
+				ret,
+			},
+			expected: []Instruction{
+				newOneIntOneStringInstruction(Opcode_SetTryLabel, "e", 2),
+				newOneIntInstruction(Opcode_Jump, 3),
+				newPrimitiveInstruction(Opcode_PopTryLabel),
+				ret,
+			},
+			origins: []uint{0, 4, 6, 7},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			instructions, origins := runPeephole(t, test.instructions)
+			assert.Equal(t, test.expected, instructions)
+			assert.Equal(t, test.origins, origins)
+		})
+	}
+}
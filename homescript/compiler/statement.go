@@ -13,7 +13,7 @@ import (
 
 func (self *Compiler) compileBlock(node ast.AnalyzedBlock, pushScope bool) {
 	if pushScope {
-		self.pushScope()
+		self.pushScope(node.Range)
 		defer self.popScope()
 	}
 
@@ -128,7 +128,7 @@ func (self *Compiler) compileStmt(node ast.AnalyzedStatement) {
 		after_label := self.mangleLabel("loop_end")
 
 		// Create initial state of iterator
-		self.pushScope()
+		self.pushScope(node.Range)
 		defer self.popScope()
 
 		// Push iter expr onto the stack
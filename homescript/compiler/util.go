@@ -18,13 +18,15 @@ func (self *Compiler) popLoop() {
 	self.loops = self.loops[:len(self.loops)-1]
 }
 
-func (self *Compiler) pushScope() {
+func (self *Compiler) pushScope(span errors.Span) {
 	self.varScopes = append(self.varScopes, make(map[string]string))
+	self.scopeRanges = append(self.scopeRanges, span)
 	self.currScope = &self.varScopes[len(self.varScopes)-1]
 }
 
 func (self *Compiler) popScope() {
 	self.varScopes = self.varScopes[:len(self.varScopes)-1]
+	self.scopeRanges = self.scopeRanges[:len(self.scopeRanges)-1]
 	if len(self.varScopes) == 0 {
 		self.currScope = nil
 		return
@@ -33,8 +35,12 @@ func (self *Compiler) popScope() {
 }
 
 func (self *Compiler) mangleFn(input string) string {
-	mangled := fmt.Sprintf("@%s_%s", self.currModule, input)
-	return mangled
+	return MangleFunction(self.currModule, input)
+}
+
+// Returns the name of a function in the compiled program, which can be invoked using a literal name.
+func MangleFunction(module string, function string) string {
+	return fmt.Sprintf("@%s_%s", module, function)
 }
 
 func (self *Compiler) addFn(srcIdent string, mangledName string) {
@@ -50,17 +56,24 @@ func (self *Compiler) addFn(srcIdent string, mangledName string) {
 
 func (self *Compiler) mangleVar(input string) string {
 	self.CurrFn().CntVariables++
-	cnt, exists := self.varNameMangle[input]
+
+	// The counter is kept per module so that the names of globals do not depend on the order in which modules are compiled.
+	prefix := fmt.Sprintf("@%s_%s", self.currModule, input)
+	cnt, exists := self.varNameMangle[prefix]
 	if !exists {
 		// The next time this variable is mangled, 0 MUST NOT be used as the counter.
-		self.varNameMangle[input] = 1
+		self.varNameMangle[prefix] = 1
 		cnt = 0
 	} else {
-		self.varNameMangle[input]++
+		self.varNameMangle[prefix]++
 	}
 
-	mangled := fmt.Sprintf("@%s_%s%d", self.currModule, input, cnt)
+	mangled := fmt.Sprintf("%s%d", prefix, cnt)
 	(*self.currScope)[input] = mangled
+	self.varSources[mangled] = variableSource{
+		name:  input,
+		scope: self.scopeRanges[len(self.scopeRanges)-1],
+	}
 
 	return mangled
 }
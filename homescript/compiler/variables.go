@@ -0,0 +1,67 @@
+package compiler
+
+import (
+	"slices"
+
+	"github.com/smarthome-go/homescript/v3/homescript/errors"
+)
+
+//
+// Debug information about local variables.
+// The compiler replaces variable names with memory slots, this table allows debuggers to resolve them by name.
+//
+
+type variableSource struct {
+	name string
+	// The range of the scope in which the variable was declared.
+	scope errors.Span
+}
+
+type VariableSlot struct {
+	// The identifier of the variable in the source code.
+	Name string `json:"name"`
+	// The offset of the variable in the memory frame of its function.
+	Slot int64 `json:"slot"`
+	// Index of the first instruction which accesses the variable, usually its declaration.
+	Declared int `json:"declared"`
+	// The variable is only visible if the current instruction belongs to this range.
+	Scope errors.Span `json:"scope"`
+}
+
+// Returns the local variables of a function which are visible before the instruction at `ip` is executed.
+// If a variable is shadowed, only its latest declaration is returned.
+// The result is sorted by memory slot.
+func (self CompileOutput) VisibleVariables(function string, ip uint) []VariableSlot {
+	sourceMap := self.SourceMap[function]
+	if int(ip) >= len(sourceMap) {
+		return nil
+	}
+	current := sourceMap[ip]
+
+	visible := make(map[string]VariableSlot)
+	for _, variable := range self.Variables[function] {
+		if variable.Declared >= int(ip) || !spanContains(variable.Scope, current) {
+			continue
+		}
+
+		if previous, found := visible[variable.Name]; found && previous.Declared > variable.Declared {
+			continue
+		}
+
+		visible[variable.Name] = variable
+	}
+
+	output := make([]VariableSlot, 0, len(visible))
+	for _, variable := range visible {
+		output = append(output, variable)
+	}
+
+	slices.SortFunc(output, func(a, b VariableSlot) int { return int(a.Slot - b.Slot) })
+	return output
+}
+
+func spanContains(outer errors.Span, inner errors.Span) bool {
+	return outer.Filename == inner.Filename &&
+		outer.Start.Index <= inner.Start.Index &&
+		inner.End.Index <= outer.End.Index
+}
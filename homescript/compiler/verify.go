@@ -0,0 +1,552 @@
+package compiler
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/smarthome-go/homescript/v3/homescript/runtime/value"
+)
+
+//
+// Bytecode verifier.
+// Statically checks compiled (or decoded) bytecode so that the VM never runs into malformed instructions.
+// The stack depth is tracked relative to the start of each function.
+// Because the stack effect of some calls is only known at runtime (such as calling builtin functions),
+// the depth can become unknown. Such paths are only checked again once they merge with a path of known depth.
+// Type errors between values are not detected: the compiler and the analyzer are responsible for those.
+// Therefore, hand-edited bytecode which passes verification can still crash a core,
+// for instance, if it pops more values than a dynamic call (`Call_Val`) left on the stack,
+// or if it applies an instruction to a value of the wrong kind.
+//
+
+type VerificationError struct {
+	Function           string
+	InstructionPointer int
+	Message            string
+}
+
+func (self VerificationError) Error() string {
+	return fmt.Sprintf("Bytecode verification failed in `%s` at %04d: %s", self.Function, self.InstructionPointer, self.Message)
+}
+
+type verifierState struct {
+	visited bool
+	// Whether the stack depth is statically known.
+	known bool
+	depth int
+	// The number of try labels which were set by the current function and are certainly still set.
+	tryDepth int
+}
+
+// Stack effect of a function: `params` values are popped off the stack, `returns` values are left on the stack.
+type verifierSummary struct {
+	params  int
+	returns int
+	known   bool
+}
+
+type verifier struct {
+	program CompileOutput
+	// Globals which are declared by the host or by the program itself.
+	globals map[string]struct{}
+	// The stack effect of each function.
+	summaries map[string]verifierSummary
+	// Only set during the last pass.
+	errors []VerificationError
+	// Whether errors should be recorded.
+	report bool
+}
+
+// Verifies that the program can be executed safely.
+// `hostGlobals` contains the names of the values which are provided by the host environment.
+func Verify(program CompileOutput, hostGlobals []string) error {
+	v := verifier{
+		program:   program,
+		globals:   make(map[string]struct{}),
+		summaries: make(map[string]verifierSummary),
+		errors:    make([]VerificationError, 0),
+		report:    false,
+	}
+
+	for _, global := range hostGlobals {
+		v.globals[global] = struct{}{}
+	}
+
+	// Each function is visited in a stable order so that the reported error is deterministic.
+	functions := make([]string, 0, len(program.Functions))
+	for name := range program.Functions {
+		functions = append(functions, name)
+	}
+	slices.Sort(functions)
+
+	if err := v.verifyMetadata(functions); err != nil {
+		return err
+	}
+
+	for _, name := range functions {
+		instructions := program.Functions[name]
+
+		for ip, inst := range instructions {
+			if err := v.verifyInstruction(name, ip, inst); err != nil {
+				return err
+			}
+		}
+
+		v.summaries[name] = verifierSummary{
+			params:  functionParams(instructions),
+			returns: 0,
+			known:   false,
+		}
+	}
+
+	// The stack effect of a function depends on the functions it calls.
+	// Therefore, the summaries are refined until nothing changes anymore.
+	for iteration := 0; iteration <= len(functions); iteration++ {
+		changed := false
+
+		for _, name := range functions {
+			summary := v.verifyStack(name)
+			if summary != v.summaries[name] {
+				v.summaries[name] = summary
+				changed = true
+			}
+		}
+
+		if !changed {
+			break
+		}
+	}
+
+	v.report = true
+	for _, name := range functions {
+		v.verifyStack(name)
+		if len(v.errors) > 0 {
+			return v.errors[0]
+		}
+	}
+
+	return nil
+}
+
+func (self *verifier) verifyMetadata(functions []string) error {
+	for _, name := range functions {
+		if len(self.program.Functions[name]) == 0 {
+			return VerificationError{Function: name, InstructionPointer: 0, Message: "function contains no instructions"}
+		}
+
+		if spans := len(self.program.SourceMap[name]); spans != len(self.program.Functions[name]) {
+			return VerificationError{
+				Function:           name,
+				InstructionPointer: 0,
+				Message: fmt.Sprintf(
+					"source map contains %d span(s) for %d instruction(s)",
+					spans,
+					len(self.program.Functions[name]),
+				),
+			}
+		}
+	}
+
+	for source, mangled := range self.program.Mappings.Functions {
+		if _, found := self.program.Functions[mangled]; !found {
+			return VerificationError{
+				Function:           mangled,
+				InstructionPointer: 0,
+				Message:            fmt.Sprintf("function `%s` is mapped to a non-existent function", source),
+			}
+		}
+	}
+
+	for _, name := range functions {
+		for _, inst := range self.program.Functions[name] {
+			switch inst.Opcode() {
+			case Opcode_SetGlobImm:
+				if inst, ok := inst.(OneStringInstruction); ok {
+					self.globals[inst.Value] = struct{}{}
+				}
+			case Opcode_Import:
+				if inst, ok := inst.(TwoStringInstruction); ok {
+					self.globals[inst.Values[1]] = struct{}{}
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// Returns the number of parameters of a function.
+// The prologue of a function pops its parameters off the stack into variables.
+func functionParams(instructions []Instruction) int {
+	idx := 0
+	if len(instructions) > 0 && instructions[0].Opcode() == Opcode_AddMempointer {
+		idx++
+	}
+
+	params := 0
+	for ; idx < len(instructions) && instructions[idx].Opcode() == Opcode_SetVarImm; idx++ {
+		params++
+	}
+
+	return params
+}
+
+// Returns the size of the memory frame which is allocated by the prologue of a function.
+func FunctionFrameSize(instructions []Instruction) int64 {
+	if len(instructions) == 0 || instructions[0].Opcode() != Opcode_AddMempointer {
+		return 0
+	}
+
+	if inst, ok := instructions[0].(OneIntInstruction); ok {
+		return inst.Value
+	}
+
+	return 0
+}
+
+//
+// Checks which only involve a single instruction.
+//
+
+func (self *verifier) verifyInstruction(function string, ip int, inst Instruction) error {
+	fail := func(format string, args ...any) error {
+		return VerificationError{Function: function, InstructionPointer: ip, Message: fmt.Sprintf(format, args...)}
+	}
+
+	instructions := self.program.Functions[function]
+
+	if inst == nil {
+		return fail("instruction is <nil>")
+	}
+
+	expected, valid := opcodeForm(inst.Opcode())
+	if !valid {
+		return fail("opcode `%s` must not occur in executable bytecode", inst.Opcode())
+	}
+
+	if actual := instructionForm(inst); actual != expected {
+		return fail("opcode `%s` requires the %s form, found %s", inst.Opcode(), expected, actual)
+	}
+
+	switch inst.Opcode() {
+	case Opcode_Jump, Opcode_JumpIfFalse:
+		target := inst.(OneIntInstruction).Value
+		if target < 0 || target >= int64(len(instructions)) {
+			return fail("jump target %d is out of bounds", target)
+		}
+	case Opcode_SetTryLabel:
+		inst := inst.(OneIntOneStringInstruction)
+		targetFn, found := self.program.Functions[inst.ValueString]
+		if !found {
+			return fail("try label refers to non-existent function `%s`", inst.ValueString)
+		}
+		if inst.ValueInt < 0 || inst.ValueInt >= int64(len(targetFn)) {
+			return fail("try label target %d is out of bounds", inst.ValueInt)
+		}
+	case Opcode_Call_Imm, Opcode_Spawn:
+		callee := inst.(OneStringInstruction).Value
+		if _, found := self.program.Functions[callee]; !found {
+			return fail("call to non-existent function `%s`", callee)
+		}
+	case Opcode_GetGlobImm, Opcode_SetGlobImm:
+		// Globals which are set by the program are collected before.
+		global := inst.(OneStringInstruction).Value
+		if _, found := self.globals[global]; !found {
+			return fail("global `%s` is never declared", global)
+		}
+	case Opcode_GetVarImm, Opcode_SetVarImm:
+		slot := inst.(OneIntInstruction).Value
+		if frameSize := FunctionFrameSize(instructions); slot < 0 || slot >= frameSize {
+			return fail("variable slot %d is outside of the frame of size %d", slot, frameSize)
+		}
+	case Opcode_HostCall:
+		switch name := inst.(OneStringInstruction).Value; name {
+		case LIST_PUSH, RegisterTriggerHostFn:
+		default:
+			return fail("unknown host function `%s`", name)
+		}
+	case Opcode_Copy_Push, Opcode_Cloning_Push:
+		if err := self.verifyValue(inst.(ValueInstruction).Value); err != nil {
+			return fail("%s", err.Error())
+		}
+	}
+
+	return nil
+}
+
+func (self *verifier) verifyValue(val value.Value) error {
+	if val == nil {
+		return fmt.Errorf("value is <nil>")
+	}
+
+	switch val := val.(type) {
+	case value.ValueVMFunction:
+		if _, found := self.program.Functions[val.Ident]; !found {
+			return fmt.Errorf("function value refers to non-existent function `%s`", val.Ident)
+		}
+	case value.ValueList:
+		if val.Values == nil {
+			return fmt.Errorf("list value is <nil>")
+		}
+		for _, item := range *val.Values {
+			if item == nil {
+				return fmt.Errorf("list element is <nil>")
+			}
+			if err := self.verifyValue(*item); err != nil {
+				return err
+			}
+		}
+	case value.ValueObject, value.ValueAnyObject:
+		fields, _ := val.Fields()
+		for key, field := range fields {
+			if field == nil {
+				return fmt.Errorf("field `%s` is <nil>", key)
+			}
+			if err := self.verifyValue(*field); err != nil {
+				return err
+			}
+		}
+	case value.ValueOption:
+		if val.Inner != nil {
+			return self.verifyValue(*val.Inner)
+		}
+	}
+
+	return nil
+}
+
+// Returns the form which instructions with the given opcode must have.
+// Labels are only used during compilation and are therefore invalid.
+func opcodeForm(op Opcode) (bytecodeInstrForm, bool) {
+	switch op {
+	case Opcode_Nop, Opcode_Clone, Opcode_Drop, Opcode_Call_Val, Opcode_Return,
+		Opcode_Assign, Opcode_Neg, Opcode_Some, Opcode_Not, Opcode_Add, Opcode_Sub,
+		Opcode_Mul, Opcode_Pow, Opcode_Div, Opcode_Rem, Opcode_Eq, Opcode_Eq_PopOnce,
+		Opcode_Lt, Opcode_Gt, Opcode_Le, Opcode_Ge, Opcode_Shl, Opcode_Shr,
+		Opcode_BitOr, Opcode_BitAnd, Opcode_BitXor, Opcode_Index, Opcode_PopTryLabel,
+		Opcode_Throw, Opcode_Member_Unwrap, Opcode_Duplicate, Opcode_IteratorAdvance,
+		Opcode_IntoIter:
+		return bytecodeFormPrimitive, true
+	case Opcode_Copy_Push, Opcode_Cloning_Push:
+		return bytecodeFormValue, true
+	case Opcode_Spawn, Opcode_Call_Imm, Opcode_HostCall, Opcode_GetGlobImm,
+		Opcode_SetGlobImm, Opcode_Member, Opcode_Member_Anyobj:
+		return bytecodeFormString, true
+	case Opcode_Load_Singleton, Opcode_Import:
+		return bytecodeFormTwoString, true
+	case Opcode_Jump, Opcode_JumpIfFalse, Opcode_GetVarImm, Opcode_SetVarImm, Opcode_AddMempointer:
+		return bytecodeFormInt, true
+	case Opcode_SetTryLabel:
+		return bytecodeFormIntString, true
+	case Opcode_Cast:
+		return bytecodeFormCast, true
+	case Opcode_Into_Range:
+		return bytecodeFormBool, true
+	default:
+		return "", false
+	}
+}
+
+func instructionForm(inst Instruction) bytecodeInstrForm {
+	switch inst.(type) {
+	case PrimitiveInstruction:
+		return bytecodeFormPrimitive
+	case OneBoolInstruction:
+		return bytecodeFormBool
+	case OneIntOneStringInstruction:
+		return bytecodeFormIntString
+	case OneIntInstruction:
+		return bytecodeFormInt
+	case OneStringInstruction:
+		return bytecodeFormString
+	case TwoStringInstruction:
+		return bytecodeFormTwoString
+	case CastInstruction:
+		return bytecodeFormCast
+	case ValueInstruction:
+		return bytecodeFormValue
+	default:
+		panic("A new instruction type was added without updating this code")
+	}
+}
+
+//
+// Stack depth analysis.
+//
+
+func (self *verifier) verifyStack(function string) verifierSummary {
+	instructions := self.program.Functions[function]
+	summary := self.summaries[function]
+
+	fail := func(ip int, format string, args ...any) {
+		if self.report {
+			self.errors = append(self.errors, VerificationError{
+				Function:           function,
+				InstructionPointer: ip,
+				Message:            fmt.Sprintf(format, args...),
+			})
+		}
+	}
+
+	states := make([]verifierState, len(instructions))
+	worklist := make([]int, 0)
+
+	// Merges the state of an incoming edge into the state of the target instruction.
+	// The compiler does not pop try labels on `return`, `break` and `continue`,
+	// therefore, the try label depths of merging paths may differ: the smallest one is kept.
+	enter := func(from int, target int, state verifierState) {
+		if target >= len(instructions) {
+			// Running past the end of a function behaves like `Return`.
+			return
+		}
+
+		existing := states[target]
+
+		switch {
+		case !existing.visited:
+			states[target] = state
+		case existing.known && state.known && existing.depth != state.depth:
+			fail(from, "stack depth %d does not match depth %d at jump target %d", state.depth, existing.depth, target)
+			return
+		case !existing.known && state.known:
+			states[target] = state
+			states[target].tryDepth = min(existing.tryDepth, state.tryDepth)
+		case state.tryDepth < existing.tryDepth:
+			states[target].tryDepth = state.tryDepth
+		default:
+			return
+		}
+
+		states[target].visited = true
+		worklist = append(worklist, target)
+	}
+
+	enter(0, 0, verifierState{visited: true, known: true, depth: summary.params, tryDepth: 0})
+
+	returns := verifierSummary{params: summary.params, returns: 0, known: false}
+	returnIP := 0
+
+	for len(worklist) > 0 {
+		ip := worklist[len(worklist)-1]
+		worklist = worklist[:len(worklist)-1]
+
+		state := states[ip]
+		inst := instructions[ip]
+
+		// Pops `pop` values and pushes `push` values.
+		apply := func(pop int, push int) {
+			if state.known && state.depth < pop {
+				fail(ip, "stack underflow: `%s` requires %d value(s), found %d", inst.Opcode(), pop, state.depth)
+				state.known = false
+				return
+			}
+			state.depth += push - pop
+		}
+
+		// The argument count of dynamic calls is pushed right before the call.
+		argc := func() (int, bool) {
+			if ip > 0 {
+				if prev, ok := instructions[ip-1].(ValueInstruction); ok && prev.opCode == Opcode_Copy_Push {
+					if count, ok := prev.Value.(value.ValueInt); ok && count.Inner >= 0 {
+						return int(count.Inner), true
+					}
+				}
+			}
+			fail(ip, "`%s` must directly follow a push of its argument count", inst.Opcode())
+			return 0, false
+		}
+
+		successors := []int{ip + 1}
+
+		switch inst.Opcode() {
+		case Opcode_Nop, Opcode_AddMempointer, Opcode_Import:
+		case Opcode_Load_Singleton, Opcode_Clone, Opcode_Cast, Opcode_Neg, Opcode_Some, Opcode_Not,
+			Opcode_Member, Opcode_Member_Anyobj, Opcode_Member_Unwrap, Opcode_IntoIter:
+			apply(1, 1)
+		case Opcode_Copy_Push, Opcode_Cloning_Push, Opcode_GetVarImm, Opcode_GetGlobImm:
+			apply(0, 1)
+		case Opcode_Duplicate:
+			apply(1, 2)
+		case Opcode_Drop, Opcode_SetVarImm, Opcode_SetGlobImm:
+			apply(1, 0)
+		case Opcode_Assign:
+			apply(2, 0)
+		case Opcode_Add, Opcode_Sub, Opcode_Mul, Opcode_Pow, Opcode_Div, Opcode_Rem, Opcode_Eq,
+			Opcode_Lt, Opcode_Gt, Opcode_Le, Opcode_Ge, Opcode_Shl, Opcode_Shr, Opcode_BitOr,
+			Opcode_BitAnd, Opcode_BitXor, Opcode_Index, Opcode_Into_Range:
+			apply(2, 1)
+		case Opcode_Eq_PopOnce:
+			// Only the right-hand side is popped.
+			apply(2, 2)
+		case Opcode_IteratorAdvance:
+			apply(1, 2)
+		case Opcode_Spawn:
+			if count, ok := argc(); ok {
+				apply(count+1, 1)
+			} else {
+				state.known = false
+			}
+		case Opcode_HostCall:
+			if count, ok := argc(); ok {
+				apply(count+1, 1)
+			} else {
+				state.known = false
+			}
+		case Opcode_Call_Val:
+			// Depending on the callee, the result may or may not be pushed.
+			if count, ok := argc(); ok {
+				apply(count+2, 0)
+			}
+			state.known = false
+		case Opcode_Call_Imm:
+			callee := self.summaries[inst.(OneStringInstruction).Value]
+			apply(callee.params, callee.returns)
+			if !callee.known {
+				state.known = false
+			}
+		case Opcode_SetTryLabel:
+			inst := inst.(OneIntOneStringInstruction)
+			state.tryDepth++
+			if inst.ValueString == function {
+				// When an exception is caught, the stack may contain arbitrary intermediate values.
+				enter(ip, int(inst.ValueInt), verifierState{visited: true, known: false, depth: 0, tryDepth: state.tryDepth})
+			}
+		case Opcode_PopTryLabel:
+			if state.tryDepth == 0 {
+				fail(ip, "`%s` without a matching `%s`", Opcode_PopTryLabel, Opcode_SetTryLabel)
+			} else {
+				state.tryDepth--
+			}
+		case Opcode_Jump:
+			successors = []int{int(inst.(OneIntInstruction).Value)}
+		case Opcode_JumpIfFalse:
+			apply(1, 0)
+			successors = append(successors, int(inst.(OneIntInstruction).Value))
+		case Opcode_Throw:
+			apply(1, 0)
+			successors = nil
+		case Opcode_Return:
+			successors = nil
+		default:
+			panic("A new opcode was added without updating this code")
+		}
+
+		// Running past the end of the function also returns.
+		if len(successors) == 0 && inst.Opcode() == Opcode_Return || slices.Contains(successors, len(instructions)) {
+			if state.known {
+				if returns.known && returns.returns != state.depth {
+					fail(ip, "function returns with stack depth %d, but also with %d at %04d", state.depth, returns.returns, returnIP)
+				}
+				returns.returns = state.depth
+				returns.known = true
+				returnIP = ip
+			}
+		}
+
+		for _, successor := range successors {
+			enter(ip, successor, state)
+		}
+	}
+
+	return returns
+}
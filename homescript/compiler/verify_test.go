@@ -0,0 +1,145 @@
+package compiler
+
+import (
+	"testing"
+
+	"github.com/smarthome-go/homescript/v3/homescript/errors"
+	"github.com/smarthome-go/homescript/v3/homescript/runtime/value"
+	"github.com/stretchr/testify/assert"
+)
+
+func verifierTestProgram(functions map[string][]Instruction) CompileOutput {
+	sourceMap := make(map[string][]errors.Span)
+	for name, instructions := range functions {
+		sourceMap[name] = make([]errors.Span, len(instructions))
+	}
+
+	return CompileOutput{
+		Functions: functions,
+		SourceMap: sourceMap,
+		Mappings: MangleMappings{
+			Functions:  map[string]string{MainFunctionIdent: "main"},
+			Globals:    map[string]string{},
+			Singletons: map[string]string{},
+		},
+		Annotations: make(ModuleAnnotations),
+	}
+}
+
+func TestVerifier(t *testing.T) {
+	add := []Instruction{
+		newOneIntInstruction(Opcode_AddMempointer, 2),
+		newOneIntInstruction(Opcode_SetVarImm, 0),
+		newOneIntInstruction(Opcode_SetVarImm, 1),
+		newOneIntInstruction(Opcode_GetVarImm, 0),
+		newOneIntInstruction(Opcode_GetVarImm, 1),
+		newPrimitiveInstruction(Opcode_Add),
+		newOneIntInstruction(Opcode_AddMempointer, -2),
+		newPrimitiveInstruction(Opcode_Return),
+	}
+
+	tests := []struct {
+		name      string
+		main      []Instruction
+		wantError string
+	}{
+		{
+			name: "valid",
+			main: []Instruction{
+				newValueInstruction(Opcode_Copy_Push, *value.NewValueInt(1)),
+				newValueInstruction(Opcode_Copy_Push, *value.NewValueInt(2)),
+				newOneStringInstruction(Opcode_Call_Imm, "add"),
+				newOneStringInstruction(Opcode_GetGlobImm, "println"),
+				newValueInstruction(Opcode_Copy_Push, *value.NewValueInt(1)),
+				newPrimitiveInstruction(Opcode_Call_Val),
+				newPrimitiveInstruction(Opcode_Return),
+			},
+			wantError: "",
+		},
+		{
+			name: "jump out of bounds",
+			main: []Instruction{
+				newOneIntInstruction(Opcode_Jump, 42),
+				newPrimitiveInstruction(Opcode_Return),
+			},
+			wantError: "jump target 42 is out of bounds",
+		},
+		{
+			name: "unbalanced branches",
+			main: []Instruction{
+				newValueInstruction(Opcode_Copy_Push, *value.NewValueBool(true)),
+				newOneIntInstruction(Opcode_JumpIfFalse, 3),
+				newValueInstruction(Opcode_Copy_Push, *value.NewValueInt(1)),
+				newPrimitiveInstruction(Opcode_Return),
+			},
+			wantError: "stack depth 1 does not match depth 0 at jump target 3",
+		},
+		{
+			name: "stack underflow",
+			main: []Instruction{
+				newValueInstruction(Opcode_Copy_Push, *value.NewValueInt(1)),
+				newOneStringInstruction(Opcode_Call_Imm, "add"),
+				newPrimitiveInstruction(Opcode_Return),
+			},
+			wantError: "stack underflow",
+		},
+		{
+			name: "unknown function",
+			main: []Instruction{
+				newOneStringInstruction(Opcode_Call_Imm, "foo"),
+				newPrimitiveInstruction(Opcode_Return),
+			},
+			wantError: "call to non-existent function `foo`",
+		},
+		{
+			name: "undeclared global",
+			main: []Instruction{
+				newOneStringInstruction(Opcode_GetGlobImm, "foo"),
+				newPrimitiveInstruction(Opcode_Drop),
+				newPrimitiveInstruction(Opcode_Return),
+			},
+			wantError: "global `foo` is never declared",
+		},
+		{
+			name: "try label which is only set on one path",
+			main: []Instruction{
+				newValueInstruction(Opcode_Copy_Push, *value.NewValueBool(true)),
+				newOneIntInstruction(Opcode_JumpIfFalse, 3),
+				newOneIntOneStringInstruction(Opcode_SetTryLabel, "main", 5),
+				newPrimitiveInstruction(Opcode_PopTryLabel),
+				newPrimitiveInstruction(Opcode_Return),
+				newPrimitiveInstruction(Opcode_PopTryLabel),
+				newPrimitiveInstruction(Opcode_Return),
+			},
+			wantError: "`PopTryLabel` without a matching `SetTryLabel`",
+		},
+		{
+			name: "wrong instruction form",
+			main: []Instruction{
+				newPrimitiveInstruction(Opcode_Jump),
+			},
+			wantError: "opcode `Jump` requires the int form",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			program := verifierTestProgram(map[string][]Instruction{
+				"main": test.main,
+				"add":  add,
+			})
+
+			err := Verify(program, []string{"println"})
+			if test.wantError == "" {
+				assert.NoError(t, err)
+			} else if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), test.wantError)
+			}
+		})
+	}
+
+	// Each instruction requires a span.
+	program := verifierTestProgram(map[string][]Instruction{"main": {newPrimitiveInstruction(Opcode_Return)}})
+	program.SourceMap["main"] = nil
+	assert.ErrorContains(t, Verify(program, nil), "source map contains 0 span(s) for 1 instruction(s)")
+}
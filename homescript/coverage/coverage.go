@@ -0,0 +1,222 @@
+package coverage
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/smarthome-go/homescript/v3/homescript/compiler"
+	"github.com/smarthome-go/homescript/v3/homescript/runtime"
+)
+
+//
+// Coverage reports.
+// Maps the instruction coverage of one or more VM runs to the source lines, functions and branches of each file.
+// As different programs may share modules, the coverage of several runs can be merged into the same report.
+//
+
+type Report struct {
+	Files map[string]*File
+}
+
+type File struct {
+	Filename string
+	// The number of executions of each line which contains code.
+	// Lines which contain several instructions count the most frequently executed one.
+	Lines     map[uint]uint64
+	Functions map[FunctionLocation]uint64
+	Branches  map[BranchLocation]Branch
+}
+
+type FunctionLocation struct {
+	Name string
+	Line uint
+}
+
+// A branch is a conditional jump, it is identified by the position of its condition.
+type BranchLocation struct {
+	Line   uint
+	Column uint
+}
+
+type Branch struct {
+	// The number of times the condition was true.
+	True uint64
+	// The number of times the condition was false.
+	False uint64
+}
+
+func NewReport() Report {
+	return Report{
+		Files: make(map[string]*File),
+	}
+}
+
+// Adds the coverage of a VM run to the report.
+// Initialization code is skipped, as it is never covered by `VM.StartCoverage`.
+func (self *Report) Add(program compiler.CompileOutput, coverage runtime.Coverage) {
+	// Lines are counted per run, otherwise, lines with several instructions would be counted several times.
+	lines := make(map[string]map[uint]uint64)
+
+	for function, spans := range program.SourceMap {
+		hits := coverage.Hits[function]
+		if len(spans) == 0 || len(hits) != len(spans) || strings.HasSuffix(function, "_"+compiler.InitFunctionIdent) {
+			continue
+		}
+
+		filename := spans[0].Filename
+		if filename == "" {
+			continue
+		}
+
+		// The first instruction does not necessarily belong to the signature of the function.
+		line := spans[0].Start.Line
+		for _, span := range spans {
+			if span.Start.Line < line {
+				line = span.Start.Line
+			}
+		}
+
+		file := self.file(filename)
+		file.Functions[FunctionLocation{Name: functionName(function, filename), Line: line}] += hits[0]
+
+		if lines[filename] == nil {
+			lines[filename] = make(map[uint]uint64)
+		}
+
+		for ip, span := range spans {
+			if hits[ip] > lines[filename][span.Start.Line] || lines[filename][span.Start.Line] == 0 {
+				lines[filename][span.Start.Line] = hits[ip]
+			}
+
+			if program.Functions[function][ip].Opcode() != compiler.Opcode_JumpIfFalse {
+				continue
+			}
+
+			location := BranchLocation{Line: span.Start.Line, Column: span.Start.Column}
+			branch := file.Branches[location]
+			branch.False += coverage.Taken[function][ip]
+			branch.True += hits[ip] - coverage.Taken[function][ip]
+			file.Branches[location] = branch
+		}
+	}
+
+	for filename, fileLines := range lines {
+		file := self.file(filename)
+		for line, hits := range fileLines {
+			file.Lines[line] += hits
+		}
+	}
+}
+
+func (self *Report) file(filename string) *File {
+	file, found := self.Files[filename]
+	if !found {
+		file = &File{
+			Filename:  filename,
+			Lines:     make(map[uint]uint64),
+			Functions: make(map[FunctionLocation]uint64),
+			Branches:  make(map[BranchLocation]Branch),
+		}
+		self.Files[filename] = file
+	}
+
+	return file
+}
+
+// Returns the files of the report, sorted by their name.
+func (self Report) SortedFiles() []*File {
+	files := make([]*File, 0, len(self.Files))
+	for _, file := range self.Files {
+		files = append(files, file)
+	}
+
+	sort.Slice(files, func(i, j int) bool { return files[i].Filename < files[j].Filename })
+
+	return files
+}
+
+// Returns the number of covered lines and the number of lines which contain code.
+func (self File) LineCoverage() (covered int, total int) {
+	for _, hits := range self.Lines {
+		if hits > 0 {
+			covered++
+		}
+	}
+
+	return covered, len(self.Lines)
+}
+
+// Returns the number of branches which were taken and the number of branches.
+// Each conditional jump counts as two branches.
+func (self File) BranchCoverage() (covered int, total int) {
+	for _, branch := range self.Branches {
+		if branch.True > 0 {
+			covered++
+		}
+		if branch.False > 0 {
+			covered++
+		}
+	}
+
+	return covered, len(self.Branches) * 2
+}
+
+// Returns the number of functions which were called and the number of functions.
+func (self File) FunctionCoverage() (covered int, total int) {
+	for _, hits := range self.Functions {
+		if hits > 0 {
+			covered++
+		}
+	}
+
+	return covered, len(self.Functions)
+}
+
+// Returns the lines which contain code, in ascending order.
+func (self File) sortedLines() []uint {
+	lines := make([]uint, 0, len(self.Lines))
+	for line := range self.Lines {
+		lines = append(lines, line)
+	}
+
+	sort.Slice(lines, func(i, j int) bool { return lines[i] < lines[j] })
+
+	return lines
+}
+
+func (self File) sortedFunctions() []FunctionLocation {
+	functions := make([]FunctionLocation, 0, len(self.Functions))
+	for function := range self.Functions {
+		functions = append(functions, function)
+	}
+
+	sort.Slice(functions, func(i, j int) bool {
+		if functions[i].Line != functions[j].Line {
+			return functions[i].Line < functions[j].Line
+		}
+		return functions[i].Name < functions[j].Name
+	})
+
+	return functions
+}
+
+func (self File) sortedBranches() []BranchLocation {
+	branches := make([]BranchLocation, 0, len(self.Branches))
+	for branch := range self.Branches {
+		branches = append(branches, branch)
+	}
+
+	sort.Slice(branches, func(i, j int) bool {
+		if branches[i].Line != branches[j].Line {
+			return branches[i].Line < branches[j].Line
+		}
+		return branches[i].Column < branches[j].Column
+	})
+
+	return branches
+}
+
+// Mangled functions are prefixed with their module, which is named after its file.
+func functionName(mangled string, filename string) string {
+	return strings.TrimPrefix(mangled, "@"+filename+"_")
+}
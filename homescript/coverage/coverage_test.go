@@ -0,0 +1,94 @@
+package coverage
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/smarthome-go/homescript/v3/homescript"
+	"github.com/smarthome-go/homescript/v3/homescript/compiler"
+	"github.com/smarthome-go/homescript/v3/homescript/runtime"
+	"github.com/stretchr/testify/assert"
+)
+
+const testProgram = `fn unused() {
+    println("never");
+}
+
+fn sign(n: int) -> int {
+    if n < 0 {
+        return -1;
+    }
+    1
+}
+
+fn main() {
+    println(sign(3));
+}
+`
+
+func TestReport(t *testing.T) {
+	analyzed, _, syntaxErrors := homescript.Analyze(
+		homescript.InputProgram{
+			ProgramText: testProgram,
+			Filename:    "main",
+		},
+		homescript.TestingAnalyzerScopeAdditions(),
+		homescript.TestingAnalyzerHost{},
+		true,
+	)
+	assert.Empty(t, syntaxErrors)
+
+	compilerStruct := compiler.NewCompiler(analyzed, "main")
+	program, err := compilerStruct.Compile()
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	executor := homescript.TestingVmExecutor{PrintBuf: new(string), PintBufMutex: &sync.Mutex{}}
+	vm := runtime.NewVM(program, executor, &ctx, &cancel, homescript.TestingVmScopeAdditions(), runtime.CoreLimits{
+		CallStackMaxSize: 100,
+		StackMaxSize:     100,
+		MaxMemorySize:    100,
+	})
+
+	vm.StartCoverage()
+	vm.SpawnAsync(runtime.MainFn(), nil, nil, nil)
+	_, interrupt := vm.Wait()
+	assert.Nil(t, interrupt)
+
+	report := NewReport()
+	report.Add(program, vm.StopCoverage())
+
+	file := report.Files["main"]
+	if !assert.NotNil(t, file) {
+		return
+	}
+
+	assert.Equal(t, uint64(0), file.Lines[2])
+	assert.Equal(t, uint64(0), file.Lines[7])
+	assert.Equal(t, uint64(1), file.Lines[9])
+	assert.Equal(t, uint64(0), file.Functions[FunctionLocation{Name: "unused", Line: 1}])
+	assert.Equal(t, uint64(1), file.Functions[FunctionLocation{Name: "sign", Line: 5}])
+
+	// The condition `n < 0` was only ever false.
+	for _, branch := range file.Branches {
+		assert.Equal(t, Branch{True: 0, False: 1}, branch)
+	}
+	covered, total := file.BranchCoverage()
+	assert.Equal(t, 1, covered)
+	assert.Equal(t, 2, total)
+
+	var lcov bytes.Buffer
+	assert.NoError(t, report.WriteLcov(&lcov))
+	assert.Contains(t, lcov.String(), "SF:main\nFN:1,unused\n")
+	assert.Contains(t, lcov.String(), "FNDA:0,unused\n")
+	assert.Contains(t, lcov.String(), "DA:7,0\n")
+
+	var html bytes.Buffer
+	assert.NoError(t, report.WriteHTML(&html, func(string) (string, error) { return testProgram, nil }))
+	assert.Contains(t, html.String(), `<tr class="miss"><td class="number">7</td>`)
+	assert.Contains(t, html.String(), `<tr class="partial"><td class="number">6</td>`)
+}
@@ -0,0 +1,129 @@
+package coverage
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+)
+
+//
+// HTML
+//
+
+var htmlTemplate = template.Must(template.New("coverage").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Coverage</title>
+<style>
+body { font-family: sans-serif; max-width: 80rem; margin: 2rem auto; padding: 0 1rem; }
+table.summary td, table.summary th { padding: 0.2rem 1rem; text-align: left; }
+table.source { border-collapse: collapse; font-family: monospace; width: 100%; }
+table.source td { padding: 0 0.5rem; white-space: pre; }
+td.number, td.hits { color: #888; text-align: right; user-select: none; }
+tr.hit td.source { background: #dfd; }
+tr.miss td.source { background: #fdd; }
+tr.partial td.source { background: #ffd; }
+</style>
+</head>
+<body>
+<h1>Coverage</h1>
+<table class="summary">
+<tr><th>File</th><th>Lines</th><th>Functions</th><th>Branches</th></tr>
+{{- range .}}
+<tr><td><a href="#{{.Filename}}">{{.Filename}}</a></td><td>{{.Lines}}</td><td>{{.Functions}}</td><td>{{.Branches}}</td></tr>
+{{- end}}
+</table>
+{{- range .}}
+<h2 id="{{.Filename}}">{{.Filename}}</h2>
+{{- if .Missing}}
+<p>The source code of this file is not available.</p>
+{{- else}}
+<table class="source">
+{{- range .Source}}
+<tr class="{{.Class}}"><td class="number">{{.Number}}</td><td class="hits">{{.Hits}}</td><td class="source">{{.Text}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- end}}
+</body>
+</html>
+`))
+
+type htmlFile struct {
+	Filename  string
+	Lines     string
+	Functions string
+	Branches  string
+	Missing   bool
+	Source    []htmlLine
+}
+
+type htmlLine struct {
+	Number uint
+	Hits   string
+	Text   string
+	// Either empty, `hit`, `miss`, or `partial` if a branch on the line was never taken.
+	Class string
+}
+
+// Writes the report as a single HTML page which contains the annotated source code of each file.
+// Files whose source code cannot be read are only listed in the summary.
+func (self Report) WriteHTML(writer io.Writer, readSource func(filename string) (string, error)) error {
+	files := make([]htmlFile, 0, len(self.Files))
+
+	for _, file := range self.SortedFiles() {
+		rendered := htmlFile{
+			Filename:  file.Filename,
+			Lines:     percentage(file.LineCoverage()),
+			Functions: percentage(file.FunctionCoverage()),
+			Branches:  percentage(file.BranchCoverage()),
+		}
+
+		source, err := readSource(file.Filename)
+		if err != nil {
+			rendered.Missing = true
+			files = append(files, rendered)
+			continue
+		}
+
+		partial := make(map[uint]bool)
+		for location, branch := range file.Branches {
+			if branch.True == 0 || branch.False == 0 {
+				partial[location.Line] = true
+			}
+		}
+
+		for idx, text := range strings.Split(source, "\n") {
+			line := htmlLine{Number: uint(idx + 1), Text: text}
+
+			if hits, hasCode := file.Lines[line.Number]; hasCode {
+				line.Hits = fmt.Sprint(hits)
+
+				switch {
+				case hits == 0:
+					line.Class = "miss"
+				case partial[line.Number]:
+					line.Class = "partial"
+				default:
+					line.Class = "hit"
+				}
+			}
+
+			rendered.Source = append(rendered.Source, line)
+		}
+
+		files = append(files, rendered)
+	}
+
+	return htmlTemplate.Execute(writer, files)
+}
+
+func percentage(covered int, total int) string {
+	if total == 0 {
+		return "-"
+	}
+
+	return fmt.Sprintf("%d/%d (%.1f%%)", covered, total, float64(covered)/float64(total)*100)
+}
@@ -0,0 +1,50 @@
+package coverage
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+)
+
+// Writes the report in the LCOV tracefile format, which is understood by `genhtml` and most CI services.
+func (self Report) WriteLcov(writer io.Writer) error {
+	output := bufio.NewWriter(writer)
+
+	for _, file := range self.SortedFiles() {
+		fmt.Fprintf(output, "TN:\nSF:%s\n", file.Filename)
+
+		functions := file.sortedFunctions()
+		for _, function := range functions {
+			fmt.Fprintf(output, "FN:%d,%s\n", function.Line, function.Name)
+		}
+		for _, function := range functions {
+			fmt.Fprintf(output, "FNDA:%d,%s\n", file.Functions[function], function.Name)
+		}
+		covered, total := file.FunctionCoverage()
+		fmt.Fprintf(output, "FNF:%d\nFNH:%d\n", total, covered)
+
+		for block, location := range file.sortedBranches() {
+			branch := file.Branches[location]
+			fmt.Fprintf(output, "BRDA:%d,%d,0,%s\n", location.Line, block, lcovBranchCount(branch, branch.True))
+			fmt.Fprintf(output, "BRDA:%d,%d,1,%s\n", location.Line, block, lcovBranchCount(branch, branch.False))
+		}
+		covered, total = file.BranchCoverage()
+		fmt.Fprintf(output, "BRF:%d\nBRH:%d\n", total, covered)
+
+		for _, line := range file.sortedLines() {
+			fmt.Fprintf(output, "DA:%d,%d\n", line, file.Lines[line])
+		}
+		covered, total = file.LineCoverage()
+		fmt.Fprintf(output, "LF:%d\nLH:%d\nend_of_record\n", total, covered)
+	}
+
+	return output.Flush()
+}
+
+// Branches whose condition was never evaluated are marked with `-`.
+func lcovBranchCount(branch Branch, count uint64) string {
+	if branch.True+branch.False == 0 {
+		return "-"
+	}
+	return fmt.Sprint(count)
+}
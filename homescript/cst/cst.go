@@ -0,0 +1,182 @@
+package cst
+
+import (
+	"strings"
+
+	"github.com/smarthome-go/homescript/v3/homescript/errors"
+	"github.com/smarthome-go/homescript/v3/homescript/lexer"
+)
+
+//
+// Concrete syntax tree.
+// Unlike the AST, this tree is lossless: it keeps every token in its original spelling,
+// together with all comments, whitespace and blank lines (trivia).
+// Therefore, it reproduces the exact source code it was created from.
+//
+// The tree is intentionally shallow: the program consists of top-level items,
+// each item consists of tokens and delimited groups, and groups may be nested.
+// It is meant for tools which operate on source text, such as formatters, refactoring tools and doc generators.
+// Tools which require semantic information should use the AST instead.
+//
+
+type Element interface {
+	Span() errors.Span
+	// Writes the exact source text of this element, including trivia.
+	write(builder *strings.Builder)
+}
+
+//
+// Token
+//
+
+type Token struct {
+	Kind lexer.TokenKind
+	// Is the source text of this token, for instance, string literals still contain their quotes.
+	Text  string
+	Range errors.Span
+	// Is the trivia in front of this token which is not on the line of the previous token.
+	Leading []lexer.Trivia
+	// Is the trivia after this token on the same line, for instance, a trailing comment.
+	Trailing []lexer.Trivia
+	// Contains the lines of the doc comments in front of this token, see `lexer.Token`.
+	DocComment []string
+}
+
+func (self *Token) Span() errors.Span { return self.Range }
+
+func (self *Token) write(builder *strings.Builder) {
+	for _, trivia := range self.Leading {
+		builder.WriteString(trivia.Text)
+	}
+
+	builder.WriteString(self.Text)
+
+	for _, trivia := range self.Trailing {
+		builder.WriteString(trivia.Text)
+	}
+}
+
+// Returns the comments in front of this token.
+func (self *Token) LeadingComments() []lexer.Trivia {
+	comments := make([]lexer.Trivia, 0)
+	for _, trivia := range self.Leading {
+		if trivia.Kind == lexer.LineCommentTrivia || trivia.Kind == lexer.BlockCommentTrivia {
+			comments = append(comments, trivia)
+		}
+	}
+	return comments
+}
+
+// Returns the number of line feeds in front of this token.
+func (self *Token) LeadingNewlines() int {
+	count := 0
+	for _, trivia := range self.Leading {
+		if trivia.Kind == lexer.NewlineTrivia {
+			count++
+		}
+	}
+	return count
+}
+
+//
+// Node
+//
+
+type NodeKind uint8
+
+const (
+	// The root of the tree, its last child is the EOF token.
+	ProgramNodeKind NodeKind = iota
+	// Top-level items.
+	ImportNodeKind
+	SingletonNodeKind
+	TypeDefinitionNodeKind
+	GlobalNodeKind
+	FunctionNodeKind
+	ImplNodeKind
+	// A top-level item which does not start with a known keyword.
+	UnknownItemNodeKind
+	// Tokens which are enclosed by `()`, `[]`, or `{}`.
+	// The first child is the opening and the last child is the closing delimiter.
+	// If the group is never closed, the closing delimiter is missing.
+	GroupNodeKind
+)
+
+func (self NodeKind) String() string {
+	switch self {
+	case ProgramNodeKind:
+		return "Program"
+	case ImportNodeKind:
+		return "Import"
+	case SingletonNodeKind:
+		return "Singleton"
+	case TypeDefinitionNodeKind:
+		return "TypeDefinition"
+	case GlobalNodeKind:
+		return "Global"
+	case FunctionNodeKind:
+		return "Function"
+	case ImplNodeKind:
+		return "Impl"
+	case UnknownItemNodeKind:
+		return "UnknownItem"
+	case GroupNodeKind:
+		return "Group"
+	default:
+		panic("A new node kind was added without updating this code")
+	}
+}
+
+type Node struct {
+	Kind     NodeKind
+	Children []Element
+}
+
+func (self *Node) Span() errors.Span {
+	tokens := self.Tokens()
+	if len(tokens) == 0 {
+		return errors.Span{}
+	}
+
+	return tokens[0].Range.Start.Until(tokens[len(tokens)-1].Range.End, tokens[0].Range.Filename)
+}
+
+func (self *Node) write(builder *strings.Builder) {
+	for _, child := range self.Children {
+		child.write(builder)
+	}
+}
+
+// Returns the exact source text of this node, including trivia.
+func (self *Node) Source() string {
+	builder := strings.Builder{}
+	self.write(&builder)
+	return builder.String()
+}
+
+// Returns all tokens of this node in source order.
+func (self *Node) Tokens() []*Token {
+	tokens := make([]*Token, 0)
+
+	for _, child := range self.Children {
+		switch child := child.(type) {
+		case *Token:
+			tokens = append(tokens, child)
+		case *Node:
+			tokens = append(tokens, child.Tokens()...)
+		default:
+			panic("A new element type was added without updating this code")
+		}
+	}
+
+	return tokens
+}
+
+// Returns the first token of this node, which carries the leading trivia of the node, for instance its doc comments.
+func (self *Node) FirstToken() *Token {
+	tokens := self.Tokens()
+	if len(tokens) == 0 {
+		return nil
+	}
+	return tokens[0]
+}
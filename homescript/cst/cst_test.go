@@ -0,0 +1,86 @@
+package cst
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/smarthome-go/homescript/v3/homescript/lexer"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLossless(t *testing.T) {
+	files := make([]string, 0)
+	for _, pattern := range []string{"../../examples/*.hms", "../../tests/*.hms"} {
+		matches, err := filepath.Glob(pattern)
+		assert.NoError(t, err)
+		files = append(files, matches...)
+	}
+
+	for _, file := range files {
+		program, err := os.ReadFile(file)
+		assert.NoError(t, err)
+
+		tree, _, lexErr := Parse(string(program), file)
+		// Some test files contain deliberately illegal characters.
+		if lexErr != nil {
+			continue
+		}
+
+		assert.Equal(t, string(program), tree.Source(), file)
+	}
+}
+
+const testProgram = `import { http } from net; // Networking.
+
+/* The entry point. */
+// Prints a greeting.
+fn main() {
+    println("hi" /* inline */);
+}
+
+let x = (1
+`
+
+func TestTrivia(t *testing.T) {
+	tree, errors, lexErr := Parse(testProgram, "test")
+	if !assert.Nil(t, lexErr) {
+		t.FailNow()
+	}
+
+	assert.Equal(t, testProgram, tree.Source())
+	assert.Len(t, errors, 1)
+
+	kinds := make([]NodeKind, 0)
+	for _, child := range tree.Children {
+		if node, isNode := child.(*Node); isNode {
+			kinds = append(kinds, node.Kind)
+		}
+	}
+	assert.Equal(t, []NodeKind{ImportNodeKind, FunctionNodeKind, GlobalNodeKind}, kinds)
+
+	// The trailing comment belongs to the last token of the import.
+	importTokens := tree.Children[0].(*Node).Tokens()
+	trailing := importTokens[len(importTokens)-1].Trailing
+	assert.Equal(t, lexer.LineCommentTrivia, trailing[len(trailing)-1].Kind)
+	assert.Equal(t, "// Networking.", trailing[len(trailing)-1].Text)
+
+	// Comments in front of the function are leading trivia of its first token.
+	fn := tree.Children[1].(*Node).FirstToken()
+	assert.Equal(t, lexer.Fn, fn.Kind)
+	assert.Equal(t, 4, fn.LeadingNewlines())
+	comments := fn.LeadingComments()
+	if assert.Len(t, comments, 2) {
+		assert.Equal(t, "/* The entry point. */", comments[0].Text)
+		assert.Equal(t, "// Prints a greeting.", comments[1].Text)
+	}
+}
+
+func TestDocComment(t *testing.T) {
+	tree, _, lexErr := Parse("/// The entry point.\nfn main() {}\n", "test")
+	if !assert.Nil(t, lexErr) {
+		t.FailNow()
+	}
+
+	assert.Equal(t, []string{"The entry point."}, tree.FirstToken().DocComment)
+}
@@ -0,0 +1,222 @@
+package cst
+
+import (
+	"fmt"
+
+	"github.com/smarthome-go/homescript/v3/homescript/errors"
+	"github.com/smarthome-go/homescript/v3/homescript/lexer"
+)
+
+// Creates the concrete syntax tree of the given program.
+// Unbalanced delimiters are reported as non-critical errors, the tree still contains every token.
+// A critical error is only returned if the program cannot be tokenized.
+func Parse(program string, filename string) (*Node, []errors.Error, *errors.Error) {
+	tokens, err := tokenize(program, filename)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	builder := builder{
+		tokens: tokens,
+		pos:    0,
+		errors: make([]errors.Error, 0),
+	}
+
+	return builder.program(), builder.errors, nil
+}
+
+func tokenize(program string, filename string) ([]*Token, *errors.Error) {
+	lex := lexer.NewLosslessLexer(program, filename)
+	tokens := make([]*Token, 0)
+
+	for {
+		token, err := lex.NextToken()
+		if err != nil {
+			return nil, err
+		}
+
+		trivia, source := lex.LastTokenSource()
+		leading := trivia
+
+		// Trivia on the line of the previous token belongs to that token.
+		if len(tokens) > 0 {
+			newline := len(trivia)
+			for idx, item := range trivia {
+				if item.Kind == lexer.NewlineTrivia {
+					newline = idx
+					break
+				}
+			}
+
+			tokens[len(tokens)-1].Trailing = trivia[:newline]
+			leading = trivia[newline:]
+		}
+
+		tokens = append(tokens, &Token{
+			Kind:       token.Kind,
+			Text:       source,
+			Range:      token.Span,
+			Leading:    leading,
+			Trailing:   make([]lexer.Trivia, 0),
+			DocComment: token.DocComment,
+		})
+
+		if token.Kind == lexer.EOF {
+			return tokens, nil
+		}
+	}
+}
+
+//
+// Tree builder
+//
+
+type builder struct {
+	tokens []*Token
+	pos    int
+	errors []errors.Error
+}
+
+func (self *builder) peek() *Token {
+	return self.tokens[self.pos]
+}
+
+func (self *builder) next() *Token {
+	token := self.tokens[self.pos]
+	// The EOF token is never consumed by the item loop, however, this guards against reading past it.
+	if token.Kind != lexer.EOF {
+		self.pos++
+	}
+	return token
+}
+
+func (self *builder) error(span errors.Span, message string) {
+	self.errors = append(self.errors, *errors.NewSyntaxError(span, errors.UnbalancedDelimiter, message))
+}
+
+func (self *builder) program() *Node {
+	program := &Node{
+		Kind:     ProgramNodeKind,
+		Children: make([]Element, 0),
+	}
+
+	for self.peek().Kind != lexer.EOF {
+		program.Children = append(program.Children, self.item())
+	}
+
+	program.Children = append(program.Children, self.next())
+	return program
+}
+
+// Reports whether the token kind can start a top-level item.
+func isItemStart(kind lexer.TokenKind) bool {
+	switch kind {
+	case lexer.Import, lexer.SINGLETON_TOKEN, lexer.FN_ANNOTATION_TOKEN, lexer.Impl,
+		lexer.Event, lexer.Pub, lexer.Type, lexer.Let, lexer.Fn, lexer.EOF:
+		return true
+	default:
+		return false
+	}
+}
+
+func (self *builder) itemKind() NodeKind {
+	pos := self.pos
+
+	// Skip modifiers.
+	for self.tokens[pos].Kind == lexer.Pub || self.tokens[pos].Kind == lexer.Event {
+		pos++
+	}
+
+	switch self.tokens[pos].Kind {
+	case lexer.Import:
+		return ImportNodeKind
+	case lexer.SINGLETON_TOKEN:
+		return SingletonNodeKind
+	case lexer.Type:
+		return TypeDefinitionNodeKind
+	case lexer.Let:
+		return GlobalNodeKind
+	case lexer.Fn, lexer.FN_ANNOTATION_TOKEN:
+		return FunctionNodeKind
+	case lexer.Impl:
+		return ImplNodeKind
+	default:
+		return UnknownItemNodeKind
+	}
+}
+
+// An item ends with a `;` or with a `{}` group which is followed by the start of another item.
+// The latter is required as the return type of a function can also be enclosed by `{}`.
+func (self *builder) item() *Node {
+	item := &Node{
+		Kind:     self.itemKind(),
+		Children: make([]Element, 0),
+	}
+
+	for self.peek().Kind != lexer.EOF {
+		element := self.element()
+		item.Children = append(item.Children, element)
+
+		switch element := element.(type) {
+		case *Token:
+			if element.Kind == lexer.Semicolon {
+				return item
+			}
+		case *Node:
+			if element.FirstToken().Kind == lexer.LCurly && isItemStart(self.peek().Kind) {
+				return item
+			}
+		}
+	}
+
+	return item
+}
+
+func closingDelimiter(kind lexer.TokenKind) (lexer.TokenKind, bool) {
+	switch kind {
+	case lexer.LParen:
+		return lexer.RParen, true
+	case lexer.LBracket:
+		return lexer.RBracket, true
+	case lexer.LCurly:
+		return lexer.RCurly, true
+	default:
+		return lexer.Unknown, false
+	}
+}
+
+func isClosingDelimiter(kind lexer.TokenKind) bool {
+	return kind == lexer.RParen || kind == lexer.RBracket || kind == lexer.RCurly
+}
+
+func (self *builder) element() Element {
+	token := self.next()
+
+	closing, isOpening := closingDelimiter(token.Kind)
+	if !isOpening {
+		if isClosingDelimiter(token.Kind) {
+			self.error(token.Range, fmt.Sprintf("Unexpected '%s'", token.Text))
+		}
+		return token
+	}
+
+	group := &Node{
+		Kind:     GroupNodeKind,
+		Children: []Element{token},
+	}
+
+	for {
+		current := self.peek()
+
+		switch {
+		case current.Kind == lexer.EOF:
+			self.error(token.Range, fmt.Sprintf("'%s' is never closed", token.Text))
+			return group
+		case current.Kind == closing:
+			group.Children = append(group.Children, self.next())
+			return group
+		default:
+			group.Children = append(group.Children, self.element())
+		}
+	}
+}
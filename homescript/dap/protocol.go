@@ -0,0 +1,157 @@
+package dap
+
+//
+// The subset of the Debug Adapter Protocol which is implemented by the server.
+// Lines and columns are always one-based.
+//
+
+type Source struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+}
+
+//
+// Lifecycle.
+//
+
+type Capabilities struct {
+	SupportsConfigurationDoneRequest bool `json:"supportsConfigurationDoneRequest"`
+	SupportsTerminateRequest         bool `json:"supportsTerminateRequest"`
+}
+
+type LaunchArguments struct {
+	// Path of the Homescript file to debug.
+	Program     string `json:"program"`
+	StopOnEntry bool   `json:"stopOnEntry"`
+}
+
+//
+// Breakpoints.
+//
+
+type SetBreakpointsArguments struct {
+	Source      Source             `json:"source"`
+	Breakpoints []SourceBreakpoint `json:"breakpoints"`
+}
+
+type SourceBreakpoint struct {
+	Line uint `json:"line"`
+}
+
+type SetBreakpointsResponse struct {
+	Breakpoints []Breakpoint `json:"breakpoints"`
+}
+
+type Breakpoint struct {
+	Verified bool    `json:"verified"`
+	Line     uint    `json:"line"`
+	Source   *Source `json:"source,omitempty"`
+	Message  string  `json:"message,omitempty"`
+}
+
+//
+// Threads and stack frames.
+// Each core of the VM is represented as a thread.
+//
+
+type Thread struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+type ThreadsResponse struct {
+	Threads []Thread `json:"threads"`
+}
+
+type StackTraceArguments struct {
+	ThreadID   int `json:"threadId"`
+	StartFrame int `json:"startFrame"`
+	// Zero means that all frames are requested.
+	Levels int `json:"levels"`
+}
+
+type StackFrame struct {
+	ID        int     `json:"id"`
+	Name      string  `json:"name"`
+	Source    *Source `json:"source,omitempty"`
+	Line      uint    `json:"line"`
+	Column    uint    `json:"column"`
+	EndLine   uint    `json:"endLine"`
+	EndColumn uint    `json:"endColumn"`
+}
+
+type StackTraceResponse struct {
+	StackFrames []StackFrame `json:"stackFrames"`
+	TotalFrames int          `json:"totalFrames"`
+}
+
+//
+// Variables.
+//
+
+type ScopesArguments struct {
+	FrameID int `json:"frameId"`
+}
+
+type Scope struct {
+	Name               string `json:"name"`
+	VariablesReference int    `json:"variablesReference"`
+	Expensive          bool   `json:"expensive"`
+}
+
+type ScopesResponse struct {
+	Scopes []Scope `json:"scopes"`
+}
+
+type VariablesArguments struct {
+	VariablesReference int `json:"variablesReference"`
+}
+
+type Variable struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+	Type  string `json:"type"`
+	// If this is not zero, the variable has children which can be requested using this reference.
+	VariablesReference int `json:"variablesReference"`
+}
+
+type VariablesResponse struct {
+	Variables []Variable `json:"variables"`
+}
+
+//
+// Execution control.
+//
+
+// Used by the `continue`, `next`, `stepIn`, `stepOut`, and `pause` requests.
+type ThreadArguments struct {
+	ThreadID int `json:"threadId"`
+}
+
+type ContinueResponse struct {
+	AllThreadsContinued bool `json:"allThreadsContinued"`
+}
+
+//
+// Events.
+//
+
+type StoppedEvent struct {
+	Reason            string `json:"reason"`
+	ThreadID          int    `json:"threadId"`
+	AllThreadsStopped bool   `json:"allThreadsStopped"`
+}
+
+type ThreadEvent struct {
+	Reason   string `json:"reason"`
+	ThreadID int    `json:"threadId"`
+}
+
+type OutputEvent struct {
+	Category string `json:"category"`
+	Output   string `json:"output"`
+}
+
+type ExitedEvent struct {
+	ExitCode int `json:"exitCode"`
+}
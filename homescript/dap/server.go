@@ -0,0 +1,760 @@
+package dap
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/smarthome-go/homescript/v3/homescript/compiler"
+	"github.com/smarthome-go/homescript/v3/homescript/runtime"
+	"github.com/smarthome-go/homescript/v3/homescript/runtime/value"
+)
+
+//
+// Debug adapter.
+// Speaks DAP over a stream, usually stdin and stdout of the process.
+// Requests are handled sequentially, whereas the cores of the VM report their progress concurrently.
+// Each core is represented as a thread which can be stopped and resumed independently of the other cores.
+//
+
+// Stack frames are identified by their thread and their depth in the call stack.
+const frameIDThreadShift = 16
+
+type stepMode uint8
+
+const (
+	stepModeNone stepMode = iota
+	stepModeIn
+	stepModeOver
+	stepModeOut
+)
+
+type location struct {
+	path string
+	line uint
+}
+
+type thread struct {
+	id   int
+	core *runtime.Core
+	// The location and call stack depth of the previous instruction.
+	// A breakpoint is only hit when its line is entered, not for every instruction on that line.
+	location location
+	depth    int
+	// Reasons to stop before the next instruction.
+	stopOnEntry bool
+	pause       bool
+	step        stepMode
+	// The location and depth at which the current step started.
+	stepLocation location
+	stepDepth    int
+	// Is set while the core is stopped, closing it resumes the core.
+	resume chan struct{}
+}
+
+type Server struct {
+	compile        func(path string) (compiler.CompileOutput, error)
+	newExecutor    func(output io.Writer) value.Executor
+	scopeAdditions func() map[string]value.Value
+	limits         runtime.CoreLimits
+
+	conn *conn
+	// Actions which must happen after the response to the current request has been sent.
+	afterReply []func()
+
+	vm     *runtime.VM
+	cancel context.CancelFunc
+	// Lines which contain at least one instruction, by source path.
+	lines map[string]map[uint]bool
+	// Maps mangled identifiers of the entry module back to their source identifiers.
+	functionNames map[string]string
+	globalNames   map[string]string
+
+	// Protects all fields below as they are also accessed by the cores.
+	lock        sync.Mutex
+	breakpoints map[string]map[uint]bool
+	threads     map[int]*thread
+	// The first core which is spawned is the main core.
+	stopMainOnEntry bool
+	// Variable references are indices into this list, offset by one.
+	// The list is cleared once no thread is stopped anymore.
+	handles    []func() []Variable
+	terminated bool
+}
+
+func NewServer(
+	compile func(path string) (compiler.CompileOutput, error),
+	newExecutor func(output io.Writer) value.Executor,
+	scopeAdditions func() map[string]value.Value,
+	limits runtime.CoreLimits,
+) *Server {
+	return &Server{
+		compile:        compile,
+		newExecutor:    newExecutor,
+		scopeAdditions: scopeAdditions,
+		limits:         limits,
+		conn:           nil,
+		afterReply:     make([]func(), 0),
+		vm:             nil,
+		cancel:         nil,
+		lines:          make(map[string]map[uint]bool),
+		functionNames:  make(map[string]string),
+		globalNames:    make(map[string]string),
+		breakpoints:    make(map[string]map[uint]bool),
+		threads:        make(map[int]*thread),
+		handles:        make([]func() []Variable, 0),
+	}
+}
+
+// Serves requests until the client disconnects or closes the stream.
+func (self *Server) Serve(in io.Reader, out io.Writer) error {
+	self.conn = newConn(in, out)
+	defer self.shutdown()
+
+	for {
+		req, err := self.conn.read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		body, handlerErr := self.handle(req)
+		if err := self.conn.reply(req, body, handlerErr); err != nil {
+			return err
+		}
+
+		for _, action := range self.afterReply {
+			action()
+		}
+		self.afterReply = self.afterReply[:0]
+
+		if req.Command == "disconnect" {
+			return nil
+		}
+	}
+}
+
+func decode(arguments json.RawMessage, target any) error {
+	if len(arguments) == 0 {
+		return nil
+	}
+	return json.Unmarshal(arguments, target)
+}
+
+func (self *Server) handle(req request) (any, error) {
+	switch req.Command {
+	case "initialize":
+		return Capabilities{
+			SupportsConfigurationDoneRequest: true,
+			SupportsTerminateRequest:         true,
+		}, nil
+	case "launch":
+		var args LaunchArguments
+		if err := decode(req.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return nil, self.launch(args)
+	case "setBreakpoints":
+		var args SetBreakpointsArguments
+		if err := decode(req.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return self.setBreakpoints(args), nil
+	case "configurationDone":
+		return nil, self.start()
+	case "threads":
+		return self.listThreads(), nil
+	case "stackTrace":
+		var args StackTraceArguments
+		if err := decode(req.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return self.stackTrace(args)
+	case "scopes":
+		var args ScopesArguments
+		if err := decode(req.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return self.scopes(args)
+	case "variables":
+		var args VariablesArguments
+		if err := decode(req.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return self.variables(args)
+	case "continue", "next", "stepIn", "stepOut", "pause":
+		var args ThreadArguments
+		if err := decode(req.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return self.control(req.Command, args.ThreadID)
+	case "terminate", "disconnect":
+		self.shutdown()
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("Unsupported request `%s`", req.Command)
+	}
+}
+
+//
+// Lifecycle.
+//
+
+// Compiles the program and creates the VM.
+// The main function is only spawned once the client has sent its configuration.
+func (self *Server) launch(args LaunchArguments) error {
+	if self.vm != nil {
+		return errors.New("A program has already been launched")
+	}
+
+	program, err := self.compile(args.Program)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	vm := runtime.NewVM(program, self.newExecutor(outputWriter{conn: self.conn}), &ctx, &cancel, self.scopeAdditions(), self.limits)
+
+	for _, spans := range program.SourceMap {
+		for _, span := range spans {
+			if span.Start.Line == 0 {
+				continue
+			}
+
+			path := sourcePath(span.Filename)
+			if self.lines[path] == nil {
+				self.lines[path] = make(map[uint]bool)
+			}
+			self.lines[path][span.Start.Line] = true
+		}
+	}
+
+	for source, mangled := range program.Mappings.Functions {
+		self.functionNames[mangled] = source
+	}
+
+	for source, mangled := range program.Mappings.Globals {
+		self.globalNames[mangled] = source
+	}
+
+	self.vm = &vm
+	self.cancel = cancel
+	self.stopMainOnEntry = args.StopOnEntry
+
+	// The client sends its configuration, like breakpoints, once the debuggee is ready.
+	self.afterReply = append(self.afterReply, func() {
+		_ = self.conn.event("initialized", nil)
+	})
+
+	return nil
+}
+
+func (self *Server) start() error {
+	if self.vm == nil {
+		return errors.New("No program has been launched")
+	}
+
+	self.vm.DebugHook = self.onInstruction
+	self.vm.SpawnAsync(runtime.MainFn(), nil, nil, nil)
+
+	go func() {
+		coreNum, interrupt := self.vm.Wait()
+
+		exitCode := 0
+		if interrupt != nil {
+			exitCode = 1
+
+			span := (*interrupt).GetSpan()
+			_ = self.conn.event("output", OutputEvent{
+				Category: "stderr",
+				Output: fmt.Sprintf(
+					"Exception on core %d at %s:%d:%d: %s\n",
+					coreNum,
+					span.Filename,
+					span.Start.Line,
+					span.Start.Column,
+					(*interrupt).Message(),
+				),
+			})
+		}
+
+		// Cores which are still stopped must not block forever.
+		self.shutdown()
+
+		_ = self.conn.event("exited", ExitedEvent{ExitCode: exitCode})
+		_ = self.conn.event("terminated", nil)
+	}()
+
+	return nil
+}
+
+// Resumes all stopped cores and prevents them from stopping again.
+func (self *Server) shutdown() {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	if self.terminated {
+		return
+	}
+	self.terminated = true
+
+	for _, thread := range self.threads {
+		if thread.resume != nil {
+			close(thread.resume)
+			thread.resume = nil
+		}
+	}
+
+	if self.cancel != nil {
+		self.cancel()
+	}
+}
+
+//
+// Breakpoints.
+//
+
+func (self *Server) setBreakpoints(args SetBreakpointsArguments) SetBreakpointsResponse {
+	path := sourcePath(args.Source.Path)
+
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	lines := make(map[uint]bool)
+	breakpoints := make([]Breakpoint, len(args.Breakpoints))
+
+	for idx, requested := range args.Breakpoints {
+		breakpoint := Breakpoint{
+			Verified: self.lines[path][requested.Line],
+			Line:     requested.Line,
+			Source:   &args.Source,
+		}
+
+		if breakpoint.Verified {
+			lines[requested.Line] = true
+		} else {
+			breakpoint.Message = "No code is generated for this line"
+		}
+
+		breakpoints[idx] = breakpoint
+	}
+
+	self.breakpoints[path] = lines
+	return SetBreakpointsResponse{Breakpoints: breakpoints}
+}
+
+//
+// Execution control.
+//
+
+// Is invoked by each core before it executes an instruction.
+// If the core should stop, it is blocked until the client resumes it.
+func (self *Server) onInstruction(core *runtime.Core) {
+	self.lock.Lock()
+
+	if self.terminated {
+		self.lock.Unlock()
+		return
+	}
+
+	id := int(core.Corenum) + 1
+	current, known := self.threads[id]
+	if !known {
+		current = &thread{
+			id:          id,
+			core:        core,
+			stopOnEntry: self.stopMainOnEntry,
+		}
+		self.stopMainOnEntry = false
+		self.threads[id] = current
+	}
+
+	span := core.CurrentSpan()
+	location := location{path: sourcePath(span.Filename), line: span.Start.Line}
+	depth := len(core.CallStack)
+
+	reason := self.stopReason(current, location, depth)
+	current.location = location
+	current.depth = depth
+
+	var resume chan struct{}
+	if reason != "" {
+		current.stopOnEntry = false
+		current.pause = false
+		current.step = stepModeNone
+		resume = make(chan struct{})
+		current.resume = resume
+	}
+
+	self.lock.Unlock()
+
+	if !known {
+		_ = self.conn.event("thread", ThreadEvent{Reason: "started", ThreadID: id})
+	}
+
+	if reason == "" {
+		return
+	}
+
+	_ = self.conn.event("stopped", StoppedEvent{
+		Reason:            reason,
+		ThreadID:          id,
+		AllThreadsStopped: false,
+	})
+
+	<-resume
+}
+
+// Returns the reason why the thread should stop at the given location or an empty string.
+func (self *Server) stopReason(thread *thread, location location, depth int) string {
+	switch {
+	case thread.stopOnEntry:
+		return "entry"
+	case thread.pause:
+		return "pause"
+	case location.line == 0:
+		// Synthetic instructions are never stopped at.
+		return ""
+	case thread.step == stepModeIn && (location != thread.stepLocation || depth != thread.stepDepth):
+		return "step"
+	case thread.step == stepModeOver && (depth < thread.stepDepth || depth == thread.stepDepth && location != thread.stepLocation):
+		return "step"
+	case thread.step == stepModeOut && depth < thread.stepDepth:
+		return "step"
+	case self.breakpoints[location.path][location.line] && (location != thread.location || depth != thread.depth):
+		return "breakpoint"
+	default:
+		return ""
+	}
+}
+
+func (self *Server) control(command string, threadID int) (any, error) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	thread, found := self.threads[threadID]
+	if !found {
+		return nil, fmt.Errorf("Thread %d does not exist", threadID)
+	}
+
+	if command == "pause" {
+		if thread.resume == nil {
+			thread.pause = true
+		}
+		return nil, nil
+	}
+
+	if thread.resume == nil {
+		return nil, fmt.Errorf("Thread %d is not stopped", threadID)
+	}
+
+	switch command {
+	case "next":
+		thread.step = stepModeOver
+	case "stepIn":
+		thread.step = stepModeIn
+	case "stepOut":
+		thread.step = stepModeOut
+	}
+	thread.stepLocation = thread.location
+	thread.stepDepth = thread.depth
+
+	// The core must only continue once the response has been sent.
+	// Otherwise, the client could receive the next `stopped` event first.
+	resume := thread.resume
+	thread.resume = nil
+	self.afterReply = append(self.afterReply, func() { close(resume) })
+
+	if self.stoppedThreads() == 0 {
+		self.handles = self.handles[:0]
+	}
+
+	if command == "continue" {
+		return ContinueResponse{AllThreadsContinued: false}, nil
+	}
+	return nil, nil
+}
+
+func (self *Server) stoppedThreads() int {
+	count := 0
+	for _, thread := range self.threads {
+		if thread.resume != nil {
+			count++
+		}
+	}
+	return count
+}
+
+//
+// Threads and stack frames.
+//
+
+func (self *Server) listThreads() ThreadsResponse {
+	threads := make([]Thread, 0)
+	if self.vm == nil {
+		return ThreadsResponse{Threads: threads}
+	}
+
+	// Cores are removed from the VM once they have terminated.
+	running := make(map[int]bool)
+	self.vm.Cores.Lock.RLock()
+	for _, core := range self.vm.Cores.Cores {
+		running[int(core.Corenum)+1] = true
+	}
+	self.vm.Cores.Lock.RUnlock()
+
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	for id := range self.threads {
+		if running[id] {
+			threads = append(threads, Thread{ID: id, Name: fmt.Sprintf("Core %d", id-1)})
+		}
+	}
+
+	sort.Slice(threads, func(i, j int) bool { return threads[i].ID < threads[j].ID })
+	return ThreadsResponse{Threads: threads}
+}
+
+// Returns a thread which is stopped, only the state of those can be inspected.
+func (self *Server) stoppedThread(threadID int) (*thread, error) {
+	thread, found := self.threads[threadID]
+	if !found {
+		return nil, fmt.Errorf("Thread %d does not exist", threadID)
+	}
+
+	if thread.resume == nil {
+		return nil, fmt.Errorf("Thread %d is not stopped", threadID)
+	}
+
+	return thread, nil
+}
+
+func (self *Server) stackTrace(args StackTraceArguments) (any, error) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	thread, err := self.stoppedThread(args.ThreadID)
+	if err != nil {
+		return nil, err
+	}
+
+	callStack := thread.core.CallStack
+	frames := make([]StackFrame, 0)
+
+	// The innermost frame comes first.
+	for depth := len(callStack) - 1 - args.StartFrame; depth >= 0; depth-- {
+		if args.Levels > 0 && len(frames) >= args.Levels {
+			break
+		}
+
+		frame := callStack[depth]
+
+		// The instruction pointer of a caller already points behind the call.
+		if depth != len(callStack)-1 && frame.InstructionPointer > 0 {
+			frame.InstructionPointer--
+		}
+
+		span := self.vm.SourceMap(frame)
+		path := sourcePath(span.Filename)
+
+		name, found := self.functionNames[frame.Function]
+		if !found {
+			name = frame.Function
+		}
+
+		frames = append(frames, StackFrame{
+			ID:        thread.id<<frameIDThreadShift | depth,
+			Name:      name,
+			Source:    &Source{Name: filepath.Base(path), Path: path},
+			Line:      span.Start.Line,
+			Column:    span.Start.Column,
+			EndLine:   span.End.Line,
+			EndColumn: span.End.Column,
+		})
+	}
+
+	return StackTraceResponse{
+		StackFrames: frames,
+		TotalFrames: len(callStack),
+	}, nil
+}
+
+//
+// Variables.
+//
+
+func (self *Server) scopes(args ScopesArguments) (any, error) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	thread, err := self.stoppedThread(args.FrameID >> frameIDThreadShift)
+	if err != nil {
+		return nil, err
+	}
+
+	depth := args.FrameID & (1<<frameIDThreadShift - 1)
+	if depth >= len(thread.core.CallStack) {
+		return nil, fmt.Errorf("Frame %d does not exist", args.FrameID)
+	}
+
+	return ScopesResponse{Scopes: []Scope{
+		{
+			Name:               "Locals",
+			VariablesReference: self.reference(func() []Variable { return self.locals(thread.core, depth) }),
+			Expensive:          false,
+		},
+		{
+			Name:               "Globals",
+			VariablesReference: self.reference(self.globals),
+			Expensive:          false,
+		},
+	}}, nil
+}
+
+func (self *Server) variables(args VariablesArguments) (any, error) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	if args.VariablesReference < 1 || args.VariablesReference > len(self.handles) {
+		return nil, fmt.Errorf("Variables reference %d does not exist", args.VariablesReference)
+	}
+
+	return VariablesResponse{Variables: self.handles[args.VariablesReference-1]()}, nil
+}
+
+// Registers a function which lists variables and returns its reference.
+// The lock must be held by the caller.
+func (self *Server) reference(variables func() []Variable) int {
+	self.handles = append(self.handles, variables)
+	return len(self.handles)
+}
+
+// Lists the named local variables of a frame.
+func (self *Server) locals(core *runtime.Core, depth int) []Variable {
+	locals := core.Locals(depth)
+	variables := make([]Variable, 0, len(locals))
+
+	for _, local := range locals {
+		variables = append(variables, self.variable(local.Name, local.Value))
+	}
+
+	return variables
+}
+
+// Lists the globals of the VM, except for builtin functions.
+func (self *Server) globals() []Variable {
+	globals := self.vm.GetGlobals()
+
+	names := make([]string, 0, len(globals))
+	for name, val := range globals {
+		if val == nil || val.Kind() == value.BuiltinFunctionValueKind {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	variables := make([]Variable, 0, len(names))
+	for _, name := range names {
+		val := globals[name]
+
+		displayName, found := self.globalNames[name]
+		if !found {
+			displayName = name
+		}
+
+		variables = append(variables, self.variable(displayName, &val))
+	}
+
+	return variables
+}
+
+// Converts a value into a variable.
+// Lists and objects can be expanded, their children are only listed once they are requested.
+func (self *Server) variable(name string, val *value.Value) Variable {
+	if val == nil || *val == nil {
+		return Variable{Name: name, Value: "<uninitialized>", Type: "", VariablesReference: 0}
+	}
+
+	display, interrupt := (*val).Display()
+	if interrupt != nil {
+		display = (*interrupt).Message()
+	}
+
+	variable := Variable{
+		Name:               name,
+		Value:              display,
+		Type:               (*val).Kind().String(),
+		VariablesReference: 0,
+	}
+
+	switch inner := (*val).(type) {
+	case value.ValueList:
+		values := *inner.Values
+		variable.VariablesReference = self.reference(func() []Variable {
+			children := make([]Variable, len(values))
+			for idx, child := range values {
+				children[idx] = self.variable(fmt.Sprintf("[%d]", idx), child)
+			}
+			return children
+		})
+	case value.ValueObject:
+		variable.VariablesReference = self.reference(func() []Variable { return self.fields(inner.FieldsInternal) })
+	case value.ValueAnyObject:
+		variable.VariablesReference = self.reference(func() []Variable { return self.fields(inner.FieldsInternal) })
+	}
+
+	return variable
+}
+
+func (self *Server) fields(fields map[string]*value.Value) []Variable {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	children := make([]Variable, len(names))
+	for idx, name := range names {
+		children[idx] = self.variable(name, fields[name])
+	}
+	return children
+}
+
+//
+// Utilities.
+//
+
+// Returns the absolute path of a source file, as the client refers to sources by their path.
+// Imported modules are referred to by their name, which lacks the file extension.
+func sourcePath(filename string) string {
+	if filepath.Ext(filename) == "" {
+		filename += ".hms"
+	}
+
+	path, err := filepath.Abs(filename)
+	if err != nil {
+		return filename
+	}
+
+	return path
+}
+
+// Forwards the output of the program to the client.
+type outputWriter struct {
+	conn *conn
+}
+
+func (self outputWriter) Write(output []byte) (int, error) {
+	if err := self.conn.event("output", OutputEvent{Category: "stdout", Output: string(output)}); err != nil {
+		return 0, err
+	}
+	return len(output), nil
+}
@@ -0,0 +1,237 @@
+package dap
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/smarthome-go/homescript/v3/homescript"
+	"github.com/smarthome-go/homescript/v3/homescript/compiler"
+	"github.com/smarthome-go/homescript/v3/homescript/diagnostic"
+	"github.com/smarthome-go/homescript/v3/homescript/runtime"
+	"github.com/smarthome-go/homescript/v3/homescript/runtime/value"
+	"github.com/stretchr/testify/assert"
+)
+
+const testProgram = `fn add(a: int, b: int) -> int {
+    a + b
+}
+
+fn main() {
+    let x = add(1, 2);
+    println(x);
+}
+`
+
+type testExecutor struct {
+	homescript.TestingVmExecutor
+	output io.Writer
+}
+
+func (self testExecutor) WriteStringTo(input string) error {
+	_, err := io.WriteString(self.output, input)
+	return err
+}
+
+func testCompile(path string) (compiler.CompileOutput, error) {
+	source, err := os.ReadFile(path)
+	if err != nil {
+		return compiler.CompileOutput{}, err
+	}
+
+	modules, diagnostics, syntaxErrors := homescript.Analyze(
+		homescript.InputProgram{ProgramText: string(source), Filename: path},
+		homescript.TestingAnalyzerScopeAdditions(),
+		homescript.TestingAnalyzerHost{},
+		true,
+	)
+	if len(syntaxErrors) != 0 {
+		return compiler.CompileOutput{}, errors.New(syntaxErrors[0].Message)
+	}
+
+	for _, item := range diagnostics {
+		if item.Level == diagnostic.DiagnosticLevelError {
+			return compiler.CompileOutput{}, errors.New(item.Message)
+		}
+	}
+
+	compilerStruct := compiler.NewCompiler(modules, path)
+	return compilerStruct.Compile()
+}
+
+type testMessage struct {
+	Type    string          `json:"type"`
+	Command string          `json:"command"`
+	Event   string          `json:"event"`
+	Success bool            `json:"success"`
+	Body    json.RawMessage `json:"body"`
+}
+
+type testClient struct {
+	t      *testing.T
+	reader *textproto.Reader
+	writer io.Writer
+	seq    int
+	output string
+}
+
+func (self *testClient) send(command string, arguments any) {
+	self.seq++
+	body, err := json.Marshal(map[string]any{"seq": self.seq, "type": "request", "command": command, "arguments": arguments})
+	assert.NoError(self.t, err)
+
+	_, err = io.WriteString(self.writer, "Content-Length: "+strconv.Itoa(len(body))+"\r\n\r\n"+string(body))
+	assert.NoError(self.t, err)
+}
+
+// Reads messages until the expected response or event arrives and decodes its body.
+// Output is collected and thread events are skipped.
+func (self *testClient) expect(kind string, name string, body any) {
+	for {
+		header, err := self.reader.ReadMIMEHeader()
+		if !assert.NoError(self.t, err) {
+			self.t.FailNow()
+		}
+
+		length, _ := strconv.Atoi(header.Get("Content-Length"))
+		raw := make([]byte, length)
+		_, err = io.ReadFull(self.reader.R, raw)
+		assert.NoError(self.t, err)
+
+		var message testMessage
+		assert.NoError(self.t, json.Unmarshal(raw, &message))
+
+		if message.Event == "output" {
+			var output OutputEvent
+			assert.NoError(self.t, json.Unmarshal(message.Body, &output))
+			self.output += output.Output
+		}
+
+		if message.Type != kind || message.Command+message.Event != name {
+			continue
+		}
+
+		assert.True(self.t, message.Type == "event" || message.Success, "request `%s` failed: %s", name, raw)
+		if body != nil {
+			assert.NoError(self.t, json.Unmarshal(message.Body, body))
+		}
+		return
+	}
+}
+
+func TestServer(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "main.hms")
+	assert.NoError(t, os.WriteFile(path, []byte(testProgram), 0644))
+
+	server := NewServer(
+		testCompile,
+		func(output io.Writer) value.Executor {
+			return testExecutor{
+				TestingVmExecutor: homescript.TestingVmExecutor{PrintBuf: new(string), PintBufMutex: &sync.Mutex{}},
+				output:            output,
+			}
+		},
+		homescript.TestingVmScopeAdditions,
+		runtime.CoreLimits{CallStackMaxSize: 100, StackMaxSize: 100, MaxMemorySize: 100},
+	)
+
+	clientIn, serverOut := io.Pipe()
+	serverIn, clientOut := io.Pipe()
+	done := make(chan error)
+	go func() { done <- server.Serve(serverIn, serverOut) }()
+
+	client := testClient{t: t, reader: textproto.NewReader(bufio.NewReader(clientIn)), writer: clientOut}
+
+	client.send("initialize", map[string]any{"adapterID": "homescript"})
+	client.expect("response", "initialize", nil)
+
+	client.send("launch", LaunchArguments{Program: path})
+	client.expect("response", "launch", nil)
+	client.expect("event", "initialized", nil)
+
+	// Line 4 is empty, therefore, its breakpoint cannot be verified.
+	var breakpoints SetBreakpointsResponse
+	client.send("setBreakpoints", SetBreakpointsArguments{
+		Source:      Source{Path: path},
+		Breakpoints: []SourceBreakpoint{{Line: 4}, {Line: 6}},
+	})
+	client.expect("response", "setBreakpoints", &breakpoints)
+	assert.False(t, breakpoints.Breakpoints[0].Verified)
+	assert.True(t, breakpoints.Breakpoints[1].Verified)
+
+	client.send("configurationDone", nil)
+	client.expect("response", "configurationDone", nil)
+
+	var stopped StoppedEvent
+	client.expect("event", "stopped", &stopped)
+	assert.Equal(t, "breakpoint", stopped.Reason)
+	threadID := stopped.ThreadID
+
+	stackTrace := func() []StackFrame {
+		var trace StackTraceResponse
+		client.send("stackTrace", StackTraceArguments{ThreadID: threadID})
+		client.expect("response", "stackTrace", &trace)
+		return trace.StackFrames
+	}
+
+	frames := stackTrace()
+	assert.Equal(t, "main", frames[0].Name)
+	assert.Equal(t, uint(6), frames[0].Line)
+	assert.Equal(t, path, frames[0].Source.Path)
+
+	// Step into `add`.
+	client.send("stepIn", ThreadArguments{ThreadID: threadID})
+	client.expect("response", "stepIn", nil)
+	client.expect("event", "stopped", &stopped)
+	assert.Equal(t, "step", stopped.Reason)
+
+	frames = stackTrace()
+	assert.Len(t, frames, 2)
+	assert.Equal(t, "add", frames[0].Name)
+	assert.Equal(t, "main", frames[1].Name)
+	assert.Equal(t, uint(6), frames[1].Line)
+
+	// Step out of `add` and over the rest of line 6.
+	client.send("stepOut", ThreadArguments{ThreadID: threadID})
+	client.expect("response", "stepOut", nil)
+	client.expect("event", "stopped", &stopped)
+
+	client.send("next", ThreadArguments{ThreadID: threadID})
+	client.expect("response", "next", nil)
+	client.expect("event", "stopped", &stopped)
+
+	frames = stackTrace()
+	assert.Len(t, frames, 1)
+	assert.Equal(t, uint(7), frames[0].Line)
+
+	// Inspect the locals of `main`.
+	var scopes ScopesResponse
+	client.send("scopes", ScopesArguments{FrameID: frames[0].ID})
+	client.expect("response", "scopes", &scopes)
+	assert.Equal(t, "Locals", scopes.Scopes[0].Name)
+
+	var variables VariablesResponse
+	client.send("variables", VariablesArguments{VariablesReference: scopes.Scopes[0].VariablesReference})
+	client.expect("response", "variables", &variables)
+	assert.Equal(t, []Variable{{Name: "x", Value: "3", Type: "int"}}, variables.Variables)
+
+	client.send("continue", ThreadArguments{ThreadID: threadID})
+	client.expect("response", "continue", nil)
+
+	var exited ExitedEvent
+	client.expect("event", "exited", &exited)
+	assert.Equal(t, 0, exited.ExitCode)
+	assert.Equal(t, "3\n", client.output)
+
+	client.send("disconnect", nil)
+	client.expect("response", "disconnect", nil)
+	assert.NoError(t, <-done)
+}
@@ -0,0 +1,131 @@
+package dap
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strconv"
+	"sync"
+)
+
+//
+// DAP transport.
+// Like LSP, each message is preceded by a `Content-Length` header.
+// However, DAP does not use JSON-RPC: messages are numbered by the sender and responses refer to the request's number.
+//
+
+type request struct {
+	Seq       int             `json:"seq"`
+	Type      string          `json:"type"`
+	Command   string          `json:"command"`
+	Arguments json.RawMessage `json:"arguments,omitempty"`
+}
+
+type response struct {
+	Seq        int    `json:"seq"`
+	Type       string `json:"type"`
+	RequestSeq int    `json:"request_seq"`
+	Success    bool   `json:"success"`
+	Command    string `json:"command"`
+	Message    string `json:"message,omitempty"`
+	Body       any    `json:"body,omitempty"`
+}
+
+type event struct {
+	Seq   int    `json:"seq"`
+	Type  string `json:"type"`
+	Event string `json:"event"`
+	Body  any    `json:"body,omitempty"`
+}
+
+type conn struct {
+	reader *textproto.Reader
+	writer io.Writer
+	// Protects the writer and the sequence number, as events are sent by the cores of the VM.
+	lock sync.Mutex
+	seq  int
+}
+
+func newConn(in io.Reader, out io.Writer) *conn {
+	return &conn{
+		reader: textproto.NewReader(bufio.NewReader(in)),
+		writer: out,
+		lock:   sync.Mutex{},
+		seq:    0,
+	}
+}
+
+func (self *conn) read() (request, error) {
+	header, err := self.reader.ReadMIMEHeader()
+	if err != nil {
+		return request{}, err
+	}
+
+	length, err := strconv.Atoi(header.Get("Content-Length"))
+	if err != nil || length < 0 {
+		return request{}, fmt.Errorf("invalid `Content-Length` header: `%s`", header.Get("Content-Length"))
+	}
+
+	body := make([]byte, length)
+	if _, err := io.ReadFull(self.reader.R, body); err != nil {
+		return request{}, err
+	}
+
+	var message request
+	if err := json.Unmarshal(body, &message); err != nil {
+		return request{}, err
+	}
+
+	return message, nil
+}
+
+// Assigns the next sequence number to the message and writes it.
+func (self *conn) write(message func(seq int) any) error {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	self.seq++
+	body, err := json.Marshal(message(self.seq))
+	if err != nil {
+		return err
+	}
+
+	if _, err := fmt.Fprintf(self.writer, "Content-Length: %d\r\n\r\n", len(body)); err != nil {
+		return err
+	}
+
+	_, err = self.writer.Write(body)
+	return err
+}
+
+func (self *conn) reply(req request, body any, err error) error {
+	return self.write(func(seq int) any {
+		res := response{
+			Seq:        seq,
+			Type:       "response",
+			RequestSeq: req.Seq,
+			Success:    err == nil,
+			Command:    req.Command,
+			Body:       body,
+		}
+
+		if err != nil {
+			res.Message = err.Error()
+		}
+
+		return res
+	})
+}
+
+func (self *conn) event(name string, body any) error {
+	return self.write(func(seq int) any {
+		return event{
+			Seq:   seq,
+			Type:  "event",
+			Event: name,
+			Body:  body,
+		}
+	})
+}
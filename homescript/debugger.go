@@ -5,12 +5,17 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/smarthome-go/homescript/v3/homescript/compiler"
+	herrors "github.com/smarthome-go/homescript/v3/homescript/errors"
+	pAst "github.com/smarthome-go/homescript/v3/homescript/parser/ast"
 	"github.com/smarthome-go/homescript/v3/homescript/runtime"
+	"github.com/smarthome-go/homescript/v3/homescript/runtime/value"
 )
 
 //
@@ -28,6 +33,12 @@ const (
 	breakpointDebuggerCommandKind
 	speedDebuggerCommandKind
 	singleStepDebuggerCommandKind
+	deleteDebuggerCommandKind
+	watchDebuggerCommandKind
+	localsDebuggerCommandKind
+	printDebuggerCommandKind
+	reverseStepDebuggerCommandKind
+	reverseContinueDebuggerCommandKind
 )
 
 type debuggerCommand interface {
@@ -67,6 +78,8 @@ type infoSubcommand uint8
 const (
 	memoryInfoSubcommand infoSubcommand = iota
 	stackInfoSubcommand
+	breakpointsInfoSubcommand
+	watchesInfoSubcommand
 )
 
 type infoDebuggerCommand struct {
@@ -99,10 +112,66 @@ type breakpointDebuggerCommand struct {
 	IsAsm          bool
 	FunctionOrFile string
 	IndexOrLine    uint
+	// Optional Homescript expression, empty if the breakpoint is unconditional.
+	Condition string
 }
 
 func (c breakpointDebuggerCommand) Kind() debuggerCommandKind { return breakpointDebuggerCommandKind }
 
+//
+// Delete Subcommand
+//
+
+type deleteDebuggerCommand struct {
+	// Index of the breakpoint or watch.
+	Index   uint
+	IsWatch bool
+}
+
+func (c deleteDebuggerCommand) Kind() debuggerCommandKind { return deleteDebuggerCommandKind }
+
+//
+// Watch Subcommand
+//
+
+type watchDebuggerCommand struct {
+	Expression string
+}
+
+func (c watchDebuggerCommand) Kind() debuggerCommandKind { return watchDebuggerCommandKind }
+
+//
+// Locals Subcommand
+//
+
+type localsDebuggerCommand struct{}
+
+func (c localsDebuggerCommand) Kind() debuggerCommandKind { return localsDebuggerCommandKind }
+
+//
+// Print Subcommand
+//
+
+type printDebuggerCommand struct {
+	Expression string
+}
+
+func (c printDebuggerCommand) Kind() debuggerCommandKind { return printDebuggerCommandKind }
+
+//
+// Reverse Subcommands
+//
+
+type reverseStepDebuggerCommand struct{}
+
+func (c reverseStepDebuggerCommand) Kind() debuggerCommandKind { return reverseStepDebuggerCommandKind }
+
+type reverseContinueDebuggerCommand struct{}
+
+func (c reverseContinueDebuggerCommand) Kind() debuggerCommandKind {
+	return reverseContinueDebuggerCommandKind
+}
+
 //
 // Speed Subcommand
 //
@@ -152,7 +221,26 @@ func parseDebuggerInput(input string) (debuggerCommand, error) {
 		}
 		return callStackDebuggerCommand{}, nil
 	case "break", "b":
-		return parseBreakpointInput(tokens[1:])
+		return parseBreakpointInput(rawArguments(input, command))
+	case "delete", "d":
+		return parseDeleteInput(tokens[1:])
+	case "watch", "w":
+		expression := rawArguments(input, command)
+		if expression == "" {
+			return nil, errors.New("Expected exactly one argument: <expression>")
+		}
+		return watchDebuggerCommand{Expression: expression}, nil
+	case "locals", "l":
+		if err := ensureEOF(tokens[1:]); err != nil {
+			return nil, err
+		}
+		return localsDebuggerCommand{}, nil
+	case "print", "p":
+		expression := rawArguments(input, command)
+		if expression == "" {
+			return nil, errors.New("Expected exactly one argument: <expression>")
+		}
+		return printDebuggerCommand{Expression: expression}, nil
 	case "si":
 		if err := ensureEOF(tokens[1:]); err != nil {
 			return nil, err
@@ -163,6 +251,16 @@ func parseDebuggerInput(input string) (debuggerCommand, error) {
 			return nil, err
 		}
 		return continueDebuggerCommand{}, nil
+	case "rsi":
+		if err := ensureEOF(tokens[1:]); err != nil {
+			return nil, err
+		}
+		return reverseStepDebuggerCommand{}, nil
+	case "rc":
+		if err := ensureEOF(tokens[1:]); err != nil {
+			return nil, err
+		}
+		return reverseContinueDebuggerCommand{}, nil
 	default:
 		return nil, fmt.Errorf("Illegal command: %s", command)
 	}
@@ -183,11 +281,24 @@ func parseInfoInput(tokens []string) (infoDebuggerCommand, error) {
 		return infoDebuggerCommand{
 			Subcommand: stackInfoSubcommand,
 		}, nil
+	case "breakpoints", "break", "b":
+		return infoDebuggerCommand{
+			Subcommand: breakpointsInfoSubcommand,
+		}, nil
+	case "watches", "watch", "w":
+		return infoDebuggerCommand{
+			Subcommand: watchesInfoSubcommand,
+		}, nil
 	default:
 		return infoDebuggerCommand{}, fmt.Errorf("Illegal subcommand: %s", subcommand)
 	}
 }
 
+// Returns the input after the command, for arguments which may contain spaces, such as expressions.
+func rawArguments(input string, command string) string {
+	return strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(input), command))
+}
+
 func ensureEOF(tokens []string) error {
 	if len(tokens) != 0 {
 		return fmt.Errorf("Got too many tokens: expected no more, got %d additional", len(tokens))
@@ -211,9 +322,17 @@ func parseExecutionSpeed(tokens []string) (speedDebuggerCommand, error) {
 	}, nil
 }
 
-func parseBreakpointInput(tokens []string) (breakpointDebuggerCommand, error) {
+// Parses `<source> <filename/function> <line/index> [if <condition>]`.
+func parseBreakpointInput(input string) (breakpointDebuggerCommand, error) {
+	location, condition, hasCondition := strings.Cut(input, " if ")
+	condition = strings.TrimSpace(condition)
+	if hasCondition && condition == "" {
+		return breakpointDebuggerCommand{}, errors.New("Expected a condition after `if`")
+	}
+
+	tokens := strings.Fields(location)
 	if len(tokens) != 3 {
-		return breakpointDebuggerCommand{}, fmt.Errorf("Expected exactly two arguments: <source> <filename/function> <line>, got %d", len(tokens))
+		return breakpointDebuggerCommand{}, fmt.Errorf("Expected exactly three arguments: <source> <filename/function> <line> [if <condition>], got %d", len(tokens))
 	}
 
 	file := tokens[1]
@@ -238,24 +357,96 @@ func parseBreakpointInput(tokens []string) (breakpointDebuggerCommand, error) {
 		IsAsm:          isAsm,
 		FunctionOrFile: file,
 		IndexOrLine:    uint(line),
+		Condition:      condition,
 	}, nil
 }
 
+func parseDeleteInput(tokens []string) (deleteDebuggerCommand, error) {
+	if len(tokens) != 2 {
+		return deleteDebuggerCommand{}, fmt.Errorf("Expected exactly two arguments: <break/watch> <index>, got %d", len(tokens))
+	}
+
+	index, err := strconv.ParseUint(tokens[1], 10, 64)
+	if err != nil {
+		return deleteDebuggerCommand{}, err
+	}
+
+	switch tokens[0] {
+	case "break", "b":
+		return deleteDebuggerCommand{Index: uint(index), IsWatch: false}, nil
+	case "watch", "w":
+		return deleteDebuggerCommand{Index: uint(index), IsWatch: true}, nil
+	default:
+		return deleteDebuggerCommand{}, fmt.Errorf("Expected <break> or <watch>, got %s", tokens[0])
+	}
+}
+
 type Breakpoint struct {
+	// Set for breakpoints on a source line.
+	Filename string
+	Line     uint
+	// Set for breakpoints on an instruction.
 	Function string
 	Index    uint
+	// Homescript expression which must evaluate to `true` for the breakpoint to trigger.
+	// If this is empty, the breakpoint is unconditional.
+	Condition string
+	condition pAst.Expression
+}
+
+func (b Breakpoint) String() string {
+	location := fmt.Sprintf("%s:%d", b.Filename, b.Line)
+	if b.Function != "" {
+		location = fmt.Sprintf("%s:%d (asm)", b.Function, b.Index)
+	}
+
+	if b.Condition != "" {
+		return fmt.Sprintf("%s if %s", location, b.Condition)
+	}
+
+	return location
+}
+
+type debuggerWatch struct {
+	Expression string
+	expression pAst.Expression
+}
+
+// The source location of the previous instruction, used to detect when execution enters a new line.
+type debuggerLocation struct {
+	depth    int
+	function string
+	filename string
+	line     uint
 }
 
 type Debugger struct {
 	speedWait      time.Duration
 	running        bool
 	singleStep     bool
-	breakpoints    map[Breakpoint]struct{}
+	breakpoints    []Breakpoint
+	watches        []debuggerWatch
+	previous       debuggerLocation
 	debuggerOutput *chan runtime.DebugOutput
 	debuggerResume *chan struct{}
-	core           *runtime.Core
-	programIn      string
-	programOut     compiler.CompileOutput
+	// Protects the selection of the core when the debugger is used as a `runtime.DebugHook`.
+	coreLock   sync.Mutex
+	core       *runtime.Core
+	input      *bufio.Scanner
+	programIn  string
+	programOut compiler.CompileOutput
+	// Number of instructions which the debugged core has executed.
+	steps uint64
+	// The step at which execution is currently stopped.
+	step uint64
+	// Steps at which execution has stopped, in ascending order.
+	stops []uint64
+	// Only set if the program is replayed from a trace: cancels the current replay so that it can be restarted.
+	rewind func()
+	// If set, execution runs silently until this step is reached.
+	rewindTarget *uint64
+	// Set while the canceled replay is still executing its last instructions.
+	canceled bool
 }
 
 func NewDebugger(
@@ -266,10 +457,13 @@ func NewDebugger(
 	programOut compiler.CompileOutput,
 ) Debugger {
 	return Debugger{
-		breakpoints:    make(map[Breakpoint]struct{}),
+		breakpoints:    make([]Breakpoint, 0),
+		watches:        make([]debuggerWatch, 0),
+		stops:          make([]uint64, 0),
 		debuggerOutput: debuggerOutput,
 		debuggerResume: debuggerResume,
 		core:           core,
+		input:          bufio.NewScanner(os.Stdin),
 		programIn:      programIn,
 		programOut:     programOut,
 	}
@@ -283,77 +477,320 @@ func (d *Debugger) DebuggerMainloop() {
 				return
 			}
 
-			lineIdx := int(msg.CurrentCallFrame.InstructionPointer)
-			programStr := d.programOut.AsmStringHighlight(true, &msg.CurrentCallFrame.Function, &lineIdx)
+			d.handle(msg)
 
-			stack := make([]string, 0)
-			for _, v := range d.core.Stack {
-				if v == nil || *v == nil {
-					stack = append(stack, "<nil>")
-					continue
-				}
+			*d.debuggerResume <- struct{}{}
+		}
+	}
+}
 
-				d, i := (*v).Display()
-				if i != nil {
-					panic(*i)
-				}
+// Returns a hook which debugs the first core that executes an instruction after the hook was installed.
+// Unlike `DebuggerMainloop`, this does not require the debugger channels of `Core.Run`.
+// If no core was passed to `NewDebugger`, the hook should be installed before the main function is spawned.
+func (d *Debugger) Hook() runtime.DebugHook {
+	return func(core *runtime.Core) {
+		d.coreLock.Lock()
+		if d.canceled {
+			d.coreLock.Unlock()
+			return
+		}
+		if d.core == nil {
+			d.core = core
+		}
+		isDebugged := d.core == core
+		d.coreLock.Unlock()
 
-				stack = append(stack, d)
-			}
+		if !isDebugged {
+			return
+		}
 
-			// If the current line is not a breakpoint, skip it.
-			_, isBreakPoint := d.breakpoints[Breakpoint{
-				Function: msg.CurrentCallFrame.Function,
-				Index:    msg.CurrentCallFrame.InstructionPointer,
-			}]
+		frame := core.CallStack[len(core.CallStack)-1]
+		d.handle(runtime.DebugOutput{
+			CurrentInstruction: (*core.Program)[frame.Function][frame.InstructionPointer],
+			CurrentSpan:        core.CurrentSpan(),
+			CurrentCallFrame:   frame,
+		})
+	}
+}
 
-			if d.singleStep || !d.running {
-				isBreakPoint = true
-			}
+// Enables reverse execution for a program which is replayed from a trace.
+// The `rewind` function must cancel the current replay, after which the replay must be started again,
+// with the hook of the debugger installed, see `Debugger.Restart`.
+func (d *Debugger) AttachReplay(rewind func()) {
+	d.rewind = rewind
+}
 
-			fmt.Printf(
-				"\033[2J\033[H%s\n---------------------------\n",
-				programStr,
-			)
-
-			for {
-				if isBreakPoint {
-					// Wait for keypress.
-					scanner := bufio.NewScanner(os.Stdin)
-					if !scanner.Scan() {
-						continue
-					}
-					inputLn := scanner.Text()
-					command, err := parseDebuggerInput(inputLn)
-
-					if err != nil {
-						fmt.Printf("ERROR: %s\n", err)
-						continue
-					}
-
-					breakoutT, err := d.interpret(command)
-					if err != nil {
-						fmt.Printf("ERROR: %s\n", err)
-						continue
-					}
-
-					if breakoutT {
-						break
-					}
+// Reports whether the current replay was canceled because execution should be reversed.
+func (d *Debugger) Rewinding() bool {
+	return d.rewindTarget != nil
+}
 
-					continue
-				}
+// Prepares the debugger for the next replay of the program.
+// Breakpoints, watches and the history of stops are kept.
+func (d *Debugger) Restart() {
+	d.coreLock.Lock()
+	defer d.coreLock.Unlock()
 
-				break
+	d.core = nil
+	d.canceled = false
+	d.steps = 0
+	d.previous = debuggerLocation{}
+}
+
+// Invoked before the debugged core executes an instruction, blocks while the core is stopped.
+func (d *Debugger) handle(msg runtime.DebugOutput) {
+	step := d.steps
+	d.steps++
+
+	if d.rewindTarget != nil {
+		if step < *d.rewindTarget {
+			d.previous = debuggerLocation{
+				depth:    len(d.core.CallStack),
+				function: msg.CurrentCallFrame.Function,
+				filename: msg.CurrentSpan.Filename,
+				line:     msg.CurrentSpan.Start.Line,
 			}
+			return
+		}
+
+		d.rewindTarget = nil
+		d.step = step
+		d.stopAt(msg, -1, nil)
+		return
+	}
+
+	breakpoint, conditionErr := d.triggeredBreakpoint(msg)
+
+	d.previous = debuggerLocation{
+		depth:    len(d.core.CallStack),
+		function: msg.CurrentCallFrame.Function,
+		filename: msg.CurrentSpan.Filename,
+		line:     msg.CurrentSpan.Start.Line,
+	}
+
+	stop := d.singleStep || !d.running || breakpoint != -1
+
+	// Without a stop or a delay, execution would be too fast to follow anyways.
+	if !stop && d.speedWait == 0 {
+		return
+	}
+
+	if !stop {
+		d.printProgram(msg)
+		time.Sleep(d.speedWait)
+		return
+	}
+
+	d.step = step
+	if len(d.stops) == 0 || d.stops[len(d.stops)-1] < step {
+		d.stops = append(d.stops, step)
+	}
+
+	d.stopAt(msg, breakpoint, conditionErr)
+
+	if !d.singleStep {
+		time.Sleep(d.speedWait)
+	}
+}
+
+func (d *Debugger) stopAt(msg runtime.DebugOutput, breakpoint int, conditionErr error) {
+	d.printProgram(msg)
+	d.printLocation(msg.CurrentSpan, breakpoint)
+	if conditionErr != nil {
+		fmt.Printf("ERROR: %s\n", conditionErr)
+	}
+	d.printWatches()
+	d.prompt()
+}
+
+func (d *Debugger) printProgram(msg runtime.DebugOutput) {
+	lineIdx := int(msg.CurrentCallFrame.InstructionPointer)
+	programStr := d.programOut.AsmStringHighlight(true, &msg.CurrentCallFrame.Function, &lineIdx)
+
+	fmt.Printf(
+		"\033[2J\033[H%s\n---------------------------\n",
+		programStr,
+	)
+}
+
+// Cancels the current replay, the next replay runs silently until the given step.
+func (d *Debugger) rewindTo(step uint64) error {
+	if d.rewind == nil {
+		return errors.New("Reverse execution is only supported while replaying a trace")
+	}
+
+	// Stops after the target are forgotten as they might not be reached again.
+	for len(d.stops) > 0 && d.stops[len(d.stops)-1] > step {
+		d.stops = d.stops[:len(d.stops)-1]
+	}
+
+	d.rewindTarget = &step
+
+	d.coreLock.Lock()
+	d.canceled = true
+	d.coreLock.Unlock()
+
+	d.rewind()
+
+	return nil
+}
+
+// Reads and interprets commands until execution is resumed.
+func (d *Debugger) prompt() {
+	for {
+		if !d.input.Scan() {
+			// Without input, the program can only run until it terminates.
+			d.running = true
+			d.singleStep = false
+			d.breakpoints = d.breakpoints[:0]
+			return
+		}
+
+		command, err := parseDebuggerInput(d.input.Text())
+		if err != nil {
+			fmt.Printf("ERROR: %s\n", err)
+			continue
+		}
+
+		breakOut, err := d.interpret(command)
+		if err != nil {
+			fmt.Printf("ERROR: %s\n", err)
+			continue
+		}
+
+		if breakOut {
+			return
+		}
+	}
+}
 
-			if !d.singleStep {
-				time.Sleep(d.speedWait)
+// Returns the index of the breakpoint which triggers at the current instruction or -1 if there is none.
+// If the condition of a breakpoint cannot be evaluated, the breakpoint triggers so that the error is noticed.
+func (d *Debugger) triggeredBreakpoint(msg runtime.DebugOutput) (int, error) {
+	frame := msg.CurrentCallFrame
+	span := msg.CurrentSpan
+
+	entersLine := d.previous != debuggerLocation{
+		depth:    len(d.core.CallStack),
+		function: frame.Function,
+		filename: span.Filename,
+		line:     span.Start.Line,
+	}
+
+	for idx, breakpoint := range d.breakpoints {
+		if breakpoint.Function != "" {
+			if breakpoint.Function != frame.Function || breakpoint.Index != frame.InstructionPointer {
+				continue
 			}
+		} else if !entersLine || breakpoint.Line != span.Start.Line || !matchesFilename(span.Filename, breakpoint.Filename) {
+			continue
+		}
 
-			*d.debuggerResume <- struct{}{}
+		if breakpoint.condition == nil {
+			return idx, nil
+		}
+
+		result, err := d.evaluate(breakpoint.condition)
+		if err != nil {
+			return idx, fmt.Errorf("Condition of breakpoint %d failed: %s", idx, err)
 		}
+
+		isTrue, isBool := (*result).(value.ValueBool)
+		if !isBool {
+			return idx, fmt.Errorf("Condition of breakpoint %d must be a `bool`, got `%s`", idx, (*result).Kind())
+		}
+
+		if isTrue.Inner {
+			return idx, nil
+		}
+	}
+
+	return -1, nil
+}
+
+// Evaluates an expression in the context of the innermost frame of the debugged core.
+// Identifiers are resolved to local variables first, then to globals.
+func (d *Debugger) evaluate(expression pAst.Expression) (*value.Value, error) {
+	if d.core == nil || len(d.core.CallStack) == 0 {
+		return nil, errors.New("Not running")
 	}
+
+	locals := d.core.Locals(len(d.core.CallStack) - 1)
+
+	return evalDebuggerExpression(expression, func(ident string) (*value.Value, bool) {
+		for _, local := range locals {
+			if local.Name == ident {
+				return local.Value, local.Value != nil
+			}
+		}
+
+		return d.core.Global(ident)
+	})
+}
+
+func (d *Debugger) printLocation(span herrors.Span, breakpoint int) {
+	reason := ""
+	if breakpoint != -1 {
+		reason = fmt.Sprintf(" (breakpoint %d: %s)", breakpoint, d.breakpoints[breakpoint])
+	}
+
+	fmt.Printf("Stopped at %s:%d:%d%s\n", span.Filename, span.Start.Line, span.Start.Column, reason)
+
+	// Only the source code of the entry module is available.
+	mainFn := d.programOut.SourceMap[d.programOut.Mappings.Functions[compiler.MainFunctionIdent]]
+	if len(mainFn) == 0 || mainFn[0].Filename != span.Filename {
+		return
+	}
+
+	lines := strings.Split(d.programIn, "\n")
+	if span.Start.Line == 0 || int(span.Start.Line) > len(lines) {
+		return
+	}
+
+	fmt.Printf("%4d | %s\n", span.Start.Line, lines[span.Start.Line-1])
+}
+
+func (d *Debugger) printWatches() {
+	for idx, watch := range d.watches {
+		fmt.Printf("%d: %s = %s\n", idx, watch.Expression, d.display(watch.expression))
+	}
+}
+
+// Evaluates an expression and displays its result or the error which occurred.
+func (d *Debugger) display(expression pAst.Expression) string {
+	result, err := d.evaluate(expression)
+	if err != nil {
+		return fmt.Sprintf("<error: %s>", err)
+	}
+
+	return displayDebuggerValue(result)
+}
+
+func displayDebuggerValue(val *value.Value) string {
+	if val == nil || *val == nil {
+		return "<nil>"
+	}
+
+	disp, i := (*val).Display()
+	if i != nil {
+		return fmt.Sprintf("<error: %s>", (*i).Message())
+	}
+
+	return fmt.Sprintf("%s (%s)", disp, (*val).Kind())
+}
+
+// Reports whether a filename of the source map refers to the file given by the user.
+// The user may omit the directory and the `.hms` extension.
+func matchesFilename(filename string, input string) bool {
+	if filepath.Ext(input) == "" {
+		input += ".hms"
+	}
+
+	if filepath.Ext(filename) == "" {
+		filename += ".hms"
+	}
+
+	return filepath.Clean(filename) == filepath.Clean(input) || filepath.Base(filename) == input
 }
 
 func (d *Debugger) interpret(command debuggerCommand) (breakOut bool, err error) {
@@ -371,8 +808,7 @@ func (d *Debugger) interpret(command debuggerCommand) (breakOut bool, err error)
 		return true, nil
 	case breakpointDebuggerCommand:
 		breakPoint := Breakpoint{
-			Function: "",
-			Index:    0,
+			Condition: c.Condition,
 		}
 
 		if c.IsAsm {
@@ -390,12 +826,71 @@ func (d *Debugger) interpret(command debuggerCommand) (breakOut bool, err error)
 				return false, fmt.Errorf("Illegal instruction index, maximum is %d", len(instr))
 			}
 		} else {
-			panic("TODO: not supported")
+			if !d.lineHasCode(c.FunctionOrFile, c.IndexOrLine) {
+				return false, fmt.Errorf("Line %d of '%s' does not contain any code", c.IndexOrLine, c.FunctionOrFile)
+			}
+
+			breakPoint.Filename = c.FunctionOrFile
+			breakPoint.Line = c.IndexOrLine
 		}
 
-		d.breakpoints[breakPoint] = struct{}{}
+		if c.Condition != "" {
+			condition, err := parseDebuggerExpression(c.Condition)
+			if err != nil {
+				return false, err
+			}
+			breakPoint.condition = condition
+		}
 
-		fmt.Printf("Breakpoint set to %s:%d\n", c.FunctionOrFile, c.IndexOrLine)
+		d.breakpoints = append(d.breakpoints, breakPoint)
+
+		fmt.Printf("Breakpoint %d set to %s\n", len(d.breakpoints)-1, breakPoint)
+	case deleteDebuggerCommand:
+		if c.IsWatch {
+			if int(c.Index) >= len(d.watches) {
+				return false, fmt.Errorf("Watch %d does not exist", c.Index)
+			}
+			d.watches = append(d.watches[:c.Index], d.watches[c.Index+1:]...)
+			fmt.Printf("Deleted watch %d\n", c.Index)
+		} else {
+			if int(c.Index) >= len(d.breakpoints) {
+				return false, fmt.Errorf("Breakpoint %d does not exist", c.Index)
+			}
+			d.breakpoints = append(d.breakpoints[:c.Index], d.breakpoints[c.Index+1:]...)
+			fmt.Printf("Deleted breakpoint %d\n", c.Index)
+		}
+	case watchDebuggerCommand:
+		expression, err := parseDebuggerExpression(c.Expression)
+		if err != nil {
+			return false, err
+		}
+
+		d.watches = append(d.watches, debuggerWatch{
+			Expression: c.Expression,
+			expression: expression,
+		})
+
+		fmt.Printf("%d: %s = %s\n", len(d.watches)-1, c.Expression, d.display(expression))
+	case localsDebuggerCommand:
+		if d.core == nil || len(d.core.CallStack) == 0 {
+			return false, errors.New("Not running")
+		}
+
+		for _, local := range d.core.Locals(len(d.core.CallStack) - 1) {
+			fmt.Printf("%s = %s\n", local.Name, displayDebuggerValue(local.Value))
+		}
+	case printDebuggerCommand:
+		expression, err := parseDebuggerExpression(c.Expression)
+		if err != nil {
+			return false, err
+		}
+
+		result, err := d.evaluate(expression)
+		if err != nil {
+			return false, err
+		}
+
+		fmt.Println(displayDebuggerValue(result))
 	case infoDebuggerCommand:
 		if !d.running {
 			return false, errors.New("Not running")
@@ -435,6 +930,12 @@ func (d *Debugger) interpret(command debuggerCommand) (breakOut bool, err error)
 			}
 			stackStr := fmt.Sprintf("[%s]", strings.Join(stack, ", "))
 			fmt.Println(stackStr)
+		case breakpointsInfoSubcommand:
+			for idx, breakpoint := range d.breakpoints {
+				fmt.Printf("%d | %s\n", idx, breakpoint)
+			}
+		case watchesInfoSubcommand:
+			d.printWatches()
 		}
 	case callStackDebuggerCommand:
 		callstack := d.core.CallStack
@@ -456,6 +957,27 @@ func (d *Debugger) interpret(command debuggerCommand) (breakOut bool, err error)
 		}
 		d.singleStep = !d.singleStep
 		fmt.Printf("Single step mode is now: %v\n", d.singleStep)
+	case reverseStepDebuggerCommand:
+		if !d.running || d.step == 0 {
+			return false, errors.New("Already at the first instruction")
+		}
+
+		return true, d.rewindTo(d.step - 1)
+	case reverseContinueDebuggerCommand:
+		if !d.running {
+			return false, errors.New("Not running")
+		}
+
+		// Returns to the previous stop or to the first instruction.
+		target := uint64(0)
+		for idx := len(d.stops) - 1; idx >= 0; idx-- {
+			if d.stops[idx] < d.step {
+				target = d.stops[idx]
+				break
+			}
+		}
+
+		return true, d.rewindTo(target)
 	case nil:
 		panic("THIS IS NIL")
 	default:
@@ -464,3 +986,16 @@ func (d *Debugger) interpret(command debuggerCommand) (breakOut bool, err error)
 
 	return false, nil
 }
+
+// Reports whether any instruction of the program belongs to the given source line.
+func (d *Debugger) lineHasCode(file string, line uint) bool {
+	for _, spans := range d.programOut.SourceMap {
+		for _, span := range spans {
+			if span.Start.Line == line && matchesFilename(span.Filename, file) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,287 @@
+package homescript
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/smarthome-go/homescript/v3/homescript/lexer"
+	"github.com/smarthome-go/homescript/v3/homescript/parser"
+	pAst "github.com/smarthome-go/homescript/v3/homescript/parser/ast"
+	"github.com/smarthome-go/homescript/v3/homescript/runtime/value"
+)
+
+//
+// Debugger expressions.
+// Breakpoint conditions and watches are evaluated against the values of a paused core.
+// As these expressions are not analyzed, the types of their operands are checked during evaluation.
+//
+
+const debuggerExpressionFilename = "<debugger>"
+
+// Resolves an identifier to the value of a local or global variable.
+type debuggerScope func(ident string) (*value.Value, bool)
+
+func parseDebuggerExpression(input string) (pAst.Expression, error) {
+	parser := parser.NewParser(lexer.NewLexer(input, debuggerExpressionFilename), debuggerExpressionFilename)
+
+	expr, softErrors, hardError := parser.ParseExpression()
+	if hardError != nil {
+		return nil, errors.New(hardError.Message)
+	}
+
+	if len(softErrors) != 0 {
+		return nil, errors.New(softErrors[0].Message)
+	}
+
+	return expr, nil
+}
+
+func evalDebuggerExpression(node pAst.Expression, scope debuggerScope) (*value.Value, error) {
+	switch node := node.(type) {
+	case pAst.IntLiteralExpression:
+		return value.NewValueInt(node.Value), nil
+	case pAst.FloatLiteralExpression:
+		return value.NewValueFloat(node.Value), nil
+	case pAst.BoolLiteralExpression:
+		return value.NewValueBool(node.Value), nil
+	case pAst.StringLiteralExpression:
+		return value.NewValueString(node.Value), nil
+	case pAst.NullLiteralExpression:
+		return value.NewValueNull(), nil
+	case pAst.NoneLiteralExpression:
+		return value.NewNoneOption(), nil
+	case pAst.IdentExpression:
+		val, found := scope(node.Ident.Ident())
+		if !found || val == nil {
+			return nil, fmt.Errorf("Variable `%s` does not exist in this frame", node.Ident.Ident())
+		}
+
+		// Pointers are transparent to the user.
+		for (*val).Kind() == value.PointerValueKind {
+			val = (*val).(value.ValuePointer).Inner
+		}
+
+		return val, nil
+	case pAst.GroupedExpression:
+		return evalDebuggerExpression(node.Inner, scope)
+	case pAst.ListLiteralExpression:
+		values := make([]*value.Value, len(node.Values))
+		for idx, item := range node.Values {
+			val, err := evalDebuggerExpression(item, scope)
+			if err != nil {
+				return nil, err
+			}
+			values[idx] = val
+		}
+
+		return value.NewValueList(values), nil
+	case pAst.PrefixExpression:
+		base, err := evalDebuggerExpression(node.Base, scope)
+		if err != nil {
+			return nil, err
+		}
+
+		return evalDebuggerPrefix(node.Operator, *base)
+	case pAst.InfixExpression:
+		lhs, err := evalDebuggerExpression(node.Lhs, scope)
+		if err != nil {
+			return nil, err
+		}
+
+		// Logical operators short-circuit.
+		if node.Operator == pAst.LogicalAndInfixOperator || node.Operator == pAst.LogicalOrInfixOperator {
+			lhsBool, ok := (*lhs).(value.ValueBool)
+			if !ok {
+				return nil, fmt.Errorf("Cannot apply `%s` to a value of type `%s`", node.Operator, (*lhs).Kind())
+			}
+
+			if lhsBool.Inner == (node.Operator == pAst.LogicalOrInfixOperator) {
+				return lhs, nil
+			}
+
+			rhs, err := evalDebuggerExpression(node.Rhs, scope)
+			if err != nil {
+				return nil, err
+			}
+
+			if (*rhs).Kind() != value.BoolValueKind {
+				return nil, fmt.Errorf("Cannot apply `%s` to a value of type `%s`", node.Operator, (*rhs).Kind())
+			}
+
+			return rhs, nil
+		}
+
+		rhs, err := evalDebuggerExpression(node.Rhs, scope)
+		if err != nil {
+			return nil, err
+		}
+
+		return evalDebuggerInfix(node.Operator, *lhs, *rhs)
+	case pAst.IndexExpression:
+		base, err := evalDebuggerExpression(node.Base, scope)
+		if err != nil {
+			return nil, err
+		}
+
+		index, err := evalDebuggerExpression(node.Index, scope)
+		if err != nil {
+			return nil, err
+		}
+
+		expected := value.IntValueKind
+		switch (*base).Kind() {
+		case value.ObjectValueKind, value.AnyObjectValueKind:
+			expected = value.StringValueKind
+		case value.ListValueKind, value.StringValueKind:
+		default:
+			return nil, fmt.Errorf("Cannot index a value of type `%s`", (*base).Kind())
+		}
+
+		if (*index).Kind() != expected {
+			return nil, fmt.Errorf("Cannot index a value of type `%s` with a value of type `%s`", (*base).Kind(), (*index).Kind())
+		}
+
+		val, i := value.IndexValue(base, index, node.Span)
+		if i != nil {
+			return nil, errors.New((*i).Message())
+		}
+
+		return val, nil
+	case pAst.MemberExpression:
+		if node.Operator != pAst.DotMemberOperator {
+			return nil, fmt.Errorf("The member operator `%s` is not supported by the debugger", node.Operator)
+		}
+
+		base, err := evalDebuggerExpression(node.Base, scope)
+		if err != nil {
+			return nil, err
+		}
+
+		fields, i := (*base).Fields()
+		if i != nil {
+			return nil, errors.New((*i).Message())
+		}
+
+		field, found := fields[node.Member.Ident()]
+		if !found {
+			return nil, fmt.Errorf("Value of type `%s` has no member named `%s`", (*base).Kind(), node.Member.Ident())
+		}
+
+		return field, nil
+	default:
+		return nil, fmt.Errorf("Expression `%s` is not supported by the debugger", node)
+	}
+}
+
+func evalDebuggerPrefix(operator pAst.PrefixOperator, base value.Value) (*value.Value, error) {
+	switch operator {
+	case pAst.MinusPrefixOperator:
+		switch base := base.(type) {
+		case value.ValueInt:
+			return value.NewValueInt(-base.Inner), nil
+		case value.ValueFloat:
+			return value.NewValueFloat(-base.Inner), nil
+		}
+	case pAst.NegatePrefixOperator:
+		switch base := base.(type) {
+		case value.ValueInt:
+			return value.NewValueInt(^base.Inner), nil
+		case value.ValueBool:
+			return value.NewValueBool(!base.Inner), nil
+		}
+	case pAst.IntoSomePrefixOperator:
+		return value.NewValueOption(&base), nil
+	}
+
+	return nil, fmt.Errorf("Cannot apply `%s` to a value of type `%s`", operator, base.Kind())
+}
+
+func evalDebuggerInfix(operator pAst.InfixOperator, lhs value.Value, rhs value.Value) (*value.Value, error) {
+	if operator == pAst.EqualInfixOperator || operator == pAst.NotEqualInfixOperator {
+		isEqual, i := lhs.IsEqual(rhs)
+		if i != nil {
+			return nil, errors.New((*i).Message())
+		}
+
+		return value.NewValueBool(isEqual == (operator == pAst.EqualInfixOperator)), nil
+	}
+
+	unsupported := fmt.Errorf("Cannot apply `%s` to values of type `%s` and `%s`", operator, lhs.Kind(), rhs.Kind())
+	if lhs.Kind() != rhs.Kind() {
+		return nil, unsupported
+	}
+
+	switch lhs := lhs.(type) {
+	case value.ValueInt:
+		l, r := lhs.Inner, rhs.(value.ValueInt).Inner
+
+		switch operator {
+		case pAst.PlusInfixOperator:
+			return value.NewValueInt(l + r), nil
+		case pAst.MinusInfixOperator:
+			return value.NewValueInt(l - r), nil
+		case pAst.MultiplyInfixOperator:
+			return value.NewValueInt(l * r), nil
+		case pAst.DivideInfixOperator, pAst.ModuloInfixOperator:
+			if r == 0 {
+				return nil, errors.New("Division by zero")
+			}
+
+			if operator == pAst.DivideInfixOperator {
+				return value.NewValueInt(l / r), nil
+			}
+			return value.NewValueInt(l % r), nil
+		case pAst.PowerInfixOperator:
+			return value.NewValueInt(int64(math.Pow(float64(l), float64(r)))), nil
+		case pAst.ShiftLeftInfixOperator:
+			return value.NewValueInt(l << r), nil
+		case pAst.ShiftRightInfixOperator:
+			return value.NewValueInt(l >> r), nil
+		case pAst.BitOrInfixOperator:
+			return value.NewValueInt(l | r), nil
+		case pAst.BitAndInfixOperator:
+			return value.NewValueInt(l & r), nil
+		case pAst.BitXorInfixOperator:
+			return value.NewValueInt(l ^ r), nil
+		case pAst.LessThanInfixOperator:
+			return value.NewValueBool(l < r), nil
+		case pAst.LessThanEqualInfixOperator:
+			return value.NewValueBool(l <= r), nil
+		case pAst.GreaterThanInfixOperator:
+			return value.NewValueBool(l > r), nil
+		case pAst.GreaterThanEqualInfixOperator:
+			return value.NewValueBool(l >= r), nil
+		}
+	case value.ValueFloat:
+		l, r := lhs.Inner, rhs.(value.ValueFloat).Inner
+
+		switch operator {
+		case pAst.PlusInfixOperator:
+			return value.NewValueFloat(l + r), nil
+		case pAst.MinusInfixOperator:
+			return value.NewValueFloat(l - r), nil
+		case pAst.MultiplyInfixOperator:
+			return value.NewValueFloat(l * r), nil
+		case pAst.DivideInfixOperator:
+			if r == 0 {
+				return nil, errors.New("Division by zero")
+			}
+			return value.NewValueFloat(l / r), nil
+		case pAst.LessThanInfixOperator:
+			return value.NewValueBool(l < r), nil
+		case pAst.LessThanEqualInfixOperator:
+			return value.NewValueBool(l <= r), nil
+		case pAst.GreaterThanInfixOperator:
+			return value.NewValueBool(l > r), nil
+		case pAst.GreaterThanEqualInfixOperator:
+			return value.NewValueBool(l >= r), nil
+		}
+	case value.ValueString:
+		if operator == pAst.PlusInfixOperator {
+			return value.NewValueString(lhs.Inner + rhs.(value.ValueString).Inner), nil
+		}
+	}
+
+	return nil, unsupported
+}
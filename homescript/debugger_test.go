@@ -0,0 +1,116 @@
+package homescript
+
+import (
+	"testing"
+
+	"github.com/smarthome-go/homescript/v3/homescript/compiler"
+	"github.com/smarthome-go/homescript/v3/homescript/diagnostic"
+	"github.com/smarthome-go/homescript/v3/homescript/runtime/value"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDebuggerExpression(t *testing.T) {
+	variables := map[string]*value.Value{
+		"count": value.NewValueInt(3),
+		"name":  value.NewValueString("lamp"),
+		"items": value.NewValueList([]*value.Value{value.NewValueInt(1), value.NewValueInt(2)}),
+		"room":  value.NewValueObject(map[string]*value.Value{"temp": value.NewValueFloat(21.5)}),
+	}
+
+	scope := func(ident string) (*value.Value, bool) {
+		val, found := variables[ident]
+		return val, found
+	}
+
+	tests := []struct {
+		input     string
+		expected  string
+		wantError string
+	}{
+		{input: "count * 2 + 1", expected: "7"},
+		{input: "count == 3 && name == \"lamp\"", expected: "true"},
+		{input: "count > 5 || !(items[-1] == 2)", expected: "false"},
+		{input: "room.temp > 20.0", expected: "true"},
+		{input: "name + \"s\"", expected: "lamps"},
+		{input: "count + 1.0", wantError: "Cannot apply `+` to values of type `int` and `float`"},
+		{input: "missing", wantError: "Variable `missing` does not exist in this frame"},
+		{input: "count / 0", wantError: "Division by zero"},
+		{input: "count count", wantError: "Expected end of expression"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			expression, err := parseDebuggerExpression(test.input)
+			if err == nil {
+				var result *value.Value
+				result, err = evalDebuggerExpression(expression, scope)
+				if err == nil {
+					display, i := (*result).Display()
+					assert.Nil(t, i)
+					assert.Equal(t, test.expected, display)
+				}
+			}
+
+			if test.wantError == "" {
+				assert.NoError(t, err)
+			} else if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), test.wantError)
+			}
+		})
+	}
+}
+
+func TestVisibleVariables(t *testing.T) {
+	const program = `fn main() {
+    let x = 1;
+    {
+        let x = 2;
+        println(x);
+    }
+    println(x);
+}
+`
+
+	modules, diagnostics, syntaxErrors := Analyze(
+		InputProgram{ProgramText: program, Filename: "main"},
+		TestingAnalyzerScopeAdditions(),
+		TestingAnalyzerHost{},
+		true,
+	)
+	assert.Empty(t, syntaxErrors)
+	for _, item := range diagnostics {
+		assert.NotEqual(t, diagnostic.DiagnosticLevelError, item.Level, item.Message)
+	}
+
+	compilerStruct := compiler.NewCompiler(modules, "main")
+	compiled, err := compilerStruct.Compile()
+	assert.NoError(t, err)
+
+	mainFn := compiled.Mappings.Functions[compiler.MainFunctionIdent]
+
+	// Returns the slot of `x` before the first instruction of the given line.
+	visibleX := func(line uint) int64 {
+		for ip, span := range compiled.SourceMap[mainFn] {
+			if span.Start.Line != line {
+				continue
+			}
+
+			variables := compiled.VisibleVariables(mainFn, uint(ip))
+			if assert.Len(t, variables, 1) {
+				assert.Equal(t, "x", variables[0].Name)
+				return variables[0].Slot
+			}
+			return -1
+		}
+
+		t.Fatalf("line %d has no instructions", line)
+		return -1
+	}
+
+	inner := visibleX(5)
+	outer := visibleX(7)
+	assert.NotEqual(t, inner, outer)
+
+	// Before its declaration, no variable is visible.
+	assert.Empty(t, compiled.VisibleVariables(mainFn, 0))
+}
@@ -36,22 +36,75 @@ func (self DiagnosticLevel) String() string {
 //
 
 type Diagnostic struct {
-	Level   DiagnosticLevel `json:"level"`
-	Message string          `json:"message"`
-	Notes   []string        `json:"notes"`
-	Span    errors.Span     `json:"span"`
+	Level DiagnosticLevel `json:"level"`
+	// Empty for diagnostics which do not originate from static analysis, for instance runtime errors.
+	Code    errors.Code `json:"code"`
+	Message string      `json:"message"`
+	Notes   []string    `json:"notes"`
+	Span    errors.Span `json:"span"`
+	// Secondary spans which explain the primary span, for instance the declaration of a shadowed variable.
+	// Labels may refer to other files than the primary span.
+	Labels []Label `json:"labels"`
+	// An optional fix which can be applied without user interaction.
+	Fix *Fix `json:"fix"`
+}
+
+// A secondary span of a diagnostic with a short message.
+type Label struct {
+	Span    errors.Span `json:"span"`
+	Message string      `json:"message"`
+}
+
+func NewLabel(span errors.Span, message string) Label {
+	return Label{
+		Span:    span,
+		Message: message,
+	}
+}
+
+// A suggested change to the source code which resolves a diagnostic.
+type Fix struct {
+	// Describes the change, for instance `Add the 'event' modifier`.
+	Description string `json:"description"`
+	Edits       []Edit `json:"edits"`
+}
+
+// Replaces the text inside the (inclusive) span with the replacement.
+type Edit struct {
+	Span        errors.Span `json:"span"`
+	Replacement string      `json:"replacement"`
+}
+
+func NewFix(description string, edits ...Edit) *Fix {
+	return &Fix{
+		Description: description,
+		Edits:       edits,
+	}
 }
 
 func (d Diagnostic) WithContext(context string) Diagnostic {
 	return Diagnostic{
 		Level:   d.Level,
+		Code:    d.Code,
 		Message: fmt.Sprintf("%s: %s", context, d.Message),
 		Notes:   d.Notes,
 		Span:    d.Span,
+		Labels:  d.Labels,
+		Fix:     d.Fix,
 	}
 }
 
+// Returns the source code of the given file.
+// It is used to render labels which are located in other files than the primary span.
+type SourceProvider func(filename string) (source string, found bool)
+
 func (d Diagnostic) Display(program string) string {
+	return d.DisplayWithSources(program, nil)
+}
+
+// Like `Display`, but labels in other files are rendered using the source code which is returned by `sources`.
+// If `sources` is nil or cannot provide the source of a file, only the location and message of its labels are shown.
+func (d Diagnostic) DisplayWithSources(program string, sources SourceProvider) string {
 	singleMarker := "^"
 	markerMul := ""
 	var color uint8
@@ -71,6 +124,25 @@ func (d Diagnostic) Display(program string) string {
 		color = 1 // Red.
 	}
 
+	level := d.Level.String()
+	if d.Code != "" {
+		level += fmt.Sprintf("[%s]", d.Code)
+	}
+
+	labels := ""
+
+	for _, label := range d.Labels {
+		source, found := program, true
+		if label.Span.Filename != d.Span.Filename {
+			source, found = "", false
+			if sources != nil {
+				source, found = sources(label.Span.Filename)
+			}
+		}
+
+		labels += displayLabel(label, source, found)
+	}
+
 	notes := ""
 
 	for _, note := range d.Notes {
@@ -78,79 +150,128 @@ func (d Diagnostic) Display(program string) string {
 	}
 
 	// take special action if there is no useful span / the source code is empty
-	if d.Span.Start.Line == 0 &&
-		d.Span.Start.Column == 0 &&
-		d.Span.End.Line == 0 &&
-		d.Span.End.Column == 0 {
+	if !hasLocation(d.Span) {
 		return fmt.Sprintf(
-			"%s%s\x1b[1;39m in %s\x1b[0m\n%s\n%s",
+			"%s%s\x1b[1;39m in %s\x1b[0m\n%s\n%s%s",
 			ansiCol(color+30, true),
-			d.Level,
+			level,
 			d.Span.Filename,
 			d.Message,
+			labels,
 			notes,
 		)
 	}
 
-	lines := strings.Split(program, "\n")
+	line1, line2, marker, line3 := excerpt(strings.Split(program, "\n"), d.Span, singleMarker, markerMul, color)
+
+	return fmt.Sprintf(
+		"%s%s\x1b[39m at %s:%d:%d\x1b[0m\n%s\n%s\n%s%s\n\n\x1b%s%s\x1b[0m\n%s%s",
+		ansiCol(color+30, true),
+		level,
+		d.Span.Filename,
+		d.Span.Start.Line,
+		d.Span.Start.Column,
+		line1,
+		line2,
+		marker,
+		line3,
+		ansiCol(color+30, true),
+		d.Message,
+		labels,
+		notes,
+	)
+}
+
+func hasLocation(span errors.Span) bool {
+	return span.Start.Line != 0 ||
+		span.Start.Column != 0 ||
+		span.End.Line != 0 ||
+		span.End.Column != 0
+}
+
+// Renders a label as a source excerpt, the message is shown next to the markers.
+// If the source is not available, only the location and the message are shown.
+func displayLabel(label Label, source string, sourceFound bool) string {
+	const color = 4 // Blue.
+	lines := strings.Split(source, "\n")
+
+	if !hasLocation(label.Span) || !sourceFound || int(label.Span.Start.Line) > len(lines) {
+		location := label.Span.Filename
+		if hasLocation(label.Span) {
+			location = fmt.Sprintf("%s:%d:%d", label.Span.Filename, label.Span.Start.Line, label.Span.Start.Column)
+		}
+
+		return fmt.Sprintf(
+			"\n%s ::: %s\x1b[0m\n%s - label:\x1b[0m %s\n",
+			ansiCol(color+30, true),
+			location,
+			ansiCol(color+30, true),
+			label.Message,
+		)
+	}
+
+	line1, line2, marker, line3 := excerpt(lines, label.Span, "-", "-", color)
+
+	return fmt.Sprintf(
+		"\n%s ::: %s:%d:%d\x1b[0m\n%s\n%s\n%s %s%s\x1b[0m%s\n",
+		ansiCol(color+30, true),
+		label.Span.Filename,
+		label.Span.Start.Line,
+		label.Span.Start.Column,
+		line1,
+		line2,
+		marker,
+		ansiCol(color+30, true),
+		label.Message,
+		line3,
+	)
+}
 
+// Returns the line before the span, the first line of the span, the markers below it, and the line after it.
+func excerpt(lines []string, span errors.Span, singleMarker string, markerMul string, color uint8) (string, string, string, string) {
 	line1 := ""
-	if d.Span.Start.Line > 1 {
-		line1 = fmt.Sprintf("\n \x1b[90m%- 3d | \x1b[0m%s", d.Span.Start.Line-1, lines[d.Span.Start.Line-2])
+	if span.Start.Line > 1 {
+		line1 = fmt.Sprintf("\n \x1b[90m%- 3d | \x1b[0m%s", span.Start.Line-1, lines[span.Start.Line-2])
 	}
-	line2 := fmt.Sprintf(" \x1b[90m%- 3d | \x1b[0m%s", d.Span.Start.Line, lines[d.Span.Start.Line-1])
+	line2 := fmt.Sprintf(" \x1b[90m%- 3d | \x1b[0m%s", span.Start.Line, lines[span.Start.Line-1])
 	line3 := ""
-	if int(d.Span.Start.Line) < len(lines) {
-		line3 = fmt.Sprintf("\n \x1b[90m%- 3d | \x1b[0m%s", d.Span.Start.Line+1, lines[d.Span.Start.Line])
+	if int(span.Start.Line) < len(lines) {
+		line3 = fmt.Sprintf("\n \x1b[90m%- 3d | \x1b[0m%s", span.Start.Line+1, lines[span.Start.Line])
 	}
 
 	markers := ""
-	if d.Span.Start.Line == d.Span.End.Line {
-		if d.Span.Start.Column == d.Span.End.Column {
+	if span.Start.Line == span.End.Line {
+		if span.Start.Column == span.End.Column {
 			// only one column difference
 			markers = singleMarker
 		} else {
 			// multiple columns difference
-			markers = strings.Repeat(markerMul, int(d.Span.End.Column-d.Span.Start.Column)+1) // This is required because token spans are inclusive
+			markers = strings.Repeat(markerMul, int(span.End.Column-span.Start.Column)+1) // This is required because token spans are inclusive
 		}
 	} else {
 		// multiline span
 		s := "s"
-		if d.Span.End.Line-d.Span.Start.Line == 1 {
+		if span.End.Line-span.Start.Line == 1 {
 			s = ""
 		}
 
 		markers = fmt.Sprintf(
 			"%s ...\n%s%s+ %d more line%s\x1b[0m",
-			strings.Repeat(markerMul, len(lines[d.Span.Start.Line-1])-int(d.Span.Start.Column)+1),
-			strings.Repeat(" ", int(d.Span.Start.Column)+6),
+			strings.Repeat(markerMul, max(len(lines[span.Start.Line-1])-int(span.Start.Column)+1, 1)),
+			strings.Repeat(" ", int(span.Start.Column)+6),
 			ansiCol(32, true),
-			d.Span.End.Line-d.Span.Start.Line,
+			span.End.Line-span.Start.Line,
 			s,
 		)
 	}
 	marker := fmt.Sprintf(
 		"%s%s%s\x1b[0m",
 		ansiCol(color+30, true),
-		strings.Repeat(" ", int(d.Span.Start.Column+6)),
+		strings.Repeat(" ", int(span.Start.Column+6)),
 		markers,
 	)
 
-	return fmt.Sprintf(
-		"%s%v\x1b[39m at %s:%d:%d\x1b[0m\n%s\n%s\n%s%s\n\n\x1b%s%s\x1b[0m\n%s",
-		ansiCol(color+30, true),
-		d.Level,
-		d.Span.Filename,
-		d.Span.Start.Line,
-		d.Span.Start.Column,
-		line1,
-		line2,
-		marker,
-		line3,
-		ansiCol(color+30, true),
-		d.Message,
-		notes,
-	)
+	return line1, line2, marker, line3
 }
 
 func ansiCol(color uint8, bold bool) string {
@@ -0,0 +1,51 @@
+package diagnostic
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/smarthome-go/homescript/v3/homescript/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+var ansiEscape = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+func TestDisplayLabels(t *testing.T) {
+	program := "fn main() {\n    return;\n    foo();\n}"
+
+	item := Diagnostic{
+		Level:   DiagnosticLevelWarning,
+		Code:    errors.UnreachableCode,
+		Message: "Unreachable statement",
+		Notes:   nil,
+		Span: errors.Span{
+			Start:    errors.Location{Line: 3, Column: 5, Index: 28},
+			End:      errors.Location{Line: 3, Column: 10, Index: 33},
+			Filename: "main",
+		},
+		Labels: []Label{
+			NewLabel(errors.Span{
+				Start:    errors.Location{Line: 2, Column: 5, Index: 16},
+				End:      errors.Location{Line: 2, Column: 11, Index: 22},
+				Filename: "main",
+			}, "Any code following this statement is unreachable"),
+			NewLabel(errors.Span{
+				Start:    errors.Location{Line: 1, Column: 4, Index: 3},
+				End:      errors.Location{Line: 1, Column: 6, Index: 5},
+				Filename: "lib",
+			}, "Declared here"),
+		},
+		Fix: nil,
+	}
+
+	output := ansiEscape.ReplaceAllString(item.Display(program), "")
+	assert.Contains(t, output, " ::: main:2:5\n")
+	assert.Contains(t, output, "------- Any code following this statement is unreachable")
+	// The source of other files is unknown.
+	assert.Contains(t, output, " ::: lib:1:4\n - label: Declared here\n")
+
+	output = ansiEscape.ReplaceAllString(item.DisplayWithSources(program, func(filename string) (string, bool) {
+		return "fn foo() {}", filename == "lib"
+	}), "")
+	assert.Contains(t, output, "--- Declared here")
+}
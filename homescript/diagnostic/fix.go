@@ -0,0 +1,67 @@
+package diagnostic
+
+import "sort"
+
+// Applies the edits of the given fixes to the source code of a single file.
+// Fixes are applied in the order of their position in the file.
+// A fix is skipped entirely if any of its edits overlaps with an edit of a previously accepted fix.
+// Returns the modified source code and the number of fixes which were applied.
+func ApplyFixes(source string, fixes []*Fix) (string, int) {
+	sorted := make([]*Fix, 0, len(fixes))
+	for _, fix := range fixes {
+		if fix != nil && len(fix.Edits) > 0 {
+			sorted = append(sorted, fix)
+		}
+	}
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return firstEditIndex(sorted[i]) < firstEditIndex(sorted[j])
+	})
+
+	accepted := make([]Edit, 0)
+	applied := 0
+
+	for _, fix := range sorted {
+		overlaps := false
+		for _, edit := range fix.Edits {
+			for _, other := range accepted {
+				if edit.Span.Start.Index <= other.Span.End.Index && other.Span.Start.Index <= edit.Span.End.Index {
+					overlaps = true
+				}
+			}
+		}
+
+		if overlaps {
+			continue
+		}
+
+		accepted = append(accepted, fix.Edits...)
+		applied++
+	}
+
+	// Edits are applied back to front so that the indices of the remaining edits stay valid.
+	sort.Slice(accepted, func(i, j int) bool {
+		return accepted[i].Span.Start.Index > accepted[j].Span.Start.Index
+	})
+
+	// Indices count characters, not bytes.
+	runes := []rune(source)
+	for _, edit := range accepted {
+		start := min(int(edit.Span.Start.Index), len(runes))
+		end := min(int(edit.Span.End.Index)+1, len(runes))
+		output := make([]rune, 0, len(runes))
+		output = append(output, runes[:start]...)
+		output = append(output, []rune(edit.Replacement)...)
+		runes = append(output, runes[end:]...)
+	}
+
+	return string(runes), applied
+}
+
+func firstEditIndex(fix *Fix) uint {
+	index := fix.Edits[0].Span.Start.Index
+	for _, edit := range fix.Edits[1:] {
+		index = min(index, edit.Span.Start.Index)
+	}
+	return index
+}
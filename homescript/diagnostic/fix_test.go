@@ -0,0 +1,32 @@
+package diagnostic
+
+import (
+	"testing"
+
+	"github.com/smarthome-go/homescript/v3/homescript/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func span(start uint, end uint) errors.Span {
+	return errors.Span{
+		Start: errors.Location{Index: start},
+		End:   errors.Location{Index: end},
+	}
+}
+
+func TestApplyFixes(t *testing.T) {
+	source := "fn tick() { let ä = 1; let y = 2; }"
+
+	fixes := []*Fix{
+		NewFix("Rename to '_y'", Edit{Span: span(27, 27), Replacement: "_y"}),
+		NewFix("Add the `event` modifier", Edit{Span: span(0, 1), Replacement: "event fn"}),
+		// Overlaps with the previous fix and is therefore skipped.
+		NewFix("Add the `pub` modifier", Edit{Span: span(1, 1), Replacement: "n pub"}),
+		NewFix("Rename to '_ä'", Edit{Span: span(16, 16), Replacement: "_ä"}),
+		nil,
+	}
+
+	output, applied := ApplyFixes(source, fixes)
+	assert.Equal(t, 3, applied)
+	assert.Equal(t, "event fn tick() { let _ä = 1; let _y = 2; }", output)
+}
@@ -0,0 +1,249 @@
+package diagnostic
+
+import (
+	"path/filepath"
+	"sort"
+
+	"github.com/smarthome-go/homescript/v3/homescript/errors"
+)
+
+//
+// SARIF 2.1.0 log.
+// Only the subset of the format which is required to report diagnostics is implemented.
+// Specification: https://docs.oasis-open.org/sarif/sarif/v2.1.0/sarif-v2.1.0.html
+//
+
+const sarifSchema = "https://json.schemastore.org/sarif-2.1.0.json"
+const sarifVersion = "2.1.0"
+
+type SarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []SarifRun `json:"runs"`
+}
+
+type SarifRun struct {
+	Tool SarifTool `json:"tool"`
+	// Columns of diagnostics count runes, not UTF-16 code units.
+	ColumnKind string        `json:"columnKind"`
+	Results    []SarifResult `json:"results"`
+}
+
+type SarifTool struct {
+	Driver SarifDriver `json:"driver"`
+}
+
+type SarifDriver struct {
+	Name    string      `json:"name"`
+	Version string      `json:"version"`
+	Rules   []SarifRule `json:"rules"`
+}
+
+// Each diagnostic code is represented as a rule.
+type SarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription SarifMessage `json:"shortDescription"`
+	FullDescription  SarifMessage `json:"fullDescription"`
+}
+
+type SarifResult struct {
+	RuleID    string          `json:"ruleId,omitempty"`
+	Level     string          `json:"level"`
+	Message   SarifMessage    `json:"message"`
+	Locations []SarifLocation `json:"locations"`
+	// Labels are represented as related locations.
+	RelatedLocations []SarifLocation  `json:"relatedLocations,omitempty"`
+	Fixes            []SarifFix       `json:"fixes,omitempty"`
+	Properties       SarifResultNotes `json:"properties"`
+}
+
+type SarifFix struct {
+	Description     SarifMessage          `json:"description"`
+	ArtifactChanges []SarifArtifactChange `json:"artifactChanges"`
+}
+
+type SarifArtifactChange struct {
+	ArtifactLocation SarifArtifactLocation `json:"artifactLocation"`
+	Replacements     []SarifReplacement    `json:"replacements"`
+}
+
+type SarifReplacement struct {
+	DeletedRegion   SarifRegion          `json:"deletedRegion"`
+	InsertedContent SarifArtifactContent `json:"insertedContent"`
+}
+
+type SarifArtifactContent struct {
+	Text string `json:"text"`
+}
+
+type SarifResultNotes struct {
+	Notes []string `json:"notes"`
+}
+
+type SarifMessage struct {
+	Text string `json:"text"`
+}
+
+type SarifLocation struct {
+	PhysicalLocation SarifPhysicalLocation `json:"physicalLocation"`
+	Message          *SarifMessage         `json:"message,omitempty"`
+}
+
+type SarifPhysicalLocation struct {
+	ArtifactLocation SarifArtifactLocation `json:"artifactLocation"`
+	Region           *SarifRegion          `json:"region,omitempty"`
+}
+
+type SarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type SarifRegion struct {
+	StartLine   uint `json:"startLine"`
+	StartColumn uint `json:"startColumn"`
+	EndLine     uint `json:"endLine"`
+	// Unlike the end of a span, this column is exclusive.
+	EndColumn uint `json:"endColumn"`
+}
+
+func sarifRegion(span errors.Span) SarifRegion {
+	return SarifRegion{
+		StartLine:   span.Start.Line,
+		StartColumn: span.Start.Column,
+		EndLine:     span.End.Line,
+		EndColumn:   span.End.Column + 1,
+	}
+}
+
+func sarifLocation(span errors.Span, message *SarifMessage) SarifLocation {
+	location := SarifLocation{
+		PhysicalLocation: SarifPhysicalLocation{
+			ArtifactLocation: SarifArtifactLocation{URI: filepath.ToSlash(span.Filename)},
+			Region:           nil,
+		},
+		Message: message,
+	}
+
+	// Spans without a useful location only reference the file.
+	if span.Start.Line != 0 {
+		region := sarifRegion(span)
+		location.PhysicalLocation.Region = &region
+	}
+
+	return location
+}
+
+// Each edit becomes a replacement of the artifact which contains its span.
+func (self Fix) sarifFix() SarifFix {
+	changes := make([]SarifArtifactChange, 0)
+
+	for _, edit := range self.Edits {
+		uri := filepath.ToSlash(edit.Span.Filename)
+		replacement := SarifReplacement{
+			DeletedRegion:   sarifRegion(edit.Span),
+			InsertedContent: SarifArtifactContent{Text: edit.Replacement},
+		}
+
+		found := false
+		for idx := range changes {
+			if changes[idx].ArtifactLocation.URI == uri {
+				changes[idx].Replacements = append(changes[idx].Replacements, replacement)
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			changes = append(changes, SarifArtifactChange{
+				ArtifactLocation: SarifArtifactLocation{URI: uri},
+				Replacements:     []SarifReplacement{replacement},
+			})
+		}
+	}
+
+	return SarifFix{
+		Description:     SarifMessage{Text: self.Description},
+		ArtifactChanges: changes,
+	}
+}
+
+func (self DiagnosticLevel) sarifLevel() string {
+	switch self {
+	case DiagnosticLevelHint, DiagnosticLevelInfo:
+		return "note"
+	case DiagnosticLevelWarning:
+		return "warning"
+	case DiagnosticLevelError:
+		return "error"
+	default:
+		panic("A new diagnostic level was added without updating this code")
+	}
+}
+
+// Converts the diagnostics into a SARIF log with a single run of the given tool.
+// The filename of each span is used as the URI of the artifact.
+func NewSarifLog(toolName string, toolVersion string, diagnostics []Diagnostic) SarifLog {
+	results := make([]SarifResult, 0)
+	codes := make(map[errors.Code]struct{})
+
+	for _, item := range diagnostics {
+		location := sarifLocation(item.Span, nil)
+
+		var relatedLocations []SarifLocation
+		for _, label := range item.Labels {
+			relatedLocations = append(relatedLocations, sarifLocation(label.Span, &SarifMessage{Text: label.Message}))
+		}
+
+		var fixes []SarifFix
+		if item.Fix != nil {
+			fixes = []SarifFix{item.Fix.sarifFix()}
+		}
+
+		notes := item.Notes
+		if notes == nil {
+			notes = make([]string, 0)
+		}
+
+		if item.Code != "" {
+			codes[item.Code] = struct{}{}
+		}
+
+		results = append(results, SarifResult{
+			RuleID:           string(item.Code),
+			Level:            item.Level.sarifLevel(),
+			Message:          SarifMessage{Text: item.Message},
+			Locations:        []SarifLocation{location},
+			RelatedLocations: relatedLocations,
+			Fixes:            fixes,
+			Properties:       SarifResultNotes{Notes: notes},
+		})
+	}
+
+	rules := make([]SarifRule, 0)
+	for code := range codes {
+		rules = append(rules, SarifRule{
+			ID:               string(code),
+			ShortDescription: SarifMessage{Text: code.Title()},
+			FullDescription:  SarifMessage{Text: code.Explanation()},
+		})
+	}
+	sort.Slice(rules, func(i, j int) bool { return rules[i].ID < rules[j].ID })
+
+	return SarifLog{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs: []SarifRun{
+			{
+				Tool: SarifTool{
+					Driver: SarifDriver{
+						Name:    toolName,
+						Version: toolVersion,
+						Rules:   rules,
+					},
+				},
+				ColumnKind: "unicodeCodePoints",
+				Results:    results,
+			},
+		},
+	}
+}
@@ -0,0 +1,70 @@
+package diagnostic
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/smarthome-go/homescript/v3/homescript/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSarifLog(t *testing.T) {
+	diagnostics := []Diagnostic{
+		{
+			Level:   DiagnosticLevelError,
+			Code:    errors.MismatchedTypes,
+			Message: "Illegal type",
+			Notes:   []string{"Expected `int`"},
+			Span: errors.Span{
+				Start:    errors.Location{Line: 2, Column: 5, Index: 10},
+				End:      errors.Location{Line: 2, Column: 7, Index: 12},
+				Filename: "dir/main.hms",
+			},
+			Labels: []Label{
+				NewLabel(errors.Span{
+					Start:    errors.Location{Line: 1, Column: 1, Index: 0},
+					End:      errors.Location{Line: 1, Column: 3, Index: 2},
+					Filename: "lib",
+				}, "Declared here"),
+			},
+		},
+		{
+			Level:   DiagnosticLevelHint,
+			Code:    "",
+			Message: "Unused variable",
+			Notes:   nil,
+			Span:    errors.Span{Filename: "lib"},
+		},
+	}
+
+	log := NewSarifLog("homescript", "latest", diagnostics)
+	assert.Equal(t, "2.1.0", log.Version)
+	assert.Len(t, log.Runs, 1)
+
+	rules := log.Runs[0].Tool.Driver.Rules
+	assert.Len(t, rules, 1)
+	assert.Equal(t, "E0050", rules[0].ID)
+	assert.Equal(t, "mismatched types", rules[0].ShortDescription.Text)
+
+	results := log.Runs[0].Results
+	assert.Len(t, results, 2)
+	assert.Equal(t, "E0050", results[0].RuleID)
+	assert.Empty(t, results[1].RuleID)
+
+	assert.Equal(t, "error", results[0].Level)
+	assert.Equal(t, "dir/main.hms", results[0].Locations[0].PhysicalLocation.ArtifactLocation.URI)
+	assert.Equal(t, &SarifRegion{StartLine: 2, StartColumn: 5, EndLine: 2, EndColumn: 8}, results[0].Locations[0].PhysicalLocation.Region)
+	assert.Equal(t, []string{"Expected `int`"}, results[0].Properties.Notes)
+	assert.Len(t, results[0].RelatedLocations, 1)
+	assert.Equal(t, "lib", results[0].RelatedLocations[0].PhysicalLocation.ArtifactLocation.URI)
+	assert.Equal(t, &SarifMessage{Text: "Declared here"}, results[0].RelatedLocations[0].Message)
+	assert.Empty(t, results[1].RelatedLocations)
+
+	assert.Equal(t, "note", results[1].Level)
+	assert.Nil(t, results[1].Locations[0].PhysicalLocation.Region)
+
+	output, err := json.Marshal(log)
+	assert.NoError(t, err)
+	assert.Contains(t, string(output), `"$schema":"https://json.schemastore.org/sarif-2.1.0.json"`)
+	assert.Contains(t, string(output), `"notes":[]`)
+}
@@ -0,0 +1,213 @@
+package doc
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/smarthome-go/homescript/v3/homescript/analyzer/ast"
+	pAst "github.com/smarthome-go/homescript/v3/homescript/parser/ast"
+)
+
+//
+// Documentation generator.
+// Renders the signatures and doc comments (`///`) of the items of an analyzed module.
+//
+
+type Format uint8
+
+const (
+	MarkdownFormat Format = iota
+	HTMLFormat
+)
+
+func ParseFormat(format string) (Format, error) {
+	switch format {
+	case "markdown", "md":
+		return MarkdownFormat, nil
+	case "html":
+		return HTMLFormat, nil
+	default:
+		return 0, fmt.Errorf("Illegal format `%s`: valid formats are `markdown` and `html`", format)
+	}
+}
+
+// Is a documented item of a module.
+type entry struct {
+	Name      string
+	Signature string
+	Doc       string
+	// Methods of an impl block.
+	Children []entry
+}
+
+type section struct {
+	Title   string
+	Entries []entry
+}
+
+type page struct {
+	Module   string
+	Sections []section
+}
+
+// Renders the documentation of the module.
+// Unless private items are included, only public functions, types and globals are documented.
+func Generate(module ast.AnalyzedProgram, moduleName string, format Format, includePrivate bool) string {
+	page := newPage(module, moduleName, includePrivate)
+
+	switch format {
+	case MarkdownFormat:
+		return page.markdown()
+	case HTMLFormat:
+		return page.html()
+	default:
+		panic("A new format was added without updating this code")
+	}
+}
+
+func newPage(module ast.AnalyzedProgram, moduleName string, includePrivate bool) page {
+	sections := []section{
+		{Title: "Imports", Entries: imports(module.Imports)},
+		{Title: "Types", Entries: make([]entry, 0)},
+		{Title: "Singletons", Entries: make([]entry, 0)},
+		{Title: "Globals", Entries: make([]entry, 0)},
+		{Title: "Functions", Entries: make([]entry, 0)},
+		{Title: "Implementations", Entries: make([]entry, 0)},
+	}
+
+	for _, typ := range module.Types {
+		if !typ.IsPub && !includePrivate {
+			continue
+		}
+
+		sections[1].Entries = append(sections[1].Entries, entry{
+			Name:      typ.LhsIdent,
+			Signature: fmt.Sprintf("%stype %s = %s;", pubPrefix(typ.IsPub), typ.LhsIdent, typ.RhsType),
+			Doc:       typ.DocComment,
+		})
+	}
+
+	for _, singleton := range module.Singletons {
+		sections[2].Entries = append(sections[2].Entries, entry{
+			Name:      singleton.Ident.Ident(),
+			Signature: fmt.Sprintf("%s = %s;", singleton.Ident, singleton.SingletonType),
+			Doc:       singleton.DocComment,
+		})
+	}
+
+	for _, global := range module.Globals {
+		if !global.IsPub && !includePrivate {
+			continue
+		}
+
+		sections[3].Entries = append(sections[3].Entries, entry{
+			Name:      global.Ident.Ident(),
+			Signature: fmt.Sprintf("%slet %s: %s;", pubPrefix(global.IsPub), global.Ident, global.VarType),
+			Doc:       global.DocComment,
+		})
+	}
+
+	for _, fn := range module.Functions {
+		if fn.Modifier == pAst.FN_MODIFIER_NONE && !includePrivate {
+			continue
+		}
+
+		sections[4].Entries = append(sections[4].Entries, function(fn))
+	}
+
+	for _, impl := range module.ImplBlocks {
+		capabilities := ""
+		if impl.UsingTemplate.UserDefinedCapabilities.Defined {
+			list := make([]string, 0)
+			for _, capability := range impl.UsingTemplate.UserDefinedCapabilities.List {
+				list = append(list, capability.Ident())
+			}
+			capabilities = fmt.Sprintf(" with { %s }", strings.Join(list, ", "))
+		}
+
+		methods := make([]entry, 0)
+		for _, method := range impl.Methods {
+			methods = append(methods, function(method))
+		}
+
+		sections[5].Entries = append(sections[5].Entries, entry{
+			Name:      fmt.Sprintf("%s for %s", impl.UsingTemplate.Template, impl.SingletonIdent),
+			Signature: fmt.Sprintf("impl %s%s for %s", impl.UsingTemplate.Template, capabilities, impl.SingletonIdent),
+			Doc:       impl.DocComment,
+			Children:  methods,
+		})
+	}
+
+	// Empty sections are omitted.
+	nonEmpty := make([]section, 0)
+	for _, section := range sections {
+		if len(section.Entries) > 0 {
+			nonEmpty = append(nonEmpty, section)
+		}
+	}
+
+	return page{
+		Module:   moduleName,
+		Sections: nonEmpty,
+	}
+}
+
+func pubPrefix(isPub bool) string {
+	if isPub {
+		return "pub "
+	}
+	return ""
+}
+
+func function(fn ast.AnalyzedFunctionDefinition) entry {
+	params := make([]string, 0)
+	for _, param := range fn.Parameters.List {
+		if param.IsSingletonExtractor {
+			params = append(params, fmt.Sprintf("%s: %s", param.Ident, param.SingletonIdent))
+			continue
+		}
+		params = append(params, param.String())
+	}
+
+	modifier := ""
+	switch fn.Modifier {
+	case pAst.FN_MODIFIER_PUB:
+		modifier = "pub "
+	case pAst.FN_MODIFIER_EVENT:
+		modifier = "event "
+	}
+
+	return entry{
+		Name:      fn.Ident.Ident(),
+		Signature: fmt.Sprintf("%sfn %s(%s) -> %s", modifier, fn.Ident, strings.Join(params, ", "), fn.ReturnType),
+		Doc:       fn.DocComment,
+	}
+}
+
+// Each imported module is an entry, its signature lists the imported values and types.
+func imports(items []ast.AnalyzedImport) []entry {
+	entries := make([]entry, 0)
+
+	for _, item := range items {
+		lines := make([]string, 0)
+
+		for _, value := range item.ToImport {
+			switch {
+			case value.Type == nil, value.Kind == pAst.IMPORT_KIND_TEMPLATE:
+				lines = append(lines, value.String())
+			case value.Kind == pAst.IMPORT_KIND_TYPE:
+				lines = append(lines, fmt.Sprintf("type %s = %s;", value.Ident, value.Type))
+			default:
+				lines = append(lines, fmt.Sprintf("%s: %s", value, value.Type))
+			}
+		}
+
+		entries = append(entries, entry{
+			Name:      item.FromModule.Ident(),
+			Signature: strings.Join(lines, "\n"),
+			Doc:       "",
+		})
+	}
+
+	return entries
+}
@@ -0,0 +1,64 @@
+package doc
+
+import (
+	"testing"
+
+	"github.com/smarthome-go/homescript/v3/homescript"
+	"github.com/smarthome-go/homescript/v3/homescript/diagnostic"
+	"github.com/stretchr/testify/assert"
+)
+
+const testModule = `import { type HttpResponse } from net;
+
+/// A user of the system.
+/// Users are identified by their name.
+pub type User = {
+    name: str,
+};
+
+/// The default greeting.
+pub let GREETING = "hello";
+
+let internal = 1;
+
+/// Greets the given user.
+//// This is not part of the doc comment.
+pub fn greet(user: User) -> str {
+    GREETING + " " + user.name
+}
+
+fn helper() {}
+`
+
+func TestGenerate(t *testing.T) {
+	analyzed, diagnostics, syntaxErrors := homescript.Analyze(
+		homescript.InputProgram{
+			ProgramText: testModule,
+			Filename:    "lib",
+		},
+		homescript.TestingAnalyzerScopeAdditions(),
+		homescript.TestingAnalyzerHost{},
+		false,
+	)
+	assert.Empty(t, syntaxErrors)
+	for _, item := range diagnostics {
+		assert.NotEqual(t, diagnostic.DiagnosticLevelError, item.Level, item.Message)
+	}
+
+	module := analyzed["lib"]
+	assert.Equal(t, "A user of the system.\nUsers are identified by their name.", module.Types[0].DocComment)
+	assert.Equal(t, "The default greeting.", module.Globals[0].DocComment)
+	assert.Equal(t, "Greets the given user.", module.Functions[0].DocComment)
+
+	markdown := Generate(module, "lib", MarkdownFormat, false)
+	assert.Contains(t, markdown, "# Module `lib`\n")
+	assert.Contains(t, markdown, "### `net`\n\n```hms\ntype HttpResponse = {")
+	assert.Contains(t, markdown, "```hms\npub let GREETING: str;\n```\n\nThe default greeting.\n")
+	assert.Contains(t, markdown, "Greets the given user.\n")
+	assert.NotContains(t, markdown, "helper")
+	assert.NotContains(t, markdown, "internal")
+
+	html := Generate(module, "lib", HTMLFormat, true)
+	assert.Contains(t, html, "<p class=\"doc\">The default greeting.</p>")
+	assert.Contains(t, html, "fn helper() -&gt; null")
+}
@@ -0,0 +1,88 @@
+package doc
+
+import (
+	"fmt"
+	"html/template"
+	"strings"
+)
+
+//
+// Markdown
+//
+
+func (self page) markdown() string {
+	output := strings.Builder{}
+	fmt.Fprintf(&output, "# Module `%s`\n", self.Module)
+
+	for _, section := range self.Sections {
+		fmt.Fprintf(&output, "\n## %s\n", section.Title)
+
+		for _, entry := range section.Entries {
+			entry.markdown(&output, "###")
+		}
+	}
+
+	return output.String()
+}
+
+func (self entry) markdown(output *strings.Builder, heading string) {
+	fmt.Fprintf(output, "\n%s `%s`\n\n```hms\n%s\n```\n", heading, self.Name, self.Signature)
+
+	if self.Doc != "" {
+		fmt.Fprintf(output, "\n%s\n", self.Doc)
+	}
+
+	for _, child := range self.Children {
+		child.markdown(output, heading+"#")
+	}
+}
+
+//
+// HTML
+//
+
+var htmlTemplate = template.Must(template.New("doc").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Module {{.Module}}</title>
+<style>
+body { font-family: sans-serif; max-width: 60rem; margin: 2rem auto; padding: 0 1rem; }
+pre { background: #f4f4f4; padding: 0.5rem; overflow-x: auto; }
+.doc { white-space: pre-wrap; }
+.children { margin-left: 2rem; }
+</style>
+</head>
+<body>
+<h1>Module <code>{{.Module}}</code></h1>
+{{- range .Sections}}
+<h2>{{.Title}}</h2>
+{{- range .Entries}}
+{{template "entry" .}}
+{{- end}}
+{{- end}}
+</body>
+</html>
+{{define "entry"}}<section id="{{.Name}}">
+<h3><code>{{.Name}}</code></h3>
+<pre><code>{{.Signature}}</code></pre>
+{{- if .Doc}}
+<p class="doc">{{.Doc}}</p>
+{{- end}}
+{{- if .Children}}
+<div class="children">
+{{- range .Children}}
+{{template "entry" .}}
+{{- end}}
+</div>
+{{- end}}
+</section>{{end}}
+`))
+
+func (self page) html() string {
+	output := strings.Builder{}
+	if err := htmlTemplate.Execute(&output, self); err != nil {
+		panic(fmt.Sprintf("The documentation template is invalid: %s", err.Error()))
+	}
+	return output.String()
+}
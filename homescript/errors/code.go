@@ -0,0 +1,173 @@
+package errors
+
+import (
+	"sort"
+	"strings"
+)
+
+// A code identifies a class of errors and diagnostics.
+// Unlike messages, codes are stable across releases so that tooling can match on them.
+// Codes starting with `E` denote errors, codes starting with `W` denote warnings.
+// Hints which accompany another diagnostic use the code of that diagnostic.
+// Existing codes must never be renumbered or reused.
+type Code string
+
+const (
+	//
+	// Syntax.
+	//
+
+	IllegalCharacter         Code = "E0001"
+	UnterminatedString       Code = "E0002"
+	InvalidEscapeSequence    Code = "E0003"
+	UnexpectedToken          Code = "E0004"
+	MissingSemicolon         Code = "E0005"
+	InvalidNumberLiteral     Code = "E0006"
+	BuiltinTypeRedeclaration Code = "E0007"
+	IllegalFieldAnnotation   Code = "E0008"
+	InvalidAssignmentTarget  Code = "E0009"
+	UnbalancedDelimiter      Code = "E0010"
+
+	//
+	// Names and declarations.
+	//
+
+	UndefinedName       Code = "E0020"
+	UndefinedType       Code = "E0021"
+	UndefinedSingleton  Code = "E0022"
+	DuplicateDefinition Code = "E0023"
+	DuplicateParameter  Code = "E0024"
+	DuplicateField      Code = "E0025"
+	ReservedFieldName   Code = "E0026"
+
+	//
+	// Imports.
+	//
+
+	ModuleNotFound         Code = "E0030"
+	ModuleResolutionFailed Code = "E0031"
+	CyclicImport           Code = "E0032"
+	ImportNotFound         Code = "E0033"
+	PrivateImport          Code = "E0034"
+
+	//
+	// Annotations and triggers.
+	//
+
+	IllegalAnnotation         Code = "E0040"
+	UnknownFieldAnnotation    Code = "E0041"
+	UndefinedTriggerFunction  Code = "E0042"
+	UndefinedCallbackFunction Code = "E0043"
+	RecursiveTrigger          Code = "E0044"
+	CallbackModifier          Code = "E0045"
+	InvalidTestFunction       Code = "E0046"
+
+	//
+	// Types.
+	//
+
+	MismatchedTypes       Code = "E0050"
+	MissingField          Code = "E0051"
+	UnexpectedField       Code = "E0052"
+	FunctionValueCast     Code = "E0053"
+	ParameterKindMismatch Code = "E0054"
+	ArgumentCountMismatch Code = "E0055"
+	MissingParameter      Code = "E0056"
+	ImplicitAny           Code = "E0057"
+	ImpossibleCast        Code = "E0058"
+	IllegalOperator       Code = "E0059"
+	NotCallable           Code = "E0060"
+	IllegalIndex          Code = "E0061"
+	UnknownMember         Code = "E0062"
+	NotIterable           Code = "E0063"
+	ResultArgument        Code = "E0064"
+	ClosureAcrossThreads  Code = "E0065"
+	MissingElseBranch     Code = "E0066"
+	IllegalLoopResultType Code = "E0067"
+
+	//
+	// Control flow.
+	//
+
+	ReturnOutsideFunction Code = "E0070"
+	BreakOutsideLoop      Code = "E0071"
+	ContinueOutsideLoop   Code = "E0072"
+	NonConstantGlobal     Code = "E0073"
+	MissingDefaultBranch  Code = "E0074"
+	MixedDefaultArm       Code = "E0075"
+
+	//
+	// Functions.
+	//
+
+	MissingMain           Code = "E0080"
+	InvalidEntrySignature Code = "E0081"
+
+	//
+	// Singletons and templates.
+	//
+
+	MisplacedExtraction      Code = "E0090"
+	DuplicateExtraction      Code = "E0091"
+	UndefinedTemplate        Code = "E0092"
+	UndefinedCapability      Code = "E0093"
+	ConflictingCapabilities  Code = "E0094"
+	MissingExtraction        Code = "E0095"
+	TemplateMethodMismatch   Code = "E0096"
+	MissingTemplateMethod    Code = "E0097"
+	AdditionalTemplateMethod Code = "E0098"
+
+	//
+	// Warnings.
+	//
+
+	UnusedVariable      Code = "W0001"
+	UnusedType          Code = "W0002"
+	UnusedImport        Code = "W0003"
+	UnusedFunction      Code = "W0004"
+	UnusedSingleton     Code = "W0005"
+	UnreachableMatchArm Code = "W0006"
+	UnreachableCode     Code = "W0007"
+	DivisionByZero      Code = "W0008"
+)
+
+type codeInfo struct {
+	title       string
+	explanation string
+}
+
+// Returns a short description of the code, for instance `use of undefined trigger function`.
+func (self Code) Title() string {
+	info, found := codes[self]
+	if !found {
+		panic("BUG: a new code was introduced without updating this code")
+	}
+	return info.title
+}
+
+// Returns a long-form explanation of the code which contains examples.
+func (self Code) Explanation() string {
+	info, found := codes[self]
+	if !found {
+		panic("BUG: a new code was introduced without updating this code")
+	}
+	return strings.TrimSpace(info.explanation)
+}
+
+// Parses a code like `E0042`.
+// The prefix is case-insensitive.
+func ParseCode(from string) (Code, bool) {
+	code := Code(strings.ToUpper(strings.TrimSpace(from)))
+	_, found := codes[code]
+	return code, found
+}
+
+// Returns all known codes in ascending order.
+func Codes() []Code {
+	output := make([]Code, 0, len(codes))
+	for code := range codes {
+		output = append(output, code)
+	}
+	sort.Slice(output, func(i, j int) bool { return output[i] < output[j] })
+	return output
+}
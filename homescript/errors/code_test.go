@@ -0,0 +1,26 @@
+package errors
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCodes(t *testing.T) {
+	format := regexp.MustCompile(`^[EW]\d{4}$`)
+
+	for _, code := range Codes() {
+		assert.Regexp(t, format, string(code))
+		assert.NotEmpty(t, code.Title(), code)
+		assert.NotEmpty(t, code.Explanation(), code)
+	}
+
+	code, found := ParseCode("e0042")
+	assert.True(t, found)
+	assert.Equal(t, UndefinedTriggerFunction, code)
+	assert.Equal(t, "use of undefined trigger function", code.Title())
+
+	_, found = ParseCode("E9999")
+	assert.False(t, found)
+}
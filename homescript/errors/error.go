@@ -6,7 +6,9 @@ import (
 )
 
 type Error struct {
-	Kind    ErrorKind
+	Kind ErrorKind
+	// Only set for errors which are reported before the program runs.
+	Code    Code
 	Message string
 	Span    Span
 }
@@ -78,13 +80,16 @@ func (self ErrorKind) String() string {
 func NewError(span Span, message string, kind ErrorKind) *Error {
 	return &Error{
 		Span:    span,
+		Code:    "",
 		Message: message,
 		Kind:    kind,
 	}
 }
 
-func NewSyntaxError(span Span, message string) *Error {
-	return NewError(span, message, SyntaxError)
+func NewSyntaxError(span Span, code Code, message string) *Error {
+	err := NewError(span, message, SyntaxError)
+	err.Code = code
+	return err
 }
 
 func (self Error) Display(program string) string {
@@ -106,9 +111,14 @@ func (self Error) Display(program string) string {
 	}
 	marker := fmt.Sprintf("%s\x1b[1;31m%s\x1b[0m", strings.Repeat(" ", int(self.Span.Start.Column+6)), markers)
 
+	kind := self.Kind.String()
+	if self.Code != "" {
+		kind += fmt.Sprintf("[%s]", self.Code)
+	}
+
 	return fmt.Sprintf(
-		"\x1b[1;36m%v\x1b[39m at %s:%d:%d\x1b[0m\n%s\n%s\n%s%s\n\n\x1b[1;31m%s\x1b[0m\n",
-		self.Kind,
+		"\x1b[1;36m%s\x1b[39m at %s:%d:%d\x1b[0m\n%s\n%s\n%s%s\n\n\x1b[1;31m%s\x1b[0m\n",
+		kind,
 		self.Span.Filename,
 		self.Span.Start.Line,
 		self.Span.Start.Column,
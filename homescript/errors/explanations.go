@@ -0,0 +1,1186 @@
+package errors
+
+// Explanations are shown by `hms explain <code>`.
+// Code examples are indented by four spaces.
+var codes = map[Code]codeInfo{
+	//
+	// Syntax.
+	//
+
+	IllegalCharacter: {
+		title: "illegal character",
+		explanation: `
+The source code contains a character which is not part of the Homescript syntax.
+Characters which are not valid outside of string literals include tabs and most special symbols.
+
+Erroneous code example:
+
+    let price = 5€;
+
+Move the character into a string literal or remove it:
+
+    let price = "5€";
+`,
+	},
+	UnterminatedString: {
+		title: "unterminated string literal",
+		explanation: `
+A string literal was opened, but the file ended before its closing quote was found.
+
+Erroneous code example:
+
+    let greeting = "hello;
+
+Add the missing closing quote:
+
+    let greeting = "hello";
+`,
+	},
+	InvalidEscapeSequence: {
+		title: "invalid escape sequence",
+		explanation: `
+A backslash inside a string literal starts an escape sequence.
+Valid escape sequences are \\, \", \', \n, \r, \t, \b, octal escapes like \101,
+hexadecimal escapes like \x41, and unicode escapes like \u0041 or \U00000041.
+
+Erroneous code example:
+
+    let path = "C:\windows";
+
+Escape the backslash itself:
+
+    let path = "C:\\windows";
+`,
+	},
+	UnexpectedToken: {
+		title: "unexpected token",
+		explanation: `
+The parser expected a different token at this position.
+This usually happens if a delimiter, an operator, or an expression is missing.
+
+Erroneous code example:
+
+    fn main() {
+        let x = ;
+    }
+
+Complete the construct which the parser was reading:
+
+    fn main() {
+        let x = 1;
+    }
+`,
+	},
+	MissingSemicolon: {
+		title: "missing semicolon",
+		explanation: `
+Statements which do not end in a block must be terminated with a semicolon.
+
+Erroneous code example:
+
+    fn main() {
+        let x = 1
+        println(x);
+    }
+
+Add the semicolon:
+
+    fn main() {
+        let x = 1;
+        println(x);
+    }
+`,
+	},
+	InvalidNumberLiteral: {
+		title: "invalid number literal",
+		explanation: `
+A number literal could not be converted into a value.
+Most commonly, an integer literal is too large to fit into a 64-bit signed integer.
+
+Erroneous code example:
+
+    let big = 99999999999999999999;
+
+Use a float literal if the precision is sufficient:
+
+    let big = 99999999999999999999.0;
+`,
+	},
+	BuiltinTypeRedeclaration: {
+		title: "redeclaration of a builtin type",
+		explanation: `
+Builtin types like 'int', 'str', or 'bool' cannot be redeclared by a type definition.
+
+Erroneous code example:
+
+    type int = float;
+
+Choose a different name:
+
+    type Number = float;
+`,
+	},
+	IllegalFieldAnnotation: {
+		title: "illegal object field annotation",
+		explanation: `
+Annotations of object fields, such as '@setting', are only legal in the type of a singleton.
+
+Erroneous code example:
+
+    type Config = {
+        @setting url: str,
+    };
+
+Remove the annotation or move the field into a singleton:
+
+    $Device = {
+        @setting url: str,
+    };
+`,
+	},
+	InvalidAssignmentTarget: {
+		title: "invalid left-hand side of assignment",
+		explanation: `
+Only variables, object fields, and list elements can be assigned to.
+
+Erroneous code example:
+
+    fn main() {
+        1 + 2 = 3;
+    }
+
+Assign to a variable instead:
+
+    fn main() {
+        let x = 0;
+        x = 1 + 2;
+    }
+`,
+	},
+	UnbalancedDelimiter: {
+		title: "unbalanced delimiter",
+		explanation: `
+Every opening parenthesis, bracket, or brace must be closed by the matching delimiter.
+
+Erroneous code example:
+
+    fn main() {
+        println((1 + 2);
+    }
+
+Remove the superfluous delimiter or add the missing one:
+
+    fn main() {
+        println(1 + 2);
+    }
+`,
+	},
+
+	//
+	// Names and declarations.
+	//
+
+	UndefinedName: {
+		title: "use of undefined variable or function",
+		explanation: `
+The name does not refer to a variable or function which is visible in the current scope.
+Variables are only visible in the block which declares them.
+Items from other modules must be imported before they can be used.
+
+Erroneous code example:
+
+    fn main() {
+        println(count);
+    }
+
+Declare the variable before using it:
+
+    fn main() {
+        let count = 1;
+        println(count);
+    }
+`,
+	},
+	UndefinedType: {
+		title: "use of undeclared type",
+		explanation: `
+The type name does not refer to a builtin type, a type definition in this module, or an imported type.
+
+Erroneous code example:
+
+    fn greet(user: User) {}
+
+Declare or import the type first:
+
+    type User = { name: str };
+
+    fn greet(user: User) {}
+`,
+	},
+	UndefinedSingleton: {
+		title: "use of undeclared singleton",
+		explanation: `
+The singleton was not declared in the current module.
+Singletons are declared using a '$' prefix.
+
+Erroneous code example:
+
+    fn main() {
+        println($Device);
+    }
+
+Declare the singleton:
+
+    $Device = { is_online: bool };
+
+    fn main() {
+        println($Device);
+    }
+`,
+	},
+	DuplicateDefinition: {
+		title: "duplicate definition",
+		explanation: `
+A name can only be defined once in a scope.
+This applies to functions, globals, types, singletons, templates, and imported items.
+
+Erroneous code example:
+
+    fn helper() {}
+    fn helper() {}
+
+Rename or remove one of the definitions:
+
+    fn helper() {}
+    fn other_helper() {}
+`,
+	},
+	DuplicateParameter: {
+		title: "duplicate parameter",
+		explanation: `
+Every parameter of a function or function type must have a unique name.
+
+Erroneous code example:
+
+    fn add(a: int, a: int) -> int { a }
+
+Rename one of the parameters:
+
+    fn add(a: int, b: int) -> int { a + b }
+`,
+	},
+	DuplicateField: {
+		title: "duplicate field",
+		explanation: `
+Every field of an object or object type must have a unique name.
+
+Erroneous code example:
+
+    let point = new { x: 1, x: 2 };
+
+Rename or remove one of the fields:
+
+    let point = new { x: 1, y: 2 };
+`,
+	},
+	ReservedFieldName: {
+		title: "field name reserved for builtin members",
+		explanation: `
+Objects have builtin members like 'keys' or 'to_json'.
+Fields cannot use these names as they would be shadowed by the builtin members.
+
+Erroneous code example:
+
+    let item = new { keys: ["a", "b"] };
+
+Choose a different name:
+
+    let item = new { names: ["a", "b"] };
+`,
+	},
+
+	//
+	// Imports.
+	//
+
+	ModuleNotFound: {
+		title: "module not found",
+		explanation: `
+The host environment does not provide a module with the given name.
+Check the spelling of the module name and whether the module exists.
+
+Erroneous code example:
+
+    import { helper } from does_not_exist;
+`,
+	},
+	ModuleResolutionFailed: {
+		title: "module could not be resolved by the host",
+		explanation: `
+The host environment encountered an error while loading the module.
+Unlike E0030, the module might exist, but it could not be read.
+The message contains the error reported by the host.
+`,
+	},
+	CyclicImport: {
+		title: "cyclic import",
+		explanation: `
+Modules cannot import each other in a cycle, as neither could be analyzed first.
+
+Erroneous code example:
+
+    // Module 'a'.
+    import { b_fn } from b;
+    pub fn a_fn() {}
+
+    // Module 'b'.
+    import { a_fn } from a;
+    pub fn b_fn() {}
+
+Move the shared items into a third module which both import.
+`,
+	},
+	ImportNotFound: {
+		title: "imported item does not exist",
+		explanation: `
+The module exists, but it does not contain an item of the given name and kind.
+Types must be imported using 'type', templates using 'templ', and triggers using 'trigger'.
+
+Erroneous code example:
+
+    import { HttpResponse } from net;
+
+Use the correct kind of the item:
+
+    import { type HttpResponse } from net;
+`,
+	},
+	PrivateImport: {
+		title: "import of a private item",
+		explanation: `
+Only items which are declared as 'pub' can be imported by other modules.
+
+Erroneous code example:
+
+    // Module 'lib'.
+    fn helper() {}
+
+    // Module 'main'.
+    import { helper } from lib;
+
+Declare the item as public:
+
+    // Module 'lib'.
+    pub fn helper() {}
+`,
+	},
+
+	//
+	// Annotations and triggers.
+	//
+
+	IllegalAnnotation: {
+		title: "illegal annotation",
+		explanation: `
+The annotation is not known or cannot be used on functions.
+Functions currently support the 'trigger', 'test', and 'allow_unused' annotations.
+
+Erroneous code example:
+
+    #[inline]
+    fn helper() {}
+`,
+	},
+	UnknownFieldAnnotation: {
+		title: "unknown type field annotation",
+		explanation: `
+The annotations which are allowed on fields of singleton types are defined by the host, for instance '@setting'.
+
+Erroneous code example:
+
+    $Device = {
+        @secret token: str,
+    };
+
+Use a known annotation:
+
+    $Device = {
+        @setting token: str,
+    };
+`,
+	},
+	UndefinedTriggerFunction: {
+		title: "use of undefined trigger function",
+		explanation: `
+Triggers are provided by the host and must be imported before they can be used.
+
+Erroneous code example:
+
+    #[trigger in minute(5)]
+    event fn tick(_elapsed: int) {}
+
+Import the trigger first:
+
+    import trigger minute from triggers;
+
+    #[trigger in minute(5)]
+    event fn tick(_elapsed: int) {}
+`,
+	},
+	UndefinedCallbackFunction: {
+		title: "use of undefined callback function",
+		explanation: `
+The function which should be invoked by the trigger does not exist.
+
+Erroneous code example:
+
+    import trigger minute from triggers;
+
+    fn main() {
+        trigger tick on minute(5);
+    }
+
+Define the callback function:
+
+    import trigger minute from triggers;
+
+    event fn tick(_elapsed: int) {}
+
+    fn main() {
+        trigger tick on minute(5);
+    }
+`,
+	},
+	RecursiveTrigger: {
+		title: "function triggers itself",
+		explanation: `
+A callback function cannot register a trigger which invokes the callback itself.
+This would register a new trigger every time the event takes place.
+
+Erroneous code example:
+
+    import trigger minute from triggers;
+
+    event fn tick(_elapsed: int) {
+        trigger tick on minute(5);
+    }
+
+Register the trigger from a different function, for instance 'main'.
+`,
+	},
+	CallbackModifier: {
+		title: "trigger callback without the event modifier",
+		explanation: `
+Functions invoked by a trigger run once an event takes place.
+Therefore, they must be declared using the 'event' modifier.
+
+Erroneous code example:
+
+    import trigger minute from triggers;
+
+    #[trigger in minute(5)]
+    fn tick(_elapsed: int) {}
+
+Add the modifier:
+
+    import trigger minute from triggers;
+
+    #[trigger in minute(5)]
+    event fn tick(_elapsed: int) {}
+`,
+	},
+	InvalidTestFunction: {
+		title: "invalid test function",
+		explanation: `
+Test functions are invoked by the test runner without any arguments.
+Therefore, they must not have parameters, a return type, or a modifier.
+A test fails if it throws an exception, for instance through a failed assertion.
+
+Erroneous code example:
+
+    #[test]
+    fn adds(a: int) -> bool {
+        a + 1 == 2
+    }
+
+Assert the expected result instead:
+
+    import { assert_eq } from testing;
+
+    #[test]
+    fn adds() {
+        assert_eq(1 + 1, 2);
+    }
+`,
+	},
+
+	//
+	// Types.
+	//
+
+	MismatchedTypes: {
+		title: "mismatched types",
+		explanation: `
+A value of one type was used where a value of a different type is expected.
+The accompanying hint points to the code which caused the expectation.
+
+Erroneous code example:
+
+    let x: int = "1";
+
+Convert the value or change the expected type:
+
+    let x: int = "1".parse_int()?;
+`,
+	},
+	MissingField: {
+		title: "missing field",
+		explanation: `
+An object lacks a field which is required by the expected object type.
+
+Erroneous code example:
+
+    type Point = { x: int, y: int };
+
+    let point: Point = new { x: 1 };
+
+Add the missing field:
+
+    let point: Point = new { x: 1, y: 2 };
+`,
+	},
+	UnexpectedField: {
+		title: "unexpected field",
+		explanation: `
+An object contains a field which is not part of the expected object type.
+
+Erroneous code example:
+
+    type Point = { x: int, y: int };
+
+    let point: Point = new { x: 1, y: 2, z: 3 };
+
+Remove the field or add it to the type:
+
+    type Point = { x: int, y: int, z: int };
+`,
+	},
+	FunctionValueCast: {
+		title: "cast of a function value",
+		explanation: `
+Function types cannot be checked at runtime, therefore values cannot be cast to or from functions.
+Usually, the function was meant to be called before casting its result.
+
+Erroneous code example:
+
+    fn value() -> any { 1 }
+
+    let x = value as int;
+
+Call the function and cast its return value:
+
+    let x = value() as int;
+`,
+	},
+	ParameterKindMismatch: {
+		title: "mismatched parameter kinds",
+		explanation: `
+Some builtin functions accept an arbitrary number of arguments.
+Such functions are not compatible with function types which expect a fixed list of parameters, and vice versa.
+
+Erroneous code example:
+
+    let log: fn(msg: str) = println;
+
+Wrap the function in a closure with a fixed list of parameters:
+
+    let log: fn(msg: str) = fn(msg: str) { println(msg); };
+`,
+	},
+	ArgumentCountMismatch: {
+		title: "wrong number of arguments or parameters",
+		explanation: `
+A function was called with a different number of arguments than it declares parameters,
+or a function value has a different number of parameters than its expected type.
+
+Erroneous code example:
+
+    fn add(a: int, b: int) -> int { a + b }
+
+    let x = add(1);
+
+Supply all arguments:
+
+    let x = add(1, 2);
+`,
+	},
+	MissingParameter: {
+		title: "missing parameter",
+		explanation: `
+A function value lacks a parameter which is required by the expected function type.
+The names and types of the parameters must match.
+
+Erroneous code example:
+
+    let f: fn(a: int) -> int = fn(b: int) -> int { b };
+
+Use the expected parameter name:
+
+    let f: fn(a: int) -> int = fn(a: int) -> int { a };
+`,
+	},
+	ImplicitAny: {
+		title: "implicit use of the any type",
+		explanation: `
+Values of type 'any' are only allowed if this is stated explicitly.
+Otherwise, a variable could silently lose its type information.
+
+Erroneous code example:
+
+    fn value() -> any { 1 }
+
+    let x = value();
+
+Add an explicit type annotation or cast the value:
+
+    let x: any = value();
+    let y = value() as int;
+`,
+	},
+	ImpossibleCast: {
+		title: "impossible cast",
+		explanation: `
+There is no conversion between the type of the value and the target type.
+Casts can only narrow values of type 'any' or convert between compatible types.
+
+Erroneous code example:
+
+    let x = [1, 2] as int;
+
+Convert the value explicitly:
+
+    let x = [1, 2].len();
+`,
+	},
+	IllegalOperator: {
+		title: "operator cannot be used on this type",
+		explanation: `
+The operator is not defined for values of this type.
+
+Erroneous code example:
+
+    let x = true + 1;
+
+Use values for which the operator is defined:
+
+    let x = 1 + 1;
+`,
+	},
+	NotCallable: {
+		title: "value cannot be called",
+		explanation: `
+Only values of a function type can be called.
+
+Erroneous code example:
+
+    let x = 1;
+    x();
+`,
+	},
+	IllegalIndex: {
+		title: "value cannot be indexed",
+		explanation: `
+The value cannot be indexed, or it cannot be indexed using a value of this type.
+Lists and strings are indexed by integers, objects by strings.
+
+Erroneous code example:
+
+    let list = [1, 2, 3];
+    let x = list["first"];
+
+Use an integer index:
+
+    let x = list[0];
+`,
+	},
+	UnknownMember: {
+		title: "no such member or field",
+		explanation: `
+The type does not have a member or field with the given name.
+
+Erroneous code example:
+
+    let point = new { x: 1, y: 2 };
+    println(point.z);
+
+Use an existing field:
+
+    println(point.x);
+`,
+	},
+	NotIterable: {
+		title: "value cannot be iterated",
+		explanation: `
+Only lists, strings, and ranges can be used in a for-loop.
+
+Erroneous code example:
+
+    for item in 42 {}
+
+Iterate over a range instead:
+
+    for item in 0..42 {}
+`,
+	},
+	ResultArgument: {
+		title: "result value used as argument",
+		explanation: `
+Values of a result type must be unwrapped before they can be passed to this function.
+
+Erroneous code example:
+
+    spawn(worker, "1".parse_int());
+
+Unwrap the value using the '?' operator:
+
+    spawn(worker, "1".parse_int()?);
+`,
+	},
+	ClosureAcrossThreads: {
+		title: "closure sent across threads",
+		explanation: `
+Closures capture variables of the current thread.
+Passing them to a function which runs on a different thread is undefined behaviour.
+
+Erroneous code example:
+
+    spawn(worker, fn() {});
+
+Pass a named function or plain data instead.
+`,
+	},
+	MissingElseBranch: {
+		title: "missing else branch",
+		explanation: `
+If the 'then' branch of an if-expression results in a value, an 'else' branch is required.
+Otherwise, the if-expression would not have a value if its condition is false.
+
+Erroneous code example:
+
+    let x = if ok { 1 };
+
+Add an else branch:
+
+    let x = if ok { 1 } else { 0 };
+`,
+	},
+	IllegalLoopResultType: {
+		title: "loop body results in a value",
+		explanation: `
+The body of a loop must not result in a value, as it would be discarded.
+
+Erroneous code example:
+
+    for i in 0..10 {
+        i
+    }
+
+Terminate the expression with a semicolon:
+
+    for i in 0..10 {
+        i;
+    }
+`,
+	},
+
+	//
+	// Control flow.
+	//
+
+	ReturnOutsideFunction: {
+		title: "return statement outside of a function",
+		explanation: `
+The 'return' statement can only be used inside of a function body.
+
+Erroneous code example:
+
+    let x = { return 1; };
+`,
+	},
+	BreakOutsideLoop: {
+		title: "break statement outside of a loop",
+		explanation: `
+The 'break' statement can only be used inside of a loop.
+
+Erroneous code example:
+
+    fn main() {
+        break;
+    }
+
+Use 'return' to leave a function early.
+`,
+	},
+	ContinueOutsideLoop: {
+		title: "continue statement outside of a loop",
+		explanation: `
+The 'continue' statement can only be used inside of a loop.
+
+Erroneous code example:
+
+    fn main() {
+        continue;
+    }
+`,
+	},
+	NonConstantGlobal: {
+		title: "non-constant global initializer",
+		explanation: `
+Global variables are initialized before the program starts.
+Therefore, their initializers must be constant and cannot call functions.
+
+Erroneous code example:
+
+    let START = time.now();
+
+Initialize the variable inside of a function:
+
+    let START: any = null;
+
+    fn main() {
+        START = time.now();
+    }
+`,
+	},
+	MissingDefaultBranch: {
+		title: "missing default branch",
+		explanation: `
+A match expression must handle every possible value.
+As literal arms cannot cover every value, a default arm using '_' is required.
+
+Erroneous code example:
+
+    let name = match x {
+        1 => "one",
+        2 => "two",
+    };
+
+Add a default arm:
+
+    let name = match x {
+        1 => "one",
+        2 => "two",
+        _ => "many",
+    };
+`,
+	},
+	MixedDefaultArm: {
+		title: "default case mixed with literal values",
+		explanation: `
+The default case '_' matches every value.
+Combining it with literal values in the same arm is redundant.
+
+Erroneous code example:
+
+    match x {
+        1 | _ => {},
+    }
+
+Use the default case on its own:
+
+    match x {
+        _ => {},
+    }
+`,
+	},
+
+	//
+	// Functions.
+	//
+
+	MissingMain: {
+		title: "missing main function",
+		explanation: `
+Programs which are executed directly must declare a 'main' function as their entry point.
+
+Erroneous code example:
+
+    fn run() {
+        println("hello");
+    }
+
+Declare the entry point:
+
+    fn main() {
+        println("hello");
+    }
+`,
+	},
+	InvalidEntrySignature: {
+		title: "invalid signature of a special function",
+		explanation: `
+Functions which are invoked by the runtime, like 'main', must not take parameters
+and must not return a value.
+Singletons may still be extracted as parameters.
+
+Erroneous code example:
+
+    fn main(args: [str]) -> int {
+        0
+    }
+
+Remove the parameters and the return type:
+
+    fn main() {}
+`,
+	},
+
+	//
+	// Singletons and templates.
+	//
+
+	MisplacedExtraction: {
+		title: "singleton extraction after a normal parameter",
+		explanation: `
+Singletons must be extracted as the first parameters of a function.
+
+Erroneous code example:
+
+    fn toggle(on: bool, device: $Device) {}
+
+Move the extraction to the front:
+
+    fn toggle(device: $Device, on: bool) {}
+`,
+	},
+	DuplicateExtraction: {
+		title: "duplicate singleton extraction",
+		explanation: `
+Each singleton can only be extracted once per function or impl block.
+
+Erroneous code example:
+
+    fn toggle(a: $Device, b: $Device) {}
+
+Remove the second extraction:
+
+    fn toggle(device: $Device) {}
+`,
+	},
+	UndefinedTemplate: {
+		title: "use of undefined template",
+		explanation: `
+Templates are provided by the host and must be imported before they can be implemented.
+
+Erroneous code example:
+
+    impl Light for $Device {}
+
+Import the template first:
+
+    import templ Light from templates;
+
+    impl Light for $Device {}
+`,
+	},
+	UndefinedCapability: {
+		title: "use of undefined capability",
+		explanation: `
+The template does not declare a capability with the given name.
+The template's documentation lists its capabilities.
+
+Erroneous code example:
+
+    import templ Light from templates;
+
+    impl Light with { teleport } for $Device {}
+`,
+	},
+	ConflictingCapabilities: {
+		title: "conflicting capabilities",
+		explanation: `
+Some capabilities of a template exclude each other and cannot be implemented together.
+Choose only one of the conflicting capabilities.
+`,
+	},
+	MissingExtraction: {
+		title: "method does not extract its singleton",
+		explanation: `
+Methods of an impl block must extract the singleton which the block is implemented for.
+
+Erroneous code example:
+
+    impl Light for $Device {
+        fn dim(percent: int) -> bool { true }
+    }
+
+Extract the singleton:
+
+    impl Light for $Device {
+        fn dim(self: $Device, percent: int) -> bool { true }
+    }
+`,
+	},
+	TemplateMethodMismatch: {
+		title: "method does not match the template",
+		explanation: `
+The signature of a method must match the one required by the template.
+This includes the names and types of the parameters, the return type, and the modifier.
+
+Erroneous code example:
+
+    // The template requires: fn dim(percent: int) -> bool
+    impl Light for $Device {
+        fn dim(self: $Device, level: int) -> bool { true }
+    }
+
+Use the required signature:
+
+    impl Light for $Device {
+        fn dim(self: $Device, percent: int) -> bool { true }
+    }
+`,
+	},
+	MissingTemplateMethod: {
+		title: "missing template method",
+		explanation: `
+Every method which is required by the template and the selected capabilities must be implemented.
+
+Erroneous code example:
+
+    // The template requires: fn dim(percent: int) -> bool
+    impl Light for $Device {}
+`,
+	},
+	AdditionalTemplateMethod: {
+		title: "method is not part of the template",
+		explanation: `
+Impl blocks of a template may only contain the methods which are required by the template.
+Move other functions out of the impl block.
+
+Erroneous code example:
+
+    impl Light for $Device {
+        fn dim(self: $Device, percent: int) -> bool { true }
+        fn helper(self: $Device) {}
+    }
+`,
+	},
+
+	//
+	// Warnings.
+	//
+
+	UnusedVariable: {
+		title: "unused variable",
+		explanation: `
+The variable or parameter is never read.
+If this is intentional, prefix its name with an underscore to hide the warning.
+
+Example:
+
+    fn main() {
+        let x = 1;
+    }
+
+Hide the warning:
+
+    fn main() {
+        let _x = 1;
+    }
+`,
+	},
+	UnusedType: {
+		title: "unused type",
+		explanation: `
+The type definition is never used.
+If this is intentional, prefix its name with an underscore to hide the warning.
+
+Example:
+
+    type Point = { x: int, y: int };
+`,
+	},
+	UnusedImport: {
+		title: "unused import",
+		explanation: `
+The imported item is never used and the import can be removed.
+
+Example:
+
+    import { helper } from lib;
+
+    fn main() {}
+`,
+	},
+	UnusedFunction: {
+		title: "unused function",
+		explanation: `
+The function is never called and it is not declared as 'pub'.
+Remove the function or declare it as 'pub' if other modules should use it.
+
+Example:
+
+    fn helper() {}
+
+    fn main() {}
+`,
+	},
+	UnusedSingleton: {
+		title: "unused singleton",
+		explanation: `
+The singleton is never extracted or referenced.
+
+Example:
+
+    $Device = { is_online: bool };
+
+    fn main() {}
+`,
+	},
+	UnreachableMatchArm: {
+		title: "unreachable match arm",
+		explanation: `
+A previous arm already matches every value, therefore this arm is never executed.
+
+Example:
+
+    match x {
+        _ => "any",
+        1 => "one",
+    }
+
+Move the default arm to the end:
+
+    match x {
+        1 => "one",
+        _ => "any",
+    }
+`,
+	},
+	UnreachableCode: {
+		title: "unreachable code",
+		explanation: `
+The code follows a statement which never completes, such as 'return', 'break', or an endless loop.
+Therefore, it is never executed and is removed by the optimizer.
+
+Example:
+
+    fn value() -> int {
+        return 1;
+        println("unreachable");
+    }
+`,
+	},
+	DivisionByZero: {
+		title: "division by zero",
+		explanation: `
+The divisor of an integer division or remainder is the constant zero.
+This expression always causes a runtime error.
+
+Example:
+
+    let x = 1 / 0;
+`,
+	},
+}
@@ -0,0 +1,59 @@
+package formatter
+
+import (
+	"strings"
+
+	"github.com/smarthome-go/homescript/v3/homescript/cst"
+	"github.com/smarthome-go/homescript/v3/homescript/errors"
+	"github.com/smarthome-go/homescript/v3/homescript/lexer"
+	"github.com/smarthome-go/homescript/v3/homescript/parser"
+)
+
+//
+// Source code formatter.
+// Re-emits a program in the canonical Homescript style.
+// The formatter operates on the concrete syntax tree, therefore, it keeps all comments.
+// Line breaks of the original program are kept, the formatter only normalizes:
+// - indentation (4 spaces per level),
+// - spacing between tokens on the same line,
+// - blank lines: at most one, none at the start or end of a block, exactly one between top-level items,
+// - trailing commas: present in lists which span multiple lines, absent otherwise,
+// - imports: sorted by module and imported names.
+//
+
+const indentation = "    "
+
+// Formats the given program.
+// Programs which contain syntax errors are not formatted as their structure is unknown.
+func Format(program string, filename string) (string, []errors.Error) {
+	lex := lexer.NewLexer(program, filename)
+	parser := parser.NewParser(lex, filename)
+	_, softErrors, criticalError := parser.Parse()
+	if criticalError != nil {
+		return "", append(softErrors, *criticalError)
+	}
+	if len(softErrors) > 0 {
+		return "", softErrors
+	}
+
+	tree, treeErrors, lexErr := cst.Parse(program, filename)
+	if lexErr != nil {
+		return "", []errors.Error{*lexErr}
+	}
+	if len(treeErrors) > 0 {
+		return "", treeErrors
+	}
+
+	sortImports(tree)
+
+	printer := newPrinter()
+	printer.analyzeGroups(tree.Children)
+	printer.program(tree)
+
+	output := strings.TrimRight(printer.output.String(), "\n")
+	if output == "" {
+		return "", nil
+	}
+
+	return output + "\n", nil
+}
@@ -0,0 +1,105 @@
+package formatter
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/smarthome-go/homescript/v3/homescript/lexer"
+	"github.com/smarthome-go/homescript/v3/homescript/parser"
+	"github.com/stretchr/testify/assert"
+)
+
+type formatTest struct {
+	name     string
+	input    string
+	expected string
+}
+
+func TestFormat(t *testing.T) {
+	tests := []formatTest{
+		{
+			name:     "spacing",
+			input:    "fn  main( ) {\nlet x=-1+foo (2)*[ 1,2 ] [0];\nlet y:?int=x->a.b~>c;\nfor i in 0..=x{ }\n}\n",
+			expected: "fn main() {\n    let x = -1 + foo(2) * [1, 2][0];\n    let y: ?int = x->a.b~>c;\n    for i in 0..=x {}\n}\n",
+		},
+		{
+			name:     "indentation",
+			input:    "fn main() {\n  if true {\n        println(1);\n  }\n  let x = 1 +\n2;\n}\n",
+			expected: "fn main() {\n    if true {\n        println(1);\n    }\n    let x = 1 +\n        2;\n}\n",
+		},
+		{
+			name:     "trailing commas",
+			input:    "type T = {\n    a: int,\n    b: str\n};\nfn foo(\n    a: int,\n    b: T\n) -> { a: int, } {\n    new { a: foo(1, b,) }\n}\n",
+			expected: "type T = {\n    a: int,\n    b: str,\n};\n\nfn foo(\n    a: int,\n    b: T,\n) -> { a: int } {\n    new { a: foo(1, b) }\n}\n",
+		},
+		{
+			name:     "imports",
+			input:    "// Header.\nimport { b, type A } from z;\n\n// Networking.\nimport { http } from net;\nfn main() {}\n",
+			expected: "// Header.\n\n// Networking.\nimport { http } from net;\nimport { type A, b } from z;\n\nfn main() {}\n",
+		},
+		{
+			name:     "blank lines and comments",
+			input:    "\n\nlet a = 1; // One.\nlet b = 2;\n\n\n\nlet c = 3;\nfn main() {\n\n    /* Block. */ foo();\n\n\n    // Line.\n    bar();\n\n}\n\n\n",
+			expected: "let a = 1; // One.\nlet b = 2;\n\nlet c = 3;\n\nfn main() {\n    /* Block. */ foo();\n\n    // Line.\n    bar();\n}\n",
+		},
+	}
+
+	for _, test := range tests {
+		formatted, errors := Format(test.input, "test")
+		assert.Empty(t, errors, test.name)
+		assert.Equal(t, test.expected, formatted, test.name)
+
+		again, _ := Format(formatted, "test")
+		assert.Equal(t, formatted, again, test.name)
+	}
+}
+
+// Returns the lines of the AST of the program.
+// As the formatter reorders imports and imported names, the lines and the words of each import are sorted.
+func astLines(t *testing.T, program string) []string {
+	parser := parser.NewParser(lexer.NewLexer(program, "test"), "test")
+	tree, softErrors, err := parser.Parse()
+	assert.Nil(t, err)
+	assert.Empty(t, softErrors)
+
+	lines := strings.Split(tree.String(), "\n")
+	for idx, line := range lines {
+		if strings.HasPrefix(line, "import") {
+			words := strings.Fields(strings.ReplaceAll(line, ",", " "))
+			sort.Strings(words)
+			lines[idx] = strings.Join(words, " ")
+		}
+	}
+
+	sort.Strings(lines)
+	return lines
+}
+
+func TestFormatPreservesPrograms(t *testing.T) {
+	files := make([]string, 0)
+	for _, pattern := range []string{"../../examples/*.hms", "../../tests/*.hms"} {
+		matches, err := filepath.Glob(pattern)
+		assert.NoError(t, err)
+		files = append(files, matches...)
+	}
+
+	for _, file := range files {
+		program, err := os.ReadFile(file)
+		assert.NoError(t, err)
+
+		formatted, errors := Format(string(program), file)
+		// Some test files are deliberately invalid.
+		if len(errors) > 0 {
+			continue
+		}
+
+		again, errors := Format(formatted, file)
+		assert.Empty(t, errors, file)
+		assert.Equal(t, formatted, again, file)
+
+		assert.Equal(t, astLines(t, string(program)), astLines(t, formatted), file)
+	}
+}
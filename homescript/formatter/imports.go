@@ -0,0 +1,158 @@
+package formatter
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/smarthome-go/homescript/v3/homescript/cst"
+	"github.com/smarthome-go/homescript/v3/homescript/lexer"
+)
+
+//
+// Import sorting
+//
+
+// Sorts each run of consecutive import items by the imported module and the imported names.
+// Comments in front of an import move together with it.
+// However, the comments in front of the first import of a run stay at the top as they usually describe the file.
+func sortImports(tree *cst.Node) {
+	start := -1
+
+	for idx := 0; idx <= len(tree.Children); idx++ {
+		isImport := false
+		if idx < len(tree.Children) {
+			node, isNode := tree.Children[idx].(*cst.Node)
+			isImport = isNode && node.Kind == cst.ImportNodeKind
+		}
+
+		switch {
+		case isImport && start == -1:
+			start = idx
+		case !isImport && start != -1:
+			sortImportRun(tree.Children[start:idx])
+			start = -1
+		}
+	}
+}
+
+func sortImportRun(run []cst.Element) {
+	for _, item := range run {
+		sortImportedNames(item.(*cst.Node))
+	}
+
+	header := run[0].(*cst.Node).FirstToken()
+	headerTrivia := header.Leading
+	header.Leading = []lexer.Trivia{{Kind: lexer.NewlineTrivia, Text: "\n"}}
+
+	sort.SliceStable(run, func(i, j int) bool {
+		return importKey(run[i].(*cst.Node)) < importKey(run[j].(*cst.Node))
+	})
+
+	first := run[0].(*cst.Node).FirstToken()
+	if first == header {
+		first.Leading = headerTrivia
+	} else {
+		first.Leading = append(headerTrivia, first.Leading...)
+	}
+}
+
+// Returns the module path of the import, followed by the imported names.
+func importKey(item *cst.Node) string {
+	names := strings.Builder{}
+	module := strings.Builder{}
+	target := &names
+
+	for _, token := range item.Tokens() {
+		switch token.Kind {
+		case lexer.From:
+			target = &module
+		case lexer.Semicolon, lexer.LCurly, lexer.RCurly, lexer.Comma:
+		default:
+			target.WriteString(token.Text)
+			target.WriteByte(' ')
+		}
+	}
+
+	return module.String() + "\x00" + names.String()
+}
+
+// Sorts the names in `import { b, a } from c;`.
+// Lists which contain comments are left alone as it is unclear which names the comments refer to.
+func sortImportedNames(item *cst.Node) {
+	var group *cst.Node
+	for _, child := range item.Children {
+		if node, isNode := child.(*cst.Node); isNode && node.Kind == cst.GroupNodeKind {
+			group = node
+			break
+		}
+	}
+
+	if group == nil || len(group.Children) < 2 {
+		return
+	}
+
+	content := group.Children[1 : len(group.Children)-1]
+	for _, token := range group.Tokens()[1:] {
+		if len(token.LeadingComments()) > 0 || hasComments(token.Trailing) {
+			return
+		}
+	}
+
+	commas := make([]cst.Element, 0)
+	segments := make([][]cst.Element, 0)
+	current := make([]cst.Element, 0)
+
+	for _, element := range content {
+		if token, isToken := element.(*cst.Token); isToken && token.Kind == lexer.Comma {
+			commas = append(commas, token)
+			segments = append(segments, current)
+			current = make([]cst.Element, 0)
+			continue
+		}
+		current = append(current, element)
+	}
+	if len(current) > 0 {
+		segments = append(segments, current)
+	}
+
+	// Names are compared without their kind, for instance, `type Foo` sorts as `Foo`.
+	sort.SliceStable(segments, func(i, j int) bool {
+		left, right := segments[i], segments[j]
+		return elementText(left[len(left)-1]) < elementText(right[len(right)-1])
+	})
+
+	sorted := []cst.Element{group.Children[0]}
+	for idx, segment := range segments {
+		sorted = append(sorted, segment...)
+		if idx < len(commas) {
+			sorted = append(sorted, commas[idx])
+		}
+	}
+	sorted = append(sorted, group.Children[len(group.Children)-1])
+
+	group.Children = sorted
+}
+
+func elementText(element cst.Element) string {
+	switch element := element.(type) {
+	case *cst.Token:
+		return element.Text
+	case *cst.Node:
+		texts := make([]string, 0)
+		for _, token := range element.Tokens() {
+			texts = append(texts, token.Text)
+		}
+		return strings.Join(texts, " ")
+	default:
+		panic("A new element type was added without updating this code")
+	}
+}
+
+func hasComments(trivia []lexer.Trivia) bool {
+	for _, item := range trivia {
+		if item.Kind == lexer.LineCommentTrivia || item.Kind == lexer.BlockCommentTrivia {
+			return true
+		}
+	}
+	return false
+}
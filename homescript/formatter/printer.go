@@ -0,0 +1,542 @@
+package formatter
+
+import (
+	"strings"
+
+	"github.com/smarthome-go/homescript/v3/homescript/cst"
+	"github.com/smarthome-go/homescript/v3/homescript/lexer"
+)
+
+type group struct {
+	// Indentation of the line which contains the opening delimiter, this is also used for the closing delimiter.
+	outer int
+	// Indentation of the lines inside the group.
+	inner int
+}
+
+type printer struct {
+	output      strings.Builder
+	atLineStart bool
+	// Indentation of the current line.
+	lineIndent int
+	groups     []group
+	// The last two tokens which were printed.
+	previous       *cst.Token
+	beforePrevious *cst.Token
+	// Is set while printing the module path of an import, which must not contain spaces.
+	inImportPath bool
+	// Tokens after which a trailing comma is inserted.
+	insertComma map[*cst.Token]bool
+	// Trailing commas which are removed.
+	dropComma map[*cst.Token]bool
+	// Occurrences of `->` which denote a return type instead of a member access.
+	returnArrows map[*cst.Token]bool
+}
+
+func newPrinter() printer {
+	return printer{
+		output:         strings.Builder{},
+		atLineStart:    true,
+		lineIndent:     0,
+		groups:         make([]group, 0),
+		previous:       nil,
+		beforePrevious: nil,
+		inImportPath:   false,
+		insertComma:    make(map[*cst.Token]bool),
+		dropComma:      make(map[*cst.Token]bool),
+		returnArrows:   make(map[*cst.Token]bool),
+	}
+}
+
+//
+// Analysis of groups
+//
+
+// Decides where trailing commas are inserted or removed and which arrows denote return types.
+func (self *printer) analyzeGroups(elements []cst.Element) {
+	for idx, element := range elements {
+		switch element := element.(type) {
+		case *cst.Token:
+			if element.Kind == lexer.Arrow && isParameterList(elements, idx-1) {
+				self.returnArrows[element] = true
+			}
+		case *cst.Node:
+			if element.Kind == cst.GroupNodeKind {
+				var previous *cst.Token
+				if idx > 0 {
+					previous = lastToken(elements[idx-1])
+				}
+				self.analyzeCommas(element, previous)
+			}
+			self.analyzeGroups(element.Children)
+		}
+	}
+}
+
+// Reports whether the element at the index is the parameter list of a function or function type.
+func isParameterList(elements []cst.Element, idx int) bool {
+	if idx < 1 {
+		return false
+	}
+
+	group, isNode := elements[idx].(*cst.Node)
+	if !isNode || group.Kind != cst.GroupNodeKind || group.FirstToken().Kind != lexer.LParen {
+		return false
+	}
+
+	switch lastToken(elements[idx-1]).Kind {
+	case lexer.Fn:
+		return true
+	case lexer.Identifier, lexer.Underscore:
+		return idx >= 2 && lastToken(elements[idx-2]).Kind == lexer.Fn
+	default:
+		return false
+	}
+}
+
+func (self *printer) analyzeCommas(group *cst.Node, previous *cst.Token) {
+	open := group.FirstToken()
+	close, isClosed := group.Children[len(group.Children)-1].(*cst.Token)
+	if !isClosed || len(group.Children) < 3 || !isClosingDelimiter(close.Kind) {
+		return
+	}
+
+	content := group.Children[1 : len(group.Children)-1]
+	if !isList(open, content, previous) {
+		return
+	}
+
+	last := lastToken(content[len(content)-1])
+
+	if close.LeadingNewlines() == 0 {
+		if last.Kind == lexer.Comma && !hasComments(last.Leading) && !hasComments(last.Trailing) {
+			self.dropComma[last] = true
+		}
+		return
+	}
+
+	if last.Kind == lexer.Comma {
+		return
+	}
+
+	// Match arms which end with a block do not require a comma.
+	if last.Kind == lexer.RCurly && containsToken(content, lexer.FatArrow) {
+		return
+	}
+
+	self.insertComma[last] = true
+}
+
+// Reports whether the group is a comma-separated list, where a trailing comma is allowed.
+func isList(open *cst.Token, content []cst.Element, previous *cst.Token) bool {
+	if containsToken(content, lexer.Comma) {
+		return true
+	}
+
+	switch open.Kind {
+	case lexer.LParen:
+		// Calls and parameter lists, but not grouped expressions.
+		return previous != nil && isCallable(previous.Kind)
+	case lexer.LCurly:
+		// Objects and object types with a single field, but not blocks.
+		return containsToken(content, lexer.Colon) &&
+			!containsToken(content, lexer.Semicolon) &&
+			!containsToken(content, lexer.FatArrow) &&
+			!containsToken(content, lexer.Assign)
+	default:
+		return false
+	}
+}
+
+// Reports whether the elements contain a token of the given kind, nested groups are not searched.
+func containsToken(elements []cst.Element, kind lexer.TokenKind) bool {
+	for _, element := range elements {
+		if token, isToken := element.(*cst.Token); isToken && token.Kind == kind {
+			return true
+		}
+	}
+	return false
+}
+
+func lastToken(element cst.Element) *cst.Token {
+	switch element := element.(type) {
+	case *cst.Token:
+		return element
+	case *cst.Node:
+		tokens := element.Tokens()
+		return tokens[len(tokens)-1]
+	default:
+		panic("A new element type was added without updating this code")
+	}
+}
+
+//
+// Token classes
+//
+
+func isClosingDelimiter(kind lexer.TokenKind) bool {
+	return kind == lexer.RParen || kind == lexer.RBracket || kind == lexer.RCurly
+}
+
+// Reports whether a `(` after the token is a call or a parameter list.
+func isCallable(kind lexer.TokenKind) bool {
+	switch kind {
+	case lexer.Identifier, lexer.Underscore, lexer.Fn, lexer.RParen, lexer.RBracket:
+		return true
+	default:
+		return false
+	}
+}
+
+// Reports whether a `[` after the token is an index.
+func isIndexable(kind lexer.TokenKind) bool {
+	switch kind {
+	case lexer.Identifier, lexer.Underscore, lexer.String, lexer.RParen, lexer.RBracket, lexer.HashTag:
+		return true
+	default:
+		return false
+	}
+}
+
+// Reports whether the token can end an operand, this is used to distinguish a binary from a unary `-`.
+func endsOperand(token *cst.Token) bool {
+	if token == nil {
+		return false
+	}
+
+	switch token.Kind {
+	case lexer.Identifier, lexer.Underscore, lexer.Int, lexer.Float, lexer.String,
+		lexer.True, lexer.False, lexer.None, lexer.Null,
+		lexer.RParen, lexer.RBracket, lexer.RCurly:
+		return true
+	default:
+		return false
+	}
+}
+
+func isBinaryOperator(kind lexer.TokenKind) bool {
+	switch kind {
+	case lexer.Or, lexer.And, lexer.Equal, lexer.NotEqual, lexer.LessThan, lexer.LessThanEqual,
+		lexer.GreaterThan, lexer.GreaterThanEqual, lexer.Plus, lexer.Minus, lexer.Multiply,
+		lexer.Divide, lexer.Modulo, lexer.Power, lexer.ShiftLeft, lexer.ShiftRight,
+		lexer.BitOr, lexer.BitAnd, lexer.BitXor,
+		lexer.Assign, lexer.PlusAssign, lexer.MinusAssign, lexer.MultiplyAssign,
+		lexer.DivideAssign, lexer.PowerAssign, lexer.ModuloAssign, lexer.ShiftLeftAssign,
+		lexer.ShiftRightAssign, lexer.BitOrAssign, lexer.BitAndAssign, lexer.BitXorAssign,
+		lexer.FatArrow, lexer.As:
+		return true
+	default:
+		return false
+	}
+}
+
+func (self *printer) isUnary(token *cst.Token, previous *cst.Token) bool {
+	switch token.Kind {
+	case lexer.Not, lexer.QuestionMark:
+		return true
+	case lexer.Minus:
+		return !endsOperand(previous)
+	default:
+		return false
+	}
+}
+
+func (self *printer) isMemberOperator(token *cst.Token) bool {
+	switch token.Kind {
+	case lexer.Dot, lexer.TildeArrow:
+		return true
+	case lexer.Arrow:
+		return !self.returnArrows[token]
+	default:
+		return false
+	}
+}
+
+// Reports whether the line which starts with the token continues the expression of the previous line.
+func (self *printer) isContinuation(token *cst.Token) bool {
+	if self.previous == nil {
+		return false
+	}
+
+	if self.isMemberOperator(token) || token.Kind == lexer.DoubleDot ||
+		isBinaryOperator(token.Kind) && !self.isUnary(token, self.previous) {
+		return true
+	}
+
+	return self.isMemberOperator(self.previous) || self.returnArrows[self.previous] ||
+		isBinaryOperator(self.previous.Kind) && !self.isUnary(self.previous, self.beforePrevious)
+}
+
+// Decides whether a space is required between the previous and the current token on the same line.
+func (self *printer) spaceBefore(token *cst.Token) bool {
+	previous := self.previous
+
+	switch {
+	case previous == nil:
+		return false
+	case self.inImportPath && previous.Kind != lexer.From:
+		return false
+	case previous.Kind == lexer.LParen || previous.Kind == lexer.LBracket:
+		return false
+	case token.Kind == lexer.RParen || token.Kind == lexer.RBracket:
+		return false
+	case previous.Kind == lexer.LCurly:
+		return token.Kind != lexer.RCurly
+	case token.Kind == lexer.RCurly:
+		return true
+	case token.Kind == lexer.Comma || token.Kind == lexer.Semicolon || token.Kind == lexer.Colon:
+		return false
+	case self.isMemberOperator(token) || self.isMemberOperator(previous):
+		return false
+	case token.Kind == lexer.DoubleDot || previous.Kind == lexer.DoubleDot:
+		return false
+	// The `=` of an inclusive range, such as in `0..=10`.
+	case previous.Kind == lexer.Assign && self.beforePrevious != nil && self.beforePrevious.Kind == lexer.DoubleDot:
+		return false
+	case self.isUnary(previous, self.beforePrevious):
+		return false
+	case previous.Kind == lexer.AtSymbol || previous.Kind == lexer.DollarSymbol || previous.Kind == lexer.HashTag:
+		return false
+	case token.Kind == lexer.LParen:
+		return !isCallable(previous.Kind)
+	case token.Kind == lexer.LBracket:
+		return !isIndexable(previous.Kind)
+	default:
+		return true
+	}
+}
+
+//
+// Printing
+//
+
+type breakPolicy struct {
+	// Bounds of the line feeds in front of the first comment or the token.
+	firstMin int
+	firstMax int
+	// Upper bound of the line feeds directly in front of the token.
+	lastMax int
+}
+
+// At most one blank line.
+var defaultBreaks = breakPolicy{firstMin: 0, firstMax: 2, lastMax: 2}
+
+func (self *printer) program(tree *cst.Node) {
+	var previous *cst.Node
+
+	for _, child := range tree.Children {
+		switch child := child.(type) {
+		case *cst.Node:
+			self.item(child, previous)
+			previous = child
+		case *cst.Token:
+			self.token(child, defaultBreaks, nil)
+		}
+	}
+}
+
+func (self *printer) item(item *cst.Node, previous *cst.Node) {
+	var breaks breakPolicy
+
+	switch {
+	case previous == nil:
+		breaks = breakPolicy{firstMin: 0, firstMax: 0, lastMax: 2}
+	case previous.Kind == cst.ImportNodeKind && item.Kind == cst.ImportNodeKind:
+		breaks = breakPolicy{firstMin: 1, firstMax: 1, lastMax: 2}
+	case previous.Kind == item.Kind && item.Kind != cst.FunctionNodeKind && item.Kind != cst.ImplNodeKind &&
+		isSingleLine(previous) && isSingleLine(item):
+		// Related single-line items, such as globals, may be grouped.
+		breaks = breakPolicy{firstMin: 1, firstMax: 2, lastMax: 2}
+	default:
+		breaks = breakPolicy{firstMin: 2, firstMax: 2, lastMax: 2}
+	}
+
+	self.elements(item.Children, breaks)
+	self.inImportPath = false
+}
+
+func isSingleLine(node *cst.Node) bool {
+	span := node.Span()
+	return span.Start.Line == span.End.Line
+}
+
+// Prints the elements, the policy applies to the first token.
+func (self *printer) elements(elements []cst.Element, breaks breakPolicy) {
+	for idx, element := range elements {
+		if idx > 0 {
+			breaks = defaultBreaks
+		}
+
+		switch element := element.(type) {
+		case *cst.Token:
+			self.token(element, breaks, nil)
+		case *cst.Node:
+			self.group(element, breaks)
+		}
+	}
+}
+
+func (self *printer) group(node *cst.Node, breaks breakPolicy) {
+	open := node.Children[0].(*cst.Token)
+	self.token(open, breaks, nil)
+
+	frame := group{outer: self.lineIndent, inner: self.lineIndent + 1}
+	self.groups = append(self.groups, frame)
+
+	for idx, element := range node.Children[1:] {
+		breaks := defaultBreaks
+		// There are no blank lines at the start of a group.
+		if idx == 0 {
+			breaks.firstMax = 1
+		}
+
+		if token, isToken := element.(*cst.Token); isToken && idx == len(node.Children)-2 && isClosingDelimiter(token.Kind) {
+			// There are no blank lines at the end of a group and empty groups are printed as `{}`.
+			breaks.lastMax = 1
+			if idx == 0 && !hasComments(token.Leading) {
+				breaks.firstMax = 0
+			}
+
+			// Comments in front of the closing delimiter still belong to the group.
+			self.token(token, breaks, &frame)
+			self.groups = self.groups[:len(self.groups)-1]
+			return
+		}
+
+		switch element := element.(type) {
+		case *cst.Token:
+			self.token(element, breaks, nil)
+		case *cst.Node:
+			self.group(element, breaks)
+		}
+	}
+
+	// The group is never closed.
+	self.groups = self.groups[:len(self.groups)-1]
+}
+
+func (self *printer) baseIndent() int {
+	if len(self.groups) == 0 {
+		return 0
+	}
+	return self.groups[len(self.groups)-1].inner
+}
+
+func (self *printer) lineBreak(count int) {
+	// The output never starts with blank lines.
+	if count == 0 || self.output.Len() == 0 {
+		return
+	}
+
+	for i := 0; i < count; i++ {
+		self.output.WriteByte('\n')
+	}
+	self.atLineStart = true
+}
+
+func (self *printer) indent(level int) {
+	self.lineIndent = level
+	self.output.WriteString(strings.Repeat(indentation, level))
+	self.atLineStart = false
+}
+
+// Line comments do not keep trailing whitespace.
+func commentText(comment lexer.Trivia) string {
+	if comment.Kind == lexer.LineCommentTrivia {
+		return strings.TrimRight(comment.Text, " \t\r")
+	}
+	return comment.Text
+}
+
+func clamp(value int, lower int, upper int) int {
+	return max(lower, min(value, upper))
+}
+
+// Prints the token, its leading comments and its trailing comments.
+// If the token closes a group, the frame of the group is given.
+func (self *printer) token(token *cst.Token, breaks breakPolicy, closes *group) {
+	// Collect the comments in front of the token and the line feeds in front of each of them.
+	type leadingComment struct {
+		breaks  int
+		comment lexer.Trivia
+	}
+
+	comments := make([]leadingComment, 0)
+	count := 0
+
+	for _, trivia := range token.Leading {
+		switch trivia.Kind {
+		case lexer.NewlineTrivia:
+			count++
+		case lexer.LineCommentTrivia, lexer.BlockCommentTrivia:
+			comments = append(comments, leadingComment{breaks: count, comment: trivia})
+			count = 0
+		}
+	}
+
+	for idx, item := range comments {
+		lower, upper := 0, 2
+		if idx == 0 {
+			lower, upper = breaks.firstMin, breaks.firstMax
+		}
+		// A line comment always ends its line.
+		if idx > 0 && comments[idx-1].comment.Kind == lexer.LineCommentTrivia {
+			lower = max(lower, 1)
+		}
+
+		self.lineBreak(clamp(item.breaks, lower, upper))
+
+		if self.atLineStart {
+			self.indent(self.baseIndent())
+		} else if self.output.Len() > 0 {
+			self.output.WriteByte(' ')
+		}
+		self.output.WriteString(commentText(item.comment))
+	}
+
+	lower, upper := 0, breaks.lastMax
+	if len(comments) == 0 {
+		lower, upper = breaks.firstMin, min(breaks.firstMax, breaks.lastMax)
+	} else if comments[len(comments)-1].comment.Kind == lexer.LineCommentTrivia {
+		lower = 1
+	}
+	self.lineBreak(clamp(count, lower, upper))
+
+	if token.Kind == lexer.EOF {
+		return
+	}
+
+	// Print the token itself.
+	switch {
+	case self.atLineStart && closes != nil:
+		self.indent(closes.outer)
+	case self.atLineStart && self.isContinuation(token):
+		self.indent(self.baseIndent() + 1)
+	case self.atLineStart:
+		self.indent(self.baseIndent())
+	case self.dropComma[token]:
+	case self.output.Len() > 0 && (self.spaceBefore(token) || len(comments) > 0):
+		self.output.WriteByte(' ')
+	}
+
+	if !self.dropComma[token] {
+		self.output.WriteString(token.Text)
+		self.beforePrevious = self.previous
+		self.previous = token
+	}
+
+	if self.insertComma[token] {
+		self.output.WriteByte(',')
+	}
+
+	for _, trivia := range token.Trailing {
+		if trivia.Kind == lexer.LineCommentTrivia || trivia.Kind == lexer.BlockCommentTrivia {
+			self.output.WriteByte(' ')
+			self.output.WriteString(commentText(trivia))
+		}
+	}
+
+	if token.Kind == lexer.From {
+		self.inImportPath = true
+	}
+}
@@ -89,6 +89,8 @@ func (self *Transformer) Transform(tree ast.AnalyzedProgram) ast.AnalyzedProgram
 			NeedsRuntimeTypeValidation: glob.NeedsRuntimeTypeValidation,
 			OptType:                    glob.OptType,
 			Range:                      glob.Range,
+			IsPub:                      glob.IsPub,
+			DocComment:                 glob.DocComment,
 		}
 
 		output.Globals = append(output.Globals, newGlob)
@@ -125,5 +127,6 @@ func (self *Transformer) Function(node ast.AnalyzedFunctionDefinition) ast.Analy
 		Body:       self.Block(node.Body),
 		Modifier:   node.Modifier,
 		Range:      node.Range,
+		DocComment: node.DocComment,
 	}
 }
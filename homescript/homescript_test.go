@@ -9,8 +9,10 @@ import (
 	"testing"
 	"time"
 
+	"github.com/smarthome-go/homescript/v3/homescript/analyzer/ast"
 	"github.com/smarthome-go/homescript/v3/homescript/compiler"
 	"github.com/smarthome-go/homescript/v3/homescript/diagnostic"
+	"github.com/smarthome-go/homescript/v3/homescript/optimizer"
 	"github.com/smarthome-go/homescript/v3/homescript/runtime"
 	"github.com/stretchr/testify/assert"
 )
@@ -194,6 +196,66 @@ func TestScripts(t *testing.T) {
 			OverrideTimeout:    0,
 			UseOverrideTimeout: false,
 		},
+		{
+			Name:               "match_drop_regression",
+			Path:               "../tests/regression_match_drop.hms",
+			IsGlob:             false,
+			Debug:              false,
+			ExpectedOutputFile: "",
+			ExpectedOutputRaw:  "11667\n",
+			ValidateOutput:     OUTPUT_VALIDATION_RAW,
+			Skip:               false,
+			OverrideTimeout:    0,
+			UseOverrideTimeout: false,
+		},
+		{
+			Name:               "tail_calls",
+			Path:               "../tests/tail_calls.hms",
+			IsGlob:             false,
+			Debug:              false,
+			ExpectedOutputFile: "",
+			ExpectedOutputRaw:  "100000\nfalse\n3628800\n20001\n",
+			ValidateOutput:     OUTPUT_VALIDATION_RAW,
+			Skip:               false,
+			OverrideTimeout:    0,
+			UseOverrideTimeout: false,
+		},
+		{
+			Name:               "try_control_flow",
+			Path:               "../tests/try_control_flow.hms",
+			IsGlob:             false,
+			Debug:              false,
+			ExpectedOutputFile: "",
+			ExpectedOutputRaw:  "1\n2\n8\n4\n25\n",
+			ValidateOutput:     OUTPUT_VALIDATION_RAW,
+			Skip:               false,
+			OverrideTimeout:    0,
+			UseOverrideTimeout: false,
+		},
+		{
+			Name:               "optimizer",
+			Path:               "../tests/optimizer.hms",
+			IsGlob:             false,
+			Debug:              false,
+			ExpectedOutputFile: "",
+			ExpectedOutputRaw:  "3\n3 1 -3 1024\n16 64 15 2 5\n3 2.5\nfoobar abc\nfalse true false\ntrue false true false\n-9223372036854775808\n25 36 16\n0 10 5\nyes no\n12 5\n330\n",
+			ValidateOutput:     OUTPUT_VALIDATION_RAW,
+			Skip:               false,
+			OverrideTimeout:    0,
+			UseOverrideTimeout: false,
+		},
+		{
+			Name:               "imports_from_a",
+			Path:               "../tests/imports_from_a.hms",
+			IsGlob:             false,
+			Debug:              false,
+			ExpectedOutputFile: "",
+			ExpectedOutputRaw:  "",
+			ValidateOutput:     OUTPUT_VALIDATION_RAW,
+			Skip:               false,
+			OverrideTimeout:    0,
+			UseOverrideTimeout: false,
+		},
 		{
 			Name:               "Linear Gradient",
 			Path:               "../tests/linear_gradient_fuzz/*.hms",
@@ -347,12 +409,78 @@ func execTest(test Test, expectedOutputCache string, t *testing.T) {
 		return
 	}
 
-	compilerStruct := compiler.NewCompiler(modules, test.Path)
+	// The program is executed with and without the optimizer, both runs must produce the same output.
+	// It is compiled before the optimizer runs, as the optimizer must not alter the analyzed modules.
+	output, ok := runTestProgram(t, test, code, modules)
+	if !ok {
+		return
+	}
+
+	opt := optimizer.NewOptimizer()
+	optimized, optimizerDiagnostics := opt.Optimize(modules)
+	for _, d := range optimizerDiagnostics {
+		if d.Level == diagnostic.DiagnosticLevelError {
+			file, err := os.ReadFile(d.Span.Filename)
+			assert.NoError(t, err)
+
+			t.Error(d.Display(string(file)))
+			return
+		}
+	}
+
+	optimizedOutput, ok := runTestProgram(t, test, code, optimized)
+	if !ok {
+		return
+	}
+
+	assert.Equal(t, output, optimizedOutput, "Output of the optimized program does not match the output of the unoptimized program.")
+
+	switch test.ValidateOutput {
+	case OUTPUT_VALIDATION_NONE:
+		break
+	case OUTPUT_VALIDATION_FILE:
+		assert.Equal(t, expectedOutputCache, output, "Generated output does match expected output.")
+	case OUTPUT_VALIDATION_RAW:
+		assert.Equal(t, test.ExpectedOutputRaw, output, "Generated output does match expected output.")
+	}
+}
+
+// Compiles and runs the analyzed modules of a test and returns everything the program printed.
+func runTestProgram(t *testing.T, test Test, code []byte, modules map[string]ast.AnalyzedProgram) (string, bool) {
+	compilerStruct := compiler.NewCompiler(optimizer.TreeShake(modules, test.Path), test.Path)
 	compiled, err := compilerStruct.Compile()
 	if err != nil {
 		panic(fmt.Sprintf("compiler failed: %s", err.Error()))
 	}
 
+	// The program is executed from its decoded bytecode so that the bytecode format is tested as well.
+	sources, err := ModuleSources(InputProgram{Filename: test.Path, ProgramText: string(code)}, TestingAnalyzerHost{IsInvokedInTests: true})
+	assert.NoError(t, err)
+	sourceHash := compiler.HashSources(sources)
+	bytecode, err := compiler.EncodeBytecode(compiled, sourceHash)
+	if err != nil {
+		panic(fmt.Sprintf("bytecode encoding failed: %s", err.Error()))
+	}
+
+	decoded, err := compiler.DecodeCachedBytecode(bytecode, sourceHash)
+	if err != nil {
+		panic(fmt.Sprintf("bytecode decoding failed: %s", err.Error()))
+	}
+
+	assert.Equal(t, len(compiled.Functions), len(decoded.Functions))
+	assert.Equal(t, compiled.Variables, decoded.Variables)
+	for name, instructions := range compiled.Functions {
+		assert.Equal(t, len(instructions), len(decoded.Functions[name]), name)
+	}
+	compiled = decoded
+
+	// The assembly listing must be parsable into an identical listing.
+	listing := compiled.AsmString(false)
+	assembled, err := compiler.ParseAsm(listing)
+	if assert.NoError(t, err) {
+		assert.Equal(t, listing, assembled.AsmString(false))
+	}
+
 	if test.Debug {
 		i := 0
 		for name, function := range compiled.Functions {
@@ -381,11 +509,16 @@ func execTest(test Test, expectedOutputCache string, t *testing.T) {
 
 	start := time.Now()
 
-	vm := runtime.NewVM(compiled, executor, &ctx, &cancel, TestingVmScopeAdditions(), runtime.CoreLimits{
+	// The decoded bytecode is verified like any bytecode which is loaded from a cache.
+	vm, err := runtime.NewVerifiedVM(compiled, executor, &ctx, &cancel, TestingVmScopeAdditions(), runtime.CoreLimits{
 		CallStackMaxSize: 10024,
 		StackMaxSize:     10024,
 		MaxMemorySize:    10024,
 	})
+	if !assert.NoError(t, err) {
+		cancel()
+		return "", false
+	}
 
 	// TODO: how to handle the debugger at this point?
 
@@ -417,15 +550,5 @@ func execTest(test Test, expectedOutputCache string, t *testing.T) {
 		panic(fmt.Sprintf("Core %d crashed", coreNum))
 	}
 
-	buf := *executor.PrintBuf
-
-	switch test.ValidateOutput {
-	case OUTPUT_VALIDATION_NONE:
-		break
-	case OUTPUT_VALIDATION_FILE:
-		assert.Equal(t, expectedOutputCache, buf, "Generated output does match expected output.")
-	case OUTPUT_VALIDATION_RAW:
-		assert.Equal(t, test.ExpectedOutputRaw, buf, "Generated output does match expected output.")
-	}
-
+	return *executor.PrintBuf, true
 }
@@ -20,6 +20,12 @@ type Lexer struct {
 	program      []rune
 	location     errors.Location
 	filename     string
+	// If set, trivia and the source text of each token are recorded.
+	lossless   bool
+	lastTrivia []Trivia
+	lastSource string
+	// Doc comments which are attached to the next token.
+	docComment []string
 }
 
 func NewLexer(program_source string, filename string) Lexer {
@@ -49,11 +55,29 @@ func NewLexer(program_source string, filename string) Lexer {
 			Line:   1,
 			Column: 1,
 		},
-		filename: filename,
+		filename:   filename,
+		lossless:   false,
+		lastTrivia: nil,
+		lastSource: "",
+		docComment: nil,
 	}
 	return lexer
 }
 
+// Creates a lexer which records the trivia and the source text of each token.
+// This is required by tools which must be able to reproduce the exact source code.
+func NewLosslessLexer(program_source string, filename string) Lexer {
+	lexer := NewLexer(program_source, filename)
+	lexer.lossless = true
+	return lexer
+}
+
+// Returns the trivia in front of the last token and the source text of the last token.
+// This is only available if the lexer was created using `NewLosslessLexer`.
+func (self *Lexer) LastTokenSource() (trivia []Trivia, source string) {
+	return self.lastTrivia, self.lastSource
+}
+
 func (self *Lexer) advance() {
 	// ddvance location
 	self.location.Advance(self.currentChar != nil && *self.currentChar == '\n')
@@ -79,13 +103,28 @@ func (self *Lexer) skipLineComment() {
 	self.advance()
 	self.advance()
 
+	start := self.currentIndex
 	for self.currentChar != nil && *self.currentChar != '\n' {
 		self.advance()
 	}
 
+	if line, isDoc := docCommentLine(string(self.program[start:min(self.currentIndex, len(self.program))])); isDoc {
+		self.docComment = append(self.docComment, line)
+	}
+
 	self.advance()
 }
 
+// Returns the text of a doc comment line, given the content of a line comment after its `//`.
+// Doc comments start with exactly three slashes, `////` is treated like a normal comment.
+func docCommentLine(content string) (string, bool) {
+	if !strings.HasPrefix(content, "/") || strings.HasPrefix(content, "//") {
+		return "", false
+	}
+
+	return strings.TrimSuffix(strings.TrimPrefix(content[1:], " "), "\r"), true
+}
+
 func (self *Lexer) skipBlockComment() {
 	self.advance()
 	self.advance()
@@ -106,6 +145,25 @@ func (self *Lexer) skipBlockComment() {
 }
 
 func (self *Lexer) NextToken() (Token, *errors.Error) {
+	if !self.lossless {
+		token, err := self.nextToken()
+		token.DocComment = self.docComment
+		self.docComment = nil
+		return token, err
+	}
+
+	self.lastTrivia = self.lexTrivia()
+	start := self.currentIndex
+
+	token, err := self.nextToken()
+	self.lastSource = string(self.program[start:min(self.currentIndex, len(self.program))])
+	token.DocComment = self.docComment
+	self.docComment = nil
+
+	return token, err
+}
+
+func (self *Lexer) nextToken() (Token, *errors.Error) {
 outer:
 	for self.currentChar != nil {
 		switch *self.currentChar {
@@ -184,11 +242,11 @@ outer:
 			if util.IsLetter(*self.currentChar) {
 				return self.makeName(), nil
 			}
-			return UnknownToken(self.location), errors.NewError(errors.Span{
+			return UnknownToken(self.location), errors.NewSyntaxError(errors.Span{
 				Start:    self.location,
 				End:      self.location,
 				Filename: self.filename,
-			}, fmt.Sprintf("illegal character: %c", *self.currentChar), errors.SyntaxError)
+			}, errors.IllegalCharacter, fmt.Sprintf("illegal character: %c", *self.currentChar))
 		}
 	}
 	return newToken(
@@ -228,11 +286,11 @@ func (self *Lexer) makeString() (Token, *errors.Error) {
 
 	// check for closing quote
 	if self.currentChar == nil {
-		return UnknownToken(startLocation), errors.NewError(errors.Span{
+		return UnknownToken(startLocation), errors.NewSyntaxError(errors.Span{
 			Start:    startLocation,
 			End:      self.location,
 			Filename: self.filename,
-		}, "String literal never closed", errors.SyntaxError)
+		}, errors.UnterminatedString, "String literal never closed")
 	}
 
 	token := newToken(
@@ -254,11 +312,11 @@ func (self *Lexer) makeEscapeSequence() (rune, *errors.Error) {
 	startLocation := self.location
 	self.advance()
 	if self.currentChar == nil {
-		return ' ', errors.NewError(errors.Span{
+		return ' ', errors.NewSyntaxError(errors.Span{
 			Start:    startLocation,
 			End:      self.location,
 			Filename: self.filename,
-		}, "Unfinished escape sequence", errors.SyntaxError)
+		}, errors.InvalidEscapeSequence, "Unfinished escape sequence")
 	}
 
 	var char rune
@@ -295,11 +353,11 @@ func (self *Lexer) makeEscapeSequence() (rune, *errors.Error) {
 		if util.IsOctalDigit(*self.currentChar) {
 			char, err = self.escapePart(string(*self.currentChar), startLocation, 8, 2)
 		} else {
-			err = errors.NewError(errors.Span{
+			err = errors.NewSyntaxError(errors.Span{
 				Start:    startLocation,
 				End:      self.location,
 				Filename: self.filename,
-			}, "Invalid escape sequence", errors.SyntaxError)
+			}, errors.InvalidEscapeSequence, "Invalid escape sequence")
 		}
 	}
 	return char, err
@@ -315,11 +373,11 @@ func (self *Lexer) escapePart(esc string, startLocation errors.Location, radix i
 	}
 	for i := 0; i < int(digits); i++ {
 		if self.currentChar == nil || !digitFun(*self.currentChar) {
-			return ' ', errors.NewError(errors.Span{
+			return ' ', errors.NewSyntaxError(errors.Span{
 				Start:    startLocation,
 				End:      self.location,
 				Filename: self.filename,
-			}, "Invalid escape sequence", errors.SyntaxError)
+			}, errors.InvalidEscapeSequence, "Invalid escape sequence")
 		}
 		esc += string(*self.currentChar)
 		self.advance()
@@ -429,10 +487,10 @@ func (self *Lexer) makeTildeArrow() (Token, *errors.Error) {
 		}
 
 		// nolint:exhaustruct
-		return Token{}, errors.NewError(
+		return Token{}, errors.NewSyntaxError(
 			self.location.Until(self.location, self.filename),
+			errors.UnexpectedToken,
 			message,
-			errors.SyntaxError,
 		)
 	}
 
@@ -498,13 +556,12 @@ func (self *Lexer) makeEquals() Token {
 
 func (self *Lexer) makeOr() Token {
 	startLocation := self.location
-	self.advance()
 
 	tokenKind := BitOr
 	value := "|"
 
-	if self.currentChar != nil {
-		switch *self.currentChar {
+	if self.nextChar != nil {
+		switch *self.nextChar {
 		case '|':
 			tokenKind = Or
 			value = "||"
@@ -532,13 +589,12 @@ func (self *Lexer) makeOr() Token {
 
 func (self *Lexer) makeAnd() Token {
 	startLocation := self.location
-	self.advance()
 
 	tokenKind := BitAnd
 	value := "&"
 
-	if self.currentChar != nil {
-		switch *self.currentChar {
+	if self.nextChar != nil {
+		switch *self.nextChar {
 		case '&':
 			tokenKind = And
 			value = "&&"
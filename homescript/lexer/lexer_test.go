@@ -38,3 +38,67 @@ func TestLexer(t *testing.T) {
 	err := os.WriteFile("../../test/lexer_test.tokens", []byte(strings.Join(tokens, "\n")), 0755)
 	assert.NoError(t, err)
 }
+
+func TestLogicalAndBitwiseOperators(t *testing.T) {
+	tests := []struct {
+		program string
+		values  []string
+		kinds   []TokenKind
+	}{
+		{program: "a|b", values: []string{"a", "|", "b"}, kinds: []TokenKind{Identifier, BitOr, Identifier}},
+		{program: "a || b", values: []string{"a", "||", "b"}, kinds: []TokenKind{Identifier, Or, Identifier}},
+		{program: "a|=b", values: []string{"a", "|=", "b"}, kinds: []TokenKind{Identifier, BitOrAssign, Identifier}},
+		{program: "a&b", values: []string{"a", "&", "b"}, kinds: []TokenKind{Identifier, BitAnd, Identifier}},
+		{program: "a && b", values: []string{"a", "&&", "b"}, kinds: []TokenKind{Identifier, And, Identifier}},
+		{program: "a&=b", values: []string{"a", "&=", "b"}, kinds: []TokenKind{Identifier, BitAndAssign, Identifier}},
+		{program: "|", values: []string{"|"}, kinds: []TokenKind{BitOr}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.program, func(t *testing.T) {
+			lexer := NewLexer(test.program, "test")
+
+			values, kinds := make([]string, 0), make([]TokenKind, 0)
+			for {
+				token, err := lexer.NextToken()
+				assert.Nil(t, err)
+				if token.Kind == EOF {
+					break
+				}
+
+				values = append(values, token.Value)
+				kinds = append(kinds, token.Kind)
+			}
+
+			assert.Equal(t, test.values, values)
+			assert.Equal(t, test.kinds, kinds)
+		})
+	}
+}
+
+func TestDocComments(t *testing.T) {
+	const program = "/// Adds two numbers.\n/// Returns the sum.\nfn add() {}\n// Not a doc comment.\n//// Neither.\nlet x = 1;\n/// Trailing.\n"
+
+	docComments := func(lexer Lexer) map[string][]string {
+		comments := make(map[string][]string)
+		for {
+			token, err := lexer.NextToken()
+			assert.Nil(t, err)
+			if len(token.DocComment) > 0 {
+				comments[token.Value] = token.DocComment
+			}
+			if token.Kind == EOF {
+				return comments
+			}
+		}
+	}
+
+	expected := map[string][]string{
+		"fn":  {"Adds two numbers.", "Returns the sum."},
+		"EOF": {"Trailing."},
+	}
+
+	assert.Equal(t, expected, docComments(NewLexer(program, "test")))
+	// Lossless lexers must attach doc comments like normal lexers.
+	assert.Equal(t, expected, docComments(NewLosslessLexer(program, "test")))
+}
@@ -8,6 +8,8 @@ type Token struct {
 	Kind  TokenKind
 	Value string
 	Span  errors.Span
+	// Contains the lines of the doc comments (`///`) in front of this token.
+	DocComment []string
 }
 
 type TokenKind uint8
@@ -116,9 +118,10 @@ const (
 
 func newToken(kind TokenKind, value string, span errors.Span) Token {
 	return Token{
-		Kind:  kind,
-		Value: value,
-		Span:  span,
+		Kind:       kind,
+		Value:      value,
+		Span:       span,
+		DocComment: nil,
 	}
 }
 
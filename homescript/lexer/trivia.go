@@ -0,0 +1,89 @@
+package lexer
+
+import "github.com/smarthome-go/homescript/v3/homescript/errors"
+
+//
+// Trivia
+// Source text which does not form tokens: whitespace and comments.
+// Trivia is only recorded by lossless lexers.
+//
+
+type TriviaKind uint8
+
+const (
+	// A sequence of spaces, tabs, or carriage returns.
+	WhitespaceTrivia TriviaKind = iota
+	// A single line feed.
+	NewlineTrivia
+	// A `//` comment, excluding the line feed which terminates it.
+	LineCommentTrivia
+	// A `/* */` comment.
+	BlockCommentTrivia
+)
+
+type Trivia struct {
+	Kind TriviaKind
+	Text string
+	Span errors.Span
+}
+
+func isWhitespace(char rune) bool {
+	return char == ' ' || char == '\t' || char == '\r'
+}
+
+// Consumes all trivia in front of the next token.
+func (self *Lexer) lexTrivia() []Trivia {
+	trivia := make([]Trivia, 0)
+
+	for self.currentChar != nil {
+		start := self.currentIndex
+		startLocation := self.location
+		var kind TriviaKind
+
+		switch {
+		case isWhitespace(*self.currentChar):
+			kind = WhitespaceTrivia
+			for self.currentChar != nil && isWhitespace(*self.currentChar) {
+				self.advance()
+			}
+		case *self.currentChar == '\n':
+			kind = NewlineTrivia
+			self.advance()
+		case *self.currentChar == '/' && self.nextChar != nil && *self.nextChar == '/':
+			kind = LineCommentTrivia
+			for self.currentChar != nil && *self.currentChar != '\n' {
+				self.advance()
+			}
+
+			if line, isDoc := docCommentLine(string(self.program[start+2 : self.currentIndex])); isDoc {
+				self.docComment = append(self.docComment, line)
+			}
+		case *self.currentChar == '/' && self.nextChar != nil && *self.nextChar == '*':
+			kind = BlockCommentTrivia
+			self.skipBlockComment()
+		default:
+			return trivia
+		}
+
+		endLocation := startLocation
+		if self.currentIndex > start+1 {
+			endLocation = self.locationBefore()
+		}
+
+		trivia = append(trivia, Trivia{
+			Kind: kind,
+			Text: string(self.program[start:self.currentIndex]),
+			Span: startLocation.Until(endLocation, self.filename),
+		})
+	}
+
+	return trivia
+}
+
+// Returns the location of the character in front of the current one, which must be on the same line.
+func (self *Lexer) locationBefore() errors.Location {
+	location := self.location
+	location.Index--
+	location.Column--
+	return location
+}
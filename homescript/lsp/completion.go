@@ -0,0 +1,168 @@
+package lsp
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/smarthome-go/homescript/v3/homescript/analyzer"
+	"github.com/smarthome-go/homescript/v3/homescript/analyzer/ast"
+	pAst "github.com/smarthome-go/homescript/v3/homescript/parser/ast"
+)
+
+//
+// Completion.
+//
+
+var (
+	// Matches an import statement whose module name is currently being typed.
+	importModulePattern = regexp.MustCompile(`\bfrom\s+[@\w:]*$`)
+	// Matches the module of an import statement.
+	importFromPattern = regexp.MustCompile(`\bfrom\s+([@\w:]+)`)
+	// Matches the kind of the import item which is currently being typed.
+	importKindPattern = regexp.MustCompile(`\b(type|templ|trigger)\s+\w*$`)
+)
+
+func isIdentRune(char rune) bool {
+	return char == '_' || char >= 'a' && char <= 'z' || char >= 'A' && char <= 'Z' || char >= '0' && char <= '9'
+}
+
+func (self *Server) completion(doc *document, pos Position) CompletionList {
+	items, isImport := self.importCompletion(doc, pos)
+	if !isImport {
+		items = self.memberCompletion(doc, pos)
+	}
+
+	slices.SortFunc(items, func(a, b CompletionItem) int { return strings.Compare(a.Label, b.Label) })
+
+	return CompletionList{
+		IsIncomplete: false,
+		Items:        items,
+	}
+}
+
+// Completes the members of the expression in front of a `.`.
+// As the source code is usually invalid while a member is typed, the `.` and the partial member are removed.
+// Then, the remaining source code is analyzed in order to obtain the type of the expression.
+func (self *Server) memberCompletion(doc *document, pos Position) []CompletionItem {
+	items := make([]CompletionItem, 0)
+
+	loc := toLocation(doc.lines, pos)
+	line := lineAt(doc.lines, pos.Line)
+	cursor := int(loc.Column - 1)
+
+	start := cursor
+	for start > 0 && isIdentRune(line[start-1]) {
+		start--
+	}
+
+	if start == 0 || line[start-1] != '.' {
+		return items
+	}
+	dot := start - 1
+
+	lines := slices.Clone(doc.lines)
+	lines[pos.Line] = string(line[:dot]) + string(line[cursor:])
+
+	modules, _, _ := self.analyze(doc.path, strings.Join(lines, "\n"))
+	if modules == nil {
+		return items
+	}
+
+	// The expression ends with the character in front of the `.`, whose one-based column equals the index of the `.`.
+	baseLoc := loc
+	baseLoc.Column = uint(dot)
+
+	baseType, found := newSymbolIndex(modules).typeOfExpressionEndingAt(doc.path, baseLoc)
+	if !found {
+		return items
+	}
+
+	for name, typ := range baseType.Fields(baseType.Span()) {
+		kind := CompletionItemKindField
+		if typ.Kind() == ast.FnTypeKind {
+			kind = CompletionItemKindMethod
+		}
+
+		items = append(items, CompletionItem{
+			Label:  name,
+			Kind:   kind,
+			Detail: typ.String(),
+		})
+	}
+
+	return items
+}
+
+// Completes module names and builtin values inside of import statements.
+// Builtin values can only be completed if the host implements `analyzer.BuiltinImportLister`.
+func (self *Server) importCompletion(doc *document, pos Position) (items []CompletionItem, isImport bool) {
+	items = make([]CompletionItem, 0)
+
+	lines := slices.Clone(doc.lines[:pos.Line])
+	line := lineAt(doc.lines, pos.Line)
+	cursor := toLocation(doc.lines, pos).Column - 1
+
+	before := strings.Join(append(lines, string(line[:cursor])), "\n")
+	after := strings.Join(append([]string{string(line[cursor:])}, doc.lines[pos.Line+1:]...), "\n")
+
+	// Only consider the statement which contains the cursor.
+	importStart := strings.LastIndex(before, "import")
+	if importStart == -1 || strings.Contains(before[importStart:], ";") {
+		return items, false
+	}
+	statementBefore := before[importStart:]
+
+	statementAfter := after
+	if end := strings.Index(after, ";"); end != -1 {
+		statementAfter = after[:end]
+	}
+
+	lister, canList := self.host.(analyzer.BuiltinImportLister)
+
+	if importModulePattern.MatchString(statementBefore) {
+		if canList {
+			for _, module := range lister.BuiltinModules() {
+				items = append(items, CompletionItem{Label: module, Kind: CompletionItemKindModule})
+			}
+		}
+
+		// Homescript modules are resolved relative to the importing document.
+		files, _ := os.ReadDir(filepath.Dir(doc.path))
+		for _, file := range files {
+			name, isModule := strings.CutSuffix(file.Name(), ".hms")
+			if !isModule || file.IsDir() || filepath.Join(filepath.Dir(doc.path), file.Name()) == doc.path {
+				continue
+			}
+
+			items = append(items, CompletionItem{Label: name, Kind: CompletionItemKindModule, Detail: "Homescript module"})
+		}
+
+		return items, true
+	}
+
+	from := importFromPattern.FindStringSubmatch(statementAfter)
+	if from == nil || !canList {
+		return items, true
+	}
+
+	kind := pAst.IMPORT_KIND_NORMAL
+	if match := importKindPattern.FindStringSubmatch(statementBefore); match != nil {
+		switch match[1] {
+		case "type":
+			kind = pAst.IMPORT_KIND_TYPE
+		case "templ":
+			kind = pAst.IMPORT_KIND_TEMPLATE
+		case "trigger":
+			kind = pAst.IMPORT_KIND_TRIGGER
+		}
+	}
+
+	for _, value := range lister.BuiltinImports(from[1], kind) {
+		items = append(items, CompletionItem{Label: value, Kind: CompletionItemKindVariable, Detail: from[1]})
+	}
+
+	return items, true
+}
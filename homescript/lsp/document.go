@@ -0,0 +1,175 @@
+package lsp
+
+import (
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/smarthome-go/homescript/v3/homescript/analyzer"
+	herrors "github.com/smarthome-go/homescript/v3/homescript/errors"
+)
+
+//
+// Open documents.
+//
+
+type document struct {
+	uri     string
+	path    string
+	version int
+	text    string
+	lines   []string
+	// Is the index of the current text.
+	// It is nil if the text could not be analyzed, as the spans of a previous index would not match the text.
+	index *symbolIndex
+}
+
+func newDocument(uri string, version int, text string) *document {
+	doc := &document{
+		uri:     uri,
+		path:    uriToPath(uri),
+		version: version,
+		text:    "",
+		lines:   nil,
+		index:   nil,
+	}
+	doc.setText(text)
+	return doc
+}
+
+func (self *document) setText(text string) {
+	self.text = text
+	self.lines = strings.Split(text, "\n")
+}
+
+func uriToPath(uri string) string {
+	parsed, err := url.Parse(uri)
+	if err != nil || parsed.Scheme != "file" {
+		return uri
+	}
+	return filepath.FromSlash(parsed.Path)
+}
+
+func pathToURI(path string) string {
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(path)}).String()
+}
+
+// Returns the path of an imported Homescript module.
+// Modules are resolved relative to the directory of the importing document.
+func modulePath(dir string, module string) string {
+	return filepath.Join(dir, module+".hms")
+}
+
+// The entry module uses its absolute path as its filename, imported modules use their module name.
+func spanPath(dir string, filename string) string {
+	if filepath.IsAbs(filename) {
+		return filename
+	}
+	return modulePath(dir, filename)
+}
+
+//
+// Positions.
+// Homescript locations are one-based and count runes, LSP positions are zero-based and count UTF-16 code units.
+//
+
+func utf16Length(runes []rune) uint {
+	return uint(len(utf16.Encode(runes)))
+}
+
+func lineAt(lines []string, line uint) []rune {
+	if line >= uint(len(lines)) {
+		return nil
+	}
+	return []rune(lines[line])
+}
+
+// Converts a location into a position.
+// If `after` is set, the position points behind the character at the location.
+func toPosition(lines []string, loc herrors.Location, after bool) Position {
+	if loc.Line == 0 {
+		return Position{Line: 0, Character: 0}
+	}
+
+	line := lineAt(lines, loc.Line-1)
+
+	column := loc.Column - 1
+	if after {
+		column++
+	}
+
+	if column > uint(len(line)) {
+		// The location lies outside of the known source code, assume that every rune is one code unit.
+		return Position{Line: loc.Line - 1, Character: column}
+	}
+
+	return Position{Line: loc.Line - 1, Character: utf16Length(line[:column])}
+}
+
+func toRange(lines []string, span herrors.Span) Range {
+	return Range{
+		Start: toPosition(lines, span.Start, false),
+		End:   toPosition(lines, span.End, true),
+	}
+}
+
+func toLocation(lines []string, pos Position) herrors.Location {
+	line := lineAt(lines, pos.Line)
+
+	units := uint(0)
+	column := uint(0)
+	for column < uint(len(line)) && units < pos.Character {
+		units += utf16Length(line[column : column+1])
+		column++
+	}
+
+	return herrors.Location{Line: pos.Line + 1, Column: column + 1, Index: 0}
+}
+
+//
+// Host.
+//
+
+// Wraps the host of the server so that imported modules are resolved relative to the importing document.
+// If an imported module is open in the editor, its unsaved content is used.
+type documentHost struct {
+	analyzer.HostProvider
+	server *Server
+	dir    string
+}
+
+func (self documentHost) ResolveCodeModule(moduleName string) (code string, moduleFound bool, err error) {
+	path := modulePath(self.dir, moduleName)
+
+	if doc, isOpen := self.server.documents[pathToURI(path)]; isOpen {
+		return doc.text, true, nil
+	}
+
+	file, err := os.ReadFile(path)
+	if err == nil {
+		return string(file), true, nil
+	}
+
+	if !errors.Is(err, os.ErrNotExist) {
+		return "", false, err
+	}
+
+	return self.HostProvider.ResolveCodeModule(moduleName)
+}
+
+// Returns the lines of any file, preferring the content of open documents.
+func (self *Server) linesOf(path string) []string {
+	if doc, isOpen := self.documents[pathToURI(path)]; isOpen {
+		return doc.lines
+	}
+
+	file, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+
+	return strings.Split(string(file), "\n")
+}
@@ -0,0 +1,515 @@
+package lsp
+
+import (
+	"github.com/smarthome-go/homescript/v3/homescript/analyzer/ast"
+	herrors "github.com/smarthome-go/homescript/v3/homescript/errors"
+	pAst "github.com/smarthome-go/homescript/v3/homescript/parser/ast"
+)
+
+//
+// Symbol index.
+// Records every identifier of the analyzed modules together with its type and the symbol it refers to.
+//
+
+type symbolKind uint8
+
+const (
+	localSymbolKind symbolKind = iota
+	parameterSymbolKind
+	functionSymbolKind
+	globalSymbolKind
+	importSymbolKind
+	singletonSymbolKind
+)
+
+func (self symbolKind) String() string {
+	switch self {
+	case localSymbolKind:
+		return "local variable"
+	case parameterSymbolKind:
+		return "parameter"
+	case functionSymbolKind:
+		return "function"
+	case globalSymbolKind:
+		return "global variable"
+	case importSymbolKind:
+		return "import"
+	case singletonSymbolKind:
+		return "singleton"
+	default:
+		panic("A new symbol kind was added without updating this code")
+	}
+}
+
+type symbol struct {
+	ident string
+	kind  symbolKind
+	// Is the span of the identifier which declares this symbol.
+	span herrors.Span
+	// Can be nil if the analyzer does not expose the type of this symbol.
+	typ ast.Type
+	// For imports: the module from which the symbol is imported.
+	fromModule string
+	// For imports of Homescript modules: the imported symbol.
+	target *symbol
+}
+
+// Follows imports until the symbol which actually defines the value is reached.
+func (self *symbol) definition() *symbol {
+	sym := self
+
+	// The analyzer rejects import cycles, however, the limit guards against looping forever.
+	for depth := 0; sym.target != nil && depth < 64; depth++ {
+		sym = sym.target
+	}
+
+	return sym
+}
+
+type reference struct {
+	span herrors.Span
+	// Is the type of the value at this reference, which can be more specific than the type of the symbol.
+	typ ast.Type
+	// Is nil for builtin values and members.
+	symbol   *symbol
+	isMember bool
+}
+
+// Is used to look up the type of an expression, for instance in order to complete its members.
+type typedSpan struct {
+	span herrors.Span
+	typ  ast.Type
+}
+
+type symbolIndex struct {
+	references  []reference
+	expressions []typedSpan
+	// Maps a module name to its top-level symbols.
+	modules map[string]map[string]*symbol
+}
+
+func newSymbolIndex(modules map[string]ast.AnalyzedProgram) *symbolIndex {
+	index := &symbolIndex{
+		references:  make([]reference, 0),
+		expressions: make([]typedSpan, 0),
+		modules:     make(map[string]map[string]*symbol),
+	}
+
+	singletons := make(map[string]map[string]*symbol)
+
+	// Collect the top-level symbols first: they can be used before they are declared.
+	for name, module := range modules {
+		topLevel := make(map[string]*symbol)
+		singletons[name] = make(map[string]*symbol)
+
+		for _, item := range module.Imports {
+			for _, value := range item.ToImport {
+				topLevel[value.Ident.Ident()] = &symbol{
+					ident:      value.Ident.Ident(),
+					kind:       importSymbolKind,
+					span:       value.Ident.Span(),
+					typ:        value.Type,
+					fromModule: item.FromModule.Ident(),
+					target:     nil,
+				}
+			}
+		}
+
+		for _, singleton := range module.Singletons {
+			singletons[name][singleton.Ident.Ident()] = &symbol{
+				ident: singleton.Ident.Ident(),
+				kind:  singletonSymbolKind,
+				span:  singleton.Ident.Span(),
+				typ:   singleton.SingletonType,
+			}
+		}
+
+		for _, global := range module.Globals {
+			topLevel[global.Ident.Ident()] = &symbol{
+				ident: global.Ident.Ident(),
+				kind:  globalSymbolKind,
+				span:  global.Ident.Span(),
+				typ:   global.VarType,
+			}
+		}
+
+		for _, fn := range module.Functions {
+			topLevel[fn.Ident.Ident()] = &symbol{
+				ident: fn.Ident.Ident(),
+				kind:  functionSymbolKind,
+				span:  fn.Ident.Span(),
+				typ:   fn.Type(),
+			}
+		}
+
+		index.modules[name] = topLevel
+	}
+
+	// Link imports of Homescript modules to the symbols which they import.
+	for name, module := range modules {
+		for _, item := range module.Imports {
+			if !item.TargetIsHMS {
+				continue
+			}
+
+			for _, value := range item.ToImport {
+				if value.Kind != pAst.IMPORT_KIND_NORMAL {
+					continue
+				}
+
+				if target, found := index.modules[item.FromModule.Ident()][value.Ident.Ident()]; found {
+					index.modules[name][value.Ident.Ident()].target = target
+				}
+			}
+		}
+	}
+
+	for name, module := range modules {
+		indexer := indexer{
+			index:      index,
+			topLevel:   index.modules[name],
+			singletons: singletons[name],
+			scopes:     make([]map[string]*symbol, 0),
+		}
+		indexer.module(module)
+	}
+
+	return index
+}
+
+// Returns the innermost reference at the given location of the given file.
+// A location directly behind an identifier also refers to it, as this is where the cursor usually is.
+func (self *symbolIndex) referenceAt(filename string, loc herrors.Location) (reference, bool) {
+	var result reference
+	found := false
+
+	for _, ref := range self.references {
+		span := ref.span
+		if span.Filename != filename || span.Start.Line != loc.Line || span.End.Line != loc.Line {
+			continue
+		}
+
+		if loc.Column < span.Start.Column || loc.Column > span.End.Column+1 {
+			continue
+		}
+
+		if !found || span.End.Column-span.Start.Column < result.span.End.Column-result.span.Start.Column {
+			result = ref
+			found = true
+		}
+	}
+
+	return result, found
+}
+
+// Returns the type of the innermost expression which ends at the given location.
+func (self *symbolIndex) typeOfExpressionEndingAt(filename string, loc herrors.Location) (ast.Type, bool) {
+	var result typedSpan
+	found := false
+
+	for _, expr := range self.expressions {
+		end := expr.span.End
+		if expr.span.Filename != filename || end.Line != loc.Line || end.Column != loc.Column {
+			continue
+		}
+
+		if !found || expr.span.Start.Index > result.span.Start.Index {
+			result = expr
+			found = true
+		}
+	}
+
+	return result.typ, found
+}
+
+// Returns all references to the given symbol in the given file, including its declaration.
+func (self *symbolIndex) referencesTo(filename string, sym *symbol) []reference {
+	references := make([]reference, 0)
+
+	for _, ref := range self.references {
+		if ref.symbol == sym && ref.span.Filename == filename {
+			references = append(references, ref)
+		}
+	}
+
+	return references
+}
+
+//
+// Indexer.
+// Walks a module while keeping track of the scopes in the same way as the analyzer.
+//
+
+type indexer struct {
+	index      *symbolIndex
+	topLevel   map[string]*symbol
+	singletons map[string]*symbol
+	scopes     []map[string]*symbol
+}
+
+func (self *indexer) pushScope() {
+	self.scopes = append(self.scopes, make(map[string]*symbol))
+}
+
+func (self *indexer) popScope() {
+	self.scopes = self.scopes[:len(self.scopes)-1]
+}
+
+func (self *indexer) addReference(span herrors.Span, typ ast.Type, sym *symbol) {
+	self.index.references = append(self.index.references, reference{
+		span:     span,
+		typ:      typ,
+		symbol:   sym,
+		isMember: false,
+	})
+}
+
+func (self *indexer) declare(ident pAst.SpannedIdent, kind symbolKind, typ ast.Type) {
+	sym := &symbol{
+		ident: ident.Ident(),
+		kind:  kind,
+		span:  ident.Span(),
+		typ:   typ,
+	}
+
+	self.scopes[len(self.scopes)-1][ident.Ident()] = sym
+	self.addReference(ident.Span(), typ, sym)
+}
+
+func (self *indexer) reference(ident pAst.SpannedIdent, typ ast.Type) {
+	self.addReference(ident.Span(), typ, self.lookup(ident.Ident()))
+}
+
+// Returns nil if the identifier refers to a builtin value.
+func (self *indexer) lookup(ident string) *symbol {
+	for idx := len(self.scopes) - 1; idx >= 0; idx-- {
+		if sym, found := self.scopes[idx][ident]; found {
+			return sym
+		}
+	}
+
+	return self.topLevel[ident]
+}
+
+func (self *indexer) topLevelSymbol(sym *symbol) {
+	if sym != nil {
+		self.addReference(sym.span, sym.typ, sym)
+	}
+}
+
+func (self *indexer) module(node ast.AnalyzedProgram) {
+	for _, item := range node.Imports {
+		for _, value := range item.ToImport {
+			self.topLevelSymbol(self.topLevel[value.Ident.Ident()])
+		}
+	}
+
+	for _, singleton := range node.Singletons {
+		self.topLevelSymbol(self.singletons[singleton.Ident.Ident()])
+	}
+
+	for _, global := range node.Globals {
+		self.expression(global.Expression)
+		self.topLevelSymbol(self.topLevel[global.Ident.Ident()])
+	}
+
+	for _, fn := range node.Functions {
+		self.topLevelSymbol(self.topLevel[fn.Ident.Ident()])
+		self.function(fn)
+	}
+
+	for _, impl := range node.ImplBlocks {
+		if singleton, found := self.singletons[impl.SingletonIdent.Ident()]; found {
+			self.addReference(impl.SingletonIdent.Span(), singleton.typ, singleton)
+		}
+
+		for _, method := range impl.Methods {
+			self.addReference(method.Ident.Span(), method.Type(), &symbol{
+				ident: method.Ident.Ident(),
+				kind:  functionSymbolKind,
+				span:  method.Ident.Span(),
+				typ:   method.Type(),
+			})
+			self.function(method)
+		}
+	}
+}
+
+func (self *indexer) function(node ast.AnalyzedFunctionDefinition) {
+	if node.Annotation != nil {
+		for _, item := range node.Annotation.Items {
+			if trigger, isTrigger := item.(ast.AnalyzedAnnotationItemTrigger); isTrigger {
+				self.reference(trigger.TriggerSource, nil)
+				self.callArgs(trigger.TriggerArgs)
+			}
+		}
+	}
+
+	self.pushScope()
+	for _, param := range node.Parameters.List {
+		self.declare(param.Ident, parameterSymbolKind, param.Type)
+	}
+	self.block(node.Body)
+	self.popScope()
+}
+
+func (self *indexer) block(node ast.AnalyzedBlock) {
+	self.pushScope()
+
+	for _, statement := range node.Statements {
+		self.statement(statement)
+	}
+
+	if node.Expression != nil {
+		self.expression(node.Expression)
+	}
+
+	self.popScope()
+}
+
+func (self *indexer) callArgs(node ast.AnalyzedCallArgs) {
+	for _, arg := range node.List {
+		self.expression(arg.Expression)
+	}
+}
+
+func (self *indexer) statement(node ast.AnalyzedStatement) {
+	switch node.Kind() {
+	case ast.TypeDefinitionStatementKind, ast.SingletonTypeDefinitionStatementKind,
+		ast.BreakStatementKind, ast.ContinueStatementKind:
+		return
+	case ast.TriggerStatementKind:
+		node := node.(ast.AnalyzedTriggerStatement)
+		self.reference(node.CallbackIdent, node.CallbackSignature)
+		self.reference(node.TriggerIdent, node.TriggerSignature)
+		self.callArgs(node.TriggerArguments)
+	case ast.LetStatementKind:
+		node := node.(ast.AnalyzedLetStatement)
+		self.expression(node.Expression)
+		self.declare(node.Ident, localSymbolKind, node.VarType)
+	case ast.ReturnStatementKind:
+		node := node.(ast.AnalyzedReturnStatement)
+		if node.ReturnValue != nil {
+			self.expression(node.ReturnValue)
+		}
+	case ast.LoopStatementKind:
+		self.block(node.(ast.AnalyzedLoopStatement).Body)
+	case ast.WhileStatementKind:
+		node := node.(ast.AnalyzedWhileStatement)
+		self.expression(node.Condition)
+		self.block(node.Body)
+	case ast.ForStatementKind:
+		node := node.(ast.AnalyzedForStatement)
+		self.expression(node.IterExpression)
+		self.pushScope()
+		self.declare(node.Identifier, localSymbolKind, node.IterVarType)
+		self.block(node.Body)
+		self.popScope()
+	case ast.ExpressionStatementKind:
+		self.expression(node.(ast.AnalyzedExpressionStatement).Expression)
+	default:
+		panic("A new statement kind was added without updating this code")
+	}
+}
+
+func (self *indexer) expression(node ast.AnalyzedExpression) {
+	if node.Kind() != ast.UnknownExpressionKind {
+		self.index.expressions = append(self.index.expressions, typedSpan{span: node.Span(), typ: node.Type()})
+	}
+
+	switch node.Kind() {
+	case ast.UnknownExpressionKind, ast.IntLiteralExpressionKind, ast.FloatLiteralExpressionKind,
+		ast.BoolLiteralExpressionKind, ast.StringLiteralExpressionKind, ast.NullLiteralExpressionKind,
+		ast.NoneLiteralExpressionKind, ast.AnyObjectLiteralExpressionKind:
+		return
+	case ast.IdentExpressionKind:
+		node := node.(ast.AnalyzedIdentExpression)
+		if node.IsSingleton {
+			self.addReference(node.Ident.Span(), node.ResultType, self.singletons[node.Ident.Ident()])
+			return
+		}
+		self.reference(node.Ident, node.ResultType)
+	case ast.RangeLiteralExpressionKind:
+		node := node.(ast.AnalyzedRangeLiteralExpression)
+		self.expression(node.Start)
+		self.expression(node.End)
+	case ast.ListLiteralExpressionKind:
+		for _, value := range node.(ast.AnalyzedListLiteralExpression).Values {
+			self.expression(value)
+		}
+	case ast.ObjectLiteralExpressionKind:
+		for _, field := range node.(ast.AnalyzedObjectLiteralExpression).Fields {
+			self.expression(field.Expression)
+		}
+	case ast.FunctionLiteralExpressionKind:
+		node := node.(ast.AnalyzedFunctionLiteralExpression)
+		self.pushScope()
+		for _, param := range node.Parameters {
+			self.declare(param.Ident, parameterSymbolKind, param.Type)
+		}
+		self.block(node.Body)
+		self.popScope()
+	case ast.GroupedExpressionKind:
+		self.expression(node.(ast.AnalyzedGroupedExpression).Inner)
+	case ast.PrefixExpressionKind:
+		self.expression(node.(ast.AnalyzedPrefixExpression).Base)
+	case ast.InfixExpressionKind:
+		node := node.(ast.AnalyzedInfixExpression)
+		self.expression(node.Lhs)
+		self.expression(node.Rhs)
+	case ast.AssignExpressionKind:
+		node := node.(ast.AnalyzedAssignExpression)
+		self.expression(node.Lhs)
+		self.expression(node.Rhs)
+	case ast.CallExpressionKind:
+		node := node.(ast.AnalyzedCallExpression)
+		self.expression(node.Base)
+		self.callArgs(node.Arguments)
+	case ast.IndexExpressionKind:
+		node := node.(ast.AnalyzedIndexExpression)
+		self.expression(node.Base)
+		self.expression(node.Index)
+	case ast.MemberExpressionKind:
+		node := node.(ast.AnalyzedMemberExpression)
+		self.expression(node.Base)
+		self.index.references = append(self.index.references, reference{
+			span:     node.Member.Span(),
+			typ:      node.ResultType,
+			symbol:   nil,
+			isMember: true,
+		})
+	case ast.CastExpressionKind:
+		self.expression(node.(ast.AnalyzedCastExpression).Base)
+	case ast.BlockExpressionKind:
+		self.block(node.(ast.AnalyzedBlockExpression).Block)
+	case ast.IfExpressionKind:
+		node := node.(ast.AnalyzedIfExpression)
+		self.expression(node.Condition)
+		self.block(node.ThenBlock)
+		if node.ElseBlock != nil {
+			self.block(*node.ElseBlock)
+		}
+	case ast.MatchExpressionKind:
+		node := node.(ast.AnalyzedMatchExpression)
+		self.expression(node.ControlExpression)
+		for _, arm := range node.Arms {
+			for _, literal := range arm.Literals {
+				self.expression(literal)
+			}
+			self.expression(arm.Action)
+		}
+		if node.DefaultArmAction != nil {
+			self.expression(*node.DefaultArmAction)
+		}
+	case ast.TryExpressionKind:
+		node := node.(ast.AnalyzedTryExpression)
+		self.block(node.TryBlock)
+		self.pushScope()
+		// The analyzer does not expose the type of the exception, however, each use of it has a type.
+		self.declare(node.CatchIdent, localSymbolKind, nil)
+		self.block(node.CatchBlock)
+		self.popScope()
+	default:
+		panic("A new expression kind was added without updating this code")
+	}
+}
@@ -0,0 +1,128 @@
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strconv"
+	"sync"
+)
+
+//
+// JSON-RPC 2.0 transport.
+// Each message is preceded by a `Content-Length` header, as specified by LSP.
+//
+
+const jsonrpcVersion = "2.0"
+
+// Error codes which are defined by JSON-RPC and LSP.
+const (
+	codeParseError     = -32700
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+	codeInternalError  = -32603
+	codeRequestFailed  = -32803
+)
+
+type rpcMessage struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      *json.RawMessage `json:"id,omitempty"`
+	Method  string           `json:"method,omitempty"`
+	Params  json.RawMessage  `json:"params,omitempty"`
+}
+
+type rpcResponse struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      *json.RawMessage `json:"id"`
+	Result  any              `json:"result"`
+	Error   *rpcError        `json:"error,omitempty"`
+}
+
+type rpcNotification struct {
+	JSONRPC string `json:"jsonrpc"`
+	Method  string `json:"method"`
+	Params  any    `json:"params"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (self rpcError) Error() string {
+	return fmt.Sprintf("JSON-RPC error %d: %s", self.Code, self.Message)
+}
+
+type rpcConn struct {
+	reader *textproto.Reader
+	writer io.Writer
+	lock   sync.Mutex
+}
+
+func newRPCConn(in io.Reader, out io.Writer) *rpcConn {
+	return &rpcConn{
+		reader: textproto.NewReader(bufio.NewReader(in)),
+		writer: out,
+		lock:   sync.Mutex{},
+	}
+}
+
+func (self *rpcConn) read() (rpcMessage, error) {
+	header, err := self.reader.ReadMIMEHeader()
+	if err != nil {
+		return rpcMessage{}, err
+	}
+
+	length, err := strconv.Atoi(header.Get("Content-Length"))
+	if err != nil || length < 0 {
+		return rpcMessage{}, fmt.Errorf("invalid `Content-Length` header: `%s`", header.Get("Content-Length"))
+	}
+
+	body := make([]byte, length)
+	if _, err := io.ReadFull(self.reader.R, body); err != nil {
+		return rpcMessage{}, err
+	}
+
+	var message rpcMessage
+	if err := json.Unmarshal(body, &message); err != nil {
+		return rpcMessage{}, rpcError{Code: codeParseError, Message: err.Error()}
+	}
+
+	return message, nil
+}
+
+func (self *rpcConn) write(message any) error {
+	body, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	if _, err := fmt.Fprintf(self.writer, "Content-Length: %d\r\n\r\n", len(body)); err != nil {
+		return err
+	}
+
+	_, err = self.writer.Write(body)
+	return err
+}
+
+func (self *rpcConn) reply(id *json.RawMessage, result any, err *rpcError) error {
+	return self.write(rpcResponse{
+		JSONRPC: jsonrpcVersion,
+		ID:      id,
+		Result:  result,
+		Error:   err,
+	})
+}
+
+func (self *rpcConn) notify(method string, params any) error {
+	return self.write(rpcNotification{
+		JSONRPC: jsonrpcVersion,
+		Method:  method,
+		Params:  params,
+	})
+}
@@ -0,0 +1,422 @@
+package lsp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"path/filepath"
+	"strings"
+
+	"github.com/smarthome-go/homescript/v3/homescript"
+	"github.com/smarthome-go/homescript/v3/homescript/analyzer"
+	"github.com/smarthome-go/homescript/v3/homescript/analyzer/ast"
+	"github.com/smarthome-go/homescript/v3/homescript/diagnostic"
+	herrors "github.com/smarthome-go/homescript/v3/homescript/errors"
+)
+
+//
+// Language server.
+// Speaks LSP over a stream, usually stdin and stdout of the process.
+// Requests are handled sequentially, therefore, the server state requires no locking.
+//
+
+const serverName = "homescript-lsp"
+
+type Server struct {
+	host analyzer.HostProvider
+	// Is invoked for every analysis as the analyzer modifies the scope additions.
+	scopeAdditions func() map[string]analyzer.Variable
+	// Maps the URI of each open document to its state.
+	documents map[string]*document
+	conn      *rpcConn
+}
+
+func NewServer(host analyzer.HostProvider, scopeAdditions func() map[string]analyzer.Variable) *Server {
+	return &Server{
+		host:           host,
+		scopeAdditions: scopeAdditions,
+		documents:      make(map[string]*document),
+		conn:           nil,
+	}
+}
+
+// Serves requests until the client sends the `exit` notification or closes the stream.
+func (self *Server) Serve(in io.Reader, out io.Writer) error {
+	self.conn = newRPCConn(in, out)
+
+	for {
+		message, err := self.conn.read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+
+		var rpcErr rpcError
+		if errors.As(err, &rpcErr) {
+			if err := self.conn.reply(nil, nil, &rpcErr); err != nil {
+				return err
+			}
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		if message.Method == "exit" {
+			return nil
+		}
+
+		result, handlerErr := self.handle(message.Method, message.Params)
+
+		// Notifications do not have an ID and must not be answered.
+		if message.ID == nil {
+			if handlerErr != nil {
+				log.Printf("Notification `%s` failed: %s\n", message.Method, handlerErr.Message)
+			}
+			continue
+		}
+
+		if err := self.conn.reply(message.ID, result, handlerErr); err != nil {
+			return err
+		}
+	}
+}
+
+func decodeParams[T any](raw json.RawMessage) (T, *rpcError) {
+	var params T
+	if err := json.Unmarshal(raw, &params); err != nil {
+		return params, &rpcError{Code: codeInvalidParams, Message: err.Error()}
+	}
+	return params, nil
+}
+
+func (self *Server) handle(method string, rawParams json.RawMessage) (any, *rpcError) {
+	switch method {
+	case "initialize":
+		return InitializeResult{
+			Capabilities: ServerCapabilities{
+				TextDocumentSync:   TextDocumentSyncKindFull,
+				HoverProvider:      true,
+				DefinitionProvider: true,
+				CompletionProvider: CompletionOptions{TriggerCharacters: []string{"."}},
+				RenameProvider:     true,
+			},
+			ServerInfo: ServerInfo{Name: serverName, Version: "latest"},
+		}, nil
+	case "initialized", "shutdown":
+		return nil, nil
+	case "textDocument/didOpen":
+		params, err := decodeParams[DidOpenTextDocumentParams](rawParams)
+		if err != nil {
+			return nil, err
+		}
+
+		doc := newDocument(params.TextDocument.URI, params.TextDocument.Version, params.TextDocument.Text)
+		self.documents[doc.uri] = doc
+
+		return nil, self.refresh(doc)
+	case "textDocument/didChange":
+		params, err := decodeParams[DidChangeTextDocumentParams](rawParams)
+		if err != nil {
+			return nil, err
+		}
+
+		doc, err := self.document(params.TextDocument.URI)
+		if err != nil {
+			return nil, err
+		}
+
+		// The server only supports full synchronization, therefore, the last change contains the entire document.
+		if len(params.ContentChanges) > 0 {
+			doc.setText(params.ContentChanges[len(params.ContentChanges)-1].Text)
+		}
+		doc.version = params.TextDocument.Version
+
+		return nil, self.refresh(doc)
+	case "textDocument/didClose":
+		params, err := decodeParams[DidCloseTextDocumentParams](rawParams)
+		if err != nil {
+			return nil, err
+		}
+
+		delete(self.documents, params.TextDocument.URI)
+
+		return nil, self.publishDiagnostics(params.TextDocument.URI, 0, make([]Diagnostic, 0))
+	case "textDocument/hover":
+		params, err := decodeParams[TextDocumentPositionParams](rawParams)
+		if err != nil {
+			return nil, err
+		}
+
+		doc, err := self.document(params.TextDocument.URI)
+		if err != nil {
+			return nil, err
+		}
+
+		return self.hover(doc, params.Position), nil
+	case "textDocument/definition":
+		params, err := decodeParams[TextDocumentPositionParams](rawParams)
+		if err != nil {
+			return nil, err
+		}
+
+		doc, err := self.document(params.TextDocument.URI)
+		if err != nil {
+			return nil, err
+		}
+
+		return self.definition(doc, params.Position), nil
+	case "textDocument/completion":
+		params, err := decodeParams[TextDocumentPositionParams](rawParams)
+		if err != nil {
+			return nil, err
+		}
+
+		doc, err := self.document(params.TextDocument.URI)
+		if err != nil {
+			return nil, err
+		}
+
+		return self.completion(doc, params.Position), nil
+	case "textDocument/rename":
+		params, err := decodeParams[RenameParams](rawParams)
+		if err != nil {
+			return nil, err
+		}
+
+		doc, err := self.document(params.TextDocument.URI)
+		if err != nil {
+			return nil, err
+		}
+
+		return self.rename(doc, params.Position, params.NewName)
+	default:
+		return nil, &rpcError{Code: codeMethodNotFound, Message: fmt.Sprintf("Method `%s` is not supported", method)}
+	}
+}
+
+func (self *Server) document(uri string) (*document, *rpcError) {
+	doc, found := self.documents[uri]
+	if !found {
+		return nil, &rpcError{Code: codeInvalidParams, Message: fmt.Sprintf("Document `%s` is not open", uri)}
+	}
+	return doc, nil
+}
+
+//
+// Analysis and diagnostics.
+//
+
+// Analyzes the given source code as the entry module.
+// A crash of the analyzer must not terminate the server, therefore, it is treated like a failed analysis.
+func (self *Server) analyze(path string, text string) (
+	modules map[string]ast.AnalyzedProgram,
+	diagnostics []diagnostic.Diagnostic,
+	syntaxErrors []herrors.Error,
+) {
+	defer func() {
+		if err := recover(); err != nil {
+			log.Printf("Analyzer crashed on `%s`: %v\n", path, err)
+			modules, diagnostics, syntaxErrors = nil, nil, nil
+		}
+	}()
+
+	return homescript.Analyze(
+		homescript.InputProgram{
+			ProgramText: text,
+			Filename:    path,
+		},
+		self.scopeAdditions(),
+		documentHost{
+			HostProvider: self.host,
+			server:       self,
+			dir:          filepath.Dir(path),
+		},
+		// The document might be a module which is only imported by other scripts.
+		false,
+	)
+}
+
+func (self *Server) refresh(doc *document) *rpcError {
+	modules, diagnostics, syntaxErrors := self.analyze(doc.path, doc.text)
+	doc.index = nil
+	if modules != nil {
+		doc.index = newSymbolIndex(modules)
+	}
+
+	result := make([]Diagnostic, 0)
+
+	for _, syntaxErr := range syntaxErrors {
+		if syntaxErr.Span.Filename != doc.path {
+			continue
+		}
+
+		result = append(result, Diagnostic{
+			Range:    toRange(doc.lines, syntaxErr.Span),
+			Severity: DiagnosticSeverityError,
+			Code:     string(syntaxErr.Code),
+			Source:   serverName,
+			Message:  syntaxErr.Message,
+		})
+	}
+
+	for _, item := range diagnostics {
+		// Diagnostics of imported modules are shown once they are opened.
+		if item.Span.Filename != doc.path {
+			continue
+		}
+
+		var severity DiagnosticSeverity
+		switch item.Level {
+		case diagnostic.DiagnosticLevelHint:
+			severity = DiagnosticSeverityHint
+		case diagnostic.DiagnosticLevelInfo:
+			severity = DiagnosticSeverityInformation
+		case diagnostic.DiagnosticLevelWarning:
+			severity = DiagnosticSeverityWarning
+		case diagnostic.DiagnosticLevelError:
+			severity = DiagnosticSeverityError
+		default:
+			panic("A new diagnostic level was added without updating this code")
+		}
+
+		var related []DiagnosticRelatedInformation
+		for _, label := range item.Labels {
+			path := spanPath(filepath.Dir(doc.path), label.Span.Filename)
+			related = append(related, DiagnosticRelatedInformation{
+				Location: Location{
+					URI:   pathToURI(path),
+					Range: toRange(self.linesOf(path), label.Span),
+				},
+				Message: label.Message,
+			})
+		}
+
+		result = append(result, Diagnostic{
+			Range:              toRange(doc.lines, item.Span),
+			Severity:           severity,
+			Code:               string(item.Code),
+			Source:             serverName,
+			Message:            strings.Join(append([]string{item.Message}, item.Notes...), "\n"),
+			RelatedInformation: related,
+		})
+	}
+
+	return self.publishDiagnostics(doc.uri, doc.version, result)
+}
+
+func (self *Server) publishDiagnostics(uri string, version int, diagnostics []Diagnostic) *rpcError {
+	// The connection is nil if the server is driven without a client, for instance in tests.
+	if self.conn == nil {
+		return nil
+	}
+
+	if err := self.conn.notify("textDocument/publishDiagnostics", PublishDiagnosticsParams{
+		URI:         uri,
+		Version:     version,
+		Diagnostics: diagnostics,
+	}); err != nil {
+		return &rpcError{Code: codeInternalError, Message: err.Error()}
+	}
+
+	return nil
+}
+
+//
+// Language features.
+//
+
+func (self *Server) referenceAt(doc *document, pos Position) (reference, bool) {
+	if doc.index == nil {
+		return reference{}, false
+	}
+	return doc.index.referenceAt(doc.path, toLocation(doc.lines, pos))
+}
+
+// Returns nil if there is nothing to show at the position.
+func (self *Server) hover(doc *document, pos Position) *Hover {
+	ref, found := self.referenceAt(doc, pos)
+	if !found {
+		return nil
+	}
+
+	line := lineAt(doc.lines, ref.span.Start.Line-1)
+	ident := string(line[ref.span.Start.Column-1 : min(ref.span.End.Column, uint(len(line)))])
+
+	typ := ref.typ
+	isFunction := ref.symbol != nil && ref.symbol.definition().kind == functionSymbolKind
+	if ref.symbol != nil && (typ == nil || isFunction) {
+		typ = ref.symbol.typ
+	}
+
+	var signature string
+	switch {
+	case typ == nil:
+		signature = ident
+	case isFunction:
+		signature = fmt.Sprintf("fn %s%s", ident, strings.TrimPrefix(typ.String(), "fn"))
+	default:
+		signature = fmt.Sprintf("%s: %s", ident, typ)
+	}
+
+	var description string
+	switch {
+	case ref.isMember:
+		description = "member"
+	case ref.symbol == nil:
+		description = "builtin"
+	case ref.symbol.kind == importSymbolKind:
+		description = fmt.Sprintf("imported from `%s`", ref.symbol.fromModule)
+	default:
+		description = ref.symbol.kind.String()
+	}
+
+	return &Hover{
+		Contents: MarkupContent{
+			Kind:  "markdown",
+			Value: fmt.Sprintf("```hms\n%s\n```\n%s", signature, description),
+		},
+		Range: toRange(doc.lines, ref.span),
+	}
+}
+
+// Returns nil if the symbol at the position is unknown or builtin.
+func (self *Server) definition(doc *document, pos Position) *Location {
+	ref, found := self.referenceAt(doc, pos)
+	if !found || ref.symbol == nil {
+		return nil
+	}
+
+	def := ref.symbol.definition()
+	path := spanPath(filepath.Dir(doc.path), def.span.Filename)
+
+	return &Location{
+		URI:   pathToURI(path),
+		Range: toRange(self.linesOf(path), def.span),
+	}
+}
+
+func (self *Server) rename(doc *document, pos Position, newName string) (*WorkspaceEdit, *rpcError) {
+	if newName == "" || newName[0] >= '0' && newName[0] <= '9' || strings.IndexFunc(newName, func(char rune) bool { return !isIdentRune(char) }) != -1 {
+		return nil, &rpcError{Code: codeRequestFailed, Message: fmt.Sprintf("`%s` is not a valid identifier", newName)}
+	}
+
+	ref, found := self.referenceAt(doc, pos)
+	if !found || ref.isMember {
+		return nil, &rpcError{Code: codeRequestFailed, Message: "There is no symbol to rename at this position"}
+	}
+
+	if ref.symbol == nil || ref.symbol.kind == importSymbolKind || ref.symbol.span.Filename != doc.path {
+		return nil, &rpcError{Code: codeRequestFailed, Message: "Only symbols which are declared in this module can be renamed"}
+	}
+
+	edits := make([]TextEdit, 0)
+	for _, ref := range doc.index.referencesTo(doc.path, ref.symbol) {
+		edits = append(edits, TextEdit{
+			Range:   toRange(doc.lines, ref.span),
+			NewText: newName,
+		})
+	}
+
+	return &WorkspaceEdit{Changes: map[string][]TextEdit{doc.uri: edits}}, nil
+}
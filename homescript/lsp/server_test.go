@@ -0,0 +1,115 @@
+package lsp
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/smarthome-go/homescript/v3/homescript"
+	"github.com/stretchr/testify/assert"
+)
+
+const testLibrary = `pub fn greet(name: str) -> str {
+    return "hello " + name;
+}
+`
+
+const testMain = `import greet from lib;
+import { http } from net;
+
+fn main() {
+    let msg = greet("you");
+    println(msg.len(), msg);
+}
+`
+
+func request(t *testing.T, server *Server, method string, params any) any {
+	raw, err := json.Marshal(params)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	result, rpcErr := server.handle(method, raw)
+	if rpcErr != nil {
+		return *rpcErr
+	}
+	return result
+}
+
+func TestServer(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "lib.hms"), []byte(testLibrary), 0644))
+
+	uri := pathToURI(filepath.Join(dir, "main.hms"))
+	at := func(line uint, character uint) TextDocumentPositionParams {
+		return TextDocumentPositionParams{
+			TextDocument: TextDocumentIdentifier{URI: uri},
+			Position:     Position{Line: line, Character: character},
+		}
+	}
+
+	server := NewServer(homescript.TestingAnalyzerHost{}, homescript.TestingAnalyzerScopeAdditions)
+	request(t, server, "textDocument/didOpen", DidOpenTextDocumentParams{
+		TextDocument: TextDocumentItem{URI: uri, Version: 1, Text: testMain},
+	})
+
+	// Hover.
+	hover := request(t, server, "textDocument/hover", at(4, 15)).(*Hover)
+	assert.Equal(t, "```hms\nfn greet(name: str) -> str\n```\nimported from `lib`", hover.Contents.Value)
+
+	hover = request(t, server, "textDocument/hover", at(5, 17)).(*Hover)
+	assert.Equal(t, "```hms\nlen: fn() -> int\n```\nmember", hover.Contents.Value)
+
+	// Go to definition across modules.
+	definition := request(t, server, "textDocument/definition", at(4, 15)).(*Location)
+	assert.Equal(t, pathToURI(filepath.Join(dir, "lib.hms")), definition.URI)
+	assert.Equal(t, Range{Start: Position{Line: 0, Character: 7}, End: Position{Line: 0, Character: 12}}, definition.Range)
+
+	// Rename within the module.
+	edit := request(t, server, "textDocument/rename", RenameParams{
+		TextDocument: TextDocumentIdentifier{URI: uri},
+		Position:     Position{Line: 4, Character: 9},
+		NewName:      "message",
+	}).(*WorkspaceEdit)
+	assert.Len(t, edit.Changes[uri], 3)
+
+	renameErr := request(t, server, "textDocument/rename", RenameParams{
+		TextDocument: TextDocumentIdentifier{URI: uri},
+		Position:     Position{Line: 4, Character: 15},
+		NewName:      "welcome",
+	}).(rpcError)
+	assert.Equal(t, "Only symbols which are declared in this module can be renamed", renameErr.Message)
+
+	// Completion of builtin imports.
+	labels := func(list CompletionList) []string {
+		result := make([]string, 0)
+		for _, item := range list.Items {
+			result = append(result, item.Label)
+		}
+		return result
+	}
+
+	completion := request(t, server, "textDocument/completion", at(1, 9)).(CompletionList)
+	assert.Equal(t, []string{"http", "ping"}, labels(completion))
+
+	// Completion of members while the source code is incomplete.
+	request(t, server, "textDocument/didChange", DidChangeTextDocumentParams{
+		TextDocument:   VersionedTextDocumentIdentifier{URI: uri, Version: 2},
+		ContentChanges: []TextDocumentContentChangeEvent{{Text: strings.Replace(testMain, "msg.len()", "msg.to_", 1)}},
+	})
+
+	completion = request(t, server, "textDocument/completion", at(5, 19)).(CompletionList)
+	assert.Contains(t, labels(completion), "to_upper")
+	assert.Contains(t, labels(completion), "len")
+
+	// The spans of a previous analysis are not used if the current text cannot be analyzed.
+	request(t, server, "textDocument/didChange", DidChangeTextDocumentParams{
+		TextDocument:   VersionedTextDocumentIdentifier{URI: uri, Version: 3},
+		ContentChanges: []TextDocumentContentChangeEvent{{Text: "fn {\n" + testMain}},
+	})
+
+	assert.Nil(t, request(t, server, "textDocument/hover", at(4, 15)))
+	assert.Nil(t, request(t, server, "textDocument/definition", at(4, 15)))
+}
@@ -0,0 +1,250 @@
+package optimizer
+
+import (
+	"github.com/smarthome-go/homescript/v3/homescript/analyzer/ast"
+	"github.com/smarthome-go/homescript/v3/homescript/errors"
+	pAst "github.com/smarthome-go/homescript/v3/homescript/parser/ast"
+)
+
+func (o *Optimizer) optExpression(node ast.AnalyzedExpression) ast.AnalyzedExpression {
+	switch node.Kind() {
+	case ast.UnknownExpressionKind, ast.IntLiteralExpressionKind, ast.FloatLiteralExpressionKind,
+		ast.BoolLiteralExpressionKind, ast.StringLiteralExpressionKind, ast.IdentExpressionKind,
+		ast.NullLiteralExpressionKind, ast.NoneLiteralExpressionKind, ast.AnyObjectLiteralExpressionKind:
+		return node
+	case ast.RangeLiteralExpressionKind:
+		node := node.(ast.AnalyzedRangeLiteralExpression)
+		node.Start = o.optExpression(node.Start)
+		node.End = o.optExpression(node.End)
+		return node
+	case ast.ListLiteralExpressionKind:
+		node := node.(ast.AnalyzedListLiteralExpression)
+		values := make([]ast.AnalyzedExpression, len(node.Values))
+		for idx, value := range node.Values {
+			values[idx] = o.optExpression(value)
+		}
+		node.Values = values
+		return node
+	case ast.ObjectLiteralExpressionKind:
+		node := node.(ast.AnalyzedObjectLiteralExpression)
+		fields := make([]ast.AnalyzedObjectLiteralField, len(node.Fields))
+		for idx, field := range node.Fields {
+			field.Expression = o.optExpression(field.Expression)
+			fields[idx] = field
+		}
+		node.Fields = fields
+		return node
+	case ast.FunctionLiteralExpressionKind:
+		node := node.(ast.AnalyzedFunctionLiteralExpression)
+		node.Body = o.block(node.Body)
+		return node
+	case ast.GroupedExpressionKind:
+		node := node.(ast.AnalyzedGroupedExpression)
+		inner := o.optExpression(node.Inner)
+
+		// Parentheses around a literal are meaningless.
+		if isLiteral(inner) {
+			return withSpan(inner, node.Range)
+		}
+
+		node.Inner = inner
+		return node
+	case ast.PrefixExpressionKind:
+		return o.prefixExpression(node.(ast.AnalyzedPrefixExpression))
+	case ast.InfixExpressionKind:
+		return o.infixExpression(node.(ast.AnalyzedInfixExpression))
+	case ast.AssignExpressionKind:
+		node := node.(ast.AnalyzedAssignExpression)
+		node.Lhs = o.optExpression(node.Lhs)
+		node.Rhs = o.optExpression(node.Rhs)
+		return node
+	case ast.CallExpressionKind:
+		node := node.(ast.AnalyzedCallExpression)
+		args := make([]ast.AnalyzedCallArgument, len(node.Arguments.List))
+		for idx, arg := range node.Arguments.List {
+			arg.Expression = o.optExpression(arg.Expression)
+			args[idx] = arg
+		}
+		node.Base = o.optExpression(node.Base)
+		node.Arguments.List = args
+
+		if inlined, ok := o.inlineCall(node); ok {
+			return inlined
+		}
+
+		return node
+	case ast.IndexExpressionKind:
+		node := node.(ast.AnalyzedIndexExpression)
+		node.Base = o.optExpression(node.Base)
+		node.Index = o.optExpression(node.Index)
+		return node
+	case ast.MemberExpressionKind:
+		node := node.(ast.AnalyzedMemberExpression)
+		node.Base = o.optExpression(node.Base)
+		return node
+	case ast.CastExpressionKind:
+		return o.castExpression(node.(ast.AnalyzedCastExpression))
+	case ast.BlockExpressionKind:
+		return o.blockExpression(o.block(node.(ast.AnalyzedBlockExpression).Block))
+	case ast.IfExpressionKind:
+		return o.ifExpression(node.(ast.AnalyzedIfExpression))
+	case ast.MatchExpressionKind:
+		node := node.(ast.AnalyzedMatchExpression)
+		node.ControlExpression = o.optExpression(node.ControlExpression)
+
+		arms := make([]ast.AnalyzedMatchArm, len(node.Arms))
+		for idx, arm := range node.Arms {
+			literals := make([]ast.AnalyzedExpression, len(arm.Literals))
+			for litIdx, lit := range arm.Literals {
+				literals[litIdx] = o.optExpression(lit)
+			}
+
+			arms[idx] = ast.AnalyzedMatchArm{
+				Literals: literals,
+				Action:   o.optExpression(arm.Action),
+			}
+		}
+		node.Arms = arms
+
+		if node.DefaultArmAction != nil {
+			defaultAction := o.optExpression(*node.DefaultArmAction)
+			node.DefaultArmAction = &defaultAction
+		}
+
+		return node
+	case ast.TryExpressionKind:
+		node := node.(ast.AnalyzedTryExpression)
+		node.TryBlock = o.block(node.TryBlock)
+		node.CatchBlock = o.block(node.CatchBlock)
+		return node
+	default:
+		panic("A new expression kind was added without updating this code")
+	}
+}
+
+//
+// Prefix expression.
+//
+
+func (o *Optimizer) prefixExpression(node ast.AnalyzedPrefixExpression) ast.AnalyzedExpression {
+	node.Base = o.optExpression(node.Base)
+
+	if folded, ok := foldPrefix(node.Operator, node.Base, node.Range); ok {
+		return folded
+	}
+
+	return node
+}
+
+//
+// Infix expression.
+//
+
+func (o *Optimizer) infixExpression(node ast.AnalyzedInfixExpression) ast.AnalyzedExpression {
+	node.Lhs = o.optExpression(node.Lhs)
+	node.Rhs = o.optExpression(node.Rhs)
+
+	// Short-circuiting operators only require a constant left-hand-side.
+	if node.Lhs.Kind() == ast.BoolLiteralExpressionKind {
+		lhs := node.Lhs.(ast.AnalyzedBoolLiteralExpression).Value
+
+		switch node.Operator {
+		case pAst.LogicalAndInfixOperator:
+			// `false && x` is always `false`, `true && x` is always `x`.
+			if !lhs {
+				return withSpan(node.Lhs, node.Range)
+			}
+			return node.Rhs
+		case pAst.LogicalOrInfixOperator:
+			// `true || x` is always `true`, `false || x` is always `x`.
+			if lhs {
+				return withSpan(node.Lhs, node.Range)
+			}
+			return node.Rhs
+		}
+	}
+
+	if !isLiteral(node.Lhs) || !isLiteral(node.Rhs) {
+		return node
+	}
+
+	if isIntDivisionByZero(node) {
+		o.warn(
+			errors.DivisionByZero,
+			"Division by zero",
+			[]string{"This expression will always cause a runtime error"},
+			node.Range,
+		)
+		return node
+	}
+
+	if folded, ok := foldInfix(node.Operator, node.Lhs, node.Rhs, node.Range); ok {
+		return folded
+	}
+
+	return node
+}
+
+//
+// Cast expression.
+//
+
+func (o *Optimizer) castExpression(node ast.AnalyzedCastExpression) ast.AnalyzedExpression {
+	node.Base = o.optExpression(node.Base)
+
+	if folded, ok := foldCast(node.Base, node.AsType, node.Range); ok {
+		return folded
+	}
+
+	return node
+}
+
+//
+// If expression.
+//
+
+func (o *Optimizer) ifExpression(node ast.AnalyzedIfExpression) ast.AnalyzedExpression {
+	node.Condition = o.optExpression(node.Condition)
+	node.ThenBlock = o.block(node.ThenBlock)
+
+	if node.ElseBlock != nil {
+		elseBlock := o.block(*node.ElseBlock)
+		node.ElseBlock = &elseBlock
+	}
+
+	if node.Condition.Kind() != ast.BoolLiteralExpressionKind {
+		return node
+	}
+
+	// The condition is constant: only one of the branches can ever be executed.
+	if node.Condition.(ast.AnalyzedBoolLiteralExpression).Value {
+		return o.blockExpression(node.ThenBlock)
+	}
+
+	if node.ElseBlock != nil {
+		return o.blockExpression(*node.ElseBlock)
+	}
+
+	return ast.AnalyzedBlockExpression{
+		Block: ast.AnalyzedBlock{
+			Statements: []ast.AnalyzedStatement{},
+			Expression: nil,
+			Range:      node.Range,
+			ResultType: ast.NewNullType(node.Range),
+		},
+	}
+}
+
+//
+// Block expression.
+//
+
+// Wraps an already optimized block into an expression.
+func (o *Optimizer) blockExpression(block ast.AnalyzedBlock) ast.AnalyzedExpression {
+	// A block which only consists of a literal can be replaced by this literal.
+	if len(block.Statements) == 0 && block.Expression != nil && isLiteral(block.Expression) {
+		return withSpan(block.Expression, block.Range)
+	}
+
+	return ast.AnalyzedBlockExpression{Block: block}
+}
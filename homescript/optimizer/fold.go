@@ -0,0 +1,264 @@
+package optimizer
+
+import (
+	"math"
+
+	"github.com/smarthome-go/homescript/v3/homescript/analyzer/ast"
+	"github.com/smarthome-go/homescript/v3/homescript/errors"
+	pAst "github.com/smarthome-go/homescript/v3/homescript/parser/ast"
+	"github.com/smarthome-go/homescript/v3/homescript/runtime/value"
+)
+
+//
+// Constant folding helpers.
+// The semantics of these functions must match the ones of the VM and the tree-walking interpreter exactly.
+//
+
+// Returns whether the expression is a primitive literal which can be used for constant folding.
+func isLiteral(node ast.AnalyzedExpression) bool {
+	switch node.Kind() {
+	case ast.IntLiteralExpressionKind, ast.FloatLiteralExpressionKind,
+		ast.BoolLiteralExpressionKind, ast.StringLiteralExpressionKind:
+		return true
+	default:
+		return false
+	}
+}
+
+// Returns a copy of the literal which is located at the given span.
+func withSpan(node ast.AnalyzedExpression, span errors.Span) ast.AnalyzedExpression {
+	switch node.Kind() {
+	case ast.IntLiteralExpressionKind:
+		return ast.AnalyzedIntLiteralExpression{Value: node.(ast.AnalyzedIntLiteralExpression).Value, Range: span}
+	case ast.FloatLiteralExpressionKind:
+		return ast.AnalyzedFloatLiteralExpression{Value: node.(ast.AnalyzedFloatLiteralExpression).Value, Range: span}
+	case ast.BoolLiteralExpressionKind:
+		return ast.AnalyzedBoolLiteralExpression{Value: node.(ast.AnalyzedBoolLiteralExpression).Value, Range: span}
+	case ast.StringLiteralExpressionKind:
+		return ast.AnalyzedStringLiteralExpression{Value: node.(ast.AnalyzedStringLiteralExpression).Value, Range: span}
+	default:
+		panic("Only literals can be respanned")
+	}
+}
+
+func isIntDivisionByZero(node ast.AnalyzedInfixExpression) bool {
+	if node.Operator != pAst.DivideInfixOperator && node.Operator != pAst.ModuloInfixOperator {
+		return false
+	}
+
+	rhs, ok := node.Rhs.(ast.AnalyzedIntLiteralExpression)
+	return ok && rhs.Value == 0
+}
+
+func foldPrefix(op ast.PrefixOperator, base ast.AnalyzedExpression, span errors.Span) (ast.AnalyzedExpression, bool) {
+	switch base := base.(type) {
+	case ast.AnalyzedIntLiteralExpression:
+		switch op {
+		case ast.MinusPrefixOperator:
+			return ast.AnalyzedIntLiteralExpression{Value: -base.Value, Range: span}, true
+		case ast.NegatePrefixOperator:
+			return ast.AnalyzedIntLiteralExpression{Value: ^base.Value, Range: span}, true
+		}
+	case ast.AnalyzedFloatLiteralExpression:
+		if op == ast.MinusPrefixOperator {
+			return ast.AnalyzedFloatLiteralExpression{Value: -base.Value, Range: span}, true
+		}
+	case ast.AnalyzedBoolLiteralExpression:
+		if op == ast.NegatePrefixOperator {
+			return ast.AnalyzedBoolLiteralExpression{Value: !base.Value, Range: span}, true
+		}
+	}
+
+	return nil, false
+}
+
+func foldInfix(op pAst.InfixOperator, lhs ast.AnalyzedExpression, rhs ast.AnalyzedExpression, span errors.Span) (ast.AnalyzedExpression, bool) {
+	if lhs.Kind() != rhs.Kind() {
+		return nil, false
+	}
+
+	switch lhs := lhs.(type) {
+	case ast.AnalyzedIntLiteralExpression:
+		return foldIntInfix(op, lhs.Value, rhs.(ast.AnalyzedIntLiteralExpression).Value, span)
+	case ast.AnalyzedFloatLiteralExpression:
+		return foldFloatInfix(op, lhs.Value, rhs.(ast.AnalyzedFloatLiteralExpression).Value, span)
+	case ast.AnalyzedBoolLiteralExpression:
+		return foldBoolInfix(op, lhs.Value, rhs.(ast.AnalyzedBoolLiteralExpression).Value, span)
+	case ast.AnalyzedStringLiteralExpression:
+		return foldStringInfix(op, lhs.Value, rhs.(ast.AnalyzedStringLiteralExpression).Value, span)
+	default:
+		return nil, false
+	}
+}
+
+func foldIntInfix(op pAst.InfixOperator, lhs int64, rhs int64, span errors.Span) (ast.AnalyzedExpression, bool) {
+	var res int64
+
+	switch op {
+	case pAst.PlusInfixOperator:
+		res = lhs + rhs
+	case pAst.MinusInfixOperator:
+		res = lhs - rhs
+	case pAst.MultiplyInfixOperator:
+		res = lhs * rhs
+	case pAst.DivideInfixOperator:
+		if rhs == 0 {
+			return nil, false
+		}
+		res = lhs / rhs
+	case pAst.ModuloInfixOperator:
+		if rhs == 0 {
+			return nil, false
+		}
+		res = lhs % rhs
+	case pAst.PowerInfixOperator:
+		res = int64(math.Pow(float64(lhs), float64(rhs)))
+	case pAst.ShiftLeftInfixOperator:
+		// Negative shift counts cause a runtime panic, leave them to the runtime.
+		if rhs < 0 {
+			return nil, false
+		}
+		res = lhs << rhs
+	case pAst.ShiftRightInfixOperator:
+		if rhs < 0 {
+			return nil, false
+		}
+		res = lhs >> rhs
+	case pAst.BitOrInfixOperator:
+		res = lhs | rhs
+	case pAst.BitAndInfixOperator:
+		res = lhs & rhs
+	case pAst.BitXorInfixOperator:
+		res = lhs ^ rhs
+	case pAst.EqualInfixOperator:
+		return ast.AnalyzedBoolLiteralExpression{Value: lhs == rhs, Range: span}, true
+	case pAst.NotEqualInfixOperator:
+		return ast.AnalyzedBoolLiteralExpression{Value: lhs != rhs, Range: span}, true
+	case pAst.LessThanInfixOperator:
+		return ast.AnalyzedBoolLiteralExpression{Value: lhs < rhs, Range: span}, true
+	case pAst.LessThanEqualInfixOperator:
+		return ast.AnalyzedBoolLiteralExpression{Value: lhs <= rhs, Range: span}, true
+	case pAst.GreaterThanInfixOperator:
+		return ast.AnalyzedBoolLiteralExpression{Value: lhs > rhs, Range: span}, true
+	case pAst.GreaterThanEqualInfixOperator:
+		return ast.AnalyzedBoolLiteralExpression{Value: lhs >= rhs, Range: span}, true
+	default:
+		return nil, false
+	}
+
+	return ast.AnalyzedIntLiteralExpression{Value: res, Range: span}, true
+}
+
+func foldFloatInfix(op pAst.InfixOperator, lhs float64, rhs float64, span errors.Span) (ast.AnalyzedExpression, bool) {
+	var res float64
+
+	switch op {
+	case pAst.PlusInfixOperator:
+		res = lhs + rhs
+	case pAst.MinusInfixOperator:
+		res = lhs - rhs
+	case pAst.MultiplyInfixOperator:
+		res = lhs * rhs
+	case pAst.DivideInfixOperator:
+		// The VM raises an error on float division by zero, this must be preserved.
+		if rhs == 0 {
+			return nil, false
+		}
+		res = lhs / rhs
+	case pAst.PowerInfixOperator:
+		res = math.Pow(lhs, rhs)
+	case pAst.EqualInfixOperator:
+		return ast.AnalyzedBoolLiteralExpression{Value: lhs == rhs, Range: span}, true
+	case pAst.NotEqualInfixOperator:
+		return ast.AnalyzedBoolLiteralExpression{Value: lhs != rhs, Range: span}, true
+	case pAst.LessThanInfixOperator:
+		return ast.AnalyzedBoolLiteralExpression{Value: lhs < rhs, Range: span}, true
+	case pAst.LessThanEqualInfixOperator:
+		return ast.AnalyzedBoolLiteralExpression{Value: lhs <= rhs, Range: span}, true
+	case pAst.GreaterThanInfixOperator:
+		return ast.AnalyzedBoolLiteralExpression{Value: lhs > rhs, Range: span}, true
+	case pAst.GreaterThanEqualInfixOperator:
+		return ast.AnalyzedBoolLiteralExpression{Value: lhs >= rhs, Range: span}, true
+	default:
+		return nil, false
+	}
+
+	return ast.AnalyzedFloatLiteralExpression{Value: res, Range: span}, true
+}
+
+func foldBoolInfix(op pAst.InfixOperator, lhs bool, rhs bool, span errors.Span) (ast.AnalyzedExpression, bool) {
+	var res bool
+
+	switch op {
+	case pAst.BitOrInfixOperator, pAst.LogicalOrInfixOperator:
+		res = lhs || rhs
+	case pAst.BitAndInfixOperator, pAst.LogicalAndInfixOperator:
+		res = lhs && rhs
+	case pAst.BitXorInfixOperator, pAst.NotEqualInfixOperator:
+		res = lhs != rhs
+	case pAst.EqualInfixOperator:
+		res = lhs == rhs
+	default:
+		return nil, false
+	}
+
+	return ast.AnalyzedBoolLiteralExpression{Value: res, Range: span}, true
+}
+
+func foldStringInfix(op pAst.InfixOperator, lhs string, rhs string, span errors.Span) (ast.AnalyzedExpression, bool) {
+	switch op {
+	case pAst.PlusInfixOperator:
+		return ast.AnalyzedStringLiteralExpression{Value: lhs + rhs, Range: span}, true
+	case pAst.EqualInfixOperator:
+		return ast.AnalyzedBoolLiteralExpression{Value: lhs == rhs, Range: span}, true
+	case pAst.NotEqualInfixOperator:
+		return ast.AnalyzedBoolLiteralExpression{Value: lhs != rhs, Range: span}, true
+	default:
+		return nil, false
+	}
+}
+
+// Folds a cast of a literal into a new literal.
+// The runtime's cast implementation is used so that the semantics cannot diverge.
+func foldCast(base ast.AnalyzedExpression, asType ast.Type, span errors.Span) (ast.AnalyzedExpression, bool) {
+	// Casts to non-primitive types (such as `any`) change the static type of the expression and must be kept.
+	switch asType.Kind() {
+	case ast.IntTypeKind, ast.FloatTypeKind, ast.BoolTypeKind, ast.StringTypeKind:
+	default:
+		return nil, false
+	}
+
+	var val *value.Value
+
+	switch base := base.(type) {
+	case ast.AnalyzedIntLiteralExpression:
+		val = value.NewValueInt(base.Value)
+	case ast.AnalyzedFloatLiteralExpression:
+		val = value.NewValueFloat(base.Value)
+	case ast.AnalyzedBoolLiteralExpression:
+		val = value.NewValueBool(base.Value)
+	case ast.AnalyzedStringLiteralExpression:
+		val = value.NewValueString(base.Value)
+	default:
+		return nil, false
+	}
+
+	casted, err := value.DeepCast(*val, asType, span, true)
+	if err != nil {
+		// This cast will fail at runtime, the resulting exception must not be optimized away.
+		return nil, false
+	}
+
+	switch casted := (*casted).(type) {
+	case value.ValueInt:
+		return ast.AnalyzedIntLiteralExpression{Value: casted.Inner, Range: span}, true
+	case value.ValueFloat:
+		return ast.AnalyzedFloatLiteralExpression{Value: casted.Inner, Range: span}, true
+	case value.ValueBool:
+		return ast.AnalyzedBoolLiteralExpression{Value: casted.Inner, Range: span}, true
+	case value.ValueString:
+		return ast.AnalyzedStringLiteralExpression{Value: casted.Inner, Range: span}, true
+	default:
+		return nil, false
+	}
+}
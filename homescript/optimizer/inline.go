@@ -0,0 +1,385 @@
+package optimizer
+
+import (
+	"fmt"
+
+	"github.com/smarthome-go/homescript/v3/homescript/analyzer/ast"
+	pAst "github.com/smarthome-go/homescript/v3/homescript/parser/ast"
+)
+
+//
+// Function inlining.
+// A call of a small function is replaced by a block which binds the arguments to the parameters
+// and then evaluates the (renamed) function body.
+// Only self-contained functions are inlined: their bodies may only reference their own parameters and local variables.
+// Therefore, inlined functions cannot call other functions, which also rules out any kind of recursion.
+// Inlined code has no call frame of its own: exceptions which it throws still point at the body of the callee,
+// however, the call stack (as seen by the debugger and the profiler) lacks the frame of the callee.
+//
+
+// The maximum number of AST nodes in the body of a function which is inlined.
+const inlineMaxNodes = 40
+
+// Tries to inline an already optimized call expression.
+func (o *Optimizer) inlineCall(node ast.AnalyzedCallExpression) (ast.AnalyzedExpression, bool) {
+	if node.IsSpawn || node.Base.Kind() != ast.IdentExpressionKind {
+		return nil, false
+	}
+
+	ident := node.Base.(ast.AnalyzedIdentExpression).Ident.Ident()
+
+	// A local variable of the caller shadows the function.
+	if _, isLocal := o.currFnLocals[ident]; isLocal {
+		return nil, false
+	}
+
+	sym, found := o.symbols.resolve(o.currModule, ident)
+	if !found {
+		return nil, false
+	}
+
+	callee, isFn := o.symbols.functions[sym]
+	if !isFn || len(callee.Parameters.List) != len(node.Arguments.List) || !inlineArgumentsAreOrderIndependent(node.Arguments) {
+		return nil, false
+	}
+
+	o.inlineCnt++
+	renamer := newInlineRenamer(o.inlineCnt)
+
+	statements := make([]ast.AnalyzedStatement, 0)
+
+	for idx, param := range callee.Parameters.List {
+		if param.IsSingletonExtractor {
+			return nil, false
+		}
+
+		statements = append(statements, ast.AnalyzedLetStatement{
+			Ident:                      pAst.NewSpannedIdent(renamer.declare(param.Ident.Ident()), param.Span),
+			Expression:                 node.Arguments.List[idx].Expression,
+			VarType:                    param.Type,
+			NeedsRuntimeTypeValidation: false,
+			OptType:                    nil,
+			Range:                      node.Arguments.List[idx].Expression.Span(),
+		})
+	}
+
+	body := renamer.block(callee.Body)
+	if !renamer.ok {
+		return nil, false
+	}
+
+	body.Statements = append(statements, body.Statements...)
+	body.Range = node.Range
+	body.ResultType = node.ResultType
+
+	// The callee is also optimized on its own, so its diagnostics must not be reported again for each call site.
+	diagnosticsCnt := len(o.diagnostics)
+	optimized := o.block(body)
+	o.diagnostics = o.diagnostics[:diagnosticsCnt]
+
+	return o.blockExpression(optimized), true
+}
+
+// The VM evaluates call arguments in reverse order, while the inlined parameter bindings are evaluated in order.
+// Therefore, only calls where the evaluation order is irrelevant can be inlined.
+func inlineArgumentsAreOrderIndependent(args ast.AnalyzedCallArgs) bool {
+	complexArgs := 0
+	hasIdent := false
+
+	for _, arg := range args.List {
+		switch {
+		case isLiteral(arg.Expression):
+		case arg.Expression.Kind() == ast.IdentExpressionKind:
+			hasIdent = true
+		default:
+			complexArgs++
+		}
+	}
+
+	// A complex argument could modify a variable which is read by another argument.
+	return complexArgs == 0 || (complexArgs == 1 && !hasIdent)
+}
+
+//
+// Renamer.
+// Renames all locals of the inlined function so that they cannot clash with the locals of the caller.
+// If the function body turns out to be unsuitable for inlining, `ok` is set to `false`.
+//
+
+type inlineRenamer struct {
+	prefix string
+	// Maps original identifiers to their new names.
+	names  map[string]string
+	scopes []map[string]struct{}
+	nodes  int
+	ok     bool
+}
+
+func newInlineRenamer(cnt int) inlineRenamer {
+	return inlineRenamer{
+		prefix: fmt.Sprintf("@inline%d_", cnt),
+		names:  make(map[string]string),
+		scopes: []map[string]struct{}{make(map[string]struct{})},
+		nodes:  0,
+		ok:     true,
+	}
+}
+
+func (r *inlineRenamer) declare(ident string) string {
+	r.scopes[len(r.scopes)-1][ident] = struct{}{}
+
+	name := r.prefix + ident
+	r.names[ident] = name
+	return name
+}
+
+func (r *inlineRenamer) isDeclared(ident string) bool {
+	for idx := len(r.scopes) - 1; idx >= 0; idx-- {
+		if _, found := r.scopes[idx][ident]; found {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *inlineRenamer) pushScope() {
+	r.scopes = append(r.scopes, make(map[string]struct{}))
+}
+
+func (r *inlineRenamer) popScope() {
+	r.scopes = r.scopes[:len(r.scopes)-1]
+}
+
+func (r *inlineRenamer) visit() {
+	r.nodes++
+	if r.nodes > inlineMaxNodes {
+		r.ok = false
+	}
+}
+
+func (r *inlineRenamer) block(node ast.AnalyzedBlock) ast.AnalyzedBlock {
+	r.pushScope()
+	defer r.popScope()
+
+	statements := make([]ast.AnalyzedStatement, len(node.Statements))
+	for idx, statement := range node.Statements {
+		statements[idx] = r.statement(statement)
+	}
+
+	if node.Expression != nil {
+		node.Expression = r.expression(node.Expression)
+	}
+
+	node.Statements = statements
+	return node
+}
+
+func (r *inlineRenamer) callArgs(node ast.AnalyzedCallArgs) ast.AnalyzedCallArgs {
+	args := make([]ast.AnalyzedCallArgument, len(node.List))
+	for idx, arg := range node.List {
+		arg.Expression = r.expression(arg.Expression)
+		args[idx] = arg
+	}
+
+	node.List = args
+	return node
+}
+
+func (r *inlineRenamer) statement(node ast.AnalyzedStatement) ast.AnalyzedStatement {
+	r.visit()
+
+	switch node.Kind() {
+	case ast.TypeDefinitionStatementKind, ast.BreakStatementKind, ast.ContinueStatementKind:
+		return node
+	case ast.TriggerStatementKind, ast.SingletonTypeDefinitionStatementKind:
+		r.ok = false
+		return node
+	case ast.ReturnStatementKind:
+		// A `return` would leave the caller instead of the inlined function.
+		r.ok = false
+		return node
+	case ast.LetStatementKind:
+		node := node.(ast.AnalyzedLetStatement)
+		// The new variable is not yet visible in its own initializer.
+		node.Expression = r.expression(node.Expression)
+		node.Ident = pAst.NewSpannedIdent(r.declare(node.Ident.Ident()), node.Ident.Span())
+		return node
+	case ast.LoopStatementKind:
+		node := node.(ast.AnalyzedLoopStatement)
+		node.Body = r.block(node.Body)
+		return node
+	case ast.WhileStatementKind:
+		node := node.(ast.AnalyzedWhileStatement)
+		node.Condition = r.expression(node.Condition)
+		node.Body = r.block(node.Body)
+		return node
+	case ast.ForStatementKind:
+		node := node.(ast.AnalyzedForStatement)
+		node.IterExpression = r.expression(node.IterExpression)
+
+		r.pushScope()
+		node.Identifier = pAst.NewSpannedIdent(r.declare(node.Identifier.Ident()), node.Identifier.Span())
+		node.Body = r.block(node.Body)
+		r.popScope()
+
+		return node
+	case ast.ExpressionStatementKind:
+		node := node.(ast.AnalyzedExpressionStatement)
+		node.Expression = r.expression(node.Expression)
+		return node
+	default:
+		panic("A new statement kind was added without updating this code")
+	}
+}
+
+func (r *inlineRenamer) expression(node ast.AnalyzedExpression) ast.AnalyzedExpression {
+	r.visit()
+
+	switch node.Kind() {
+	case ast.UnknownExpressionKind, ast.IntLiteralExpressionKind, ast.FloatLiteralExpressionKind,
+		ast.BoolLiteralExpressionKind, ast.StringLiteralExpressionKind, ast.NullLiteralExpressionKind,
+		ast.NoneLiteralExpressionKind, ast.AnyObjectLiteralExpressionKind:
+		return node
+	case ast.IdentExpressionKind:
+		node := node.(ast.AnalyzedIdentExpression)
+
+		// Globals, singletons, functions and builtins would be resolved in the scope of the caller.
+		if node.IsGlobal || node.IsSingleton || !r.isDeclared(node.Ident.Ident()) {
+			r.ok = false
+			return node
+		}
+
+		node.Ident = pAst.NewSpannedIdent(r.names[node.Ident.Ident()], node.Ident.Span())
+		return node
+	case ast.FunctionLiteralExpressionKind:
+		// Closures are not inlined as they would capture the renamed variables.
+		r.ok = false
+		return node
+	case ast.RangeLiteralExpressionKind:
+		node := node.(ast.AnalyzedRangeLiteralExpression)
+		node.Start = r.expression(node.Start)
+		node.End = r.expression(node.End)
+		return node
+	case ast.ListLiteralExpressionKind:
+		node := node.(ast.AnalyzedListLiteralExpression)
+		values := make([]ast.AnalyzedExpression, len(node.Values))
+		for idx, value := range node.Values {
+			values[idx] = r.expression(value)
+		}
+		node.Values = values
+		return node
+	case ast.ObjectLiteralExpressionKind:
+		node := node.(ast.AnalyzedObjectLiteralExpression)
+		fields := make([]ast.AnalyzedObjectLiteralField, len(node.Fields))
+		for idx, field := range node.Fields {
+			field.Expression = r.expression(field.Expression)
+			fields[idx] = field
+		}
+		node.Fields = fields
+		return node
+	case ast.GroupedExpressionKind:
+		node := node.(ast.AnalyzedGroupedExpression)
+		node.Inner = r.expression(node.Inner)
+		return node
+	case ast.PrefixExpressionKind:
+		node := node.(ast.AnalyzedPrefixExpression)
+		node.Base = r.expression(node.Base)
+		return node
+	case ast.InfixExpressionKind:
+		node := node.(ast.AnalyzedInfixExpression)
+		node.Lhs = r.expression(node.Lhs)
+		node.Rhs = r.expression(node.Rhs)
+		return node
+	case ast.AssignExpressionKind:
+		node := node.(ast.AnalyzedAssignExpression)
+		node.Lhs = r.expression(node.Lhs)
+		node.Rhs = r.expression(node.Rhs)
+		return node
+	case ast.CallExpressionKind:
+		node := node.(ast.AnalyzedCallExpression)
+		node.Base = r.expression(node.Base)
+		node.Arguments = r.callArgs(node.Arguments)
+		return node
+	case ast.IndexExpressionKind:
+		node := node.(ast.AnalyzedIndexExpression)
+		node.Base = r.expression(node.Base)
+		node.Index = r.expression(node.Index)
+		return node
+	case ast.MemberExpressionKind:
+		node := node.(ast.AnalyzedMemberExpression)
+		node.Base = r.expression(node.Base)
+		return node
+	case ast.CastExpressionKind:
+		node := node.(ast.AnalyzedCastExpression)
+		node.Base = r.expression(node.Base)
+		return node
+	case ast.BlockExpressionKind:
+		node := node.(ast.AnalyzedBlockExpression)
+		node.Block = r.block(node.Block)
+		return node
+	case ast.IfExpressionKind:
+		node := node.(ast.AnalyzedIfExpression)
+		node.Condition = r.expression(node.Condition)
+		node.ThenBlock = r.block(node.ThenBlock)
+		if node.ElseBlock != nil {
+			elseBlock := r.block(*node.ElseBlock)
+			node.ElseBlock = &elseBlock
+		}
+		return node
+	case ast.MatchExpressionKind:
+		node := node.(ast.AnalyzedMatchExpression)
+		node.ControlExpression = r.expression(node.ControlExpression)
+
+		arms := make([]ast.AnalyzedMatchArm, len(node.Arms))
+		for idx, arm := range node.Arms {
+			literals := make([]ast.AnalyzedExpression, len(arm.Literals))
+			for litIdx, lit := range arm.Literals {
+				literals[litIdx] = r.expression(lit)
+			}
+
+			arms[idx] = ast.AnalyzedMatchArm{
+				Literals: literals,
+				Action:   r.expression(arm.Action),
+			}
+		}
+		node.Arms = arms
+
+		if node.DefaultArmAction != nil {
+			defaultAction := r.expression(*node.DefaultArmAction)
+			node.DefaultArmAction = &defaultAction
+		}
+
+		return node
+	case ast.TryExpressionKind:
+		node := node.(ast.AnalyzedTryExpression)
+		node.TryBlock = r.block(node.TryBlock)
+		node.CatchBlock = r.block(node.CatchBlock)
+		return node
+	default:
+		panic("A new expression kind was added without updating this code")
+	}
+}
+
+//
+// Caller locals.
+//
+
+// Collects the identifiers of all variables which are declared in a function.
+// Calls of functions whose name is shadowed by one of these variables are never inlined.
+func fnLocals(node ast.AnalyzedFunctionDefinition) map[string]struct{} {
+	locals := make(map[string]struct{})
+
+	for _, param := range node.Parameters.List {
+		locals[param.Ident.Ident()] = struct{}{}
+	}
+
+	walker := astWalker{
+		reference: func(string) {},
+		declare: func(ident string) {
+			locals[ident] = struct{}{}
+		},
+	}
+	walker.block(node.Body)
+
+	return locals
+}
@@ -10,27 +10,42 @@ import (
 // Optimizer helper functions.
 //
 
-func (o *Optimizer) error(message string, notes []string, span errors.Span) {
+func (o *Optimizer) error(code errors.Code, message string, notes []string, span errors.Span) {
 	o.diagnostics = append(o.diagnostics, diagnostic.Diagnostic{
 		Level:   diagnostic.DiagnosticLevelError,
+		Code:    code,
 		Message: message,
 		Notes:   notes,
 		Span:    span,
 	})
 }
 
-func (o *Optimizer) warn(message string, notes []string, span errors.Span) {
+func (o *Optimizer) warn(code errors.Code, message string, notes []string, span errors.Span) {
 	o.diagnostics = append(o.diagnostics, diagnostic.Diagnostic{
 		Level:   diagnostic.DiagnosticLevelWarning,
+		Code:    code,
 		Message: message,
 		Notes:   notes,
 		Span:    span,
 	})
 }
 
-func (o *Optimizer) hint(message string, notes []string, span errors.Span) {
+// Like `warn`, but the diagnostic also points at other spans which explain it.
+func (o *Optimizer) warnWithLabels(code errors.Code, message string, notes []string, span errors.Span, labels ...diagnostic.Label) {
+	o.diagnostics = append(o.diagnostics, diagnostic.Diagnostic{
+		Level:   diagnostic.DiagnosticLevelWarning,
+		Code:    code,
+		Message: message,
+		Notes:   notes,
+		Span:    span,
+		Labels:  labels,
+	})
+}
+
+func (o *Optimizer) hint(code errors.Code, message string, notes []string, span errors.Span) {
 	o.diagnostics = append(o.diagnostics, diagnostic.Diagnostic{
 		Level:   diagnostic.DiagnosticLevelHint,
+		Code:    code,
 		Message: message,
 		Notes:   notes,
 		Span:    span,
@@ -43,11 +58,22 @@ func (o *Optimizer) hint(message string, notes []string, span errors.Span) {
 
 type Optimizer struct {
 	diagnostics []diagnostic.Diagnostic
+	// Top-level definitions of the unoptimized modules, used for inlining.
+	symbols    symbolTable
+	currModule string
+	// Identifiers of all variables declared in the current function.
+	currFnLocals map[string]struct{}
+	// Counts inlined calls so that each one receives unique variable names.
+	inlineCnt int
 }
 
 func NewOptimizer() Optimizer {
 	return Optimizer{
-		diagnostics: []diagnostic.Diagnostic{},
+		diagnostics:  []diagnostic.Diagnostic{},
+		symbols:      symbolTable{},
+		currModule:   "",
+		currFnLocals: make(map[string]struct{}),
+		inlineCnt:    0,
 	}
 }
 
@@ -58,6 +84,7 @@ func (o *Optimizer) Optimize(
 	diagnostics []diagnostic.Diagnostic,
 ) {
 	modulesOut := make(map[string]ast.AnalyzedProgram)
+	o.symbols = newSymbolTable(analyzedModule)
 
 	for moduleName, module := range analyzedModule {
 		modulesOut[moduleName] = o.analyzeModule(moduleName, module)
@@ -66,7 +93,9 @@ func (o *Optimizer) Optimize(
 	return modulesOut, o.diagnostics
 }
 
-func (o *Optimizer) analyzeModule(_moduleName string, module ast.AnalyzedProgram) ast.AnalyzedProgram {
+func (o *Optimizer) analyzeModule(moduleName string, module ast.AnalyzedProgram) ast.AnalyzedProgram {
+	o.currModule = moduleName
+
 	functionsOut := make([]ast.AnalyzedFunctionDefinition, 0)
 
 	for _, fn := range module.Functions {
@@ -74,17 +103,39 @@ func (o *Optimizer) analyzeModule(_moduleName string, module ast.AnalyzedProgram
 		functionsOut = append(functionsOut, newFn)
 	}
 
+	implBlocksOut := make([]ast.AnalyzedImplBlock, len(module.ImplBlocks))
+
+	for idx, impl := range module.ImplBlocks {
+		methods := make([]ast.AnalyzedFunctionDefinition, len(impl.Methods))
+		for methodIdx, method := range impl.Methods {
+			methods[methodIdx] = o.optimizeFn(method)
+		}
+
+		impl.Methods = methods
+		implBlocksOut[idx] = impl
+	}
+
+	globalsOut := make([]ast.AnalyzedLetStatement, len(module.Globals))
+
+	// Global initializers do not belong to any function.
+	o.currFnLocals = make(map[string]struct{})
+
+	for idx, glob := range module.Globals {
+		globalsOut[idx] = o.letStatement(glob)
+	}
+
 	return ast.AnalyzedProgram{
 		Imports:    module.Imports,
 		Types:      module.Types,
 		Singletons: module.Singletons,
-		ImplBlocks: module.ImplBlocks,
-		Globals:    module.Globals,
+		ImplBlocks: implBlocksOut,
+		Globals:    globalsOut,
 		Functions:  functionsOut,
 	}
 }
 
 func (o *Optimizer) optimizeFn(node ast.AnalyzedFunctionDefinition) ast.AnalyzedFunctionDefinition {
+	o.currFnLocals = fnLocals(node)
 	newBlock := o.block(node.Body)
 
 	// TODO: optimize the parameters.
@@ -106,6 +157,7 @@ func (o *Optimizer) optimizeFn(node ast.AnalyzedFunctionDefinition) ast.Analyzed
 		Modifier:   node.Modifier,
 		Annotation: node.Annotation,
 		Range:      node.Range,
+		DocComment: node.DocComment,
 	}
 }
 
@@ -115,20 +167,20 @@ func (o *Optimizer) block(node ast.AnalyzedBlock) ast.AnalyzedBlock {
 	warnedUnreachable := false
 
 	for _, statement := range node.Statements {
-		newStatement := o.optStatement(statement)
+		newStatement, keep := o.optStatement(statement)
+		if !keep {
+			continue
+		}
 
 		// If the previous statement had the never type, warn that this statement is unreachable.
 		if unreachableSpan != nil && !warnedUnreachable {
 			warnedUnreachable = true
-			o.warn(
+			o.warnWithLabels(
+				errors.UnreachableCode,
 				"Unreachable statement",
 				nil,
 				newStatement.Span(),
-			)
-			o.hint(
-				"Any code following this statement is unreachable",
-				nil,
-				*unreachableSpan,
+				diagnostic.NewLabel(*unreachableSpan, "Any code following this statement is unreachable"),
 			)
 		}
 
@@ -152,15 +204,12 @@ func (o *Optimizer) block(node ast.AnalyzedBlock) ast.AnalyzedBlock {
 		trailingExpr = o.optExpression(node.Expression)
 
 		if unreachableSpan != nil && !warnedUnreachable {
-			o.warn(
+			o.warnWithLabels(
+				errors.UnreachableCode,
 				"Unreachable expression",
 				nil,
 				node.Expression.Span(),
-			)
-			o.hint(
-				"Any code following this statement is unreachable",
-				nil,
-				*unreachableSpan,
+				diagnostic.NewLabel(*unreachableSpan, "Any code following this statement is unreachable"),
 			)
 		}
 	}
@@ -185,11 +234,3 @@ func (o *Optimizer) block(node ast.AnalyzedBlock) ast.AnalyzedBlock {
 		Range:      node.Range,
 	}
 }
-
-func (o *Optimizer) optStatement(node ast.AnalyzedStatement) ast.AnalyzedStatement {
-	return node
-}
-
-func (o *Optimizer) optExpression(node ast.AnalyzedExpression) ast.AnalyzedExpression {
-	return node
-}
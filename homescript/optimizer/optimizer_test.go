@@ -0,0 +1,249 @@
+package optimizer
+
+import (
+	"testing"
+
+	"github.com/smarthome-go/homescript/v3/homescript"
+	"github.com/smarthome-go/homescript/v3/homescript/analyzer/ast"
+	"github.com/smarthome-go/homescript/v3/homescript/diagnostic"
+	"github.com/smarthome-go/homescript/v3/homescript/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+const constantFoldingProgram = `
+fn main() {
+    let a = 1 + 2 * 3;
+    let b = (10 - 4) / 2 % 2;
+    let c = 1.5 * 2.0;
+    let d = "foo" + "bar";
+    let e = !(1 < 2) || 3 >= 3;
+    let f = -(2 ** 8);
+    let g = if 1 == 1 { "then" } else { "else" };
+    let h = 3.9 as int;
+    let i = 1 << 4 | 1;
+    let j = false && a == 7;
+    println(a, b, c, d, e, f, g, h, i, j);
+}
+`
+
+func TestConstantFolding(t *testing.T) {
+	const filename = "constant_folding.hms"
+
+	modules, diagnostics, syntaxErrors := homescript.Analyze(
+		homescript.InputProgram{
+			ProgramText: constantFoldingProgram,
+			Filename:    filename,
+		},
+		homescript.TestingAnalyzerScopeAdditions(),
+		homescript.TestingAnalyzerHost{IsInvokedInTests: true},
+		true,
+	)
+
+	assert.Empty(t, syntaxErrors)
+	for _, d := range diagnostics {
+		assert.NotEqual(t, diagnostic.DiagnosticLevelError, d.Level, d.Message)
+	}
+
+	opt := NewOptimizer()
+	optimized, optDiagnostics := opt.Optimize(modules)
+	assert.Empty(t, optDiagnostics)
+
+	expected := map[string]ast.AnalyzedExpression{
+		"a": ast.AnalyzedIntLiteralExpression{Value: 7},
+		"b": ast.AnalyzedIntLiteralExpression{Value: 1},
+		"c": ast.AnalyzedFloatLiteralExpression{Value: 3.0},
+		"d": ast.AnalyzedStringLiteralExpression{Value: "foobar"},
+		"e": ast.AnalyzedBoolLiteralExpression{Value: true},
+		"f": ast.AnalyzedIntLiteralExpression{Value: -256},
+		"g": ast.AnalyzedStringLiteralExpression{Value: "then"},
+		"h": ast.AnalyzedIntLiteralExpression{Value: 3},
+		"i": ast.AnalyzedIntLiteralExpression{Value: 17},
+		"j": ast.AnalyzedBoolLiteralExpression{Value: false},
+	}
+
+	var mainFn *ast.AnalyzedFunctionDefinition
+	for _, fn := range optimized[filename].Functions {
+		if fn.Ident.Ident() == "main" {
+			fn := fn
+			mainFn = &fn
+		}
+	}
+	assert.NotNil(t, mainFn)
+
+	for _, stmt := range mainFn.Body.Statements {
+		if stmt.Kind() != ast.LetStatementKind {
+			continue
+		}
+
+		let := stmt.(ast.AnalyzedLetStatement)
+
+		want, found := expected[let.Ident.Ident()]
+		if !assert.True(t, found, let.Ident.Ident()) {
+			continue
+		}
+
+		assert.Equal(t, want.Kind(), let.Expression.Kind(), let.Ident.Ident())
+		assert.Equal(t, want.String(), let.Expression.String(), let.Ident.Ident())
+		assert.Equal(t, let.Span().Filename, let.Expression.Span().Filename)
+	}
+}
+
+const treeShakingLibrary = `
+let UNUSED = 42;
+let USED = [1, 2, 3];
+let USED_BY_CLOSURE = "foo";
+
+pub fn used_by_main() {
+    println(USED);
+    let f = fn() { println(USED_BY_CLOSURE) };
+}
+fn only_called_by_unused() {}
+pub fn unused() { only_called_by_unused(); }
+
+#[test]
+fn tests_double() {}
+
+fn main() {}
+`
+
+const treeShakingProgram = `
+import { used_by_main } from lib;
+
+let ENTRY_GLOBAL = 1;
+
+fn looked_up_by_host() {}
+
+fn main() {
+    used_by_main();
+}
+`
+
+// Resolves imported modules from memory instead of the file system.
+type treeShakingHost struct {
+	homescript.TestingAnalyzerHost
+	modules map[string]string
+}
+
+func (self treeShakingHost) ResolveCodeModule(moduleName string) (code string, moduleFound bool, err error) {
+	code, moduleFound = self.modules[moduleName]
+	return code, moduleFound, nil
+}
+
+func TestTreeShaking(t *testing.T) {
+	const filename = "tree_shaking.hms"
+
+	modules, diagnostics, syntaxErrors := homescript.Analyze(
+		homescript.InputProgram{
+			ProgramText: treeShakingProgram,
+			Filename:    filename,
+		},
+		homescript.TestingAnalyzerScopeAdditions(),
+		treeShakingHost{modules: map[string]string{"lib": treeShakingLibrary}},
+		true,
+	)
+
+	assert.Empty(t, syntaxErrors)
+	for _, d := range diagnostics {
+		assert.NotEqual(t, diagnostic.DiagnosticLevelError, d.Level, d.Message)
+	}
+
+	shaken := TreeShake(modules, filename)
+
+	names := func(module ast.AnalyzedProgram) (functions []string, globals []string) {
+		functions, globals = make([]string, 0), make([]string, 0)
+		for _, fn := range module.Functions {
+			functions = append(functions, fn.Ident.Ident())
+		}
+		for _, glob := range module.Globals {
+			globals = append(globals, glob.Ident.Ident())
+		}
+		return functions, globals
+	}
+
+	// The host may look up every function and global of the entry module.
+	functions, globals := names(shaken[filename])
+	assert.ElementsMatch(t, []string{"looked_up_by_host", "main"}, functions)
+	assert.ElementsMatch(t, []string{"ENTRY_GLOBAL"}, globals)
+
+	functions, globals = names(shaken["lib"])
+	assert.ElementsMatch(t, []string{"used_by_main", "tests_double"}, functions, "`main` of an imported module is never executed")
+	assert.ElementsMatch(t, []string{"USED", "USED_BY_CLOSURE"}, globals)
+}
+
+const inliningProgram = `
+fn clamp(v: int, lo: int, hi: int) -> int {
+    if v < lo { lo } else if v > hi { hi } else { v }
+}
+
+fn fac(n: int) -> int {
+    if n <= 1 { 1 } else { n * fac(n - 1) }
+}
+
+fn fails(v: int) -> int {
+    v + 1 / 0
+}
+
+fn main() {
+    let inlined = clamp(42, 0, 10);
+    let failing = fails(1) + fails(2);
+    let recursive = fac(5);
+    let clamp_local = fn(v: int, lo: int, hi: int) -> int { v };
+    let not_a_fn = clamp_local(1, 2, 3);
+    println(inlined, recursive, not_a_fn, failing);
+}
+`
+
+func TestInlining(t *testing.T) {
+	const filename = "inlining.hms"
+
+	modules, diagnostics, syntaxErrors := homescript.Analyze(
+		homescript.InputProgram{
+			ProgramText: inliningProgram,
+			Filename:    filename,
+		},
+		homescript.TestingAnalyzerScopeAdditions(),
+		homescript.TestingAnalyzerHost{IsInvokedInTests: true},
+		true,
+	)
+
+	assert.Empty(t, syntaxErrors)
+	for _, d := range diagnostics {
+		assert.NotEqual(t, diagnostic.DiagnosticLevelError, d.Level, d.Message)
+	}
+
+	opt := NewOptimizer()
+	optimized, optDiagnostics := opt.Optimize(modules)
+
+	// The warning in the body of `fails` is reported once, not for every inlined call.
+	divisionsByZero := 0
+	for _, d := range optDiagnostics {
+		if d.Code == errors.DivisionByZero {
+			divisionsByZero++
+		}
+	}
+	assert.Equal(t, 1, divisionsByZero)
+
+	expected := map[string]ast.ExpressionKind{
+		"inlined":   ast.BlockExpressionKind,
+		"failing":   ast.InfixExpressionKind,
+		"recursive": ast.CallExpressionKind,
+		"not_a_fn":  ast.CallExpressionKind,
+	}
+
+	for _, fn := range optimized[filename].Functions {
+		if fn.Ident.Ident() != "main" {
+			continue
+		}
+
+		for _, stmt := range fn.Body.Statements {
+			if stmt.Kind() != ast.LetStatementKind {
+				continue
+			}
+
+			let := stmt.(ast.AnalyzedLetStatement)
+			if want, found := expected[let.Ident.Ident()]; found {
+				assert.Equal(t, want, let.Expression.Kind(), let.Ident.Ident())
+			}
+		}
+	}
+}
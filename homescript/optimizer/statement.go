@@ -0,0 +1,73 @@
+package optimizer
+
+import (
+	"github.com/smarthome-go/homescript/v3/homescript/analyzer/ast"
+)
+
+// Optimizes a single statement.
+// If `keep` is `false`, the statement has no effect and can be removed from its parent block.
+func (o *Optimizer) optStatement(node ast.AnalyzedStatement) (output ast.AnalyzedStatement, keep bool) {
+	switch node.Kind() {
+	case ast.TypeDefinitionStatementKind, ast.TriggerStatementKind, ast.SingletonTypeDefinitionStatementKind,
+		ast.BreakStatementKind, ast.ContinueStatementKind:
+		return node, true
+	case ast.LetStatementKind:
+		return o.letStatement(node.(ast.AnalyzedLetStatement)), true
+	case ast.ReturnStatementKind:
+		node := node.(ast.AnalyzedReturnStatement)
+		if node.ReturnValue != nil {
+			node.ReturnValue = o.optExpression(node.ReturnValue)
+		}
+		return node, true
+	case ast.LoopStatementKind:
+		node := node.(ast.AnalyzedLoopStatement)
+		node.Body = o.block(node.Body)
+		return node, true
+	case ast.WhileStatementKind:
+		return o.whileStatement(node.(ast.AnalyzedWhileStatement))
+	case ast.ForStatementKind:
+		node := node.(ast.AnalyzedForStatement)
+		node.IterExpression = o.optExpression(node.IterExpression)
+		node.Body = o.block(node.Body)
+		return node, true
+	case ast.ExpressionStatementKind:
+		node := node.(ast.AnalyzedExpressionStatement)
+		node.Expression = o.optExpression(node.Expression)
+
+		// Expressions which were folded into literals have no side effects.
+		if isLiteral(node.Expression) {
+			return node, false
+		}
+
+		return node, true
+	default:
+		panic("A new statement kind was added without updating this code")
+	}
+}
+
+func (o *Optimizer) letStatement(node ast.AnalyzedLetStatement) ast.AnalyzedLetStatement {
+	node.Expression = o.optExpression(node.Expression)
+	return node
+}
+
+func (o *Optimizer) whileStatement(node ast.AnalyzedWhileStatement) (ast.AnalyzedStatement, bool) {
+	condition := o.optExpression(node.Condition)
+
+	if condition.Kind() == ast.BoolLiteralExpressionKind {
+		// `while false { ... }`: the body is never executed.
+		if !condition.(ast.AnalyzedBoolLiteralExpression).Value {
+			return node, false
+		}
+
+		// `while true { ... }`: the condition does not need to be checked on every iteration.
+		return ast.AnalyzedLoopStatement{
+			Body:            o.block(node.Body),
+			NeverTerminates: node.NeverTerminates,
+			Range:           node.Range,
+		}, true
+	}
+
+	node.Condition = condition
+	node.Body = o.block(node.Body)
+	return node, true
+}
@@ -0,0 +1,97 @@
+package optimizer
+
+import (
+	"github.com/smarthome-go/homescript/v3/homescript/analyzer/ast"
+	pAst "github.com/smarthome-go/homescript/v3/homescript/parser/ast"
+)
+
+//
+// Symbol table.
+// Maps top-level identifiers of every module to their definitions.
+//
+
+type symbol struct {
+	module string
+	ident  string
+}
+
+type symbolTable struct {
+	// Maps a symbol to its function definition, including impl-block methods.
+	functions map[symbol]ast.AnalyzedFunctionDefinition
+	// Maps a symbol to the initializer of a global variable.
+	globals map[symbol]ast.AnalyzedLetStatement
+	// Maps a symbol to the module from which it is imported.
+	imports map[symbol]string
+}
+
+func newSymbolTable(modules map[string]ast.AnalyzedProgram) symbolTable {
+	table := symbolTable{
+		functions: make(map[symbol]ast.AnalyzedFunctionDefinition),
+		globals:   make(map[symbol]ast.AnalyzedLetStatement),
+		imports:   make(map[symbol]string),
+	}
+
+	for moduleName, module := range modules {
+		for _, fn := range module.Functions {
+			table.functions[symbol{module: moduleName, ident: fn.Ident.Ident()}] = fn
+		}
+
+		for _, impl := range module.ImplBlocks {
+			for _, method := range impl.Methods {
+				table.functions[symbol{module: moduleName, ident: method.Ident.Ident()}] = method
+			}
+		}
+
+		for _, glob := range module.Globals {
+			table.globals[symbol{module: moduleName, ident: glob.Ident.Ident()}] = glob
+		}
+
+		for _, item := range module.Imports {
+			// Builtin imports are resolved by the host and do not reference any Homescript code.
+			if !item.TargetIsHMS {
+				continue
+			}
+
+			for _, importItem := range item.ToImport {
+				if importItem.Kind != pAst.IMPORT_KIND_NORMAL {
+					continue
+				}
+
+				table.imports[symbol{module: moduleName, ident: importItem.Ident.Ident()}] = item.FromModule.Ident()
+			}
+		}
+	}
+
+	return table
+}
+
+// Resolves an identifier which is used in the given module to the function or global which defines it.
+// Imports are followed until the defining module is found.
+// Identifiers which do not refer to a function or global (such as local variables) cannot be resolved.
+func (t symbolTable) resolve(module string, ident string) (sym symbol, found bool) {
+	sym = symbol{module: module, ident: ident}
+
+	// The analyzer rejects import cycles, however, `visited` guards against looping forever.
+	visited := make(map[symbol]struct{})
+
+	for {
+		_, isFn := t.functions[sym]
+		_, isGlobal := t.globals[sym]
+
+		if isFn || isGlobal {
+			return sym, true
+		}
+
+		fromModule, isImported := t.imports[sym]
+		if !isImported {
+			return symbol{}, false
+		}
+
+		if _, isVisited := visited[sym]; isVisited {
+			return symbol{}, false
+		}
+		visited[sym] = struct{}{}
+
+		sym = symbol{module: fromModule, ident: ident}
+	}
+}
@@ -0,0 +1,229 @@
+package optimizer
+
+import (
+	"github.com/smarthome-go/homescript/v3/homescript/analyzer"
+	"github.com/smarthome-go/homescript/v3/homescript/analyzer/ast"
+	pAst "github.com/smarthome-go/homescript/v3/homescript/parser/ast"
+)
+
+//
+// Tree shaking.
+// Removes functions, impl-block methods and globals which can never be reached during execution.
+// The roots of the reachability analysis are:
+// - all functions, globals and impl-block methods of the entry module,
+//   as the host may look them up through the mappings of the compiled program,
+// - the module initializers (`@init`), which evaluate every global initializer with side effects,
+// - all `event` functions, trigger callbacks and `#[test]` functions.
+// Consequently, only code of imported modules is ever removed.
+// Identifiers are resolved by name, which over-approximates the set of reachable symbols
+// (for instance, a local variable shadowing a function keeps this function alive).
+//
+
+type treeShaker struct {
+	modules map[string]ast.AnalyzedProgram
+	symbols symbolTable
+
+	reachable map[symbol]struct{}
+	worklist  []symbol
+	// The module whose code is currently being visited.
+	currModule string
+}
+
+// Returns a copy of the program which only contains reachable functions, impl-block methods and globals.
+// This pass should run directly before the compiler.
+func TreeShake(modules map[string]ast.AnalyzedProgram, entryModule string) map[string]ast.AnalyzedProgram {
+	shaker := treeShaker{
+		modules:    modules,
+		symbols:    newSymbolTable(modules),
+		reachable:  make(map[symbol]struct{}),
+		worklist:   make([]symbol, 0),
+		currModule: "",
+	}
+
+	shaker.markRoots(entryModule)
+	shaker.propagate()
+
+	return shaker.output()
+}
+
+func (s *treeShaker) markRoots(entryModule string) {
+	for moduleName, module := range s.modules {
+		if moduleName == entryModule {
+			for _, fn := range module.Functions {
+				s.mark(symbol{module: moduleName, ident: fn.Ident.Ident()})
+			}
+
+			for _, glob := range module.Globals {
+				s.mark(symbol{module: moduleName, ident: glob.Ident.Ident()})
+			}
+
+			for _, impl := range module.ImplBlocks {
+				for _, method := range impl.Methods {
+					s.mark(symbol{module: moduleName, ident: method.Ident.Ident()})
+				}
+			}
+		}
+
+		for _, fn := range module.Functions {
+			if fn.Modifier == pAst.FN_MODIFIER_EVENT || hasTriggerAnnotation(fn) || hasTestAnnotation(fn) {
+				s.mark(symbol{module: moduleName, ident: fn.Ident.Ident()})
+			}
+		}
+
+		// Globals are initialized by `@init`: their initializers must be kept if they have side effects.
+		for _, glob := range module.Globals {
+			if !isPure(glob.Expression) {
+				s.mark(symbol{module: moduleName, ident: glob.Ident.Ident()})
+			}
+		}
+	}
+}
+
+func hasTriggerAnnotation(fn ast.AnalyzedFunctionDefinition) bool {
+	if fn.Annotation == nil {
+		return false
+	}
+
+	for _, item := range fn.Annotation.Items {
+		if _, isTrigger := item.(ast.AnalyzedAnnotationItemTrigger); isTrigger {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Test functions are invoked by the test runner, even if they are part of an imported module.
+func hasTestAnnotation(fn ast.AnalyzedFunctionDefinition) bool {
+	if fn.Annotation == nil {
+		return false
+	}
+
+	for _, item := range fn.Annotation.Items {
+		if ident, isIdent := item.(ast.AnalyzedAnnotationItemIdent); isIdent && ident.Ident.Ident() == analyzer.TestAnnotation {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Marks a symbol as reachable so that its body is visited later.
+func (s *treeShaker) mark(sym symbol) {
+	if _, alreadyReachable := s.reachable[sym]; alreadyReachable {
+		return
+	}
+
+	s.reachable[sym] = struct{}{}
+	s.worklist = append(s.worklist, sym)
+}
+
+// Marks the function or global which an identifier in the current module refers to.
+func (s *treeShaker) use(ident string) {
+	if sym, found := s.symbols.resolve(s.currModule, ident); found {
+		s.mark(sym)
+	}
+}
+
+func (s *treeShaker) propagate() {
+	for len(s.worklist) > 0 {
+		sym := s.worklist[len(s.worklist)-1]
+		s.worklist = s.worklist[:len(s.worklist)-1]
+
+		s.currModule = sym.module
+
+		walker := astWalker{
+			reference: s.use,
+			declare:   func(string) {},
+		}
+
+		if fn, isFn := s.symbols.functions[sym]; isFn {
+			walker.function(fn)
+		}
+
+		if glob, isGlobal := s.symbols.globals[sym]; isGlobal {
+			walker.expression(glob.Expression)
+		}
+	}
+}
+
+func (s *treeShaker) output() map[string]ast.AnalyzedProgram {
+	modulesOut := make(map[string]ast.AnalyzedProgram)
+
+	for moduleName, module := range s.modules {
+		functionsOut := make([]ast.AnalyzedFunctionDefinition, 0)
+		for _, fn := range module.Functions {
+			if s.isReachable(moduleName, fn.Ident.Ident()) {
+				functionsOut = append(functionsOut, fn)
+			}
+		}
+
+		implBlocksOut := make([]ast.AnalyzedImplBlock, len(module.ImplBlocks))
+		for idx, impl := range module.ImplBlocks {
+			methods := make([]ast.AnalyzedFunctionDefinition, 0)
+			for _, method := range impl.Methods {
+				if s.isReachable(moduleName, method.Ident.Ident()) {
+					methods = append(methods, method)
+				}
+			}
+
+			impl.Methods = methods
+			implBlocksOut[idx] = impl
+		}
+
+		globalsOut := make([]ast.AnalyzedLetStatement, 0)
+		for _, glob := range module.Globals {
+			if s.isReachable(moduleName, glob.Ident.Ident()) {
+				globalsOut = append(globalsOut, glob)
+			}
+		}
+
+		modulesOut[moduleName] = ast.AnalyzedProgram{
+			Imports:    module.Imports,
+			Types:      module.Types,
+			Singletons: module.Singletons,
+			ImplBlocks: implBlocksOut,
+			Globals:    globalsOut,
+			Functions:  functionsOut,
+		}
+	}
+
+	return modulesOut
+}
+
+func (s *treeShaker) isReachable(module string, ident string) bool {
+	_, reachable := s.reachable[symbol{module: module, ident: ident}]
+	return reachable
+}
+
+// Returns whether evaluating the expression can neither fail nor cause side effects.
+// Only initializers of such globals may be removed if the global is never used.
+func isPure(node ast.AnalyzedExpression) bool {
+	switch node.Kind() {
+	case ast.IntLiteralExpressionKind, ast.FloatLiteralExpressionKind, ast.BoolLiteralExpressionKind,
+		ast.StringLiteralExpressionKind, ast.NullLiteralExpressionKind, ast.NoneLiteralExpressionKind,
+		ast.AnyObjectLiteralExpressionKind, ast.FunctionLiteralExpressionKind:
+		return true
+	case ast.RangeLiteralExpressionKind:
+		node := node.(ast.AnalyzedRangeLiteralExpression)
+		return isPure(node.Start) && isPure(node.End)
+	case ast.ListLiteralExpressionKind:
+		for _, value := range node.(ast.AnalyzedListLiteralExpression).Values {
+			if !isPure(value) {
+				return false
+			}
+		}
+		return true
+	case ast.ObjectLiteralExpressionKind:
+		for _, field := range node.(ast.AnalyzedObjectLiteralExpression).Fields {
+			if !isPure(field.Expression) {
+				return false
+			}
+		}
+		return true
+	case ast.GroupedExpressionKind:
+		return isPure(node.(ast.AnalyzedGroupedExpression).Inner)
+	default:
+		return false
+	}
+}
@@ -0,0 +1,161 @@
+package optimizer
+
+import (
+	"github.com/smarthome-go/homescript/v3/homescript/analyzer/ast"
+)
+
+//
+// AST walker.
+// Visits every node of the analyzed AST without modifying it.
+//
+
+type astWalker struct {
+	// Called for every identifier which is used, for instance in an identifier expression.
+	reference func(ident string)
+	// Called for every variable which is declared, including parameters of function literals.
+	declare func(ident string)
+}
+
+func (w astWalker) function(node ast.AnalyzedFunctionDefinition) {
+	if node.Annotation != nil {
+		for _, item := range node.Annotation.Items {
+			if trigger, isTrigger := item.(ast.AnalyzedAnnotationItemTrigger); isTrigger {
+				w.callArgs(trigger.TriggerArgs)
+			}
+		}
+	}
+
+	w.block(node.Body)
+}
+
+func (w astWalker) block(node ast.AnalyzedBlock) {
+	for _, statement := range node.Statements {
+		w.statement(statement)
+	}
+
+	if node.Expression != nil {
+		w.expression(node.Expression)
+	}
+}
+
+func (w astWalker) callArgs(node ast.AnalyzedCallArgs) {
+	for _, arg := range node.List {
+		w.expression(arg.Expression)
+	}
+}
+
+func (w astWalker) statement(node ast.AnalyzedStatement) {
+	switch node.Kind() {
+	case ast.TypeDefinitionStatementKind, ast.SingletonTypeDefinitionStatementKind,
+		ast.BreakStatementKind, ast.ContinueStatementKind:
+		return
+	case ast.TriggerStatementKind:
+		node := node.(ast.AnalyzedTriggerStatement)
+		w.reference(node.CallbackIdent.Ident())
+		w.callArgs(node.TriggerArguments)
+	case ast.LetStatementKind:
+		node := node.(ast.AnalyzedLetStatement)
+		w.expression(node.Expression)
+		w.declare(node.Ident.Ident())
+	case ast.ReturnStatementKind:
+		node := node.(ast.AnalyzedReturnStatement)
+		if node.ReturnValue != nil {
+			w.expression(node.ReturnValue)
+		}
+	case ast.LoopStatementKind:
+		w.block(node.(ast.AnalyzedLoopStatement).Body)
+	case ast.WhileStatementKind:
+		node := node.(ast.AnalyzedWhileStatement)
+		w.expression(node.Condition)
+		w.block(node.Body)
+	case ast.ForStatementKind:
+		node := node.(ast.AnalyzedForStatement)
+		w.expression(node.IterExpression)
+		w.declare(node.Identifier.Ident())
+		w.block(node.Body)
+	case ast.ExpressionStatementKind:
+		w.expression(node.(ast.AnalyzedExpressionStatement).Expression)
+	default:
+		panic("A new statement kind was added without updating this code")
+	}
+}
+
+func (w astWalker) expression(node ast.AnalyzedExpression) {
+	switch node.Kind() {
+	case ast.UnknownExpressionKind, ast.IntLiteralExpressionKind, ast.FloatLiteralExpressionKind,
+		ast.BoolLiteralExpressionKind, ast.StringLiteralExpressionKind, ast.NullLiteralExpressionKind,
+		ast.NoneLiteralExpressionKind, ast.AnyObjectLiteralExpressionKind:
+		return
+	case ast.IdentExpressionKind:
+		w.reference(node.(ast.AnalyzedIdentExpression).Ident.Ident())
+	case ast.RangeLiteralExpressionKind:
+		node := node.(ast.AnalyzedRangeLiteralExpression)
+		w.expression(node.Start)
+		w.expression(node.End)
+	case ast.ListLiteralExpressionKind:
+		for _, value := range node.(ast.AnalyzedListLiteralExpression).Values {
+			w.expression(value)
+		}
+	case ast.ObjectLiteralExpressionKind:
+		for _, field := range node.(ast.AnalyzedObjectLiteralExpression).Fields {
+			w.expression(field.Expression)
+		}
+	case ast.FunctionLiteralExpressionKind:
+		node := node.(ast.AnalyzedFunctionLiteralExpression)
+		for _, param := range node.Parameters {
+			w.declare(param.Ident.Ident())
+		}
+		w.block(node.Body)
+	case ast.GroupedExpressionKind:
+		w.expression(node.(ast.AnalyzedGroupedExpression).Inner)
+	case ast.PrefixExpressionKind:
+		w.expression(node.(ast.AnalyzedPrefixExpression).Base)
+	case ast.InfixExpressionKind:
+		node := node.(ast.AnalyzedInfixExpression)
+		w.expression(node.Lhs)
+		w.expression(node.Rhs)
+	case ast.AssignExpressionKind:
+		node := node.(ast.AnalyzedAssignExpression)
+		w.expression(node.Lhs)
+		w.expression(node.Rhs)
+	case ast.CallExpressionKind:
+		node := node.(ast.AnalyzedCallExpression)
+		w.expression(node.Base)
+		w.callArgs(node.Arguments)
+	case ast.IndexExpressionKind:
+		node := node.(ast.AnalyzedIndexExpression)
+		w.expression(node.Base)
+		w.expression(node.Index)
+	case ast.MemberExpressionKind:
+		w.expression(node.(ast.AnalyzedMemberExpression).Base)
+	case ast.CastExpressionKind:
+		w.expression(node.(ast.AnalyzedCastExpression).Base)
+	case ast.BlockExpressionKind:
+		w.block(node.(ast.AnalyzedBlockExpression).Block)
+	case ast.IfExpressionKind:
+		node := node.(ast.AnalyzedIfExpression)
+		w.expression(node.Condition)
+		w.block(node.ThenBlock)
+		if node.ElseBlock != nil {
+			w.block(*node.ElseBlock)
+		}
+	case ast.MatchExpressionKind:
+		node := node.(ast.AnalyzedMatchExpression)
+		w.expression(node.ControlExpression)
+		for _, arm := range node.Arms {
+			for _, lit := range arm.Literals {
+				w.expression(lit)
+			}
+			w.expression(arm.Action)
+		}
+		if node.DefaultArmAction != nil {
+			w.expression(*node.DefaultArmAction)
+		}
+	case ast.TryExpressionKind:
+		node := node.(ast.AnalyzedTryExpression)
+		w.block(node.TryBlock)
+		w.block(node.CatchBlock)
+	default:
+		panic("A new expression kind was added without updating this code")
+	}
+}
@@ -72,6 +72,8 @@ type ImportStatementCandidate struct {
 	Ident string
 	Kind  IMPORT_KIND
 	Span  errors.Span
+	// Unlike `Span`, this does not include the kind keyword, for instance `type`.
+	NameSpan errors.Span
 }
 
 func (self ImportStatementCandidate) String() string {
@@ -150,6 +152,8 @@ type TypeDefinition struct {
 	RhsType  HmsType
 	IsPub    bool
 	Range    errors.Span
+	// The doc comment (`///`) in front of the definition.
+	DocComment string
 }
 
 func (self TypeDefinition) Kind() StatementKind { return TypeDefinitionStatementKind }
@@ -169,6 +173,8 @@ type LetStatement struct {
 	OptType    HmsType
 	IsPub      bool
 	Range      errors.Span
+	// The doc comment (`///`) in front of the statement, this is only set for globals.
+	DocComment string
 }
 
 func (self LetStatement) Kind() StatementKind { return LetStatementKind }
@@ -214,9 +220,13 @@ type FunctionDefinition struct {
 	ReturnType HmsType
 	Body       Block
 	Modifier   FunctionModifier
+	// The span of the modifier, or of the `fn` keyword if there is no modifier.
+	ModifierSpan errors.Span
 	// Optional annotation, like #[foo].
 	Annotation *FunctionAnnotationInner
 	Range      errors.Span
+	// The doc comment (`///`) in front of the function.
+	DocComment string
 }
 
 func (self FunctionDefinition) Kind() StatementKind { return FnDefinitionStatementKind }
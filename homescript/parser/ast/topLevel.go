@@ -15,6 +15,8 @@ type SingletonTypeDefinition struct {
 	Ident SpannedIdent
 	Type  HmsType
 	Range errors.Span
+	// The doc comment (`///`) in front of the singleton.
+	DocComment string
 }
 
 func (self SingletonTypeDefinition) Span() errors.Span { return self.Range }
@@ -42,6 +44,8 @@ type ImplBlock struct {
 	UsingTemplate  ImplBlockTemplate
 	Methods        []FunctionDefinition
 	Span           errors.Span
+	// The doc comment (`///`) in front of the impl block.
+	DocComment string
 }
 
 //
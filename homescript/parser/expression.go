@@ -182,6 +182,7 @@ func (self *Parser) literal(useLiteralInErr bool) (ast.Expression, *errors.Error
 
 		return nil, errors.NewSyntaxError(
 			self.CurrentToken.Span,
+			errors.UnexpectedToken,
 			message,
 		)
 	}
@@ -202,6 +203,7 @@ func (self *Parser) intFloatLiteral() (ast.Expression, *errors.Error) {
 		if err != nil {
 			return nil, errors.NewSyntaxError(
 				self.PreviousToken.Span,
+				errors.InvalidNumberLiteral,
 				fmt.Sprintf("Cannot use '%s' as integer: %s", self.PreviousToken.Value, err),
 			)
 		}
@@ -215,6 +217,7 @@ func (self *Parser) intFloatLiteral() (ast.Expression, *errors.Error) {
 		if err != nil {
 			return nil, errors.NewSyntaxError(
 				self.PreviousToken.Span,
+				errors.InvalidNumberLiteral,
 				fmt.Sprintf("Cannot use '%s' as float: %s", self.PreviousToken.Value, err),
 			)
 		}
@@ -596,7 +599,7 @@ func (self *Parser) assignExpression(start errors.Location, lhs ast.Expression)
 	case ast.IdentExpressionKind, ast.IndexExpressionKind, ast.MemberExpressionKind, ast.CastExpressionKind:
 		// do nothing, this is legal
 	default:
-		return ast.AssignExpression{}, errors.NewSyntaxError(lhs.Span(), "Invalid left-hand side of assignment")
+		return ast.AssignExpression{}, errors.NewSyntaxError(lhs.Span(), errors.InvalidAssignmentTarget, "Invalid left-hand side of assignment")
 	}
 
 	return ast.AssignExpression{
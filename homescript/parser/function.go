@@ -13,6 +13,12 @@ import (
 func (self *Parser) functionDefinition(fnModifier ast.FunctionModifier) (ast.FunctionDefinition, *errors.Error) {
 	startLoc := self.CurrentToken.Span.Start
 
+	// The modifier was already consumed by the caller.
+	modifierSpan := self.CurrentToken.Span
+	if fnModifier != ast.FN_MODIFIER_NONE {
+		modifierSpan = self.PreviousToken.Span
+	}
+
 	// skip `fn`
 	if err := self.next(); err != nil {
 		return ast.FunctionDefinition{}, err
@@ -53,13 +59,14 @@ func (self *Parser) functionDefinition(fnModifier ast.FunctionModifier) (ast.Fun
 	}
 
 	return ast.FunctionDefinition{
-		Ident:      ident,
-		Parameters: params,
-		ParamSpan:  paramStartLoc.Until(paramEndLoc, self.Filename),
-		ReturnType: returnType,
-		Body:       body,
-		Modifier:   fnModifier,
-		Range:      startLoc.Until(self.PreviousToken.Span.End, self.Filename),
+		Ident:        ident,
+		Parameters:   params,
+		ParamSpan:    paramStartLoc.Until(paramEndLoc, self.Filename),
+		ReturnType:   returnType,
+		Body:         body,
+		Modifier:     fnModifier,
+		ModifierSpan: modifierSpan,
+		Range:        startLoc.Until(self.PreviousToken.Span.End, self.Filename),
 	}, nil
 }
 
@@ -96,9 +96,10 @@ func (self *Parser) importItem() (ast.ImportStatement, *errors.Error) {
 		// }
 
 		toImport = append(toImport, ast.ImportStatementCandidate{
-			Ident: self.CurrentToken.Value,
-			Kind:  importKind,
-			Span:  startLoc.Until(self.CurrentToken.Span.End, self.Filename),
+			Ident:    self.CurrentToken.Value,
+			Kind:     importKind,
+			Span:     startLoc.Until(self.CurrentToken.Span.End, self.Filename),
+			NameSpan: self.CurrentToken.Span,
 		})
 
 		if err := self.next(); err != nil {
@@ -140,9 +141,10 @@ func (self *Parser) importItem() (ast.ImportStatement, *errors.Error) {
 		}
 
 		toImport = append(toImport, ast.ImportStatementCandidate{
-			Ident: self.PreviousToken.Value,
-			Kind:  importKind,
-			Span:  startLoc.Until(self.PreviousToken.Span.End, self.Filename),
+			Ident:    self.PreviousToken.Value,
+			Kind:     importKind,
+			Span:     startLoc.Until(self.PreviousToken.Span.End, self.Filename),
+			NameSpan: self.PreviousToken.Span,
 		})
 
 		// make remaining imports
@@ -177,9 +179,10 @@ func (self *Parser) importItem() (ast.ImportStatement, *errors.Error) {
 			}
 
 			toImport = append(toImport, ast.ImportStatementCandidate{
-				Ident: self.PreviousToken.Value,
-				Kind:  importKind,
-				Span:  startLoc.Until(self.PreviousToken.Span.End, self.Filename),
+				Ident:    self.PreviousToken.Value,
+				Kind:     importKind,
+				Span:     startLoc.Until(self.PreviousToken.Span.End, self.Filename),
+				NameSpan: self.PreviousToken.Span,
 			})
 		}
 
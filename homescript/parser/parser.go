@@ -1,6 +1,9 @@
 package parser
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/smarthome-go/homescript/v3/homescript/errors"
 	"github.com/smarthome-go/homescript/v3/homescript/lexer"
 	"github.com/smarthome-go/homescript/v3/homescript/parser/ast"
@@ -35,6 +38,11 @@ func (self *Parser) next() *errors.Error {
 	return nil
 }
 
+// Returns the doc comment in front of the current token, its lines are separated by line feeds.
+func (self *Parser) docComment() string {
+	return strings.Join(self.CurrentToken.DocComment, "\n")
+}
+
 func (self *Parser) Parse() (program ast.Program, softErrors []errors.Error, hardError *errors.Error) {
 	tree, err := self.program()
 	if err != nil {
@@ -43,6 +51,29 @@ func (self *Parser) Parse() (program ast.Program, softErrors []errors.Error, har
 	return tree, self.Errors, nil
 }
 
+// Parses a single expression which must span the entire input.
+// This is used by debuggers, for instance, to evaluate watch expressions.
+func (self *Parser) ParseExpression() (expr ast.Expression, softErrors []errors.Error, hardError *errors.Error) {
+	if err := self.next(); err != nil {
+		return nil, self.Errors, err
+	}
+
+	expr, _, err := self.expression(0)
+	if err != nil {
+		return nil, self.Errors, err
+	}
+
+	if self.CurrentToken.Kind != lexer.EOF {
+		return nil, self.Errors, errors.NewSyntaxError(
+			self.CurrentToken.Span,
+			errors.UnexpectedToken,
+			fmt.Sprintf("Expected end of expression, found '%s'", self.CurrentToken.Kind),
+		)
+	}
+
+	return expr, self.Errors, nil
+}
+
 func (self *Parser) program() (ast.Program, *errors.Error) {
 	if err := self.next(); err != nil {
 		return ast.Program{}, err
@@ -57,6 +88,8 @@ func (self *Parser) program() (ast.Program, *errors.Error) {
 	}
 
 	for self.CurrentToken.Kind != lexer.EOF {
+		docComment := self.docComment()
+
 		switch self.CurrentToken.Kind {
 		case lexer.Import:
 			importStmt, err := self.importItem()
@@ -71,6 +104,7 @@ func (self *Parser) program() (ast.Program, *errors.Error) {
 				return ast.Program{}, err
 			}
 
+			singleton.DocComment = docComment
 			tree.Singletons = append(tree.Singletons, singleton)
 		case lexer.FN_ANNOTATION_TOKEN:
 			annotation, err := self.functionAnnotation()
@@ -78,6 +112,7 @@ func (self *Parser) program() (ast.Program, *errors.Error) {
 				return ast.Program{}, err
 			}
 
+			annotation.Function.DocComment = docComment
 			tree.Functions = append(tree.Functions, annotation.Function)
 		case lexer.Impl:
 			implBlock, err := self.implBlockHead()
@@ -85,6 +120,7 @@ func (self *Parser) program() (ast.Program, *errors.Error) {
 				return ast.Program{}, err
 			}
 
+			implBlock.DocComment = docComment
 			tree.ImplBlocks = append(tree.ImplBlocks, implBlock)
 		case lexer.Event, lexer.Pub, lexer.Type, lexer.Let, lexer.Fn:
 			isPub := self.CurrentToken.Kind == lexer.Pub
@@ -102,12 +138,14 @@ func (self *Parser) program() (ast.Program, *errors.Error) {
 				if err != nil {
 					return ast.Program{}, err
 				}
+				typeDefinition.DocComment = docComment
 				tree.Types = append(tree.Types, typeDefinition)
 			case lexer.Let:
 				letStmt, err := self.letStatement(isPub)
 				if err != nil {
 					return ast.Program{}, err
 				}
+				letStmt.DocComment = docComment
 				tree.Globals = append(tree.Globals, letStmt)
 			case lexer.Fn:
 				fnModifier := ast.FN_MODIFIER_NONE
@@ -122,6 +160,7 @@ func (self *Parser) program() (ast.Program, *errors.Error) {
 				if err != nil {
 					return ast.Program{}, err
 				}
+				fnDefinition.DocComment = docComment
 				tree.Functions = append(tree.Functions, fnDefinition)
 			default:
 				return ast.Program{}, self.expectedOneOfErr([]lexer.TokenKind{lexer.Let, lexer.Fn})
@@ -188,6 +188,7 @@ func (self *Parser) implBlockBody() ([]ast.FunctionDefinition, *errors.Error) {
 	// Loop over function definitions until the end (`}`) is reached
 	methods := make([]ast.FunctionDefinition, 0)
 	for self.CurrentToken.Kind != lexer.EOF && self.CurrentToken.Kind != lexer.RCurly {
+		docComment := self.docComment()
 		modifier := ast.FN_MODIFIER_NONE
 
 		if self.CurrentToken.Kind == lexer.Pub {
@@ -206,6 +207,7 @@ func (self *Parser) implBlockBody() ([]ast.FunctionDefinition, *errors.Error) {
 		if err != nil {
 			return nil, err
 		}
+		fn.DocComment = docComment
 		methods = append(methods, fn)
 	}
 
@@ -139,6 +139,7 @@ func (self *Parser) triggerConnective() (ast.TriggerDispatchKeywordKind, *errors
 	default:
 		return 0, errors.NewSyntaxError(
 			self.CurrentToken.Span,
+			errors.UnexpectedToken,
 			fmt.Sprintf("Expected trigger keyword (`on` or `at`), found %s", self.CurrentToken.Kind),
 		)
 	}
@@ -179,6 +180,7 @@ func (self *Parser) typeDefinition(isPub bool) (ast.TypeDefinition, *errors.Erro
 	if isBuiltin {
 		return ast.TypeDefinition{}, errors.NewSyntaxError(
 			self.PreviousToken.Span,
+			errors.BuiltinTypeRedeclaration,
 			fmt.Sprintf("Cannot redeclare builtin type '%s'", self.PreviousToken.Value),
 		)
 	}
@@ -498,6 +500,7 @@ func (self *Parser) expressionStatement() (ast.EitherStatementOrExpression, *err
 	} else if !withBlock {
 		self.Errors = append(self.Errors, *errors.NewSyntaxError(
 			expression.Span(),
+			errors.MissingSemicolon,
 			"Missing semicolon after statemtent",
 		))
 	}
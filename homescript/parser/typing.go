@@ -30,6 +30,7 @@ func (self *Parser) hmsType(allowAnnotations bool) (ast.HmsType, *errors.Error)
 	default:
 		return nil, errors.NewSyntaxError(
 			self.CurrentToken.Span,
+			errors.UnexpectedToken,
 			fmt.Sprintf("Expected type, found '%s'", self.CurrentToken.Kind),
 		)
 	}
@@ -182,6 +183,7 @@ func (self *Parser) objectTypeFieldComponent(allowAnnotations bool) (ast.ObjectT
 		if !allowAnnotations {
 			return ast.ObjectTypeField{}, errors.NewSyntaxError(
 				self.CurrentToken.Span,
+				errors.IllegalFieldAnnotation,
 				"Object field annotations are not legal here",
 			)
 		}
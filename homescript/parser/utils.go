@@ -8,11 +8,11 @@ import (
 	"github.com/smarthome-go/homescript/v3/homescript/parser/ast"
 )
 
-func (self *Parser) nonCriticalErr(span errors.Span, message string) {
-	self.Errors = append(self.Errors, *errors.NewError(
+func (self *Parser) nonCriticalErr(span errors.Span, code errors.Code, message string) {
+	self.Errors = append(self.Errors, *errors.NewSyntaxError(
 		span,
+		code,
 		message,
-		errors.SyntaxError,
 	))
 }
 
@@ -20,6 +20,7 @@ func (self *Parser) expect(expected lexer.TokenKind) *errors.Error {
 	if self.CurrentToken.Kind != expected {
 		return errors.NewSyntaxError(
 			self.CurrentToken.Span,
+			errors.UnexpectedToken,
 			fmt.Sprintf("Expected '%s', found '%s'", expected, self.CurrentToken.Kind),
 		)
 	}
@@ -39,11 +40,13 @@ func (self *Parser) expectRecoverable(expected lexer.TokenKind) *errors.Error {
 		if expected == lexer.Semicolon {
 			self.nonCriticalErr(
 				self.PreviousToken.Span,
+				errors.MissingSemicolon,
 				fmt.Sprintf("Missing semicolon ('%s') after this entity", expected),
 			)
 		} else {
 			self.nonCriticalErr(
 				self.CurrentToken.Span,
+				errors.UnexpectedToken,
 				fmt.Sprintf("Expected '%s', found '%s'", expected, self.CurrentToken.Kind),
 			)
 		}
@@ -94,6 +97,7 @@ func (self Parser) expectedOneOfErr(expected []lexer.TokenKind) *errors.Error {
 
 	return errors.NewSyntaxError(
 		self.CurrentToken.Span,
+		errors.UnexpectedToken,
 		fmt.Sprintf("Expected %s, found '%s'", message, self.CurrentToken.Kind),
 	)
 }
@@ -0,0 +1,82 @@
+package homescript
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/smarthome-go/homescript/v3/homescript/compiler"
+	"github.com/smarthome-go/homescript/v3/homescript/runtime"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProfile(t *testing.T) {
+	const program = `fn fib(n: int) -> int {
+    if n < 2 {
+        return n;
+    }
+    fib(n - 1) + fib(n - 2)
+}
+
+fn main() {
+    time.sleep(0.05);
+    println(fib(10));
+}
+`
+
+	modules, _, syntaxErrors := Analyze(
+		InputProgram{ProgramText: program, Filename: "main"},
+		TestingAnalyzerScopeAdditions(),
+		TestingAnalyzerHost{},
+		true,
+	)
+	assert.Empty(t, syntaxErrors)
+
+	compilerStruct := compiler.NewCompiler(modules, "main")
+	compiled, err := compilerStruct.Compile()
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	executor := TestingVmExecutor{PrintBuf: new(string), PintBufMutex: &sync.Mutex{}}
+	vm := runtime.NewVM(compiled, executor, &ctx, &cancel, TestingVmScopeAdditions(), runtime.CoreLimits{
+		CallStackMaxSize: 100,
+		StackMaxSize:     100,
+		MaxMemorySize:    100,
+	})
+
+	vm.StartProfiling(time.Millisecond)
+	vm.SpawnAsync(runtime.MainFn(), nil, nil, nil)
+	_, interrupt := vm.Wait()
+	assert.Nil(t, interrupt)
+	profile := vm.StopProfiling()
+
+	instructions := make(map[string]int64)
+	var sleep time.Duration
+	for _, sample := range profile.Samples {
+		instructions[sample.Stack[0].Function] += sample.Instructions
+
+		// The time spent inside the builtin is attributed to its call.
+		if sample.Stack[0].Function == "main" && sample.Stack[0].Line == 9 {
+			sleep += sample.WallTime
+		}
+	}
+
+	assert.Greater(t, instructions["fib"], instructions["main"])
+	// Sampling is imprecise, however, most of the sleep must be visible.
+	assert.GreaterOrEqual(t, sleep, time.Millisecond*25)
+
+	var encoded bytes.Buffer
+	assert.NoError(t, profile.WritePprof(&encoded))
+
+	reader, err := gzip.NewReader(&encoded)
+	assert.NoError(t, err)
+	decoded, err := io.ReadAll(reader)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, decoded)
+}
@@ -61,6 +61,8 @@ type Core struct {
 	CancelCtx *context.Context
 	// Describes some resource limits for the current core
 	Limits CoreLimits
+	// Only set while the VM is profiling
+	profile *coreProfile
 }
 
 type CoreLimits struct {
@@ -154,6 +156,17 @@ func (self *Core) Run(function string, debuggerOut *chan DebugOutput, debuggerRe
 		defer close(*debuggerResume)
 	}
 
+	if self.parent.tracer != nil {
+		defer self.parent.tracer.finish(self.Corenum)
+	}
+
+	profiler := self.parent.profiler
+	if profiler != nil {
+		defer profiler.finish(self)
+	}
+
+	coverage := self.parent.coverage
+
 	catchPanic := func() {
 		if err := recover(); err != nil {
 			span := self.parent.SourceMap(*self.callFrame())
@@ -299,7 +312,19 @@ outer:
 				}
 			}
 
-			if i := self.runInstruction(i); i != nil {
+			if profiler != nil {
+				profiler.observe(self)
+			}
+
+			if coverage != nil {
+				coverage.observe(self, i)
+			}
+
+			if self.parent.DebugHook != nil {
+				self.parent.DebugHook(self)
+			}
+
+			if i := self.runScheduledInstruction(i); i != nil {
 				switch (*i).Kind() {
 				// Only non-fatal exceptions can be handled
 				case value.Vm_NormalExceptionInterruptKind:
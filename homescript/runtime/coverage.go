@@ -0,0 +1,86 @@
+package runtime
+
+import (
+	"sync/atomic"
+
+	"github.com/smarthome-go/homescript/v3/homescript/compiler"
+	"github.com/smarthome-go/homescript/v3/homescript/runtime/value"
+)
+
+//
+// Coverage.
+// Counts how often each instruction of the program is executed.
+// For conditional jumps, it is also counted how often the jump was taken.
+//
+
+type Coverage struct {
+	// For each function, the number of executions of each instruction.
+	Hits map[string][]uint64
+	// For each function, the number of times each conditional jump was taken.
+	// Instructions which are not conditional jumps are always zero.
+	Taken map[string][]uint64
+}
+
+type coverage struct {
+	hits  map[string][]uint64
+	taken map[string][]uint64
+}
+
+// Starts counting the executed instructions of every core which is spawned from now on.
+// As the initialization code has already been executed by `NewVM`, it is not covered.
+func (self *VM) StartCoverage() {
+	coverage := coverage{
+		hits:  make(map[string][]uint64),
+		taken: make(map[string][]uint64),
+	}
+
+	// The maps are never modified afterwards, therefore, the cores only need atomic counters.
+	for function, instructions := range self.Program.Functions {
+		coverage.hits[function] = make([]uint64, len(instructions))
+		coverage.taken[function] = make([]uint64, len(instructions))
+	}
+
+	self.coverage = &coverage
+}
+
+// Stops counting and returns the coverage of all instructions executed in the meantime.
+// WARNING: this is unsafe before all cores have terminated.
+func (self *VM) StopCoverage() Coverage {
+	coverage := self.coverage
+	if coverage == nil {
+		return Coverage{}
+	}
+
+	self.coverage = nil
+
+	return Coverage{
+		Hits:  coverage.hits,
+		Taken: coverage.taken,
+	}
+}
+
+// Invoked by a core before it executes an instruction.
+func (self *coverage) observe(core *Core, instruction compiler.Instruction) {
+	frame := core.callFrame()
+
+	// Functions which were added by `VM.Reload` are not covered.
+	hits := self.hits[frame.Function]
+	if frame.InstructionPointer >= uint(len(hits)) {
+		return
+	}
+
+	atomic.AddUint64(&hits[frame.InstructionPointer], 1)
+
+	if instruction.Opcode() != compiler.Opcode_JumpIfFalse || len(core.Stack) == 0 {
+		return
+	}
+
+	condition := core.Stack[len(core.Stack)-1]
+	if condition == nil || *condition == nil {
+		return
+	}
+
+	if isTrue, isBool := (*condition).(value.ValueBool); isBool && !isTrue.Inner {
+		atomic.AddUint64(&self.taken[frame.Function][frame.InstructionPointer], 1)
+	}
+}
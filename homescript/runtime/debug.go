@@ -0,0 +1,102 @@
+package runtime
+
+import (
+	"github.com/smarthome-go/homescript/v3/homescript/compiler"
+	"github.com/smarthome-go/homescript/v3/homescript/errors"
+	"github.com/smarthome-go/homescript/v3/homescript/runtime/value"
+)
+
+// A debug hook is invoked by a core before it executes the instruction at the top of its call stack.
+// The core is blocked until the hook returns, which allows a debugger to pause individual cores.
+// Unlike the debugger channels of `Core.Run`, the hook also applies to cores which are spawned by the program.
+type DebugHook func(core *Core)
+
+// Returns the span of the instruction which is executed next.
+func (self *Core) CurrentSpan() errors.Span {
+	return self.parent.SourceMap(*self.callFrame())
+}
+
+// Returns the memory of a call frame, indexed by the offset of each variable in the frame.
+// The frame at `depth = 0` is the outermost frame of the call stack.
+// This is only safe while the core is blocked, for instance inside a `DebugHook`.
+func (self *Core) FrameMemory(depth int) []*value.Value {
+	memoryPointer := self.MemoryPointer
+
+	for idx := len(self.CallStack) - 1; idx >= 0; idx-- {
+		frame := self.CallStack[idx]
+
+		// The frame is allocated by the first instruction of the function.
+		size := int64(0)
+		if frame.InstructionPointer > 0 {
+			size = compiler.FunctionFrameSize((*self.Program)[frame.Function])
+		}
+
+		if idx > depth {
+			memoryPointer -= size
+			continue
+		}
+
+		memory := make([]*value.Value, 0, size)
+		for offset := int64(0); offset < size; offset++ {
+			absolute := memoryPointer - offset
+			if absolute < 0 || absolute >= int64(len(self.Memory)) {
+				break
+			}
+			memory = append(memory, self.Memory[absolute])
+		}
+
+		return memory
+	}
+
+	return nil
+}
+
+type Local struct {
+	Name  string
+	Value *value.Value
+}
+
+// Returns the named local variables of a call frame which are visible at its current instruction.
+// See `FrameMemory` for the meaning of `depth` and when this is safe to call.
+func (self *Core) Locals(depth int) []Local {
+	if depth < 0 || depth >= len(self.CallStack) {
+		return nil
+	}
+
+	frame := self.CallStack[depth]
+	memory := self.FrameMemory(depth)
+
+	variables := self.parent.Program.VisibleVariables(frame.Function, frame.InstructionPointer)
+	locals := make([]Local, 0, len(variables))
+
+	for _, variable := range variables {
+		if variable.Slot >= int64(len(memory)) {
+			continue
+		}
+
+		locals = append(locals, Local{
+			Name:  variable.Name,
+			Value: memory[variable.Slot],
+		})
+	}
+
+	return locals
+}
+
+// Returns the value of a global variable by its source identifier.
+func (self *Core) Global(ident string) (*value.Value, bool) {
+	mangled, found := self.parent.Program.Mappings.Globals[ident]
+	if !found {
+		return nil, false
+	}
+
+	self.parent.globals.Mutex.RLock()
+	defer self.parent.globals.Mutex.RUnlock()
+
+	val, found := self.parent.globals.Data[mangled]
+	if !found {
+		return nil, false
+	}
+
+	return &val, true
+}
@@ -84,6 +84,10 @@ func (self *Core) runInstruction(instruction compiler.Instruction) *value.VmInte
 		case value.VmFunctionValueKind:
 			function := function.(value.ValueVMFunction)
 
+			if self.tailCall(function.Ident) {
+				return nil
+			}
+
 			self.callFrame().InstructionPointer++
 			self.pushCallStack(function.Ident)
 
@@ -107,12 +111,7 @@ func (self *Core) runInstruction(instruction compiler.Instruction) *value.VmInte
 				}
 			}
 
-			res, i := fn.Callback(
-				self.Executor,
-				self.CancelCtx,
-				self.parent.SourceMap(*self.callFrame()),
-				args...,
-			)
+			res, i := self.callBuiltin(fn, args)
 			if i != nil {
 				return i
 			}
@@ -127,6 +126,11 @@ func (self *Core) runInstruction(instruction compiler.Instruction) *value.VmInte
 		}
 	case compiler.Opcode_Call_Imm:
 		i := instruction.(compiler.OneStringInstruction)
+
+		if self.tailCall(i.Value) {
+			return nil
+		}
+
 		self.callFrame().InstructionPointer++
 		self.pushCallStack(i.Value)
 		return nil
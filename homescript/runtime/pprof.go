@@ -0,0 +1,211 @@
+package runtime
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+)
+
+//
+// Encoding of profiles in the pprof format, which is a gzip-compressed protocol buffer.
+// See https://github.com/google/pprof/blob/main/proto/profile.proto for the schema.
+// Each source line of a function becomes a location, so that `go tool pprof` can attribute samples to lines.
+//
+
+// Field numbers of the `Profile` message and its nested messages.
+const (
+	pprofProfileSampleType    = 1
+	pprofProfileSample        = 2
+	pprofProfileLocation      = 4
+	pprofProfileFunction      = 5
+	pprofProfileStringTable   = 6
+	pprofProfileTimeNanos     = 9
+	pprofProfileDurationNanos = 10
+	pprofProfilePeriodType    = 11
+	pprofProfilePeriod        = 12
+
+	pprofValueTypeType = 1
+	pprofValueTypeUnit = 2
+
+	pprofSampleLocationID = 1
+	pprofSampleValue      = 2
+
+	pprofLocationID   = 1
+	pprofLocationLine = 4
+
+	pprofLineFunctionID = 1
+	pprofLineLine       = 2
+
+	pprofFunctionID         = 1
+	pprofFunctionName       = 2
+	pprofFunctionSystemName = 3
+	pprofFunctionFilename   = 4
+)
+
+// Writes the profile in the pprof format.
+// Every sample has two values: the number of executed instructions and the sampled wall time in nanoseconds.
+func (self Profile) WritePprof(writer io.Writer) error {
+	encoder := newPprofEncoder()
+
+	instructionsType := encoder.valueType("instructions", "count")
+	wallType := encoder.valueType("wall", "nanoseconds")
+	encoder.profile.message(pprofProfileSampleType, instructionsType)
+	encoder.profile.message(pprofProfileSampleType, wallType)
+
+	for _, sample := range self.Samples {
+		locations := make([]uint64, 0, len(sample.Stack))
+		for _, frame := range sample.Stack {
+			locations = append(locations, encoder.location(frame))
+		}
+
+		var message protoBuffer
+		message.packed(pprofSampleLocationID, locations)
+		message.packed(pprofSampleValue, []uint64{uint64(sample.Instructions), uint64(sample.WallTime.Nanoseconds())})
+		encoder.profile.message(pprofProfileSample, message)
+	}
+
+	encoder.profile.varint(pprofProfileTimeNanos, uint64(self.Start.UnixNano()))
+	encoder.profile.varint(pprofProfileDurationNanos, uint64(self.Duration.Nanoseconds()))
+	encoder.profile.message(pprofProfilePeriodType, wallType)
+	encoder.profile.varint(pprofProfilePeriod, uint64(self.Period.Nanoseconds()))
+
+	for _, str := range encoder.strings {
+		encoder.profile.bytes(pprofProfileStringTable, []byte(str))
+	}
+
+	compressed := gzip.NewWriter(writer)
+	if _, err := compressed.Write(encoder.profile); err != nil {
+		return fmt.Errorf("Could not write profile: %s", err.Error())
+	}
+
+	return compressed.Close()
+}
+
+type pprofFunctionKey struct {
+	name     string
+	filename string
+}
+
+type pprofLocationKey struct {
+	function uint64
+	line     uint
+}
+
+type pprofEncoder struct {
+	profile   protoBuffer
+	strings   []string
+	stringIDs map[string]uint64
+	functions map[pprofFunctionKey]uint64
+	locations map[pprofLocationKey]uint64
+}
+
+func newPprofEncoder() pprofEncoder {
+	return pprofEncoder{
+		profile: make(protoBuffer, 0),
+		// The first string must always be empty.
+		strings:   []string{""},
+		stringIDs: map[string]uint64{"": 0},
+		functions: make(map[pprofFunctionKey]uint64),
+		locations: make(map[pprofLocationKey]uint64),
+	}
+}
+
+func (self *pprofEncoder) string(str string) uint64 {
+	if id, found := self.stringIDs[str]; found {
+		return id
+	}
+
+	id := uint64(len(self.strings))
+	self.strings = append(self.strings, str)
+	self.stringIDs[str] = id
+
+	return id
+}
+
+func (self *pprofEncoder) valueType(kind string, unit string) protoBuffer {
+	var message protoBuffer
+	message.varint(pprofValueTypeType, self.string(kind))
+	message.varint(pprofValueTypeUnit, self.string(unit))
+	return message
+}
+
+// Returns the ID of the location of a frame, the location and its function are encoded on first use.
+func (self *pprofEncoder) location(frame ProfileFrame) uint64 {
+	functionKey := pprofFunctionKey{name: frame.Function, filename: frame.Filename}
+	functionID, found := self.functions[functionKey]
+	if !found {
+		functionID = uint64(len(self.functions) + 1)
+		self.functions[functionKey] = functionID
+
+		var message protoBuffer
+		message.varint(pprofFunctionID, functionID)
+		message.varint(pprofFunctionName, self.string(frame.Function))
+		message.varint(pprofFunctionSystemName, self.string(frame.Function))
+		message.varint(pprofFunctionFilename, self.string(frame.Filename))
+		self.profile.message(pprofProfileFunction, message)
+	}
+
+	locationKey := pprofLocationKey{function: functionID, line: frame.Line}
+	locationID, found := self.locations[locationKey]
+	if !found {
+		locationID = uint64(len(self.locations) + 1)
+		self.locations[locationKey] = locationID
+
+		var line protoBuffer
+		line.varint(pprofLineFunctionID, functionID)
+		line.varint(pprofLineLine, uint64(frame.Line))
+
+		var message protoBuffer
+		message.varint(pprofLocationID, locationID)
+		message.message(pprofLocationLine, line)
+		self.profile.message(pprofProfileLocation, message)
+	}
+
+	return locationID
+}
+
+//
+// Protocol buffer wire format.
+//
+
+const (
+	protoWireVarint = 0
+	protoWireBytes  = 2
+)
+
+type protoBuffer []byte
+
+func (self *protoBuffer) rawVarint(value uint64) {
+	for value >= 0x80 {
+		*self = append(*self, byte(value)|0x80)
+		value >>= 7
+	}
+	*self = append(*self, byte(value))
+}
+
+func (self *protoBuffer) tag(field uint64, wireType uint64) {
+	self.rawVarint(field<<3 | wireType)
+}
+
+func (self *protoBuffer) varint(field uint64, value uint64) {
+	self.tag(field, protoWireVarint)
+	self.rawVarint(value)
+}
+
+func (self *protoBuffer) bytes(field uint64, value []byte) {
+	self.tag(field, protoWireBytes)
+	self.rawVarint(uint64(len(value)))
+	*self = append(*self, value...)
+}
+
+func (self *protoBuffer) message(field uint64, message protoBuffer) {
+	self.bytes(field, message)
+}
+
+func (self *protoBuffer) packed(field uint64, values []uint64) {
+	var packed protoBuffer
+	for _, value := range values {
+		packed.rawVarint(value)
+	}
+	self.bytes(field, packed)
+}
@@ -0,0 +1,236 @@
+package runtime
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/smarthome-go/homescript/v3/homescript/errors"
+)
+
+//
+// Profiler.
+// While profiling, every instruction is attributed to the call stack of the core which executes it.
+// Wall time is sampled: a ticker advances a global clock and each core attributes the elapsed ticks
+// to the instruction which was running while they elapsed, including time spent in builtin functions.
+//
+
+const DefaultProfilingInterval = time.Millisecond * 10
+
+type Profile struct {
+	Samples []ProfileSample
+	// The interval at which wall time was sampled.
+	Period   time.Duration
+	Start    time.Time
+	Duration time.Duration
+}
+
+type ProfileSample struct {
+	// The innermost frame comes first.
+	Stack        []ProfileFrame
+	Instructions int64
+	WallTime     time.Duration
+}
+
+type ProfileFrame struct {
+	// The name of the function as it appears in the source code, if it is known.
+	Function string
+	Filename string
+	Line     uint
+}
+
+type profiler struct {
+	interval time.Duration
+	start    time.Time
+	// Advanced by the ticker, read by the cores on every instruction.
+	tick atomic.Uint64
+	stop chan struct{}
+
+	// Collects the profiles of the cores once they terminate.
+	lock    sync.Mutex
+	samples map[string]*profilerSample
+	// Cores signal their termination before their profile is merged.
+	active sync.WaitGroup
+}
+
+type profilerSample struct {
+	stack        []CallFrame
+	instructions int64
+	ticks        uint64
+}
+
+// The profile of a single core, it is only merged into the profiler once the core terminates.
+type coreProfile struct {
+	samples map[string]*profilerSample
+	// The sample of the previous instruction, elapsed ticks are attributed to it.
+	previous *profilerSample
+	lastTick uint64
+	key      strings.Builder
+}
+
+// Starts profiling every core which is spawned from now on.
+// If `interval` is zero, the `DefaultProfilingInterval` is used.
+func (self *VM) StartProfiling(interval time.Duration) {
+	if interval == 0 {
+		interval = DefaultProfilingInterval
+	}
+
+	profiler := profiler{
+		interval: interval,
+		start:    time.Now(),
+		stop:     make(chan struct{}),
+		samples:  make(map[string]*profilerSample),
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				profiler.tick.Add(1)
+			case <-profiler.stop:
+				return
+			}
+		}
+	}()
+
+	self.profiler = &profiler
+}
+
+// Stops profiling and returns the profile of all cores which have terminated in the meantime.
+// WARNING: this is unsafe before all cores have terminated.
+func (self *VM) StopProfiling() Profile {
+	profiler := self.profiler
+	if profiler == nil {
+		return Profile{}
+	}
+
+	self.profiler = nil
+	close(profiler.stop)
+	profiler.active.Wait()
+
+	profile := Profile{
+		Samples:  make([]ProfileSample, 0, len(profiler.samples)),
+		Period:   profiler.interval,
+		Start:    profiler.start,
+		Duration: time.Since(profiler.start),
+	}
+
+	for _, sample := range profiler.samples {
+		stack := make([]ProfileFrame, 0, len(sample.stack))
+		for idx := len(sample.stack) - 1; idx >= 0; idx-- {
+			stack = append(stack, self.profileFrame(sample.stack[idx]))
+		}
+
+		profile.Samples = append(profile.Samples, ProfileSample{
+			Stack:        stack,
+			Instructions: sample.instructions,
+			WallTime:     time.Duration(sample.ticks) * profiler.interval,
+		})
+	}
+
+	return profile
+}
+
+func (self *VM) profileFrame(frame CallFrame) ProfileFrame {
+	span := errors.Span{}
+	if len(self.Program.SourceMap[frame.Function]) != 0 {
+		span = self.SourceMap(frame)
+	}
+
+	name := frame.Function
+	for ident, mangled := range self.Program.Mappings.Functions {
+		if mangled == frame.Function {
+			name = ident
+			break
+		}
+	}
+
+	return ProfileFrame{
+		Function: name,
+		Filename: span.Filename,
+		Line:     span.Start.Line,
+	}
+}
+
+// Invoked by a core before it executes an instruction.
+func (self *profiler) observe(core *Core) {
+	if core.profile == nil {
+		self.active.Add(1)
+		core.profile = &coreProfile{
+			samples:  make(map[string]*profilerSample),
+			lastTick: self.tick.Load(),
+		}
+	}
+	profile := core.profile
+
+	if tick := self.tick.Load(); tick != profile.lastTick {
+		if profile.previous != nil {
+			profile.previous.ticks += tick - profile.lastTick
+		}
+		profile.lastTick = tick
+	}
+
+	// Callers have already advanced their instruction pointer past the call.
+	profile.key.Reset()
+	for idx, frame := range core.CallStack {
+		ip := frame.InstructionPointer
+		if idx != len(core.CallStack)-1 && ip > 0 {
+			ip--
+		}
+
+		profile.key.WriteString(frame.Function)
+		profile.key.WriteByte(':')
+		profile.key.WriteString(strconv.FormatUint(uint64(ip), 10))
+		profile.key.WriteByte(';')
+	}
+
+	key := profile.key.String()
+	sample, found := profile.samples[key]
+	if !found {
+		stack := make([]CallFrame, len(core.CallStack))
+		copy(stack, core.CallStack)
+		for idx := range stack[:len(stack)-1] {
+			if stack[idx].InstructionPointer > 0 {
+				stack[idx].InstructionPointer--
+			}
+		}
+
+		sample = &profilerSample{stack: stack}
+		profile.samples[key] = sample
+	}
+
+	sample.instructions++
+	profile.previous = sample
+}
+
+// Invoked once a core terminates.
+func (self *profiler) finish(core *Core) {
+	profile := core.profile
+	if profile == nil {
+		return
+	}
+	defer self.active.Done()
+
+	if profile.previous != nil {
+		profile.previous.ticks += self.tick.Load() - profile.lastTick
+	}
+
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	for key, sample := range profile.samples {
+		merged, found := self.samples[key]
+		if !found {
+			self.samples[key] = sample
+			continue
+		}
+
+		merged.instructions += sample.instructions
+		merged.ticks += sample.ticks
+	}
+}
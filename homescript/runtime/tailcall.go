@@ -0,0 +1,63 @@
+package runtime
+
+import (
+	"github.com/smarthome-go/homescript/v3/homescript/compiler"
+)
+
+// The maximum number of instructions which are inspected in order to detect a tail call.
+const tailCallLookahead = 8
+
+// Returns whether the call at the current instruction pointer is in tail position.
+// A call is in tail position if only a return follows it, possibly preceded by unconditional jumps
+// and the cleanup of the current memory window (`AddMempointer`).
+// In this case, `memoryOffset` is the sum of all memory pointer adjustments which would have been executed.
+func (self *Core) tailPosition() (memoryOffset int64, isTail bool) {
+	callFrame := self.callFrame()
+	fn := (*self.Program)[callFrame.Function]
+	ip := callFrame.InstructionPointer + 1
+
+	for step := 0; step < tailCallLookahead && ip < uint(len(fn)); step++ {
+		switch inst := fn[ip]; inst.Opcode() {
+		case compiler.Opcode_Return:
+			return memoryOffset, true
+		case compiler.Opcode_Jump:
+			ip = uint(inst.(compiler.OneIntInstruction).Value)
+		case compiler.Opcode_AddMempointer:
+			memoryOffset += inst.(compiler.OneIntInstruction).Value
+			ip++
+		case compiler.Opcode_Nop:
+			ip++
+		default:
+			return 0, false
+		}
+	}
+
+	return 0, false
+}
+
+// Performs a tail call if possible: instead of pushing a new call frame,
+// the current call frame and memory window are reused by the called function.
+// Therefore, recursion in tail position runs in constant call stack space.
+func (self *Core) tailCall(function string) (performed bool) {
+	memoryOffset, isTail := self.tailPosition()
+	if !isTail {
+		return false
+	}
+
+	// Exceptions are caught by the function which registered the catch label.
+	// If the current function has registered one, its call frame must be kept.
+	if len(self.ExceptionCatchLabels) > 0 &&
+		self.ExceptionCatchLabels[len(self.ExceptionCatchLabels)-1].Function == self.callFrame().Function {
+		return false
+	}
+
+	// Free the memory window of the current function, just like its cleanup code would have done.
+	self.MemoryPointer += memoryOffset
+
+	*self.callFrame() = CallFrame{
+		Function:           function,
+		InstructionPointer: 0,
+	}
+
+	return true
+}
@@ -0,0 +1,489 @@
+package runtime
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/smarthome-go/homescript/v3/homescript/compiler"
+	herrors "github.com/smarthome-go/homescript/v3/homescript/errors"
+	"github.com/smarthome-go/homescript/v3/homescript/runtime/value"
+)
+
+//
+// Record and replay.
+// A trace contains every input which can make two runs of the same program behave differently:
+// the results of the executor, the results of builtin functions and the order in which the cores execute instructions.
+// While recording, the cores execute their instructions one at a time so that this order is well-defined.
+// A replay substitutes all inputs from the trace, the host is only used for writing output.
+//
+
+// The version must be incremented each time the encoding of a trace changes.
+const TraceVersion uint32 = 1
+
+var ErrTraceVersionMismatch = errors.New("trace was recorded by an incompatible version")
+
+type Trace struct {
+	Version  uint32       `json:"version"`
+	Events   []TraceEvent `json:"events"`
+	Schedule []TraceSlice `json:"schedule"`
+}
+
+type TraceEventKind string
+
+const (
+	// The result of a builtin function, named after the location of the call.
+	TraceEventBuiltin TraceEventKind = "builtin"
+	// A singleton which was loaded from the executor.
+	TraceEventSingleton TraceEventKind = "singleton"
+	// The registration of a trigger, named after its callback function.
+	TraceEventTrigger TraceEventKind = "trigger"
+	// Output which was written to the executor.
+	TraceEventOutput TraceEventKind = "output"
+)
+
+type TraceEvent struct {
+	Kind TraceEventKind `json:"kind"`
+	Name string         `json:"name,omitempty"`
+	// The core which executed the instruction that caused the event.
+	Core uint `json:"core"`
+	// The resulting value, omitted if there was none.
+	Result json.RawMessage `json:"result,omitempty"`
+	// Singletons: whether the executor provided a saved instance.
+	Found bool `json:"found,omitempty"`
+	// Output events.
+	Output string `json:"output,omitempty"`
+	// Errors of the executor or values which could not be recorded.
+	Error     string          `json:"error,omitempty"`
+	Interrupt *TraceInterrupt `json:"interrupt,omitempty"`
+}
+
+type TraceInterrupt struct {
+	Kind    string `json:"kind"`
+	Message string `json:"message,omitempty"`
+	// Exit interrupts.
+	Code int64 `json:"code,omitempty"`
+	// Fatal exceptions.
+	FatalKind value.VMFatalExceptionKind `json:"fatalKind,omitempty"`
+}
+
+// A number of consecutive instructions which were executed by one core.
+type TraceSlice struct {
+	Core         uint   `json:"core"`
+	Instructions uint64 `json:"instructions"`
+}
+
+type traceFile struct {
+	Trace   Trace           `json:"trace"`
+	Program json.RawMessage `json:"program"`
+}
+
+// Encodes a trace together with the program it was recorded from, so that it can be replayed without the sources.
+func EncodeTrace(trace Trace, program compiler.CompileOutput) ([]byte, error) {
+	bytecode, err := compiler.EncodeBytecode(program, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(traceFile{
+		Trace:   trace,
+		Program: bytecode,
+	})
+}
+
+// Decodes a trace which was produced by `EncodeTrace`.
+func DecodeTrace(data []byte) (Trace, compiler.CompileOutput, error) {
+	var file traceFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return Trace{}, compiler.CompileOutput{}, fmt.Errorf("invalid trace: %s", err.Error())
+	}
+
+	if file.Trace.Version != TraceVersion {
+		return Trace{}, compiler.CompileOutput{}, fmt.Errorf(
+			"%w: expected version %d, found %d",
+			ErrTraceVersionMismatch,
+			TraceVersion,
+			file.Trace.Version,
+		)
+	}
+
+	_, program, err := compiler.DecodeBytecode(file.Program)
+	if err != nil {
+		return Trace{}, compiler.CompileOutput{}, err
+	}
+
+	return file.Trace, program, nil
+}
+
+// Like `NewVM`, but all nondeterministic inputs of the VM are recorded, see `VM.Trace`.
+// The trace is complete once all cores have terminated.
+func NewRecordingVM(
+	program compiler.CompileOutput,
+	executor value.Executor,
+	ctx *context.Context,
+	cancelFunc *context.CancelFunc,
+	globalScopeAdditions map[string]value.Value,
+	limits CoreLimits,
+) VM {
+	mustVerifyProgram(program, globalScopeAdditions)
+
+	tracer := newTracer(&Trace{
+		Version:  TraceVersion,
+		Events:   make([]TraceEvent, 0),
+		Schedule: make([]TraceSlice, 0),
+	}, false)
+
+	return newVM(program, recordingExecutor{Executor: executor, tracer: tracer}, ctx, cancelFunc, globalScopeAdditions, limits, tracer)
+}
+
+// Like `NewVM`, but the inputs of the VM are taken from a trace instead of the host.
+// Builtin functions are not invoked, their recorded output is written to the executor instead.
+// If the program does not behave like the recorded one, a fatal exception is raised.
+func NewReplayVM(
+	program compiler.CompileOutput,
+	trace Trace,
+	executor value.Executor,
+	ctx *context.Context,
+	cancelFunc *context.CancelFunc,
+	globalScopeAdditions map[string]value.Value,
+	limits CoreLimits,
+) (VM, error) {
+	// Traces are loaded from disk, therefore, their bytecode is verified.
+	if err := verifyProgram(program, globalScopeAdditions); err != nil {
+		return VM{}, err
+	}
+
+	tracer := newTracer(&trace, true)
+	return newVM(program, replayExecutor{Executor: executor, tracer: tracer}, ctx, cancelFunc, globalScopeAdditions, limits, tracer), nil
+}
+
+// Returns the trace which is recorded or replayed by the VM, or `nil` if there is none.
+// WARNING: this is unsafe before all cores have terminated.
+func (self *VM) Trace() *Trace {
+	if self.tracer == nil {
+		return nil
+	}
+
+	return self.tracer.trace
+}
+
+//
+// Tracer.
+//
+
+type tracer struct {
+	trace  *Trace
+	replay bool
+
+	// Serializes the instructions of all cores.
+	lock sync.Mutex
+	// Signaled each time the schedule advances or a core terminates.
+	turn     *sync.Cond
+	finished map[uint]bool
+	// The core which is currently executing an instruction.
+	current uint
+	// Replay position in the schedule.
+	slice    int
+	executed uint64
+
+	// Protects the events as the executor could also be used outside of instructions.
+	eventLock sync.Mutex
+	// Replay position in the events.
+	event int
+}
+
+func newTracer(trace *Trace, replay bool) *tracer {
+	tracer := tracer{
+		trace:    trace,
+		replay:   replay,
+		finished: make(map[uint]bool),
+	}
+	tracer.turn = sync.NewCond(&tracer.lock)
+
+	return &tracer
+}
+
+// Blocks until the core may execute its next instruction.
+func (self *tracer) acquire(core uint) {
+	self.lock.Lock()
+	self.current = core
+
+	if !self.replay {
+		schedule := self.trace.Schedule
+		if len(schedule) > 0 && schedule[len(schedule)-1].Core == core {
+			schedule[len(schedule)-1].Instructions++
+		} else {
+			self.trace.Schedule = append(schedule, TraceSlice{Core: core, Instructions: 1})
+		}
+		return
+	}
+
+	for {
+		// Cores which terminated earlier than recorded must not block the others.
+		for self.slice < len(self.trace.Schedule) && self.finished[self.trace.Schedule[self.slice].Core] {
+			self.slice++
+			self.executed = 0
+		}
+
+		// Once the schedule is exhausted, the cores run in arbitrary order.
+		if self.slice >= len(self.trace.Schedule) || self.trace.Schedule[self.slice].Core == core {
+			return
+		}
+
+		self.turn.Wait()
+	}
+}
+
+// Must be called after the instruction was executed.
+func (self *tracer) release() {
+	if self.replay && self.slice < len(self.trace.Schedule) {
+		self.executed++
+		if self.executed >= self.trace.Schedule[self.slice].Instructions {
+			self.slice++
+			self.executed = 0
+		}
+		self.turn.Broadcast()
+	}
+
+	self.lock.Unlock()
+}
+
+func (self *tracer) finish(core uint) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	self.finished[core] = true
+	self.turn.Broadcast()
+}
+
+func (self *tracer) record(event TraceEvent) {
+	self.eventLock.Lock()
+	defer self.eventLock.Unlock()
+
+	event.Core = self.current
+	self.trace.Events = append(self.trace.Events, event)
+}
+
+// Returns the next recorded event, which must be of the given kind and name.
+// Output in front of the event is written to the executor.
+func (self *tracer) next(kind TraceEventKind, name string, output value.Executor) (TraceEvent, error) {
+	self.eventLock.Lock()
+	defer self.eventLock.Unlock()
+
+	for self.event < len(self.trace.Events) && self.trace.Events[self.event].Kind == TraceEventOutput {
+		if err := output.WriteStringTo(self.trace.Events[self.event].Output); err != nil {
+			return TraceEvent{}, err
+		}
+		self.event++
+	}
+
+	if self.event >= len(self.trace.Events) {
+		return TraceEvent{}, fmt.Errorf("Replay diverged from the trace: expected no more events, found %s `%s`", kind, name)
+	}
+
+	event := self.trace.Events[self.event]
+	if event.Kind != kind || event.Name != name {
+		return TraceEvent{}, fmt.Errorf("Replay diverged from the trace: expected %s `%s`, found %s `%s`", event.Kind, event.Name, kind, name)
+	}
+	self.event++
+
+	return event, nil
+}
+
+// Invokes a builtin function or, during a replay, returns its recorded result.
+func (self *Core) callBuiltin(fn value.ValueBuiltinFunction, args []value.Value) (*value.Value, *value.VmInterrupt) {
+	span := self.parent.SourceMap(*self.callFrame())
+	tracer := self.parent.tracer
+
+	if tracer == nil {
+		return fn.Callback(self.Executor, self.CancelCtx, span, args...)
+	}
+
+	name := fmt.Sprintf("%s:%d:%d", span.Filename, span.Start.Line, span.Start.Column)
+
+	if tracer.replay {
+		event, err := tracer.next(TraceEventBuiltin, name, self.Executor)
+		if err != nil {
+			return nil, value.NewVMFatalException(err.Error(), value.Vm_HostErrorKind, span)
+		}
+
+		if event.Interrupt != nil {
+			return nil, decodeTraceInterrupt(*event.Interrupt, span)
+		}
+
+		return decodeTraceResult(event, span)
+	}
+
+	res, interrupt := fn.Callback(self.Executor, self.CancelCtx, span, args...)
+
+	event := TraceEvent{Kind: TraceEventBuiltin, Name: name}
+	if interrupt != nil {
+		event.Interrupt = encodeTraceInterrupt(*interrupt)
+	} else if res != nil {
+		encodeTraceResult(&event, *res)
+	}
+	tracer.record(event)
+
+	return res, interrupt
+}
+
+// Executes an instruction, if the VM records or replays a trace, the tracer decides when.
+func (self *Core) runScheduledInstruction(instruction compiler.Instruction) *value.VmInterrupt {
+	tracer := self.parent.tracer
+	if tracer == nil {
+		return self.runInstruction(instruction)
+	}
+
+	tracer.acquire(self.Corenum)
+	defer tracer.release()
+
+	return self.runInstruction(instruction)
+}
+
+func encodeTraceResult(event *TraceEvent, result value.Value) {
+	encoded, err := compiler.EncodeValue(result)
+	if err != nil {
+		event.Error = fmt.Sprintf("Value was not recorded: %s", err.Error())
+		return
+	}
+
+	event.Result = encoded
+}
+
+func decodeTraceResult(event TraceEvent, span herrors.Span) (*value.Value, *value.VmInterrupt) {
+	if event.Error != "" {
+		return nil, value.NewVMFatalException(event.Error, value.Vm_HostErrorKind, span)
+	}
+
+	if event.Result == nil {
+		return nil, nil
+	}
+
+	result, err := compiler.DecodeValue(event.Result)
+	if err != nil {
+		return nil, value.NewVMFatalException(fmt.Sprintf("Invalid value in trace: %s", err.Error()), value.Vm_HostErrorKind, span)
+	}
+
+	return result, nil
+}
+
+func encodeTraceInterrupt(interrupt value.VmInterrupt) *TraceInterrupt {
+	encoded := TraceInterrupt{
+		Kind:    interrupt.Kind().String(),
+		Message: interrupt.Message(),
+	}
+
+	switch interrupt := interrupt.(type) {
+	case value.Vm_ExitInterrupt:
+		encoded.Code = interrupt.Code
+	case value.VmFatalException:
+		encoded.FatalKind = interrupt.ErrKind
+	}
+
+	return &encoded
+}
+
+func decodeTraceInterrupt(interrupt TraceInterrupt, span herrors.Span) *value.VmInterrupt {
+	switch interrupt.Kind {
+	case value.Vm_ExitInterruptKind.String():
+		return value.NewVMExitInterrupt(interrupt.Code, span)
+	case value.Vm_TerminateInterruptKind.String():
+		return value.NewVMTerminationInterrupt(interrupt.Message, span)
+	case value.Vm_NormalExceptionInterruptKind.String():
+		return value.NewVMThrowInterrupt(span, interrupt.Message)
+	default:
+		return value.NewVMFatalException(interrupt.Message, interrupt.FatalKind, span)
+	}
+}
+
+//
+// Executors.
+//
+
+type recordingExecutor struct {
+	value.Executor
+	tracer *tracer
+}
+
+func (self recordingExecutor) LoadSingleton(singletonIdent string, moduleName string) (value.Value, bool, error) {
+	val, found, err := self.Executor.LoadSingleton(singletonIdent, moduleName)
+
+	event := TraceEvent{Kind: TraceEventSingleton, Name: singletonIdent, Found: found}
+	if err != nil {
+		event.Error = err.Error()
+	} else if found {
+		encodeTraceResult(&event, val)
+	}
+	self.tracer.record(event)
+
+	return val, found, err
+}
+
+func (self recordingExecutor) WriteStringTo(input string) error {
+	self.tracer.record(TraceEvent{Kind: TraceEventOutput, Output: input})
+	return self.Executor.WriteStringTo(input)
+}
+
+func (self recordingExecutor) RegisterTrigger(
+	callbackFunctionIdent string,
+	eventTriggerIdent string,
+	span herrors.Span,
+	args []value.Value,
+) error {
+	err := self.Executor.RegisterTrigger(callbackFunctionIdent, eventTriggerIdent, span, args)
+
+	event := TraceEvent{Kind: TraceEventTrigger, Name: callbackFunctionIdent}
+	if err != nil {
+		event.Error = err.Error()
+	}
+	self.tracer.record(event)
+
+	return err
+}
+
+type replayExecutor struct {
+	value.Executor
+	tracer *tracer
+}
+
+func (self replayExecutor) LoadSingleton(singletonIdent string, moduleName string) (value.Value, bool, error) {
+	event, err := self.tracer.next(TraceEventSingleton, singletonIdent, self.Executor)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if event.Error != "" {
+		return nil, false, errors.New(event.Error)
+	}
+
+	if !event.Found {
+		return nil, false, nil
+	}
+
+	val, err := compiler.DecodeValue(event.Result)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return *val, true, nil
+}
+
+func (self replayExecutor) RegisterTrigger(
+	callbackFunctionIdent string,
+	_ string,
+	_ herrors.Span,
+	_ []value.Value,
+) error {
+	event, err := self.tracer.next(TraceEventTrigger, callbackFunctionIdent, self.Executor)
+	if err != nil {
+		return err
+	}
+
+	if event.Error != "" {
+		return errors.New(event.Error)
+	}
+
+	return nil
+}
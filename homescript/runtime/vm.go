@@ -3,6 +3,7 @@ package runtime
 import (
 	"context"
 	"fmt"
+	"strings"
 	"sync"
 	"time"
 
@@ -50,6 +51,14 @@ type VM struct {
 	CancelFunc    *context.CancelFunc
 	Interrupts    map[uint]value.VmInterrupt
 	LimitsPerCore CoreLimits
+	// If set, this is invoked by every core before it executes an instruction.
+	DebugHook DebugHook
+	// Only set if the VM records or replays a trace.
+	tracer *tracer
+	// Only set while the VM is profiling, see `VM.StartProfiling`.
+	profiler *profiler
+	// Only set while the VM measures coverage, see `VM.StartCoverage`.
+	coverage *coverage
 }
 
 func MainFn() FunctionInvocation {
@@ -66,6 +75,8 @@ func MainFn() FunctionInvocation {
 	return functionInvocation
 }
 
+// The program is verified before it is accepted, see `compiler.Verify`.
+// As malformed bytecode could otherwise crash a core, this panics if the verification fails.
 func NewVM(
 	program compiler.CompileOutput,
 	executor value.Executor,
@@ -73,6 +84,52 @@ func NewVM(
 	cancelFunc *context.CancelFunc,
 	globalScopeAdditions map[string]value.Value,
 	limits CoreLimits,
+) VM {
+	mustVerifyProgram(program, globalScopeAdditions)
+	return newVM(program, executor, ctx, cancelFunc, globalScopeAdditions, limits, nil)
+}
+
+// Like `NewVM`, but an error is returned if the verification of the program fails.
+// This should be used for bytecode which was not produced by the compiler in the same process,
+// for instance cached or hand-edited bytecode.
+func NewVerifiedVM(
+	program compiler.CompileOutput,
+	executor value.Executor,
+	ctx *context.Context,
+	cancelFunc *context.CancelFunc,
+	globalScopeAdditions map[string]value.Value,
+	limits CoreLimits,
+) (VM, error) {
+	if err := verifyProgram(program, globalScopeAdditions); err != nil {
+		return VM{}, err
+	}
+
+	return newVM(program, executor, ctx, cancelFunc, globalScopeAdditions, limits, nil), nil
+}
+
+func verifyProgram(program compiler.CompileOutput, globalScopeAdditions map[string]value.Value) error {
+	hostGlobals := make([]string, 0, len(globalScopeAdditions))
+	for ident := range globalScopeAdditions {
+		hostGlobals = append(hostGlobals, ident)
+	}
+
+	return compiler.Verify(program, hostGlobals)
+}
+
+func mustVerifyProgram(program compiler.CompileOutput, globalScopeAdditions map[string]value.Value) {
+	if err := verifyProgram(program, globalScopeAdditions); err != nil {
+		panic(fmt.Sprintf("Fatal: VM received invalid bytecode: %s", err.Error()))
+	}
+}
+
+func newVM(
+	program compiler.CompileOutput,
+	executor value.Executor,
+	ctx *context.Context,
+	cancelFunc *context.CancelFunc,
+	globalScopeAdditions map[string]value.Value,
+	limits CoreLimits,
+	tracer *tracer,
 ) VM {
 	vm := VM{
 		Program:       program,
@@ -84,10 +141,66 @@ func NewVM(
 		CancelFunc:    cancelFunc,
 		Interrupts:    make(map[uint]value.VmInterrupt),
 		LimitsPerCore: limits,
+		tracer:        tracer,
 	}
 
 	// nolint:contextcheck
-	res := vm.SpawnSync(
+	if exception := vm.runInit(); exception != nil {
+		panic(fmt.Sprintf(
+			"Fatal: VM encountered exception during initialization code: %s",
+			exception.Interrupt.Message()),
+		)
+	}
+
+	return vm
+}
+
+// Replaces the program of the VM while keeping its globals.
+// This is used by interactive sessions which compile every input into a new program.
+// Like in `NewVerifiedVM`, the program is verified before its initialization code is executed.
+// Modules which were already part of the previous program are not initialized again.
+// The initialization code of the entry module always runs, however, globals which already exist keep their values.
+// WARNING: this is unsafe before all cores have terminated.
+func (self *VM) Reload(program compiler.CompileOutput) error {
+	if err := verifyProgram(program, self.globals.Data); err != nil {
+		return err
+	}
+
+	previousGlobals := make(map[string]value.Value, len(self.globals.Data))
+	for ident, val := range self.globals.Data {
+		previousGlobals[ident] = val
+	}
+
+	// The init functions of known modules are replaced by their final `Return` instruction.
+	entryInit := program.Mappings.Functions[compiler.InitFunctionIdent]
+	functions := make(map[string][]compiler.Instruction, len(program.Functions))
+	for ident, instructions := range program.Functions {
+		_, known := self.Program.Functions[ident]
+		if known && ident != entryInit && strings.HasSuffix(ident, "_"+compiler.InitFunctionIdent) && len(instructions) != 0 {
+			instructions = instructions[len(instructions)-1:]
+		}
+		functions[ident] = instructions
+	}
+
+	self.Program = program
+	self.Program.Functions = functions
+
+	exception := self.runInit()
+
+	self.Program.Functions = program.Functions
+	for ident, val := range previousGlobals {
+		self.globals.Data[ident] = val
+	}
+
+	if exception != nil {
+		return fmt.Errorf("Exception during initialization code: %s", exception.Interrupt.Message())
+	}
+
+	return nil
+}
+
+func (self *VM) runInit() *VMException {
+	res := self.SpawnSync(
 		FunctionInvocation{
 			Function:    compiler.InitFunctionIdent,
 			LiteralName: false,
@@ -101,14 +214,7 @@ func NewVM(
 		nil,
 	)
 
-	if res.Exception != nil {
-		panic(fmt.Sprintf(
-			"Fatal: VM encountered exception during initialization code: %s",
-			res.Exception.Interrupt.Message()),
-		)
-	}
-
-	return vm
+	return res.Exception
 }
 
 // TODO: why is this not a real method?
@@ -481,8 +587,7 @@ func (self *VM) Wait() (coreNum uint, i *value.VmInterrupt) {
 					self.Cores.Cores = make([]Core, 0)
 					self.Cores.Lock.Unlock()
 
-					self.Cores.Lock.RLock()
-
+					// The lock must not be held anymore, otherwise the VM could not spawn new cores.
 					return core.Corenum, i
 				}
 			default:
@@ -0,0 +1,49 @@
+package homescript
+
+import (
+	"fmt"
+
+	"github.com/smarthome-go/homescript/v3/homescript/analyzer"
+)
+
+// Returns the source code of the program and of every Homescript module it imports, directly or indirectly (module name => code).
+// Modules are resolved through the host like during analysis, however, they are only parsed.
+// Therefore, this is cheap enough to compute the hash of cached bytecode before deciding whether to recompile,
+// see `compiler.HashSources` and `compiler.DecodeCachedBytecode`.
+// Imports of modules which do not parse are not followed: such a program fails to analyze anyway.
+func ModuleSources(input InputProgram, host analyzer.HostProvider) (map[string]string, error) {
+	sources := map[string]string{input.Filename: input.ProgramText}
+	pending := []string{input.Filename}
+
+	for len(pending) > 0 {
+		module := pending[0]
+		pending = pending[1:]
+
+		program, _, criticalErr := Parse(sources[module], module)
+		if criticalErr != nil {
+			continue
+		}
+
+		for _, item := range program.Imports {
+			name := item.FromModule.Ident()
+			if _, alreadyLoaded := sources[name]; alreadyLoaded {
+				continue
+			}
+
+			code, found, err := host.ResolveCodeModule(name)
+			if err != nil {
+				return nil, fmt.Errorf("could not resolve module '%s': %s", name, err.Error())
+			}
+
+			// The module is provided by the host instead of Homescript code.
+			if !found {
+				continue
+			}
+
+			sources[name] = code
+			pending = append(pending, name)
+		}
+	}
+
+	return sources, nil
+}
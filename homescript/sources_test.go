@@ -0,0 +1,55 @@
+package homescript
+
+import (
+	"testing"
+
+	"github.com/smarthome-go/homescript/v3/homescript/compiler"
+	"github.com/stretchr/testify/assert"
+)
+
+// Resolves code modules from memory.
+type sourcesHost struct {
+	TestingAnalyzerHost
+	modules map[string]string
+}
+
+func (self sourcesHost) ResolveCodeModule(moduleName string) (string, bool, error) {
+	code, found := self.modules[moduleName]
+	return code, found, nil
+}
+
+func TestModuleSources(t *testing.T) {
+	const program = `import { http } from net;
+import { b } from b;
+
+fn main() { b(); }
+`
+
+	host := sourcesHost{
+		modules: map[string]string{
+			"b": "import { c } from c;\npub fn b() { c(); }\n",
+			// Import cycles are rejected by the analyzer, but they must not prevent hashing.
+			"c": "import { b } from b;\npub fn c() {}\n",
+			// Modules which are not imported do not belong to the program.
+			"unused": "pub fn unused() {}\n",
+		},
+	}
+
+	input := InputProgram{ProgramText: program, Filename: "main.hms"}
+
+	sources, err := ModuleSources(input, host)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"main.hms": program,
+		"b":        host.modules["b"],
+		"c":        host.modules["c"],
+	}, sources)
+
+	// A change in an indirectly imported module changes the hash of the program.
+	hash := compiler.HashSources(sources)
+	host.modules["c"] = "import { b } from b;\npub fn c() { println(); }\n"
+
+	sources, err = ModuleSources(input, host)
+	assert.NoError(t, err)
+	assert.NotEqual(t, hash, compiler.HashSources(sources))
+}
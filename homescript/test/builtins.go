@@ -0,0 +1,131 @@
+package test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/smarthome-go/homescript/v3/homescript/analyzer"
+	"github.com/smarthome-go/homescript/v3/homescript/analyzer/ast"
+	herrors "github.com/smarthome-go/homescript/v3/homescript/errors"
+	pAst "github.com/smarthome-go/homescript/v3/homescript/parser/ast"
+	"github.com/smarthome-go/homescript/v3/homescript/runtime/value"
+)
+
+//
+// The `testing` module.
+// Hosts should expose these builtins so that script authors can write test functions, see `Discover`.
+//
+
+// The name of the builtin module which provides the assertions.
+const ModuleName = "testing"
+
+// Returns the names of the builtins of the module.
+func Imports() []string {
+	return []string{"assert", "assert_eq"}
+}
+
+// Returns the type of a builtin of the module, to be used in `analyzer.HostProvider.GetBuiltinImport`.
+func AnalyzerImport(valueName string, span herrors.Span) (analyzer.BuiltinImport, bool) {
+	var params []ast.FunctionTypeParam
+
+	switch valueName {
+	case "assert":
+		params = []ast.FunctionTypeParam{
+			ast.NewFunctionTypeParam(pAst.NewSpannedIdent("condition", span), ast.NewBoolType(span), nil),
+		}
+	case "assert_eq":
+		params = []ast.FunctionTypeParam{
+			ast.NewFunctionTypeParam(pAst.NewSpannedIdent("lhs", span), ast.NewUnknownType(), nil),
+			ast.NewFunctionTypeParam(pAst.NewSpannedIdent("rhs", span), ast.NewUnknownType(), nil),
+		}
+	default:
+		return analyzer.BuiltinImport{}, false
+	}
+
+	return analyzer.BuiltinImport{
+		Type: ast.NewFunctionType(
+			ast.NewNormalFunctionTypeParamKind(params),
+			span,
+			ast.NewNullType(span),
+			span,
+		),
+		Template: nil,
+	}, true
+}
+
+// Returns the implementation of a builtin of the module, to be used in `value.Executor.GetBuiltinImport`.
+// Failed assertions throw an exception, which fails the test unless it is caught.
+func VmImport(valueName string) (value.Value, bool) {
+	switch valueName {
+	case "assert":
+		return *value.NewValueBuiltinFunction(assert), true
+	case "assert_eq":
+		return *value.NewValueBuiltinFunction(assertEq), true
+	default:
+		return nil, false
+	}
+}
+
+func assert(_ value.Executor, _ *context.Context, span herrors.Span, args ...value.Value) (*value.Value, *value.VmInterrupt) {
+	if !args[0].(value.ValueBool).Inner {
+		return nil, value.NewVMThrowInterrupt(span, "Assertion failed")
+	}
+
+	return value.NewValueNull(), nil
+}
+
+func assertEq(_ value.Executor, _ *context.Context, span herrors.Span, args ...value.Value) (*value.Value, *value.VmInterrupt) {
+	lhs, rhs := args[0], args[1]
+
+	if lhs.Kind() == rhs.Kind() {
+		isEqual, i := lhs.IsEqual(rhs)
+		if i != nil {
+			return nil, i
+		}
+
+		if isEqual {
+			return value.NewValueNull(), nil
+		}
+	}
+
+	lhsDisp, i := lhs.Display()
+	if i != nil {
+		return nil, i
+	}
+
+	rhsDisp, i := rhs.Display()
+	if i != nil {
+		return nil, i
+	}
+
+	return nil, value.NewVMThrowInterrupt(span, assertionDiff(lhsDisp, lhs.Kind(), rhsDisp, rhs.Kind()))
+}
+
+// Describes how two unequal values differ.
+// Values which span several lines are compared line by line.
+func assertionDiff(lhs string, lhsKind value.ValueKind, rhs string, rhsKind value.ValueKind) string {
+	message := "Assertion failed: `lhs == rhs`"
+
+	if lhsKind != rhsKind {
+		message = fmt.Sprintf("%s\nValues of type `%s` and `%s` are never equal", message, lhsKind, rhsKind)
+	}
+
+	if !strings.Contains(lhs, "\n") && !strings.Contains(rhs, "\n") {
+		return fmt.Sprintf("%s\n  lhs: %s\n  rhs: %s", message, lhs, rhs)
+	}
+
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(lhs),
+		B:        difflib.SplitLines(rhs),
+		FromFile: "lhs",
+		ToFile:   "rhs",
+		Context:  3,
+	})
+	if err != nil {
+		return fmt.Sprintf("%s\n  lhs: %s\n  rhs: %s", message, lhs, rhs)
+	}
+
+	return fmt.Sprintf("%s\n%s", message, strings.TrimSuffix(diff, "\n"))
+}
@@ -0,0 +1,170 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/smarthome-go/homescript/v3/homescript/analyzer"
+	"github.com/smarthome-go/homescript/v3/homescript/analyzer/ast"
+	"github.com/smarthome-go/homescript/v3/homescript/compiler"
+	herrors "github.com/smarthome-go/homescript/v3/homescript/errors"
+	"github.com/smarthome-go/homescript/v3/homescript/runtime"
+	"github.com/smarthome-go/homescript/v3/homescript/runtime/value"
+)
+
+//
+// Test runner.
+// Test functions are annotated using `#[test]`, they are discovered in every module of a compiled program.
+// Each test runs in a new VM, so that tests cannot influence each other through global variables.
+//
+
+const DefaultTimeout = time.Second * 10
+
+type Test struct {
+	Module   string
+	Function string
+	// The location of the function, used to order and report tests.
+	Span herrors.Span
+}
+
+func (self Test) String() string {
+	return fmt.Sprintf("%s::%s", self.Module, self.Function)
+}
+
+// Returns the test functions of all modules of the program, ordered by module and location.
+func Discover(program compiler.CompileOutput) []Test {
+	tests := make([]Test, 0)
+
+	for fn, annotations := range program.Annotations {
+		for _, item := range annotations.Items {
+			ident, isIdent := item.(compiler.IdentCompiledAnnotation)
+			if !isIdent || ident.Ident != analyzer.TestAnnotation {
+				continue
+			}
+
+			test := Test{Module: fn.Module, Function: fn.UnmangledFunction}
+			if spans := program.SourceMap[compiler.MangleFunction(fn.Module, fn.UnmangledFunction)]; len(spans) != 0 {
+				test.Span = spans[0]
+			}
+
+			tests = append(tests, test)
+		}
+	}
+
+	sort.Slice(tests, func(i, j int) bool {
+		if tests[i].Module != tests[j].Module {
+			return tests[i].Module < tests[j].Module
+		}
+		return tests[i].Span.Start.Line < tests[j].Span.Start.Line
+	})
+
+	return tests
+}
+
+type Outcome uint8
+
+const (
+	Passed Outcome = iota
+	Failed
+	TimedOut
+)
+
+func (self Outcome) String() string {
+	switch self {
+	case Passed:
+		return "ok"
+	case Failed:
+		return "FAILED"
+	case TimedOut:
+		return "TIMEOUT"
+	default:
+		panic("A new outcome was added without updating this code")
+	}
+}
+
+type Result struct {
+	Test     Test
+	Outcome  Outcome
+	Duration time.Duration
+	// Only set if the test did not pass.
+	Message string
+	Span    herrors.Span
+}
+
+type Options struct {
+	// If this is zero, the `DefaultTimeout` is used.
+	Timeout time.Duration
+	Limits  runtime.CoreLimits
+	// Invoked for every test, so that its output can be captured separately.
+	NewExecutor       func() value.Executor
+	NewScopeAdditions func() map[string]value.Value
+}
+
+// Runs a single test in a new VM.
+// The test fails if it throws an exception, exits with a nonzero code or does not terminate within the timeout.
+func Run(program compiler.CompileOutput, test Test, options Options) (result Result) {
+	timeout := options.Timeout
+	if timeout == 0 {
+		timeout = DefaultTimeout
+	}
+
+	result = Result{Test: test, Outcome: Passed}
+	start := time.Now()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	// The VM panics if the initialization code of a module throws an exception.
+	defer func() {
+		if err := recover(); err != nil {
+			result.Outcome = Failed
+			result.Message = fmt.Sprint(err)
+			result.Duration = time.Since(start)
+		}
+	}()
+
+	vm := runtime.NewVM(program, options.NewExecutor(), &ctx, &cancel, options.NewScopeAdditions(), options.Limits)
+
+	vm.SpawnAsync(runtime.FunctionInvocation{
+		Function:    compiler.MangleFunction(test.Module, test.Function),
+		LiteralName: true,
+		Args:        make([]value.Value, 0),
+		FunctionSignature: runtime.FunctionInvocationSignature{
+			Params:     make([]runtime.FunctionInvocationSignatureParam, 0),
+			ReturnType: ast.NewNullType(herrors.Span{}),
+		},
+	}, nil, nil, nil)
+
+	_, i := vm.Wait()
+	result.Duration = time.Since(start)
+
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		result.Outcome = TimedOut
+		result.Message = fmt.Sprintf("Test did not terminate within %v", timeout)
+		if i != nil {
+			result.Span = (*i).GetSpan()
+		}
+		return result
+	}
+
+	if i == nil {
+		return result
+	}
+
+	if exit, isExit := (*i).(value.Vm_ExitInterrupt); isExit {
+		if exit.Code == 0 {
+			return result
+		}
+		result.Message = fmt.Sprintf("Test exited with code %d", exit.Code)
+	} else {
+		result.Message = (*i).Message()
+	}
+
+	result.Outcome = Failed
+	result.Span = (*i).GetSpan()
+
+	return result
+}
@@ -0,0 +1,118 @@
+package test_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/smarthome-go/homescript/v3/homescript"
+	"github.com/smarthome-go/homescript/v3/homescript/compiler"
+	"github.com/smarthome-go/homescript/v3/homescript/diagnostic"
+	"github.com/smarthome-go/homescript/v3/homescript/errors"
+	"github.com/smarthome-go/homescript/v3/homescript/runtime"
+	"github.com/smarthome-go/homescript/v3/homescript/runtime/value"
+	"github.com/smarthome-go/homescript/v3/homescript/test"
+	"github.com/stretchr/testify/assert"
+)
+
+const testProgram = `import { assert_eq } from testing;
+
+fn add(a: int, b: int) -> int { a + b }
+
+#[test]
+fn adds() {
+    assert_eq(add(1, 2), 3);
+}
+
+#[test]
+fn fails() {
+    assert_eq([1, 2], [1, 3]);
+}
+
+#[test]
+fn hangs() {
+    loop {}
+}
+
+fn main() {}
+`
+
+func analyze(t *testing.T, program string) (compiler.CompileOutput, []diagnostic.Diagnostic) {
+	analyzed, diagnostics, syntaxErrors := homescript.Analyze(
+		homescript.InputProgram{
+			ProgramText: program,
+			Filename:    "main",
+		},
+		homescript.TestingAnalyzerScopeAdditions(),
+		homescript.TestingAnalyzerHost{},
+		true,
+	)
+	assert.Empty(t, syntaxErrors)
+
+	for _, item := range diagnostics {
+		if item.Level == diagnostic.DiagnosticLevelError {
+			return compiler.CompileOutput{}, diagnostics
+		}
+	}
+
+	compilerStruct := compiler.NewCompiler(analyzed, "main")
+	compiled, err := compilerStruct.Compile()
+	assert.NoError(t, err)
+
+	return compiled, diagnostics
+}
+
+func TestRun(t *testing.T) {
+	program, _ := analyze(t, testProgram)
+
+	tests := test.Discover(program)
+	if !assert.Len(t, tests, 3) {
+		return
+	}
+
+	assert.Equal(t, "main::adds", tests[0].String())
+	assert.Equal(t, "main::fails", tests[1].String())
+	assert.Equal(t, "main::hangs", tests[2].String())
+
+	options := test.Options{
+		Timeout: time.Millisecond * 200,
+		Limits: runtime.CoreLimits{
+			CallStackMaxSize: 100,
+			StackMaxSize:     100,
+			MaxMemorySize:    100,
+		},
+		NewExecutor: func() value.Executor {
+			return homescript.TestingVmExecutor{PrintBuf: new(string), PintBufMutex: &sync.Mutex{}}
+		},
+		NewScopeAdditions: homescript.TestingVmScopeAdditions,
+	}
+
+	passed := test.Run(program, tests[0], options)
+	assert.Equal(t, test.Passed, passed.Outcome)
+
+	failed := test.Run(program, tests[1], options)
+	assert.Equal(t, test.Failed, failed.Outcome)
+	assert.Contains(t, failed.Message, "Assertion failed: `lhs == rhs`")
+	assert.Contains(t, failed.Message, "rhs: [1, 3]")
+	assert.Equal(t, uint(12), failed.Span.Start.Line)
+
+	timedOut := test.Run(program, tests[2], options)
+	assert.Equal(t, test.TimedOut, timedOut.Outcome)
+}
+
+func TestInvalidTestFunction(t *testing.T) {
+	_, diagnostics := analyze(t, `#[test]
+fn takes(n: int) -> int { n }
+
+fn main() {}
+`)
+
+	codes := make([]errors.Code, 0)
+	for _, item := range diagnostics {
+		if item.Level == diagnostic.DiagnosticLevelError {
+			codes = append(codes, item.Code)
+		}
+	}
+
+	assert.Equal(t, []errors.Code{errors.InvalidTestFunction, errors.InvalidTestFunction}, codes)
+}
@@ -4,12 +4,14 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"slices"
 
 	"github.com/smarthome-go/homescript/v3/homescript/analyzer"
 	"github.com/smarthome-go/homescript/v3/homescript/analyzer/ast"
 	"github.com/smarthome-go/homescript/v3/homescript/diagnostic"
 	herrors "github.com/smarthome-go/homescript/v3/homescript/errors"
 	pAst "github.com/smarthome-go/homescript/v3/homescript/parser/ast"
+	"github.com/smarthome-go/homescript/v3/homescript/test"
 )
 
 //
@@ -174,230 +176,250 @@ func (self TestingAnalyzerHost) ResolveCodeModule(moduleName string) (code strin
 	return string(file), true, nil
 }
 
-func (self TestingAnalyzerHost) GetBuiltinImport(
-	moduleName string,
-	valueName string,
-	span herrors.Span,
-	kind pAst.IMPORT_KIND,
-) (res analyzer.BuiltinImport, moduleFound bool, valueFound bool) {
-	switch moduleName {
-	case "net":
-		newHttpResponse := func() ast.Type {
-			return ast.NewObjectType(
-				[]ast.ObjectTypeField{
-					ast.NewObjectTypeField(pAst.NewSpannedIdent("status", span), ast.NewStringType(span), span),
-					ast.NewObjectTypeField(pAst.NewSpannedIdent("status_code", span), ast.NewIntType(span), span),
-					ast.NewObjectTypeField(pAst.NewSpannedIdent("body", span), ast.NewStringType(span), span),
-					ast.NewObjectTypeField(pAst.NewSpannedIdent("cookies", span), ast.NewAnyObjectType(span), span),
-				},
-				span,
-			)
-		}
+// A builtin value of the testing host, it is only found if imported with the matching kind.
+type testingBuiltin struct {
+	kind pAst.IMPORT_KIND
+	new  func(span herrors.Span) analyzer.BuiltinImport
+}
 
-		switch valueName {
-		case "ping":
+// All builtin modules of the testing host, `GetBuiltinImport`, `BuiltinModules`,
+// and `BuiltinImports` are derived from this table so that they cannot drift apart.
+var testingBuiltins = newTestingBuiltins()
+
+func newTestingBuiltins() map[string]map[string]testingBuiltin {
+	testingModule := map[string]testingBuiltin{
+		"any_func": {kind: pAst.IMPORT_KIND_NORMAL, new: func(span herrors.Span) analyzer.BuiltinImport {
 			return analyzer.BuiltinImport{
 				Type: ast.NewFunctionType(
-					ast.NewNormalFunctionTypeParamKind([]ast.FunctionTypeParam{
-						ast.NewFunctionTypeParam(pAst.NewSpannedIdent("ip", span), ast.NewStringType(span), nil),
-						ast.NewFunctionTypeParam(pAst.NewSpannedIdent("timeout", span), ast.NewFloatType(span), nil),
-					}),
+					ast.NewNormalFunctionTypeParamKind(make([]ast.FunctionTypeParam, 0)),
 					span,
-					ast.NewBoolType(span),
+					ast.NewAnyType(span),
 					span,
 				),
-				Template: &ast.TemplateSpec{},
-			}, true, true
-		case "HttpResponse":
-			return analyzer.BuiltinImport{
-				Type:     newHttpResponse(),
-				Template: &ast.TemplateSpec{},
-			}, true, true
-		case "http":
-			return analyzer.BuiltinImport{
-				Type: ast.NewObjectType([]ast.ObjectTypeField{
-					ast.NewObjectTypeField(pAst.NewSpannedIdent("get", span), ast.NewFunctionType(
-						ast.NewNormalFunctionTypeParamKind([]ast.FunctionTypeParam{ast.NewFunctionTypeParam(pAst.NewSpannedIdent("url", span), ast.NewStringType(span), nil)}),
-						span,
-						newHttpResponse(),
-						span,
-					), span),
-					ast.NewObjectTypeField(pAst.NewSpannedIdent("generic", span), ast.NewFunctionType(
-						ast.NewNormalFunctionTypeParamKind(
-							[]ast.FunctionTypeParam{
-								ast.NewFunctionTypeParam(pAst.NewSpannedIdent("url", span), ast.NewStringType(span), nil),
-								ast.NewFunctionTypeParam(pAst.NewSpannedIdent("method", span), ast.NewStringType(span), nil),
-								ast.NewFunctionTypeParam(pAst.NewSpannedIdent("body", span), ast.NewOptionType(ast.NewStringType(span), span), nil),
-								ast.NewFunctionTypeParam(pAst.NewSpannedIdent("headers", span), ast.NewAnyObjectType(span), nil),
-								ast.NewFunctionTypeParam(pAst.NewSpannedIdent("cookies", span), ast.NewAnyObjectType(span), nil),
-							},
-						),
-						span,
-						newHttpResponse(),
-						span,
-					), span),
-				}, span),
-				Template: &ast.TemplateSpec{},
-			}, true, true
-		default:
-			return analyzer.BuiltinImport{}, true, false
-		}
-	case "triggers":
-		if kind != pAst.IMPORT_KIND_TRIGGER {
-			return analyzer.BuiltinImport{}, true, false
-		}
-
-		switch valueName {
-		case "minute":
+				Template: nil,
+			}
+		}},
+		"any_list": {kind: pAst.IMPORT_KIND_NORMAL, new: func(span herrors.Span) analyzer.BuiltinImport {
 			return analyzer.BuiltinImport{
-				Trigger: &analyzer.TriggerFunction{
-					TriggerFnType: ast.NewFunctionType(
-						ast.NewNormalFunctionTypeParamKind(
-							[]ast.FunctionTypeParam{ast.NewFunctionTypeParam(
-								pAst.NewSpannedIdent("minutes", span),
-								ast.NewIntType(span),
-								nil,
-							)},
-						),
-						span,
-						ast.NewNullType(span),
-						span,
-					).(ast.FunctionType),
-					CallbackFnType: ast.NewFunctionType(
-						ast.NewNormalFunctionTypeParamKind([]ast.FunctionTypeParam{
-							ast.NewFunctionTypeParam(
-								pAst.NewSpannedIdent("elapsed", span),
-								ast.NewIntType(span),
-								nil,
-							),
-						}),
-						span,
-						ast.NewNullType(span),
-						span,
-					).(ast.FunctionType),
-					Connective: pAst.AtTriggerDispatchKeyword,
-					ImportedAt: span,
-				},
-				Type:     nil,
+				Type:     ast.NewListType(ast.NewAnyType(span), span),
 				Template: nil,
-			}, true, true
-		default:
-			return analyzer.BuiltinImport{}, true, false
-		}
-	case "testing":
-		if kind != pAst.IMPORT_KIND_NORMAL {
-			return analyzer.BuiltinImport{}, true, false
-		}
+			}
+		}},
+	}
 
-		switch valueName {
-		case "assert_eq":
-			return analyzer.BuiltinImport{
+	for _, name := range test.Imports() {
+		testingModule[name] = testingBuiltin{kind: pAst.IMPORT_KIND_NORMAL, new: func(span herrors.Span) analyzer.BuiltinImport {
+			builtin, _ := test.AnalyzerImport(name, span)
+			return builtin
+		}}
+	}
+
+	return map[string]map[string]testingBuiltin{
+		"net": {
+			"ping": {kind: pAst.IMPORT_KIND_NORMAL, new: func(span herrors.Span) analyzer.BuiltinImport {
+				return analyzer.BuiltinImport{
 					Type: ast.NewFunctionType(
 						ast.NewNormalFunctionTypeParamKind([]ast.FunctionTypeParam{
-							ast.NewFunctionTypeParam(pAst.NewSpannedIdent("lhs", herrors.Span{}), ast.NewUnknownType(), nil),
-							ast.NewFunctionTypeParam(pAst.NewSpannedIdent("rhs", herrors.Span{}), ast.NewUnknownType(), nil),
+							ast.NewFunctionTypeParam(pAst.NewSpannedIdent("ip", span), ast.NewStringType(span), nil),
+							ast.NewFunctionTypeParam(pAst.NewSpannedIdent("timeout", span), ast.NewFloatType(span), nil),
 						}),
-						herrors.Span{},
-						ast.NewNullType(herrors.Span{}),
-						herrors.Span{},
-					),
-					Template: nil,
-				},
-				true, true
-		case "any_func":
-			return analyzer.BuiltinImport{
-					Type: ast.NewFunctionType(
-						ast.NewNormalFunctionTypeParamKind(make([]ast.FunctionTypeParam, 0)),
 						span,
-						ast.NewAnyType(span),
+						ast.NewBoolType(span),
 						span,
 					),
+					Template: &ast.TemplateSpec{},
+				}
+			}},
+			"HttpResponse": {kind: pAst.IMPORT_KIND_TYPE, new: func(span herrors.Span) analyzer.BuiltinImport {
+				return analyzer.BuiltinImport{
+					Type:     httpResponseType(span),
+					Template: &ast.TemplateSpec{},
+				}
+			}},
+			"http": {kind: pAst.IMPORT_KIND_NORMAL, new: func(span herrors.Span) analyzer.BuiltinImport {
+				return analyzer.BuiltinImport{
+					Type: ast.NewObjectType([]ast.ObjectTypeField{
+						ast.NewObjectTypeField(pAst.NewSpannedIdent("get", span), ast.NewFunctionType(
+							ast.NewNormalFunctionTypeParamKind([]ast.FunctionTypeParam{ast.NewFunctionTypeParam(pAst.NewSpannedIdent("url", span), ast.NewStringType(span), nil)}),
+							span,
+							httpResponseType(span),
+							span,
+						), span),
+						ast.NewObjectTypeField(pAst.NewSpannedIdent("generic", span), ast.NewFunctionType(
+							ast.NewNormalFunctionTypeParamKind(
+								[]ast.FunctionTypeParam{
+									ast.NewFunctionTypeParam(pAst.NewSpannedIdent("url", span), ast.NewStringType(span), nil),
+									ast.NewFunctionTypeParam(pAst.NewSpannedIdent("method", span), ast.NewStringType(span), nil),
+									ast.NewFunctionTypeParam(pAst.NewSpannedIdent("body", span), ast.NewOptionType(ast.NewStringType(span), span), nil),
+									ast.NewFunctionTypeParam(pAst.NewSpannedIdent("headers", span), ast.NewAnyObjectType(span), nil),
+									ast.NewFunctionTypeParam(pAst.NewSpannedIdent("cookies", span), ast.NewAnyObjectType(span), nil),
+								},
+							),
+							span,
+							httpResponseType(span),
+							span,
+						), span),
+					}, span),
+					Template: &ast.TemplateSpec{},
+				}
+			}},
+		},
+		"triggers": {
+			"minute": {kind: pAst.IMPORT_KIND_TRIGGER, new: func(span herrors.Span) analyzer.BuiltinImport {
+				return analyzer.BuiltinImport{
+					Trigger: &analyzer.TriggerFunction{
+						TriggerFnType: ast.NewFunctionType(
+							ast.NewNormalFunctionTypeParamKind(
+								[]ast.FunctionTypeParam{ast.NewFunctionTypeParam(
+									pAst.NewSpannedIdent("minutes", span),
+									ast.NewIntType(span),
+									nil,
+								)},
+							),
+							span,
+							ast.NewNullType(span),
+							span,
+						).(ast.FunctionType),
+						CallbackFnType: ast.NewFunctionType(
+							ast.NewNormalFunctionTypeParamKind([]ast.FunctionTypeParam{
+								ast.NewFunctionTypeParam(
+									pAst.NewSpannedIdent("elapsed", span),
+									ast.NewIntType(span),
+									nil,
+								),
+							}),
+							span,
+							ast.NewNullType(span),
+							span,
+						).(ast.FunctionType),
+						Connective: pAst.AtTriggerDispatchKeyword,
+						ImportedAt: span,
+					},
+					Type:     nil,
 					Template: nil,
-				},
-				true, true
-		case "any_list":
-			return analyzer.BuiltinImport{
-					Type:     ast.NewListType(ast.NewAnyType(span), span),
-					Template: nil,
-				},
-				true, true
-		}
-		return analyzer.BuiltinImport{}, false, false
-	case "templates":
-		if kind != pAst.IMPORT_KIND_TEMPLATE {
-			return analyzer.BuiltinImport{}, true, false
-		}
+				}
+			}},
+		},
+		test.ModuleName: testingModule,
+		"templates": {
+			"FooFeature": {kind: pAst.IMPORT_KIND_TEMPLATE, new: fooFeatureTemplate},
+		},
+	}
+}
 
-		switch valueName {
-		case "FooFeature":
-			return analyzer.BuiltinImport{
-				Type: nil,
-				Template: &ast.TemplateSpec{
-					BaseMethods: map[string]ast.TemplateMethod{
-						"dim": {
-							Signature: ast.FunctionType{
-								Params: ast.NewNormalFunctionTypeParamKind([]ast.FunctionTypeParam{
-									{
-										Name:                 pAst.NewSpannedIdent("percent", span),
-										Type:                 ast.NewIntType(span),
-										IsSingletonExtractor: false,
-										SingletonIdent:       "",
-									},
-								}),
-								ParamsSpan: span,
-								ReturnType: ast.NewBoolType(span),
-								Range:      span,
-							},
-							Modifier: pAst.FN_MODIFIER_NONE,
-						},
-						"set_temp": {
-							Signature: ast.FunctionType{
-								Params: ast.NewNormalFunctionTypeParamKind([]ast.FunctionTypeParam{
-									{
-										Name:                 pAst.NewSpannedIdent("celsius", span),
-										Type:                 ast.NewFloatType(span),
-										IsSingletonExtractor: false,
-										SingletonIdent:       "",
-									},
-								}),
-								ParamsSpan: span,
-								ReturnType: ast.NewNullType(span),
-								Range:      span,
+func httpResponseType(span herrors.Span) ast.Type {
+	return ast.NewObjectType(
+		[]ast.ObjectTypeField{
+			ast.NewObjectTypeField(pAst.NewSpannedIdent("status", span), ast.NewStringType(span), span),
+			ast.NewObjectTypeField(pAst.NewSpannedIdent("status_code", span), ast.NewIntType(span), span),
+			ast.NewObjectTypeField(pAst.NewSpannedIdent("body", span), ast.NewStringType(span), span),
+			ast.NewObjectTypeField(pAst.NewSpannedIdent("cookies", span), ast.NewAnyObjectType(span), span),
+		},
+		span,
+	)
+}
+
+func fooFeatureTemplate(span herrors.Span) analyzer.BuiltinImport {
+	return analyzer.BuiltinImport{
+		Type: nil,
+		Template: &ast.TemplateSpec{
+			BaseMethods: map[string]ast.TemplateMethod{
+				"dim": {
+					Signature: ast.FunctionType{
+						Params: ast.NewNormalFunctionTypeParamKind([]ast.FunctionTypeParam{
+							{
+								Name:                 pAst.NewSpannedIdent("percent", span),
+								Type:                 ast.NewIntType(span),
+								IsSingletonExtractor: false,
+								SingletonIdent:       "",
 							},
-							Modifier: pAst.FN_MODIFIER_NONE,
-						},
+						}),
+						ParamsSpan: span,
+						ReturnType: ast.NewBoolType(span),
+						Range:      span,
 					},
-					Capabilities: map[string]ast.TemplateCapability{
-						"light": {
-							RequiresMethods: []string{"dim"},
-							ConflictsWithCapabilities: []ast.TemplateConflict{
-								{
-									ConflictingCapability: "temperature",
-									ConflictReason:        "",
-								},
+					Modifier: pAst.FN_MODIFIER_NONE,
+				},
+				"set_temp": {
+					Signature: ast.FunctionType{
+						Params: ast.NewNormalFunctionTypeParamKind([]ast.FunctionTypeParam{
+							{
+								Name:                 pAst.NewSpannedIdent("celsius", span),
+								Type:                 ast.NewFloatType(span),
+								IsSingletonExtractor: false,
+								SingletonIdent:       "",
 							},
+						}),
+						ParamsSpan: span,
+						ReturnType: ast.NewNullType(span),
+						Range:      span,
+					},
+					Modifier: pAst.FN_MODIFIER_NONE,
+				},
+			},
+			Capabilities: map[string]ast.TemplateCapability{
+				"light": {
+					RequiresMethods: []string{"dim"},
+					ConflictsWithCapabilities: []ast.TemplateConflict{
+						{
+							ConflictingCapability: "temperature",
+							ConflictReason:        "",
 						},
-						"temperature": {
-							RequiresMethods: []string{"set_temp"},
-							ConflictsWithCapabilities: []ast.TemplateConflict{
-								{
-									ConflictingCapability: "light",
-									ConflictReason:        "",
-								},
-							},
+					},
+				},
+				"temperature": {
+					RequiresMethods: []string{"set_temp"},
+					ConflictsWithCapabilities: []ast.TemplateConflict{
+						{
+							ConflictingCapability: "light",
+							ConflictReason:        "",
 						},
 					},
-					DefaultCapabilities: []string{},
-					Span:                span,
 				},
-			}, true, true
-		default:
-			return analyzer.BuiltinImport{}, true, false
-		}
-	default:
+			},
+			DefaultCapabilities: []string{},
+			Span:                span,
+		},
+	}
+}
+
+func (self TestingAnalyzerHost) GetBuiltinImport(
+	moduleName string,
+	valueName string,
+	span herrors.Span,
+	kind pAst.IMPORT_KIND,
+) (res analyzer.BuiltinImport, moduleFound bool, valueFound bool) {
+	module, found := testingBuiltins[moduleName]
+	if !found {
 		return analyzer.BuiltinImport{}, false, false
+	}
+
+	builtin, found := module[valueName]
+	if !found || builtin.kind != kind {
+		return analyzer.BuiltinImport{}, true, false
+	}
 
+	return builtin.new(span), true, true
+}
+
+func (self TestingAnalyzerHost) BuiltinModules() []string {
+	modules := make([]string, 0, len(testingBuiltins))
+	for name := range testingBuiltins {
+		modules = append(modules, name)
+	}
+	slices.Sort(modules)
+	return modules
+}
+
+func (self TestingAnalyzerHost) BuiltinImports(moduleName string, kind pAst.IMPORT_KIND) []string {
+	var imports []string
+	for name, builtin := range testingBuiltins[moduleName] {
+		if builtin.kind == kind {
+			imports = append(imports, name)
+		}
 	}
+	slices.Sort(imports)
+	return imports
 }
 
 func timeObjType(span herrors.Span) ast.Type {
@@ -13,6 +13,7 @@ import (
 	herrors "github.com/smarthome-go/homescript/v3/homescript/errors"
 	"github.com/smarthome-go/homescript/v3/homescript/runtime/value"
 	vmValue "github.com/smarthome-go/homescript/v3/homescript/runtime/value"
+	"github.com/smarthome-go/homescript/v3/homescript/test"
 )
 
 //
@@ -276,37 +277,12 @@ func (self TestingVmExecutor) GetBuiltinImport(moduleName string, toImport strin
 			}), true
 		}
 		return nil, false
-	case "testing":
-		switch toImport {
-		case "assert_eq":
-			return *vmValue.NewValueBuiltinFunction(func(executor vmValue.Executor, cancelCtx *context.Context, span herrors.Span, args ...vmValue.Value) (*vmValue.Value, *vmValue.VmInterrupt) {
-				lhsDisp, i := args[0].Display()
-				if i != nil {
-					return nil, i
-				}
-				rhsDisp, i := args[1].Display()
-				if i != nil {
-					return nil, i
-				}
-
-				if args[0].Kind() != args[1].Kind() {
-					return nil, vmValue.NewVMThrowInterrupt(span, fmt.Sprintf("`%s` is not equal to `%s`", lhsDisp, rhsDisp))
-				}
-
-				isEqual, i := args[0].IsEqual(args[1])
-				if i != nil {
-					return nil, i
-				}
-
-				if args[0].Kind() != args[1].Kind() {
-					return nil, vmValue.NewVMThrowInterrupt(span, fmt.Sprintf("`%s` is not equal to `%s`", lhsDisp, rhsDisp))
-				}
-				if !isEqual {
-					return nil, vmValue.NewVMThrowInterrupt(span, fmt.Sprintf("`%s` is not equal to `%s`", lhsDisp, rhsDisp))
-				}
+	case test.ModuleName:
+		if val, found := test.VmImport(toImport); found {
+			return val, true
+		}
 
-				return vmValue.NewValueNull(), nil
-			}), true
+		switch toImport {
 		case "any_func":
 			return *vmValue.NewValueBuiltinFunction(func(executor vmValue.Executor, cancelCtx *context.Context, span herrors.Span, args ...vmValue.Value) (*vmValue.Value, *vmValue.VmInterrupt) {
 				return vmValue.NewValueInt(42), nil
@@ -0,0 +1,70 @@
+package homescript
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/smarthome-go/homescript/v3/homescript/compiler"
+	"github.com/smarthome-go/homescript/v3/homescript/runtime"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecordReplay(t *testing.T) {
+	const program = `fn main() {
+    let start = time.now();
+    time.sleep(0.01);
+    let end = time.now();
+    println(start.unix_milli, end.unix_milli - start.unix_milli >= 10);
+}
+`
+
+	modules, _, syntaxErrors := Analyze(
+		InputProgram{ProgramText: program, Filename: "main"},
+		TestingAnalyzerScopeAdditions(),
+		TestingAnalyzerHost{},
+		true,
+	)
+	assert.Empty(t, syntaxErrors)
+
+	compilerStruct := compiler.NewCompiler(modules, "main")
+	compiled, err := compilerStruct.Compile()
+	assert.NoError(t, err)
+
+	limits := runtime.CoreLimits{CallStackMaxSize: 100, StackMaxSize: 100, MaxMemorySize: 100}
+
+	run := func(newVM func(executor TestingVmExecutor, ctx *context.Context, cancel *context.CancelFunc) (runtime.VM, error)) (string, *runtime.Trace) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+
+		executor := TestingVmExecutor{PrintBuf: new(string), PintBufMutex: &sync.Mutex{}}
+		vm, err := newVM(executor, &ctx, &cancel)
+		assert.NoError(t, err)
+
+		vm.SpawnAsync(runtime.MainFn(), nil, nil, nil)
+		_, interrupt := vm.Wait()
+		assert.Nil(t, interrupt)
+
+		return *executor.PrintBuf, vm.Trace()
+	}
+
+	recorded, trace := run(func(executor TestingVmExecutor, ctx *context.Context, cancel *context.CancelFunc) (runtime.VM, error) {
+		return runtime.NewRecordingVM(compiled, executor, ctx, cancel, TestingVmScopeAdditions(), limits), nil
+	})
+	assert.Contains(t, recorded, "true")
+
+	// The trace must survive its encoding.
+	encoded, err := runtime.EncodeTrace(*trace, compiled)
+	assert.NoError(t, err)
+	decodedTrace, decodedProgram, err := runtime.DecodeTrace(encoded)
+	assert.NoError(t, err)
+
+	// Time has passed since the recording, nevertheless, the replay observes the recorded time.
+	time.Sleep(time.Millisecond * 5)
+
+	replayed, _ := run(func(executor TestingVmExecutor, ctx *context.Context, cancel *context.CancelFunc) (runtime.VM, error) {
+		return runtime.NewReplayVM(decodedProgram, decodedTrace, executor, ctx, cancel, TestingVmScopeAdditions(), limits)
+	})
+	assert.Equal(t, recorded, replayed)
+}
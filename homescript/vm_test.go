@@ -0,0 +1,55 @@
+package homescript
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/smarthome-go/homescript/v3/homescript/compiler"
+	"github.com/smarthome-go/homescript/v3/homescript/runtime"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVMVerifiesProgram(t *testing.T) {
+	const program = `fn greet() { println("hello"); }
+
+fn main() {
+    greet();
+}
+`
+
+	modules, _, syntaxErrors := Analyze(
+		InputProgram{ProgramText: program, Filename: "main"},
+		TestingAnalyzerScopeAdditions(),
+		TestingAnalyzerHost{},
+		true,
+	)
+	assert.Empty(t, syntaxErrors)
+
+	compilerStruct := compiler.NewCompiler(modules, "main")
+	compiled, err := compilerStruct.Compile()
+	assert.NoError(t, err)
+
+	// `main` now calls a function which does not exist.
+	delete(compiled.Functions, compiled.Mappings.Functions["greet"])
+	delete(compiled.SourceMap, compiled.Mappings.Functions["greet"])
+	delete(compiled.Mappings.Functions, "greet")
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	executor := TestingVmExecutor{PrintBuf: new(string), PintBufMutex: &sync.Mutex{}}
+	limits := runtime.CoreLimits{
+		CallStackMaxSize: 100,
+		StackMaxSize:     100,
+		MaxMemorySize:    100,
+	}
+
+	_, err = runtime.NewVerifiedVM(compiled, executor, &ctx, &cancel, TestingVmScopeAdditions(), limits)
+	assert.ErrorContains(t, err, "call to non-existent function")
+
+	assert.PanicsWithValue(t, "Fatal: VM received invalid bytecode: "+err.Error(), func() {
+		runtime.NewVM(compiled, executor, &ctx, &cancel, TestingVmScopeAdditions(), limits)
+	})
+}